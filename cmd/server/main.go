@@ -18,6 +18,8 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/config_access"
+	jwtaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/jwt_access"
+	mtlsaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/mtls_access"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/cmd"
@@ -90,6 +92,10 @@ func main() {
 	var tuiMode bool
 	var standalone bool
 	var localModel bool
+	var replayTarget string
+	var replayAuthIndex string
+	var replayModel string
+	var replayTranslateOnly bool
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&codexLogin, "codex-login", false, "Login to Codex using OAuth")
@@ -109,6 +115,10 @@ func main() {
 	flag.BoolVar(&tuiMode, "tui", false, "Start with terminal management UI")
 	flag.BoolVar(&standalone, "standalone", false, "In TUI mode, start an embedded local server")
 	flag.BoolVar(&localModel, "local-model", false, "Use embedded models.json and codex_client_models.json only, skip remote model catalog fetching")
+	flag.StringVar(&replayTarget, "replay", "", "Replay a stored request log (request ID or log file) through the running server and print diffs")
+	flag.StringVar(&replayAuthIndex, "replay-auth-index", "", "Pin the replay to the credential with this auth index (use with -replay)")
+	flag.StringVar(&replayModel, "replay-model", "", "Override the requested model (use with -replay)")
+	flag.BoolVar(&replayTranslateOnly, "replay-translate-only", false, "Show the translated upstream payload without sending it (use with -replay)")
 
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
@@ -588,7 +598,7 @@ func main() {
 		CallbackPort: oauthCallbackPort,
 	}
 
-	commandMode := vertexImport != "" || antigravityLogin || codexLogin || codexDeviceLogin || claudeLogin || kimiLogin || xaiLogin
+	commandMode := vertexImport != "" || replayTarget != "" || antigravityLogin || codexLogin || codexDeviceLogin || claudeLogin || kimiLogin || xaiLogin
 	cloudConfigMissing := isCloudDeploy && !configFileExists
 	homeMode := configLoadedFromHome || (cfg != nil && cfg.Home.Enabled)
 	exampleAPIKeySafeMode := shouldEnableExampleAPIKeySafeMode(cfg, commandMode, tuiMode, standalone, cloudConfigMissing, homeMode)
@@ -612,6 +622,8 @@ func main() {
 
 	// Register built-in access providers before constructing services.
 	configaccess.Register(&cfg.SDKConfig)
+	jwtaccess.Register(&cfg.SDKConfig)
+	mtlsaccess.Register(&cfg.SDKConfig)
 	pluginHost.ApplyConfig(context.Background(), cfg)
 	if configLoadedFromHome && homePluginStatusReady {
 		errHomePluginLoad := homeplugins.MarkLoadResults(&homePluginSyncReport, pluginHost)
@@ -646,6 +658,13 @@ func main() {
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport, vertexImportPrefix)
+	} else if replayTarget != "" {
+		// Replay a stored request log against the running server
+		cmd.DoRequestReplay(cfg, password, replayTarget, cmd.RequestReplayOptions{
+			AuthIndex:     replayAuthIndex,
+			Model:         replayModel,
+			TranslateOnly: replayTranslateOnly,
+		})
 	} else if antigravityLogin {
 		// Handle Antigravity login
 		cmd.DoAntigravityLogin(cfg, options)
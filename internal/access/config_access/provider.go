@@ -2,8 +2,13 @@ package configaccess
 
 import (
 	"context"
+	"crypto/sha256"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
@@ -17,20 +22,57 @@ func Register(cfg *sdkconfig.SDKConfig) {
 	}
 
 	keys := normalizeKeys(cfg.APIKeys)
-	if len(keys) == 0 {
+	if len(keys) == 0 && len(cfg.ClientAPIKeys) == 0 {
 		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeConfigAPIKey)
 		return
 	}
 
-	sdkaccess.RegisterProvider(
-		sdkaccess.AccessProviderTypeConfigAPIKey,
-		newProvider(sdkaccess.DefaultAccessProviderName, keys),
-	)
+	p := newProvider(sdkaccess.DefaultAccessProviderName, keys)
+	p.addClientKeys(cfg.ClientAPIKeys)
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeConfigAPIKey, p)
+}
+
+// clientKey holds the policy of one configured key. Plain api-keys entries have a zero policy.
+type clientKey struct {
+	name      string
+	expiresAt time.Time
+	disabled  bool
+	labels    map[string]string
+	models    *sdkaccess.ModelRules
 }
 
+// hashedKey is a configured key stored as a "sha256:" or "argon2:" hash. lookupID is set for
+// argon2 entries only.
+type hashedKey struct {
+	stored   string
+	lookupID string
+	policy   clientKey
+}
+
+const (
+	// maxRejectedCacheEntries bounds the cache of presented keys known not to match any hash.
+	maxRejectedCacheEntries = 4096
+	// maxConcurrentArgon2 bounds argon2 verifications in flight, each of which holds ~19MiB.
+	maxConcurrentArgon2 = 4
+)
+
 type provider struct {
-	name string
-	keys map[string]struct{}
+	name   string
+	keys   map[string]clientKey
+	hashed []hashedKey
+	now    func() time.Time
+
+	// sha256Entries index the cheap hashes, checked in turn. argon2Entries index the slow
+	// hashes by lookup ID so an unknown key runs argon2 at most for entries sharing its ID.
+	sha256Entries []int
+	argon2Entries map[string][]int
+	argon2Slots   chan struct{}
+
+	// Verifying hashes costs a digest per entry (and argon2 is deliberately slow), so
+	// outcomes are cached by the digest of the presented key for the provider's lifetime.
+	cacheMu  sync.Mutex
+	verified map[[sha256.Size]byte]int
+	rejected map[[sha256.Size]byte]struct{}
 }
 
 func newProvider(name string, keys []string) *provider {
@@ -38,11 +80,135 @@ func newProvider(name string, keys []string) *provider {
 	if providerName == "" {
 		providerName = sdkaccess.DefaultAccessProviderName
 	}
-	keySet := make(map[string]struct{}, len(keys))
+	p := &provider{
+		name:     providerName,
+		keys:     make(map[string]clientKey, len(keys)),
+		now:      time.Now,
+		verified: make(map[[sha256.Size]byte]int),
+		rejected: make(map[[sha256.Size]byte]struct{}),
+
+		argon2Entries: make(map[string][]int),
+		argon2Slots:   make(chan struct{}, maxConcurrentArgon2),
+	}
 	for _, key := range keys {
-		keySet[key] = struct{}{}
+		p.addKey(key, clientKey{})
+	}
+	return p
+}
+
+func (p *provider) addKey(key string, policy clientKey) {
+	if sdkconfig.IsHashedClientKey(key) {
+		for _, existing := range p.hashed {
+			if existing.stored == key {
+				return
+			}
+		}
+		entry := hashedKey{stored: key, lookupID: sdkconfig.ClientKeyHashLookupID(key), policy: policy}
+		if entry.lookupID != "" {
+			p.argon2Entries[entry.lookupID] = append(p.argon2Entries[entry.lookupID], len(p.hashed))
+		} else {
+			p.sha256Entries = append(p.sha256Entries, len(p.hashed))
+		}
+		p.hashed = append(p.hashed, entry)
+		return
+	}
+	if _, exists := p.keys[key]; exists {
+		return
+	}
+	p.keys[key] = policy
+}
+
+// lookup resolves a presented key to the configured entry. It returns the configured value,
+// which is the hash for hashed entries so the plaintext never becomes the principal.
+func (p *provider) lookup(ctx context.Context, candidate string) (string, clientKey, bool) {
+	if policy, ok := p.keys[candidate]; ok {
+		return candidate, policy, true
+	}
+	if len(p.hashed) == 0 {
+		return "", clientKey{}, false
+	}
+	cacheKey := sha256.Sum256([]byte(candidate))
+	p.cacheMu.Lock()
+	index, verified := p.verified[cacheKey]
+	_, rejected := p.rejected[cacheKey]
+	p.cacheMu.Unlock()
+	if verified {
+		return p.hashed[index].stored, p.hashed[index].policy, true
+	}
+	if rejected {
+		return "", clientKey{}, false
+	}
+
+	index = -1
+	for _, i := range p.sha256Entries {
+		if sdkconfig.VerifyClientKey(p.hashed[i].stored, candidate) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		var completed bool
+		index, completed = p.verifyArgon2(ctx, candidate)
+		if !completed {
+			return "", clientKey{}, false
+		}
+	}
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+	if index < 0 {
+		if len(p.rejected) >= maxRejectedCacheEntries {
+			clear(p.rejected)
+		}
+		p.rejected[cacheKey] = struct{}{}
+		return "", clientKey{}, false
+	}
+	p.verified[cacheKey] = index
+	return p.hashed[index].stored, p.hashed[index].policy, true
+}
+
+// verifyArgon2 checks candidate against the argon2 entries sharing its lookup ID, waiting for a
+// verification slot first. completed is false when ctx ended before every entry was checked.
+func (p *provider) verifyArgon2(ctx context.Context, candidate string) (index int, completed bool) {
+	entries := p.argon2Entries[sdkconfig.ClientKeyLookupID(candidate)]
+	if len(entries) == 0 {
+		return -1, true
+	}
+	select {
+	case p.argon2Slots <- struct{}{}:
+	case <-ctx.Done():
+		return -1, false
+	}
+	defer func() { <-p.argon2Slots }()
+	for _, i := range entries {
+		if sdkconfig.VerifyClientKey(p.hashed[i].stored, candidate) {
+			return i, true
+		}
+	}
+	return -1, true
+}
+
+func (p *provider) addClientKeys(entries []sdkconfig.ClientAPIKey) {
+	for _, entry := range entries {
+		key := strings.TrimSpace(entry.Key)
+		if key == "" {
+			continue
+		}
+		policy := clientKey{
+			name:      strings.TrimSpace(entry.Name),
+			expiresAt: entry.ExpiresAt,
+			disabled:  entry.Disabled,
+			labels:    entry.Labels,
+		}
+		rules := &sdkaccess.ModelRules{
+			Allowed:         append([]string(nil), entry.AllowedModels...),
+			Denied:          append([]string(nil), entry.DeniedModels...),
+			AllowedPrefixes: append([]string(nil), entry.AllowedPrefixes...),
+		}
+		if !rules.Empty() {
+			policy.models = rules
+		}
+		p.addKey(key, policy)
 	}
-	return &provider{name: providerName, keys: keySet}
 }
 
 func (p *provider) Identifier() string {
@@ -52,11 +218,11 @@ func (p *provider) Identifier() string {
 	return p.name
 }
 
-func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
 	if p == nil {
 		return nil, sdkaccess.NewNotHandledError()
 	}
-	if len(p.keys) == 0 {
+	if len(p.keys) == 0 && len(p.hashed) == 0 {
 		return nil, sdkaccess.NewNotHandledError()
 	}
 	authHeader := r.Header.Get("Authorization")
@@ -89,20 +255,45 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 		if candidate.value == "" {
 			continue
 		}
-		if _, ok := p.keys[candidate.value]; ok {
-			return &sdkaccess.Result{
-				Provider:  p.Identifier(),
-				Principal: candidate.value,
-				Metadata: map[string]string{
-					"source": candidate.source,
-				},
-			}, nil
+		principal, policy, ok := p.lookup(ctx, candidate.value)
+		if !ok {
+			continue
+		}
+		if policy.disabled {
+			log.Debugf("access: rejected disabled client key %q", policy.displayName())
+			return nil, sdkaccess.NewInvalidCredentialError()
 		}
+		if !policy.expiresAt.IsZero() && !p.now().Before(policy.expiresAt) {
+			log.Debugf("access: rejected expired client key %q", policy.displayName())
+			return nil, sdkaccess.NewInvalidCredentialError()
+		}
+		metadata := map[string]string{
+			"source": candidate.source,
+		}
+		if policy.name != "" {
+			metadata["key-name"] = policy.name
+		}
+		for label, value := range policy.labels {
+			metadata["label."+label] = value
+		}
+		return &sdkaccess.Result{
+			Provider:  p.Identifier(),
+			Principal: principal,
+			Metadata:  metadata,
+			Models:    policy.models,
+		}, nil
 	}
 
 	return nil, sdkaccess.NewInvalidCredentialError()
 }
 
+func (k clientKey) displayName() string {
+	if k.name != "" {
+		return k.name
+	}
+	return "unnamed"
+}
+
 func extractBearerToken(header string) string {
 	if header == "" {
 		return ""
@@ -0,0 +1,155 @@
+package configaccess
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestProviderAuthenticatesPlainAndStructuredKeys(t *testing.T) {
+	p := newProvider("", []string{"plain-key"})
+	p.addClientKeys([]sdkconfig.ClientAPIKey{{
+		Name:          "contractor",
+		Key:           "structured-key",
+		AllowedModels: []string{"gpt-5*"},
+		Labels:        map[string]string{"team": "contractors"},
+	}})
+
+	plain := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	plain.Header.Set("Authorization", "Bearer plain-key")
+	result, authErr := p.Authenticate(context.Background(), plain)
+	if authErr != nil {
+		t.Fatalf("Authenticate(plain) error = %v", authErr)
+	}
+	if result.Principal != "plain-key" || result.Models != nil {
+		t.Fatalf("Authenticate(plain) = %+v, want unrestricted plain-key principal", result)
+	}
+
+	structured := httptest.NewRequest("POST", "/v1/messages", nil)
+	structured.Header.Set("X-Api-Key", "structured-key")
+	result, authErr = p.Authenticate(context.Background(), structured)
+	if authErr != nil {
+		t.Fatalf("Authenticate(structured) error = %v", authErr)
+	}
+	if result.Principal != "structured-key" {
+		t.Fatalf("principal = %q, want structured-key", result.Principal)
+	}
+	if result.Metadata["key-name"] != "contractor" || result.Metadata["label.team"] != "contractors" {
+		t.Fatalf("metadata = %v, want key name and labels", result.Metadata)
+	}
+	if result.Models == nil || result.Models.Allows("gemini-2.5-pro") || !result.Models.Allows("gpt-5") {
+		t.Fatalf("model rules = %+v, want gpt-5* allowlist", result.Models)
+	}
+}
+
+func TestProviderRejectsDisabledAndExpiredKeys(t *testing.T) {
+	now := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	p := newProvider("", nil)
+	p.now = func() time.Time { return now }
+	p.addClientKeys([]sdkconfig.ClientAPIKey{
+		{Key: "disabled-key", Disabled: true},
+		{Key: "expired-key", ExpiresAt: now.Add(-time.Second)},
+		{Key: "valid-key", ExpiresAt: now.Add(time.Hour)},
+	})
+
+	for _, key := range []string{"disabled-key", "expired-key"} {
+		request := httptest.NewRequest("GET", "/v1/models", nil)
+		request.Header.Set("Authorization", "Bearer "+key)
+		if _, authErr := p.Authenticate(context.Background(), request); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Fatalf("Authenticate(%s) error = %v, want invalid credential", key, authErr)
+		}
+	}
+	request := httptest.NewRequest("GET", "/v1/models", nil)
+	request.Header.Set("Authorization", "Bearer valid-key")
+	if _, authErr := p.Authenticate(context.Background(), request); authErr != nil {
+		t.Fatalf("Authenticate(valid-key) error = %v", authErr)
+	}
+}
+
+func TestProviderVerifiesHashedKeys(t *testing.T) {
+	plainHash, errHash := sdkconfig.HashClientKey("sk-plain-secret", sdkconfig.ClientKeyHashSHA256)
+	if errHash != nil {
+		t.Fatalf("HashClientKey() error = %v", errHash)
+	}
+	teamHash, errHash := sdkconfig.HashClientKey("sk-team-secret", sdkconfig.ClientKeyHashArgon2)
+	if errHash != nil {
+		t.Fatalf("HashClientKey() error = %v", errHash)
+	}
+	p := newProvider("", []string{plainHash})
+	p.addClientKeys([]sdkconfig.ClientAPIKey{{Name: "team", Key: teamHash, AllowedModels: []string{"gpt-5*"}}})
+
+	for range 2 { // the second round is served from the verification cache
+		request := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+		request.Header.Set("Authorization", "Bearer sk-plain-secret")
+		result, authErr := p.Authenticate(context.Background(), request)
+		if authErr != nil {
+			t.Fatalf("Authenticate(sha256) error = %v", authErr)
+		}
+		if result.Principal != plainHash {
+			t.Fatalf("principal = %q, want the stored hash", result.Principal)
+		}
+
+		request = httptest.NewRequest("POST", "/v1/messages", nil)
+		request.Header.Set("X-Api-Key", "sk-team-secret")
+		result, authErr = p.Authenticate(context.Background(), request)
+		if authErr != nil {
+			t.Fatalf("Authenticate(argon2) error = %v", authErr)
+		}
+		if result.Principal != teamHash || result.Metadata["key-name"] != "team" || result.Models == nil {
+			t.Fatalf("Authenticate(argon2) = %+v, want team policy", result)
+		}
+
+		request = httptest.NewRequest("POST", "/v1/messages", nil)
+		request.Header.Set("X-Api-Key", "sk-wrong-secret")
+		if _, authErr = p.Authenticate(context.Background(), request); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Fatalf("Authenticate(wrong) error = %v, want invalid credential", authErr)
+		}
+	}
+}
+
+func TestProviderOnlyRunsArgon2ForMatchingLookupID(t *testing.T) {
+	teamHash, errHash := sdkconfig.HashClientKey("sk-team-secret", sdkconfig.ClientKeyHashArgon2)
+	if errHash != nil {
+		t.Fatalf("HashClientKey() error = %v", errHash)
+	}
+	if got, want := sdkconfig.ClientKeyHashLookupID(teamHash), sdkconfig.ClientKeyLookupID("sk-team-secret"); got != want {
+		t.Fatalf("ClientKeyHashLookupID() = %q, want %q", got, want)
+	}
+	p := newProvider("", nil)
+	p.addClientKeys([]sdkconfig.ClientAPIKey{{Name: "team", Key: teamHash}})
+
+	// With every verification slot taken, only a key that would need argon2 has to wait.
+	for range maxConcurrentArgon2 {
+		p.argon2Slots <- struct{}{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	unknown := "sk-unknown"
+	for sdkconfig.ClientKeyLookupID(unknown) == sdkconfig.ClientKeyLookupID("sk-team-secret") {
+		unknown += "x"
+	}
+	if _, _, ok := p.lookup(ctx, unknown); ok {
+		t.Fatal("lookup(unknown) matched")
+	}
+	if _, rejected := p.rejected[sha256.Sum256([]byte(unknown))]; !rejected {
+		t.Fatal("lookup(unknown) waited for argon2 instead of rejecting by lookup ID")
+	}
+	if _, _, ok := p.lookup(ctx, "sk-team-secret"); ok {
+		t.Fatal("lookup(team) matched without a verification slot")
+	}
+	if _, rejected := p.rejected[sha256.Sum256([]byte("sk-team-secret"))]; rejected {
+		t.Fatal("lookup(team) cached a rejection after giving up on a slot")
+	}
+
+	for range maxConcurrentArgon2 {
+		<-p.argon2Slots
+	}
+	if principal, _, ok := p.lookup(context.Background(), "sk-team-secret"); !ok || principal != teamHash {
+		t.Fatalf("lookup(team) = %q, %v; want the stored hash", principal, ok)
+	}
+}
@@ -0,0 +1,315 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+const (
+	defaultRefreshInterval = time.Hour
+	// unknownKeyRefreshInterval bounds reloads triggered by tokens with an unknown kid, so
+	// forged kids cannot hammer the identity provider.
+	unknownKeyRefreshInterval = 30 * time.Second
+	jwksFetchTimeout          = 10 * time.Second
+	maxJWKSBytes              = 1 << 20
+	// minRSAKeyBits is the smallest RSA modulus trusted, per RFC 7518 section 3.3.
+	minRSAKeyBits = 2048
+)
+
+type verificationKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet holds the trusted keys. Remote and file key sets are loaded lazily on first use
+// and reloaded once the refresh interval passes; a failed reload keeps the previous keys.
+// Reloads run in the background so verification never holds the lock across a fetch.
+type keySet struct {
+	url             string
+	file            string
+	refreshInterval time.Duration
+	client          *http.Client
+	static          []verificationKey
+
+	mu          sync.Mutex
+	loaded      []verificationKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+	// reloading is closed when the in-flight reload finishes; nil when none is running.
+	reloading chan struct{}
+	now       func() time.Time
+}
+
+func newKeySet(cfg sdkconfig.JWTAuthConfig) (*keySet, error) {
+	set := &keySet{
+		url:             cfg.JWKSURL,
+		file:            cfg.JWKSFile,
+		refreshInterval: defaultRefreshInterval,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		now:             time.Now,
+	}
+	if cfg.RefreshIntervalSeconds > 0 {
+		set.refreshInterval = time.Duration(cfg.RefreshIntervalSeconds) * time.Second
+	}
+	for i, entry := range cfg.StaticKeys {
+		key, errParse := parsePEMPublicKey(entry.PublicKey)
+		if errParse != nil {
+			return nil, fmt.Errorf("jwt-auth.static-keys[%d]: %w", i, errParse)
+		}
+		set.static = append(set.static, verificationKey{kid: strings.TrimSpace(entry.KeyID), key: key})
+	}
+	return set, nil
+}
+
+// candidates returns the keys that may have signed a token with the given kid. A stale
+// key set is refreshed in the background while the previous keys keep serving; callers
+// only wait when no keys are loaded yet or the kid is unknown, and stop waiting when ctx ends.
+func (s *keySet) candidates(ctx context.Context, kid string) []verificationKey {
+	s.mu.Lock()
+	if s.url != "" || s.file != "" {
+		now := s.now()
+		unknown := kid != "" && !containsKeyID(s.loaded, kid) && !containsKeyID(s.static, kid)
+		stale := s.loadedAt.IsZero() || now.Sub(s.loadedAt) >= s.refreshInterval
+		if (stale || unknown) && s.reloading == nil && now.Sub(s.attemptedAt) >= unknownKeyRefreshInterval {
+			s.attemptedAt = now
+			s.reloading = make(chan struct{})
+			go s.reload(s.reloading)
+		}
+		if done := s.reloading; done != nil && (s.loadedAt.IsZero() || unknown) {
+			s.mu.Unlock()
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+			s.mu.Lock()
+		}
+	}
+
+	matches := make([]verificationKey, 0, len(s.static)+len(s.loaded))
+	for _, group := range [][]verificationKey{s.static, s.loaded} {
+		for _, key := range group {
+			if kid == "" || key.kid == "" || key.kid == kid {
+				matches = append(matches, key)
+			}
+		}
+	}
+	s.mu.Unlock()
+	return matches
+}
+
+// reload fetches the key set without holding s.mu. It uses its own context so a client
+// that disconnects mid-request cannot abort the fetch for everyone else.
+func (s *keySet) reload(done chan struct{}) {
+	keys, errLoad := s.fetch(context.Background())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloading = nil
+	close(done)
+	if errLoad != nil {
+		log.Warnf("jwt access: %v", errLoad)
+		return
+	}
+	s.loaded = keys
+	s.loadedAt = s.attemptedAt
+	log.Debugf("jwt access: loaded %d key(s)", len(keys))
+}
+
+func (s *keySet) fetch(ctx context.Context) ([]verificationKey, error) {
+	data, errRead := s.read(ctx)
+	if errRead != nil {
+		return nil, fmt.Errorf("failed to load key set: %w", errRead)
+	}
+	keys, errParse := parseJWKS(data)
+	if errParse != nil {
+		return nil, fmt.Errorf("failed to parse key set: %w", errParse)
+	}
+	return keys, nil
+}
+
+func (s *keySet) read(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if errReq != nil {
+		return nil, errReq
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, errDo := s.client.Do(req)
+	if errDo != nil {
+		return nil, errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("jwt access: close key set response: %v", errClose)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+}
+
+func containsKeyID(keys []verificationKey, kid string) bool {
+	for _, key := range keys {
+		if key.kid == kid {
+			return true
+		}
+	}
+	return false
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// parseJWKS decodes a JSON Web Key Set. Encryption keys, unsupported key types and keys
+// whose "alg" does not suit the key are skipped so one exotic entry does not invalidate
+// the whole set.
+func parseJWKS(data []byte) ([]verificationKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if errUnmarshal := json.Unmarshal(data, &document); errUnmarshal != nil {
+		return nil, errUnmarshal
+	}
+	keys := make([]verificationKey, 0, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, errKey := jwk.publicKey()
+		if errKey == nil && jwk.Algorithm != "" {
+			if _, ok := algorithmHash(jwk.Algorithm); !ok {
+				errKey = fmt.Errorf("unsupported algorithm %q", jwk.Algorithm)
+			} else {
+				errKey = keyAllowsAlgorithm(key, jwk.Algorithm)
+			}
+		}
+		if errKey != nil {
+			log.Debugf("jwt access: skipping key %q: %v", jwk.KeyID, errKey)
+			continue
+		}
+		keys = append(keys, verificationKey{kid: jwk.KeyID, alg: jwk.Algorithm, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("key set contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA parameters")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key has %d bits, want at least %d", key.N.BitLen(), minRSAKeyBits)
+		}
+		return key, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC parameters")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		if errX != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 parameters")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+// parsePEMPublicKey accepts PKIX public keys, PKCS#1 RSA public keys, and certificates.
+func parsePEMPublicKey(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(data)))
+	if block == nil {
+		return nil, errors.New("public-key is not PEM encoded")
+	}
+	var (
+		key      any
+		errParse error
+	)
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, errParse = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, errParse = x509.ParseCertificate(block.Bytes)
+		if errParse == nil {
+			key = cert.PublicKey
+		}
+	default:
+		key, errParse = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if errParse != nil {
+		return nil, errParse
+	}
+	switch typed := key.(type) {
+	case *rsa.PublicKey:
+		if typed.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key has %d bits, want at least %d", typed.N.BitLen(), minRSAKeyBits)
+		}
+		return key, nil
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
@@ -0,0 +1,266 @@
+// Package jwtaccess implements the jwt access provider, which authenticates clients with
+// bearer JWTs issued by an SSO / OIDC identity provider and maps their claims to a
+// principal and group-based model rules.
+package jwtaccess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+const (
+	providerName          = "jwt"
+	defaultClockSkew      = 60 * time.Second
+	defaultPrincipalClaim = "sub"
+	defaultGroupsClaim    = "groups"
+)
+
+var (
+	registeredMu sync.Mutex
+	registered   *provider
+)
+
+// Register ensures the jwt provider matches cfg.JWTAuth. An unchanged configuration keeps
+// the registered provider so its cached key set survives config reloads.
+func Register(cfg *sdkconfig.SDKConfig) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	if cfg == nil || !cfg.JWTAuth.Enable {
+		registered = nil
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeJWT)
+		return
+	}
+	if registered != nil && reflect.DeepEqual(registered.cfg, cfg.JWTAuth) {
+		sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeJWT, registered)
+		return
+	}
+	p, errProvider := newProvider(cfg.JWTAuth)
+	if errProvider != nil {
+		log.Errorf("jwt access: %v", errProvider)
+		registered = nil
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeJWT)
+		return
+	}
+	registered = p
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeJWT, p)
+}
+
+type provider struct {
+	cfg            sdkconfig.JWTAuthConfig
+	keys           *keySet
+	clockSkew      time.Duration
+	principalClaim string
+	groupsClaim    string
+	now            func() time.Time
+}
+
+func newProvider(cfg sdkconfig.JWTAuthConfig) (*provider, error) {
+	keys, errKeys := newKeySet(cfg)
+	if errKeys != nil {
+		return nil, errKeys
+	}
+	p := &provider{
+		cfg:            cfg,
+		keys:           keys,
+		clockSkew:      defaultClockSkew,
+		principalClaim: defaultPrincipalClaim,
+		groupsClaim:    defaultGroupsClaim,
+		now:            time.Now,
+	}
+	if cfg.ClockSkewSeconds > 0 {
+		p.clockSkew = time.Duration(cfg.ClockSkewSeconds) * time.Second
+	}
+	if cfg.PrincipalClaim != "" {
+		p.principalClaim = cfg.PrincipalClaim
+	}
+	if cfg.GroupsClaim != "" {
+		p.groupsClaim = cfg.GroupsClaim
+	}
+	return p, nil
+}
+
+func (p *provider) Identifier() string {
+	return providerName
+}
+
+// Authenticate validates a JWT bearer token. Requests without a JWT-shaped bearer token are
+// left to other providers so JWTs and client API keys can be used side by side.
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	authHeader := strings.TrimSpace(r.Header.Get("Authorization"))
+	if authHeader == "" {
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	scheme, token, found := strings.Cut(authHeader, " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	token = strings.TrimSpace(token)
+	if !looksLikeJWT(token) {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+
+	claims, errVerify := p.verify(ctx, token)
+	if errVerify != nil {
+		log.Debugf("jwt access: rejected token: %v", errVerify)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	principal := claimString(claims, p.principalClaim)
+	if principal == "" {
+		log.Debugf("jwt access: rejected token without %q claim", p.principalClaim)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	groups := claimStrings(claims, p.groupsClaim)
+	rules, matched := p.modelRules(groups)
+	if !matched && p.cfg.RequireGroupMatch {
+		log.Debugf("jwt access: rejected %q: no group rule matches", principal)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	metadata := map[string]string{
+		"source":  "authorization",
+		"subject": claimString(claims, "sub"),
+	}
+	if email := claimString(claims, "email"); email != "" {
+		metadata["email"] = email
+		metadata["key-name"] = email
+	} else {
+		metadata["key-name"] = principal
+	}
+	if issuer := claimString(claims, "iss"); issuer != "" {
+		metadata["issuer"] = issuer
+	}
+	if len(groups) > 0 {
+		metadata["groups"] = strings.Join(groups, ",")
+	}
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: principal,
+		Metadata:  metadata,
+		Models:    rules,
+	}, nil
+}
+
+// verify checks the signature and the registered claims and returns the token claims.
+func (p *provider) verify(ctx context.Context, raw string) (map[string]any, error) {
+	token, errParse := parseToken(raw)
+	if errParse != nil {
+		return nil, errParse
+	}
+	alg := token.header.Algorithm
+	if _, ok := algorithmHash(alg); !ok {
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	verified := false
+	for _, key := range p.keys.candidates(ctx, token.header.KeyID) {
+		if key.alg != "" && key.alg != alg {
+			continue
+		}
+		if verifySignature(alg, key.key, token.signingInput, token.signature) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("no trusted key verifies the signature (kid %q)", token.header.KeyID)
+	}
+
+	now := p.now()
+	expiresAt, ok := claimTime(token.claims, "exp")
+	if !ok {
+		return nil, fmt.Errorf("missing exp claim")
+	}
+	if !now.Before(expiresAt.Add(p.clockSkew)) {
+		return nil, fmt.Errorf("token expired at %s", expiresAt.UTC().Format(time.RFC3339))
+	}
+	if notBefore, hasNotBefore := claimTime(token.claims, "nbf"); hasNotBefore && now.Add(p.clockSkew).Before(notBefore) {
+		return nil, fmt.Errorf("token not valid before %s", notBefore.UTC().Format(time.RFC3339))
+	}
+	if p.cfg.Issuer != "" && claimString(token.claims, "iss") != p.cfg.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claimString(token.claims, "iss"))
+	}
+	if len(p.cfg.Audience) > 0 {
+		audiences := claimStrings(token.claims, "aud")
+		if !slices.ContainsFunc(audiences, func(aud string) bool { return slices.Contains(p.cfg.Audience, aud) }) {
+			return nil, fmt.Errorf("unexpected audience %v", audiences)
+		}
+	}
+	return token.claims, nil
+}
+
+// modelRules returns the rules of the first group rule the token carries.
+func (p *provider) modelRules(groups []string) (*sdkaccess.ModelRules, bool) {
+	for _, rule := range p.cfg.GroupRules {
+		if !slices.Contains(groups, rule.Group) {
+			continue
+		}
+		rules := &sdkaccess.ModelRules{
+			Allowed:         append([]string(nil), rule.AllowedModels...),
+			Denied:          append([]string(nil), rule.DeniedModels...),
+			AllowedPrefixes: append([]string(nil), rule.AllowedPrefixes...),
+		}
+		if rules.Empty() {
+			return nil, true
+		}
+		return rules, true
+	}
+	return nil, false
+}
+
+func claimString(claims map[string]any, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case json.Number:
+		return value.String()
+	default:
+		return ""
+	}
+}
+
+// claimStrings reads a claim that may be a single string or an array of strings, as "aud"
+// and group claims commonly are.
+func claimStrings(claims map[string]any, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return []string{trimmed}
+		}
+	case []any:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			if text, ok := item.(string); ok && strings.TrimSpace(text) != "" {
+				out = append(out, strings.TrimSpace(text))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func claimTime(claims map[string]any, name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, errFloat := number.Float64()
+	if errFloat != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
@@ -0,0 +1,380 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+var testNow = time.Date(2026, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	input := tokenInput(t, map[string]any{"alg": "RS256", "kid": kid, "typ": "JWT"}, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, errSign := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if errSign != nil {
+		t.Fatalf("SignPKCS1v15() error = %v", errSign)
+	}
+	return input + "." + b64(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	input := tokenInput(t, map[string]any{"alg": "ES256", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(input))
+	r, s, errSign := ecdsa.Sign(rand.Reader, key, digest[:])
+	if errSign != nil {
+		t.Fatalf("ecdsa.Sign() error = %v", errSign)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return input + "." + b64(signature)
+}
+
+func tokenInput(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+	headerJSON, errHeader := json.Marshal(header)
+	claimsJSON, errClaims := json.Marshal(claims)
+	if errHeader != nil || errClaims != nil {
+		t.Fatalf("marshal token: %v %v", errHeader, errClaims)
+	}
+	return b64(headerJSON) + "." + b64(claimsJSON)
+}
+
+func writeJWKS(t *testing.T, path string, rsaKeys map[string]*rsa.PublicKey, ecKeys map[string]*ecdsa.PublicKey) {
+	t.Helper()
+	keys := make([]map[string]string, 0, len(rsaKeys)+len(ecKeys))
+	for kid, key := range rsaKeys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+			"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	for kid, key := range ecKeys {
+		point, errBytes := key.Bytes()
+		if errBytes != nil {
+			t.Fatalf("ecdsa Bytes() error = %v", errBytes)
+		}
+		keys = append(keys, map[string]string{
+			"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(point[1:33]), "y": b64(point[33:]),
+		})
+	}
+	data, errMarshal := json.Marshal(map[string]any{"keys": keys})
+	if errMarshal != nil {
+		t.Fatalf("marshal JWKS: %v", errMarshal)
+	}
+	if errWrite := os.WriteFile(path, data, 0o600); errWrite != nil {
+		t.Fatalf("write JWKS: %v", errWrite)
+	}
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":    "https://sso.example.com",
+		"aud":    []string{"cliproxy", "other"},
+		"sub":    "user-123",
+		"email":  "dev@example.com",
+		"groups": []string{"engineering", "staff"},
+		"exp":    testNow.Add(time.Hour).Unix(),
+		"nbf":    testNow.Add(-time.Minute).Unix(),
+	}
+}
+
+func newTestProvider(t *testing.T, cfg sdkconfig.JWTAuthConfig, now *time.Time) *provider {
+	t.Helper()
+	cfg.Enable = true
+	p, errProvider := newProvider(cfg)
+	if errProvider != nil {
+		t.Fatalf("newProvider() error = %v", errProvider)
+	}
+	p.now = func() time.Time { return *now }
+	p.keys.now = p.now
+	return p
+}
+
+func authenticate(p *provider, token string) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	request := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	return p.Authenticate(context.Background(), request)
+}
+
+func TestProviderAuthenticatesTokensFromJWKSFile(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]*rsa.PublicKey{"rsa-1": &rsaKey.PublicKey}, map[string]*ecdsa.PublicKey{"ec-1": &ecKey.PublicKey})
+
+	now := testNow
+	p := newTestProvider(t, sdkconfig.JWTAuthConfig{
+		JWKSFile: jwksPath,
+		Issuer:   "https://sso.example.com",
+		Audience: []string{"cliproxy"},
+		GroupRules: []sdkconfig.JWTGroupRule{
+			{Group: "contractors", AllowedModels: []string{"gpt-5-mini"}},
+			{Group: "engineering", AllowedModels: []string{"gpt-5*", "claude-*"}},
+		},
+	}, &now)
+
+	result, authErr := authenticate(p, signRS256(t, rsaKey, "rsa-1", validClaims()))
+	if authErr != nil {
+		t.Fatalf("Authenticate(RS256) error = %v", authErr)
+	}
+	if result.Provider != "jwt" || result.Principal != "user-123" {
+		t.Fatalf("Authenticate(RS256) = %+v, want jwt principal user-123", result)
+	}
+	if result.Metadata["email"] != "dev@example.com" || result.Metadata["groups"] != "engineering,staff" || result.Metadata["key-name"] != "dev@example.com" {
+		t.Fatalf("metadata = %v", result.Metadata)
+	}
+	if result.Models == nil || !result.Models.Allows("claude-sonnet-4") || result.Models.Allows("gemini-2.5-pro") {
+		t.Fatalf("model rules = %+v, want engineering rule", result.Models)
+	}
+
+	if _, authErr = authenticate(p, signES256(t, ecKey, "ec-1", validClaims())); authErr != nil {
+		t.Fatalf("Authenticate(ES256) error = %v", authErr)
+	}
+}
+
+func TestProviderRejectsInvalidTokens(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]*rsa.PublicKey{"rsa-1": &rsaKey.PublicKey}, nil)
+
+	now := testNow
+	p := newTestProvider(t, sdkconfig.JWTAuthConfig{
+		JWKSFile:          jwksPath,
+		Issuer:            "https://sso.example.com",
+		Audience:          []string{"cliproxy"},
+		RequireGroupMatch: true,
+		GroupRules:        []sdkconfig.JWTGroupRule{{Group: "engineering"}},
+	}, &now)
+
+	with := func(name string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	unsigned := tokenInput(t, map[string]any{"alg": "none"}, validClaims()) + "."
+	cases := map[string]string{
+		"expired":        signRS256(t, rsaKey, "rsa-1", with("exp", testNow.Add(-2*time.Minute).Unix())),
+		"missing exp":    signRS256(t, rsaKey, "rsa-1", with("exp", nil)),
+		"not yet valid":  signRS256(t, rsaKey, "rsa-1", with("nbf", testNow.Add(5*time.Minute).Unix())),
+		"wrong issuer":   signRS256(t, rsaKey, "rsa-1", with("iss", "https://evil.example.com")),
+		"wrong audience": signRS256(t, rsaKey, "rsa-1", with("aud", "someone-else")),
+		"no group match": signRS256(t, rsaKey, "rsa-1", with("groups", []string{"sales"})),
+		"bad signature":  signRS256(t, otherKey, "rsa-1", validClaims()),
+		"alg none":       unsigned,
+	}
+	for name, token := range cases {
+		if _, authErr := authenticate(p, token); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Errorf("%s: Authenticate() error = %v, want invalid credential", name, authErr)
+		}
+	}
+
+	// Expiry within the clock skew is still accepted.
+	if _, authErr := authenticate(p, signRS256(t, rsaKey, "rsa-1", with("exp", testNow.Add(-30*time.Second).Unix()))); authErr != nil {
+		t.Fatalf("Authenticate() within clock skew error = %v", authErr)
+	}
+
+	// Opaque API keys are left to other providers.
+	if _, authErr := authenticate(p, "sk-plain-client-key"); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("Authenticate(api key) error = %v, want not handled", authErr)
+	}
+}
+
+func TestProviderReloadsKeySetForUnknownKeyID(t *testing.T) {
+	firstKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rotatedKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]*rsa.PublicKey{"key-1": &firstKey.PublicKey}, nil)
+
+	now := testNow
+	p := newTestProvider(t, sdkconfig.JWTAuthConfig{JWKSFile: jwksPath}, &now)
+	if _, authErr := authenticate(p, signRS256(t, firstKey, "key-1", validClaims())); authErr != nil {
+		t.Fatalf("Authenticate(key-1) error = %v", authErr)
+	}
+
+	writeJWKS(t, jwksPath, map[string]*rsa.PublicKey{"key-2": &rotatedKey.PublicKey}, nil)
+	longLived := validClaims()
+	longLived["exp"] = testNow.Add(24 * time.Hour).Unix()
+	rotated := signRS256(t, rotatedKey, "key-2", longLived)
+	if _, authErr := authenticate(p, rotated); authErr == nil {
+		t.Fatal("Authenticate(key-2) succeeded before the unknown-kid reload window")
+	}
+	now = now.Add(unknownKeyRefreshInterval)
+	if _, authErr := authenticate(p, rotated); authErr != nil {
+		t.Fatalf("Authenticate(key-2) after reload error = %v", authErr)
+	}
+
+	// A broken key set keeps the last good keys.
+	if errWrite := os.WriteFile(jwksPath, []byte("not json"), 0o600); errWrite != nil {
+		t.Fatalf("write JWKS: %v", errWrite)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, authErr := authenticate(p, rotated); authErr != nil {
+		t.Fatalf("Authenticate(key-2) after failed reload error = %v", authErr)
+	}
+}
+
+func TestKeySetFetchesOutsideLockAndSurvivesCancelledCallers(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]*rsa.PublicKey{"key-1": &rsaKey.PublicKey}, nil)
+	body, errRead := os.ReadFile(jwksPath)
+	if errRead != nil {
+		t.Fatalf("read JWKS: %v", errRead)
+	}
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	now := testNow
+	p := newTestProvider(t, sdkconfig.JWTAuthConfig{JWKSURL: server.URL}, &now)
+	claims := validClaims()
+	claims["exp"] = testNow.Add(24 * time.Hour).Unix()
+	token := signRS256(t, rsaKey, "key-1", claims)
+	if _, authErr := authenticate(p, token); authErr != nil {
+		t.Fatalf("Authenticate() error = %v", authErr)
+	}
+
+	// The refresh hangs upstream; verification keeps using the previous keys meanwhile.
+	now = now.Add(2 * time.Hour)
+	if _, authErr := authenticate(p, token); authErr != nil {
+		t.Fatalf("Authenticate() during slow refresh error = %v", authErr)
+	}
+
+	// A caller waiting on an unknown kid gives up with its own context, not the fetch.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if keys := p.keys.candidates(ctx, "key-2"); len(keys) != 0 {
+		t.Fatalf("candidates(key-2) = %d keys, want none", len(keys))
+	}
+	for deadline := time.Now().Add(time.Second); fetches.Load() < 2 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	p.keys.mu.Lock()
+	inFlight := p.keys.reloading != nil
+	p.keys.mu.Unlock()
+	if got := fetches.Load(); got != 2 || !inFlight {
+		t.Fatalf("fetches = %d, in flight = %v; want the refresh still running", got, inFlight)
+	}
+	if keys := p.keys.candidates(context.Background(), "key-1"); len(keys) != 1 {
+		t.Fatalf("candidates(key-1) = %d keys, want the previous key", len(keys))
+	}
+}
+
+func TestProviderAcceptsStaticPEMKeys(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, errMarshal := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if errMarshal != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", errMarshal)
+	}
+	now := testNow
+	p := newTestProvider(t, sdkconfig.JWTAuthConfig{
+		StaticKeys:     []sdkconfig.JWTStaticKey{{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}},
+		PrincipalClaim: "email",
+	}, &now)
+
+	result, authErr := authenticate(p, signES256(t, ecKey, "", validClaims()))
+	if authErr != nil {
+		t.Fatalf("Authenticate() error = %v", authErr)
+	}
+	if result.Principal != "dev@example.com" || result.Models != nil {
+		t.Fatalf("Authenticate() = %+v, want unrestricted email principal", result)
+	}
+}
+
+func TestRegisterKeepsProviderForUnchangedConfig(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{JWTAuth: sdkconfig.JWTAuthConfig{
+		Enable:     true,
+		JWKSURL:    "https://sso.example.com/jwks",
+		GroupRules: []sdkconfig.JWTGroupRule{{Group: "engineering"}},
+	}}
+	Register(cfg)
+	first := registered
+	Register(cfg)
+	if registered == nil || registered != first {
+		t.Fatal("Register() replaced the provider for an unchanged config")
+	}
+	Register(&sdkconfig.SDKConfig{})
+	if registered != nil {
+		t.Fatal("Register() kept the provider after jwt-auth was disabled")
+	}
+	for _, p := range sdkaccess.RegisteredProviders() {
+		if p.Identifier() == providerName {
+			t.Fatal("jwt provider still registered after disable")
+		}
+	}
+}
+
+func TestKeyChecksFollowRFC7518(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if errKey := keyAllowsAlgorithm(&p256.PublicKey, "ES256"); errKey != nil {
+		t.Fatalf("ES256 with P-256: %v", errKey)
+	}
+	if errKey := keyAllowsAlgorithm(&p521.PublicKey, "ES256"); errKey == nil {
+		t.Fatal("ES256 accepted a P-521 key")
+	}
+	if errVerify := verifySignature("ES384", &p256.PublicKey, []byte("input"), make([]byte, 64)); errVerify == nil {
+		t.Fatal("verifySignature() accepted ES384 with a P-256 key")
+	}
+
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	weakDER := x509.MarshalPKCS1PublicKey(&weak.PublicKey)
+	if _, errParse := parsePEMPublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: weakDER}))); errParse == nil {
+		t.Fatal("parsePEMPublicKey() accepted a 1024-bit RSA key")
+	}
+
+	strong, _ := rsa.GenerateKey(rand.Reader, 2048)
+	point, _ := p256.PublicKey.Bytes()
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "weak", "alg": "RS256", "n": b64(weak.N.Bytes()), "e": b64(big.NewInt(int64(weak.E)).Bytes())},
+		{"kty": "RSA", "kid": "strong", "alg": "RS256", "n": b64(strong.N.Bytes()), "e": b64(big.NewInt(int64(strong.E)).Bytes())},
+		{"kty": "EC", "kid": "wrong-alg", "alg": "ES384", "crv": "P-256", "x": b64(point[1:33]), "y": b64(point[33:])},
+		{"kty": "EC", "kid": "rsa-alg", "alg": "RS256", "crv": "P-256", "x": b64(point[1:33]), "y": b64(point[33:])},
+	}})
+	keys, errParse := parseJWKS(jwks)
+	if errParse != nil {
+		t.Fatalf("parseJWKS() error = %v", errParse)
+	}
+	if len(keys) != 1 || keys[0].kid != "strong" {
+		t.Fatalf("parseJWKS() kept %+v, want only the strong RSA key", keys)
+	}
+}
@@ -0,0 +1,171 @@
+package jwtaccess
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 for crypto.Hash
+	_ "crypto/sha512" // register SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type parsedToken struct {
+	header       tokenHeader
+	claims       map[string]any
+	signingInput []byte
+	signature    []byte
+}
+
+var errMalformedToken = errors.New("malformed token")
+
+// looksLikeJWT reports whether raw is a compact JWS with a JSON header carrying "alg", so
+// opaque API keys are left to other providers.
+func looksLikeJWT(raw string) bool {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return false
+	}
+	headerJSON, errDecode := base64.RawURLEncoding.DecodeString(parts[0])
+	if errDecode != nil {
+		return false
+	}
+	var header tokenHeader
+	return json.Unmarshal(headerJSON, &header) == nil && header.Algorithm != ""
+}
+
+func parseToken(raw string) (*parsedToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+	headerJSON, errHeader := base64.RawURLEncoding.DecodeString(parts[0])
+	if errHeader != nil {
+		return nil, errMalformedToken
+	}
+	payloadJSON, errPayload := base64.RawURLEncoding.DecodeString(parts[1])
+	if errPayload != nil {
+		return nil, errMalformedToken
+	}
+	signature, errSignature := base64.RawURLEncoding.DecodeString(parts[2])
+	if errSignature != nil {
+		return nil, errMalformedToken
+	}
+	token := &parsedToken{signingInput: []byte(parts[0] + "." + parts[1]), signature: signature}
+	if errUnmarshal := json.Unmarshal(headerJSON, &token.header); errUnmarshal != nil {
+		return nil, errMalformedToken
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payloadJSON))
+	decoder.UseNumber()
+	if errDecode := decoder.Decode(&token.claims); errDecode != nil || token.claims == nil {
+		return nil, errMalformedToken
+	}
+	return token, nil
+}
+
+// algorithmHash maps a JWS algorithm to its digest. HMAC and "none" are not supported:
+// the provider only trusts asymmetric keys published by the identity provider.
+func algorithmHash(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		return crypto.SHA256, true
+	case "RS384", "PS384", "ES384":
+		return crypto.SHA384, true
+	case "RS512", "PS512", "ES512":
+		return crypto.SHA512, true
+	case "EdDSA":
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// keyAllowsAlgorithm reports whether key may verify alg. RFC 7518 ties each ES algorithm
+// to one curve, and RSA keys shorter than minRSAKeyBits are never trusted.
+func keyAllowsAlgorithm(key crypto.PublicKey, alg string) error {
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") && !strings.HasPrefix(alg, "PS") {
+			break
+		}
+		if publicKey.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("RSA key has %d bits, want at least %d", publicKey.N.BitLen(), minRSAKeyBits)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if curve := ecdsaAlgorithmCurve(alg); curve != nil && curve.Params().Name == publicKey.Curve.Params().Name {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if alg == "EdDSA" {
+			return nil
+		}
+	}
+	return fmt.Errorf("key type %T does not match algorithm %q", key, alg)
+}
+
+func ecdsaAlgorithmCurve(alg string) elliptic.Curve {
+	switch alg {
+	case "ES256":
+		return elliptic.P256()
+	case "ES384":
+		return elliptic.P384()
+	case "ES512":
+		return elliptic.P521()
+	default:
+		return nil
+	}
+}
+
+// verifySignature checks the token signature with key, which must suit the algorithm.
+func verifySignature(alg string, key crypto.PublicKey, signingInput, signature []byte) error {
+	hash, ok := algorithmHash(alg)
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if errKey := keyAllowsAlgorithm(key, alg); errKey != nil {
+		return errKey
+	}
+	var digest []byte
+	if hash != 0 {
+		hasher := hash.New()
+		hasher.Write(signingInput)
+		digest = hasher.Sum(nil)
+	}
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(publicKey, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(publicKey, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ECDSA signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(publicKey, signingInput, signature) {
+			return errors.New("invalid EdDSA signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("key type %T does not match algorithm %q", key, alg)
+}
@@ -0,0 +1,136 @@
+// Package mtlsaccess implements the mtls access provider, which maps the verified TLS client
+// certificate of a request to a principal with optional per-principal model rules.
+package mtlsaccess
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+const providerName = "mtls"
+
+// Register ensures the mtls provider matches cfg.MTLSAuth.
+func Register(cfg *sdkconfig.SDKConfig) {
+	if cfg == nil || !cfg.MTLSAuth.Enable {
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeMTLS)
+		return
+	}
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeMTLS, newProvider(cfg.MTLSAuth))
+}
+
+type provider struct {
+	source       string
+	principals   map[string]*sdkaccess.ModelRules
+	requireKnown bool
+}
+
+func newProvider(cfg sdkconfig.MTLSAuthConfig) *provider {
+	p := &provider{
+		source:       cfg.NormalizedPrincipalSource(),
+		principals:   make(map[string]*sdkaccess.ModelRules, len(cfg.Principals)),
+		requireKnown: cfg.RequireKnownPrincipal,
+	}
+	for _, entry := range cfg.Principals {
+		name := strings.TrimSpace(entry.Name)
+		if name == "" {
+			continue
+		}
+		rules := &sdkaccess.ModelRules{
+			Allowed:         append([]string(nil), entry.AllowedModels...),
+			Denied:          append([]string(nil), entry.DeniedModels...),
+			AllowedPrefixes: append([]string(nil), entry.AllowedPrefixes...),
+		}
+		if rules.Empty() {
+			rules = nil
+		}
+		p.principals[name] = rules
+	}
+	return p
+}
+
+func (p *provider) Identifier() string {
+	return providerName
+}
+
+// Authenticate accepts requests whose TLS client certificate was verified against
+// tls.client-ca during the handshake.
+func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	principal := principalFromCertificate(cert, p.source)
+	if principal == "" {
+		log.Debugf("mtls access: rejected certificate %q without a %s principal", cert.Subject.String(), p.source)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	rules, known := p.principals[principal]
+	if !known && p.requireKnown {
+		log.Debugf("mtls access: rejected unknown principal %q", principal)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	metadata := map[string]string{
+		"source":   "client-certificate",
+		"key-name": principal,
+		"subject":  cert.Subject.String(),
+		"issuer":   cert.Issuer.String(),
+		"serial":   cert.SerialNumber.String(),
+	}
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: principal,
+		Metadata:  metadata,
+		Models:    rules,
+	}, nil
+}
+
+// principalFromCertificate reads the configured field. The "cn" source falls back to the
+// first SAN because mesh certificates often leave the common name empty.
+func principalFromCertificate(cert *x509.Certificate, source string) string {
+	firstURI := func() string {
+		for _, uri := range cert.URIs {
+			if uri != nil {
+				return uri.String()
+			}
+		}
+		return ""
+	}
+	firstOf := func(values []string) string {
+		for _, value := range values {
+			if trimmed := strings.TrimSpace(value); trimmed != "" {
+				return trimmed
+			}
+		}
+		return ""
+	}
+	switch source {
+	case sdkconfig.MTLSPrincipalSourceSANURI:
+		return firstURI()
+	case sdkconfig.MTLSPrincipalSourceSANDNS:
+		return firstOf(cert.DNSNames)
+	case sdkconfig.MTLSPrincipalSourceSANEmail:
+		return firstOf(cert.EmailAddresses)
+	default:
+		if cn := strings.TrimSpace(cert.Subject.CommonName); cn != "" {
+			return cn
+		}
+		if uri := firstURI(); uri != "" {
+			return uri
+		}
+		if dns := firstOf(cert.DNSNames); dns != "" {
+			return dns
+		}
+		return firstOf(cert.EmailAddresses)
+	}
+}
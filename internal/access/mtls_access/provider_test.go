@@ -0,0 +1,111 @@
+package mtlsaccess
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func issueCertificate(t *testing.T, template *x509.Certificate) *x509.Certificate {
+	t.Helper()
+	key, errKey := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if errKey != nil {
+		t.Fatalf("GenerateKey() error = %v", errKey)
+	}
+	template.SerialNumber = big.NewInt(42)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, errCreate := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if errCreate != nil {
+		t.Fatalf("CreateCertificate() error = %v", errCreate)
+	}
+	cert, errParse := x509.ParseCertificate(der)
+	if errParse != nil {
+		t.Fatalf("ParseCertificate() error = %v", errParse)
+	}
+	return cert
+}
+
+func authenticate(p *provider, cert *x509.Certificate) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	request := httptest.NewRequest("POST", "https://proxy.internal/v1/chat/completions", nil)
+	if cert != nil {
+		request.TLS = &tls.ConnectionState{
+			HandshakeComplete: true,
+			PeerCertificates:  []*x509.Certificate{cert},
+			VerifiedChains:    [][]*x509.Certificate{{cert}},
+		}
+	}
+	return p.Authenticate(context.Background(), request)
+}
+
+func TestProviderMapsCertificateToPrincipal(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://mesh.internal/ns/billing/sa/worker")
+	cnCert := issueCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "search-service"}})
+	meshCert := issueCertificate(t, &x509.Certificate{URIs: []*url.URL{spiffe}, DNSNames: []string{"worker.billing.svc"}})
+
+	p := newProvider(sdkconfig.MTLSAuthConfig{
+		Enable: true,
+		Principals: []sdkconfig.MTLSPrincipal{
+			{Name: "search-service", AllowedModels: []string{"gpt-5-mini"}},
+		},
+	})
+	result, authErr := authenticate(p, cnCert)
+	if authErr != nil {
+		t.Fatalf("Authenticate(cn) error = %v", authErr)
+	}
+	if result.Provider != "mtls" || result.Principal != "search-service" || result.Metadata["key-name"] != "search-service" {
+		t.Fatalf("Authenticate(cn) = %+v", result)
+	}
+	if result.Models == nil || result.Models.Allows("gpt-5") || !result.Models.Allows("gpt-5-mini") {
+		t.Fatalf("model rules = %+v, want gpt-5-mini only", result.Models)
+	}
+
+	// Without a common name the first SAN is used, and unlisted principals are unrestricted.
+	result, authErr = authenticate(p, meshCert)
+	if authErr != nil {
+		t.Fatalf("Authenticate(mesh) error = %v", authErr)
+	}
+	if result.Principal != spiffe.String() || result.Models != nil {
+		t.Fatalf("Authenticate(mesh) = %+v, want unrestricted SPIFFE principal", result)
+	}
+
+	dns := newProvider(sdkconfig.MTLSAuthConfig{Enable: true, PrincipalSource: "san-dns"})
+	if result, authErr = authenticate(dns, meshCert); authErr != nil || result.Principal != "worker.billing.svc" {
+		t.Fatalf("Authenticate(san-dns) = %+v, %v", result, authErr)
+	}
+}
+
+func TestProviderRejectsMissingAndUnknownCertificates(t *testing.T) {
+	cert := issueCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "unknown-service"}})
+	p := newProvider(sdkconfig.MTLSAuthConfig{
+		Enable:                true,
+		RequireKnownPrincipal: true,
+		Principals:            []sdkconfig.MTLSPrincipal{{Name: "search-service"}},
+	})
+
+	if _, authErr := authenticate(p, nil); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNoCredentials) {
+		t.Fatalf("Authenticate(no cert) error = %v, want no credentials", authErr)
+	}
+	if _, authErr := authenticate(p, cert); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("Authenticate(unknown) error = %v, want invalid credential", authErr)
+	}
+
+	// A presented but unverified certificate is not trusted.
+	request := httptest.NewRequest("POST", "https://proxy.internal/v1/models", nil)
+	request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if _, authErr := p.Authenticate(context.Background(), request); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNoCredentials) {
+		t.Fatalf("Authenticate(unverified) error = %v, want no credentials", authErr)
+	}
+}
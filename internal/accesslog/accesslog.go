@@ -0,0 +1,178 @@
+// Package accesslog writes one JSON line per client API request.
+//
+// The schema is stable so log shippers such as Loki or Elasticsearch can ingest the lines
+// without parsing the human-oriented gin log or the multi-section request logs. New fields
+// may be added; existing fields keep their names and meaning.
+package accesslog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const fileName = "access.log"
+
+// Entry is one access log line.
+type Entry struct {
+	Time            time.Time `json:"time"`
+	RequestID       string    `json:"request_id,omitempty"`
+	TraceID         string    `json:"trace_id,omitempty"`
+	Principal       string    `json:"principal,omitempty"`
+	Protocol        string    `json:"protocol"`
+	Method          string    `json:"method"`
+	Path            string    `json:"path"`
+	ClientIP        string    `json:"client_ip"`
+	RequestedModel  string    `json:"requested_model,omitempty"`
+	ResolvedModel   string    `json:"resolved_model,omitempty"`
+	Provider        string    `json:"provider,omitempty"`
+	AuthIndex       string    `json:"auth_index,omitempty"`
+	Retries         int       `json:"retries"`
+	Status          int       `json:"status"`
+	TTFBMs          int64     `json:"ttfb_ms"`
+	DurationMs      int64     `json:"duration_ms"`
+	RequestBytes    int64     `json:"request_bytes"`
+	ResponseBytes   int64     `json:"response_bytes"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	CachedTokens    int64     `json:"cached_tokens"`
+	TotalTokens     int64     `json:"total_tokens"`
+}
+
+// Logger writes access entries to a rotating file or stdout.
+type Logger struct {
+	mu       sync.Mutex
+	settings config.AccessLogConfig
+	dir      string
+	writer   io.Writer
+	closer   io.Closer
+	stdout   io.Writer
+}
+
+// NewLogger creates a disabled logger.
+func NewLogger() *Logger {
+	return &Logger{stdout: os.Stdout}
+}
+
+var defaultLogger = NewLogger()
+
+// Default returns the process-wide logger used by the API server.
+func Default() *Logger {
+	return defaultLogger
+}
+
+// Configure applies cfg to the process-wide logger.
+func Configure(cfg *config.Config) {
+	defaultLogger.Configure(cfg)
+}
+
+// Configure applies access-log settings. The file is reopened when its location or
+// rotation settings change.
+func (l *Logger) Configure(cfg *config.Config) {
+	if l == nil {
+		return
+	}
+	var settings config.AccessLogConfig
+	dir := ""
+	if cfg != nil {
+		settings = cfg.AccessLog
+		dir = strings.TrimSpace(settings.Dir)
+		if dir == "" {
+			dir = filepath.Join(logging.ResolveLogDirectory(cfg), "access")
+		}
+	}
+	settings.Output = settings.NormalizedOutput()
+	if settings.MaxSizeMB <= 0 {
+		settings.MaxSizeMB = config.DefaultAccessLogMaxSizeMB
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer != nil && (!settings.Enable || dir != l.dir || settings != l.settings) {
+		l.closeLocked()
+	}
+	l.settings = settings
+	l.dir = dir
+}
+
+// Enabled reports whether requests are logged.
+func (l *Logger) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.settings.Enable
+}
+
+// Write appends entry as one JSON line.
+func (l *Logger) Write(entry Entry) {
+	if l == nil {
+		return
+	}
+	line, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		log.WithError(errMarshal).Warn("access log: failed to encode entry")
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.settings.Enable {
+		return
+	}
+	if l.writer == nil && !l.openLocked() {
+		return
+	}
+	if _, errWrite := l.writer.Write(append(line, '\n')); errWrite != nil {
+		log.WithError(errWrite).Warn("access log: failed to write entry")
+	}
+}
+
+// Close releases the log file.
+func (l *Logger) Close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closeLocked()
+}
+
+func (l *Logger) openLocked() bool {
+	if l.settings.Output == config.AccessLogOutputStdout {
+		l.writer = l.stdout
+		return true
+	}
+	if errMkdir := os.MkdirAll(l.dir, 0o700); errMkdir != nil {
+		log.WithError(errMkdir).Warn("access log: failed to create log directory")
+		return false
+	}
+	file := &lumberjack.Logger{
+		Filename:   filepath.Join(l.dir, fileName),
+		MaxSize:    l.settings.MaxSizeMB,
+		MaxBackups: l.settings.MaxBackups,
+		MaxAge:     l.settings.MaxAgeDays,
+		Compress:   l.settings.Compress,
+	}
+	l.writer = file
+	l.closer = file
+	return true
+}
+
+func (l *Logger) closeLocked() {
+	if l.closer != nil {
+		_ = l.closer.Close()
+	}
+	l.writer = nil
+	l.closer = nil
+}
@@ -0,0 +1,45 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestLoggerWritesStdoutAndStopsWhenDisabled(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger()
+	logger.stdout = &out
+	logger.Configure(&config.Config{AccessLog: config.AccessLogConfig{Enable: true, Output: "stdout"}})
+
+	logger.Write(Entry{Time: time.Unix(0, 0).UTC(), RequestID: "a1b2c3d4", Protocol: "claude", Status: 200})
+	logger.Configure(&config.Config{})
+	logger.Write(Entry{RequestID: "dropped"})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("lines = %q, want one", out.String())
+	}
+	var decoded map[string]any
+	if errUnmarshal := json.Unmarshal([]byte(lines[0]), &decoded); errUnmarshal != nil {
+		t.Fatalf("decode: %v", errUnmarshal)
+	}
+	for _, field := range []string{"time", "request_id", "protocol", "status", "retries", "ttfb_ms", "duration_ms", "total_tokens"} {
+		if _, ok := decoded[field]; !ok {
+			t.Errorf("field %q missing from %s", field, lines[0])
+		}
+	}
+}
+
+func TestAccessLogConfigValidate(t *testing.T) {
+	if errValidate := (config.AccessLogConfig{Output: "syslog"}).Validate(); errValidate == nil {
+		t.Fatal("unknown output accepted")
+	}
+	if errValidate := (config.AccessLogConfig{Output: "STDOUT"}).Validate(); errValidate != nil {
+		t.Fatalf("stdout rejected: %v", errValidate)
+	}
+}
@@ -0,0 +1,282 @@
+// Package alerting turns credential, quota and runtime events from the activity bus into
+// webhook alerts.
+//
+// Alerts are derived from internal/events, so every source that already publishes there
+// (the auth manager, refresh loop, health probes, plugin host, budgets and the config
+// watcher) can raise one without knowing about webhooks. Repeats of the same alert for the
+// same subject are throttled.
+package alerting
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+)
+
+const (
+	// SeverityWarning marks alerts that need attention soon.
+	SeverityWarning = "warning"
+	// SeverityCritical marks alerts where traffic is already failing.
+	SeverityCritical = "critical"
+
+	subscriberBuffer = 1024
+)
+
+// Alert is one notification sent to webhooks.
+type Alert struct {
+	Kind      string     `json:"event"`
+	Severity  string     `json:"severity"`
+	Time      time.Time  `json:"time"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message,omitempty"`
+	Provider  string     `json:"provider,omitempty"`
+	AuthID    string     `json:"auth_id,omitempty"`
+	AuthIndex string     `json:"auth_index,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	PluginID  string     `json:"plugin_id,omitempty"`
+	Principal string     `json:"principal,omitempty"`
+	Status    int        `json:"status,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	Count     int        `json:"count,omitempty"`
+
+	// subject identifies what the alert is about for throttling.
+	subject string
+}
+
+// Notifier subscribes to the event bus and delivers alerts to the configured webhooks.
+type Notifier struct {
+	bus *events.Bus
+
+	mu              sync.Mutex
+	settings        config.AlertingConfig
+	sub             *events.Subscription
+	client          *http.Client
+	lastSent        map[string]time.Time
+	refreshFailures map[string]int
+	now             func() time.Time
+}
+
+// NewNotifier creates a notifier for bus. It stays idle until configured with webhooks.
+func NewNotifier(bus *events.Bus) *Notifier {
+	return &Notifier{
+		bus:             bus,
+		client:          &http.Client{},
+		lastSent:        make(map[string]time.Time),
+		refreshFailures: make(map[string]int),
+		now:             time.Now,
+	}
+}
+
+var defaultNotifier = NewNotifier(events.Default())
+
+// Default returns the process-wide notifier.
+func Default() *Notifier {
+	return defaultNotifier
+}
+
+// Configure applies cfg to the process-wide notifier.
+func Configure(cfg *config.Config) {
+	defaultNotifier.Configure(cfg)
+}
+
+// Configure applies alerting settings, subscribing to the bus while webhooks exist.
+func (n *Notifier) Configure(cfg *config.Config) {
+	if n == nil {
+		return
+	}
+	var settings config.AlertingConfig
+	if cfg != nil {
+		settings = cfg.Alerting
+	}
+	settings = settings.WithDefaults()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.settings = settings
+	n.client = &http.Client{Timeout: time.Duration(settings.TimeoutSeconds) * time.Second}
+	switch {
+	case settings.Enabled() && n.sub == nil:
+		n.sub = n.bus.Subscribe(events.Filter{Types: []string{
+			events.TypeRefreshResult,
+			events.TypeCooldownEntered,
+			events.TypeProbeResult,
+			events.TypePluginError,
+			events.TypeModelExhausted,
+			events.TypeBudgetThreshold,
+			events.TypeConfigReloadFailed,
+		}}, subscriberBuffer)
+		go n.run(n.sub)
+	case !settings.Enabled() && n.sub != nil:
+		n.sub.Close()
+		n.sub = nil
+	}
+}
+
+// Close stops listening for events.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.sub != nil {
+		n.sub.Close()
+		n.sub = nil
+	}
+}
+
+func (n *Notifier) run(sub *events.Subscription) {
+	for event := range sub.Events() {
+		n.handle(event)
+	}
+}
+
+func (n *Notifier) handle(event events.Event) {
+	n.mu.Lock()
+	alert, ok := n.alertFromEventLocked(event)
+	if !ok || !n.allowLocked(alert) {
+		n.mu.Unlock()
+		return
+	}
+	var targets []config.AlertWebhook
+	for _, webhook := range n.settings.Webhooks {
+		if webhook.Wants(alert.Kind) {
+			targets = append(targets, webhook)
+		}
+	}
+	client := n.client
+	n.mu.Unlock()
+
+	for _, webhook := range targets {
+		go deliver(client, webhook, alert)
+	}
+}
+
+// alertFromEventLocked maps an activity event to an alert. Events that do not warrant an
+// alert, including refresh failures below the threshold, return false.
+func (n *Notifier) alertFromEventLocked(event events.Event) (Alert, bool) {
+	alert := Alert{
+		Time:      event.Time,
+		Message:   event.Message,
+		Provider:  event.Provider,
+		AuthID:    event.AuthID,
+		AuthIndex: event.AuthIndex,
+		Model:     event.Model,
+		PluginID:  event.PluginID,
+		Principal: event.Principal,
+		Status:    event.Status,
+		Until:     event.Until,
+	}
+	if alert.Time.IsZero() {
+		alert.Time = n.now().UTC()
+	}
+	switch event.Type {
+	case events.TypeRefreshResult:
+		if event.Success == nil || *event.Success {
+			delete(n.refreshFailures, event.AuthID)
+			return Alert{}, false
+		}
+		n.refreshFailures[event.AuthID]++
+		count := n.refreshFailures[event.AuthID]
+		if count < n.settings.RefreshFailureThreshold {
+			return Alert{}, false
+		}
+		alert.Kind = config.AlertRefreshFailed
+		alert.Severity = SeverityWarning
+		alert.Count = count
+		alert.Title = fmt.Sprintf("Refresh failed %d times for %s credential %s", count, event.Provider, authLabel(event))
+		alert.subject = event.AuthID
+	case events.TypeCooldownEntered:
+		switch event.Status {
+		case http.StatusTooManyRequests:
+			alert.Kind = config.AlertQuotaExceeded
+			alert.Severity = SeverityWarning
+			alert.Title = fmt.Sprintf("Quota exceeded for %s credential %s", event.Provider, authLabel(event))
+			if event.Model != "" {
+				alert.Title += " on " + event.Model
+			}
+			alert.subject = event.AuthID + "|" + event.Model
+		case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden:
+			alert.Kind = config.AlertAuthUnavailable
+			alert.Severity = SeverityCritical
+			alert.Title = fmt.Sprintf("%s credential %s is unavailable (status %d)", event.Provider, authLabel(event), event.Status)
+			alert.subject = event.AuthID
+		default:
+			return Alert{}, false
+		}
+	case events.TypeProbeResult:
+		if event.Success == nil || *event.Success {
+			return Alert{}, false
+		}
+		// Only statuses that prove the credential is unusable page, matching the probe itself.
+		switch event.Status {
+		case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden:
+		default:
+			return Alert{}, false
+		}
+		alert.Kind = config.AlertAuthUnavailable
+		alert.Severity = SeverityCritical
+		alert.Title = fmt.Sprintf("%s credential %s failed its health probe (status %d)", event.Provider, authLabel(event), event.Status)
+		alert.subject = event.AuthID
+	case events.TypePluginError:
+		alert.Kind = config.AlertPluginCrashed
+		alert.Severity = SeverityCritical
+		alert.Title = fmt.Sprintf("Plugin %s crashed and was disabled", event.PluginID)
+		alert.subject = event.PluginID
+	case events.TypeModelExhausted:
+		alert.Kind = config.AlertModelExhausted
+		alert.Severity = SeverityCritical
+		alert.Title = fmt.Sprintf("All credentials for %s are cooling down", event.Model)
+		alert.subject = event.Model
+	case events.TypeBudgetThreshold:
+		alert.Kind = config.AlertBudgetThreshold
+		alert.Severity = SeverityWarning
+		alert.Title = fmt.Sprintf("Budget threshold crossed for client key %s", event.Principal)
+		// Each threshold is reported once per window already; keep distinct thresholds apart.
+		alert.subject = event.Principal + "|" + event.Message
+	case events.TypeConfigReloadFailed:
+		alert.Kind = config.AlertConfigReloadFailed
+		alert.Severity = SeverityCritical
+		alert.Title = "Config reload failed"
+		if event.Path != "" {
+			alert.Title += ": " + event.Path
+		}
+		alert.subject = event.Path
+	default:
+		return Alert{}, false
+	}
+	return alert, true
+}
+
+// allowLocked applies per-event throttling and records the send.
+func (n *Notifier) allowLocked(alert Alert) bool {
+	window := time.Duration(n.settings.ThrottleFor(alert.Kind)) * time.Second
+	key := alert.Kind + "|" + alert.subject
+	now := n.now()
+	if last, ok := n.lastSent[key]; ok && now.Sub(last) < window {
+		return false
+	}
+	n.lastSent[key] = now
+	longest := time.Duration(n.settings.ThrottleSeconds) * time.Second
+	for _, seconds := range n.settings.EventThrottleSeconds {
+		longest = max(longest, time.Duration(seconds)*time.Second)
+	}
+	for candidate, last := range n.lastSent {
+		if now.Sub(last) >= longest && candidate != key {
+			delete(n.lastSent, candidate)
+		}
+	}
+	return true
+}
+
+func authLabel(event events.Event) string {
+	if label := strings.TrimSpace(event.AuthID); label != "" {
+		return label
+	}
+	return event.AuthIndex
+}
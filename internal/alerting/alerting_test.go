@@ -0,0 +1,139 @@
+package alerting
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+)
+
+func TestNotifierDeliversSignedAlertsWithThreshold(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan delivery, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{header: r.Header.Clone(), body: body}
+	}))
+	defer server.Close()
+
+	bus := events.NewBus()
+	notifier := NewNotifier(bus)
+	cfg := &config.Config{Alerting: config.AlertingConfig{
+		RefreshFailureThreshold: 2,
+		Webhooks:                []config.AlertWebhook{{URL: server.URL, Secret: "s3cret"}},
+	}}
+	notifier.Configure(cfg)
+	defer notifier.Close()
+
+	failed := false
+	refreshFailed := events.Event{Type: events.TypeRefreshResult, Provider: "claude", AuthID: "claude-a.json", Success: &failed, Message: "invalid_grant"}
+	bus.Publish(refreshFailed)
+	bus.Publish(refreshFailed)
+	bus.Publish(refreshFailed)
+
+	var got delivery
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert delivered")
+	}
+	var alert map[string]any
+	if errUnmarshal := json.Unmarshal(got.body, &alert); errUnmarshal != nil {
+		t.Fatalf("decode alert: %v", errUnmarshal)
+	}
+	if alert["event"] != config.AlertRefreshFailed || alert["count"] != float64(2) || alert["auth_id"] != "claude-a.json" {
+		t.Fatalf("unexpected alert %s", got.body)
+	}
+	timestamp := got.header.Get(TimestampHeader)
+	if want := "sha256=" + Sign("s3cret", timestamp, got.body); got.header.Get(SignatureHeader) != want {
+		t.Fatalf("signature = %q, want %q", got.header.Get(SignatureHeader), want)
+	}
+	select {
+	case extra := <-received:
+		t.Fatalf("third failure was not throttled: %s", extra.body)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestAlertFromEventMapping(t *testing.T) {
+	notifier := NewNotifier(events.NewBus())
+	notifier.settings = config.AlertingConfig{}.WithDefaults()
+	failed := false
+	cases := []struct {
+		event events.Event
+		kind  string
+	}{
+		{events.Event{Type: events.TypeCooldownEntered, AuthID: "a", Model: "m", Status: http.StatusTooManyRequests}, config.AlertQuotaExceeded},
+		{events.Event{Type: events.TypeCooldownEntered, AuthID: "a", Status: http.StatusForbidden}, config.AlertAuthUnavailable},
+		{events.Event{Type: events.TypeCooldownEntered, AuthID: "a", Status: http.StatusBadGateway}, ""},
+		{events.Event{Type: events.TypeProbeResult, AuthID: "a", Status: http.StatusUnauthorized, Success: &failed}, config.AlertAuthUnavailable},
+		{events.Event{Type: events.TypeProbeResult, AuthID: "a", Status: http.StatusServiceUnavailable, Success: &failed}, ""},
+		{events.Event{Type: events.TypeProbeResult, AuthID: "a", Status: http.StatusBadRequest, Success: &failed}, ""},
+		{events.Event{Type: events.TypeModelExhausted, Model: "claude-sonnet-4-5"}, config.AlertModelExhausted},
+		{events.Event{Type: events.TypePluginError, PluginID: "p"}, config.AlertPluginCrashed},
+		{events.Event{Type: events.TypeBudgetThreshold, Principal: "team-a"}, config.AlertBudgetThreshold},
+		{events.Event{Type: events.TypeConfigReloadFailed, Path: "config.yaml"}, config.AlertConfigReloadFailed},
+	}
+	for _, tc := range cases {
+		alert, ok := notifier.alertFromEventLocked(tc.event)
+		if tc.kind == "" {
+			if ok {
+				t.Errorf("%s status %d raised %s, want none", tc.event.Type, tc.event.Status, alert.Kind)
+			}
+			continue
+		}
+		if !ok || alert.Kind != tc.kind {
+			t.Errorf("%s status %d raised %q, want %q", tc.event.Type, tc.event.Status, alert.Kind, tc.kind)
+		}
+	}
+}
+
+func TestEncodeChatFormats(t *testing.T) {
+	alert := Alert{Kind: config.AlertModelExhausted, Severity: SeverityCritical, Title: "All credentials for m are cooling down", Model: "m", Time: time.Unix(0, 0)}
+
+	slack, errSlack := Encode(config.AlertFormatSlack, alert)
+	if errSlack != nil {
+		t.Fatalf("encode slack: %v", errSlack)
+	}
+	var slackBody struct {
+		Text        string           `json:"text"`
+		Attachments []map[string]any `json:"attachments"`
+	}
+	if errUnmarshal := json.Unmarshal(slack, &slackBody); errUnmarshal != nil || slackBody.Text == "" || len(slackBody.Attachments) != 1 {
+		t.Fatalf("unexpected slack payload %s", slack)
+	}
+
+	discord, errDiscord := Encode(config.AlertFormatDiscord, alert)
+	if errDiscord != nil {
+		t.Fatalf("encode discord: %v", errDiscord)
+	}
+	var discordBody struct {
+		Embeds []struct {
+			Title string `json:"title"`
+			Color int    `json:"color"`
+		} `json:"embeds"`
+	}
+	if errUnmarshal := json.Unmarshal(discord, &discordBody); errUnmarshal != nil || len(discordBody.Embeds) != 1 || discordBody.Embeds[0].Color != colorCritical {
+		t.Fatalf("unexpected discord payload %s", discord)
+	}
+}
+
+func TestAlertingConfigValidate(t *testing.T) {
+	if errValidate := (config.AlertingConfig{Webhooks: []config.AlertWebhook{{URL: "ftp://example.com"}}}).Validate(); errValidate == nil {
+		t.Fatal("non-http url accepted")
+	}
+	if errValidate := (config.AlertingConfig{Webhooks: []config.AlertWebhook{{URL: "https://example.com", Format: "teams"}}}).Validate(); errValidate == nil {
+		t.Fatal("unknown format accepted")
+	}
+	if errValidate := (config.AlertingConfig{Webhooks: []config.AlertWebhook{{URL: "https://example.com", Events: []string{"nope"}}}}).Validate(); errValidate == nil {
+		t.Fatal("unknown event accepted")
+	}
+}
@@ -0,0 +1,177 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex HMAC>" of "<timestamp>.<body>" when the webhook
+	// has a secret.
+	SignatureHeader = "X-CPA-Signature"
+	// TimestampHeader carries the Unix time used in the signature.
+	TimestampHeader = "X-CPA-Timestamp"
+	// EventHeader carries the alert kind.
+	EventHeader = "X-CPA-Event"
+
+	colorWarning  = 0xF2A900
+	colorCritical = 0xD0021B
+)
+
+func deliver(client *http.Client, webhook config.AlertWebhook, alert Alert) {
+	if errSend := Send(context.Background(), client, webhook, alert); errSend != nil {
+		name := strings.TrimSpace(webhook.Name)
+		if name == "" {
+			name = webhook.URL
+		}
+		log.WithField("webhook", name).WithField("event", alert.Kind).Warnf("alerting: %v", errSend)
+	}
+}
+
+// Send posts alert to webhook in the webhook's format, signing the body when a secret is set.
+func Send(ctx context.Context, client *http.Client, webhook config.AlertWebhook, alert Alert) error {
+	body, errEncode := Encode(webhook.NormalizedFormat(), alert)
+	if errEncode != nil {
+		return fmt.Errorf("encode alert: %w", errEncode)
+	}
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSpace(webhook.URL), bytes.NewReader(body))
+	if errReq != nil {
+		return fmt.Errorf("build request: %w", errReq)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range webhook.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set(EventHeader, alert.Kind)
+	if webhook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, timestamp, body))
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return fmt.Errorf("post alert: %w", errDo)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Encode renders alert as a webhook body in format.
+func Encode(format string, alert Alert) ([]byte, error) {
+	switch format {
+	case config.AlertFormatSlack:
+		return json.Marshal(slackPayload(alert))
+	case config.AlertFormatDiscord:
+		return json.Marshal(discordPayload(alert))
+	default:
+		return json.Marshal(alert)
+	}
+}
+
+type field struct {
+	name  string
+	value string
+}
+
+// fields lists the populated alert attributes shown by chat formats.
+func (a Alert) fields() []field {
+	var out []field
+	add := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, field{name: name, value: value})
+		}
+	}
+	add("Event", a.Kind)
+	add("Provider", a.Provider)
+	add("Credential", a.AuthID)
+	add("Auth index", a.AuthIndex)
+	add("Model", a.Model)
+	add("Plugin", a.PluginID)
+	add("Client key", a.Principal)
+	if a.Status > 0 {
+		add("Status", strconv.Itoa(a.Status))
+	}
+	if a.Count > 0 {
+		add("Count", strconv.Itoa(a.Count))
+	}
+	if a.Until != nil {
+		add("Until", a.Until.UTC().Format(time.RFC3339))
+	}
+	return out
+}
+
+func (a Alert) color() int {
+	if a.Severity == SeverityCritical {
+		return colorCritical
+	}
+	return colorWarning
+}
+
+func slackPayload(alert Alert) map[string]any {
+	fields := make([]map[string]any, 0, len(alert.fields()))
+	for _, f := range alert.fields() {
+		fields = append(fields, map[string]any{"title": f.name, "value": f.value, "short": true})
+	}
+	return map[string]any{
+		"text": fmt.Sprintf("*[%s] %s*", strings.ToUpper(alert.Severity), alert.Title),
+		"attachments": []map[string]any{{
+			"color":  fmt.Sprintf("#%06X", alert.color()),
+			"text":   alert.Message,
+			"fields": fields,
+			"ts":     alert.Time.Unix(),
+		}},
+	}
+}
+
+func discordPayload(alert Alert) map[string]any {
+	fields := make([]map[string]any, 0, len(alert.fields()))
+	for _, f := range alert.fields() {
+		fields = append(fields, map[string]any{"name": f.name, "value": f.value, "inline": true})
+	}
+	embed := map[string]any{
+		"title":     truncate(fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.Title), 256),
+		"color":     alert.color(),
+		"fields":    fields,
+		"timestamp": alert.Time.UTC().Format(time.RFC3339),
+	}
+	if alert.Message != "" {
+		embed["description"] = truncate(alert.Message, 4096)
+	}
+	return map[string]any{"embeds": []map[string]any{embed}}
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-3]) + "..."
+}
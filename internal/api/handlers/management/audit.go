@@ -0,0 +1,208 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clientnet"
+)
+
+const (
+	// auditErrorBodyLimit bounds how much of a failed response is kept to extract its error.
+	auditErrorBodyLimit = 4096
+	defaultAuditLimit   = 100
+	maxAuditLimit       = 1000
+)
+
+// auditResponseWriter keeps the start of the response body so failures can be audited
+// with their error message.
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseWriter) capture(data []byte) {
+	if remaining := auditErrorBodyLimit - w.body.Len(); remaining > 0 {
+		w.body.Write(data[:min(len(data), remaining)])
+	}
+}
+
+// serveAudited runs the handler chain and records the mutation with the config sections
+// and auth entries it changed.
+func (h *Handler) serveAudited(c *gin.Context, principal *ManagementPrincipal) {
+	logger := audit.Default()
+	if !logger.Enabled() {
+		c.Next()
+		return
+	}
+	entry := newAuditEntry(c, principal)
+	authRoute := strings.HasPrefix(entry.Route, "/auth-files")
+	configBefore := h.auditConfigSnapshot()
+	var authsBefore map[string]any
+	if authRoute {
+		authsBefore = h.auditAuthSnapshot()
+	}
+
+	c.Request = c.Request.WithContext(logger.Begin(c.Request.Context(), entry))
+	writer := &auditResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+
+	entry.Changes = audit.Diff("", configBefore, h.auditConfigSnapshot())
+	if authRoute {
+		entry.Changes = append(entry.Changes, audit.Diff(audit.AuthSectionPrefix, authsBefore, h.auditAuthSnapshot())...)
+	}
+	finishAuditEntry(&entry, writer.Status(), writer.body.Bytes())
+	logger.Record(entry)
+}
+
+// recordDeniedMutation audits a mutation rejected before reaching its handler.
+func recordDeniedMutation(c *gin.Context, principal *ManagementPrincipal, status int, message string) {
+	logger := audit.Default()
+	if !logger.Enabled() {
+		return
+	}
+	entry := newAuditEntry(c, principal)
+	entry.Status = status
+	entry.Result = audit.ResultFailure
+	entry.Error = message
+	logger.Record(entry)
+}
+
+func newAuditEntry(c *gin.Context, principal *ManagementPrincipal) audit.Entry {
+	entry := audit.Entry{
+		Time:     time.Now().UTC(),
+		SourceIP: clientnet.Default().ClientAddress(c.Request),
+		Method:   c.Request.Method,
+		Route:    managementRoute(c),
+	}
+	if principal != nil {
+		entry.Principal = principal.Name
+		entry.Role = principal.Role
+	}
+	return entry
+}
+
+func finishAuditEntry(entry *audit.Entry, status int, body []byte) {
+	entry.Status = status
+	if status < http.StatusBadRequest {
+		entry.Result = audit.ResultSuccess
+		return
+	}
+	entry.Result = audit.ResultFailure
+	var payload struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		entry.Error = payload.Error
+		if entry.Error == "" {
+			entry.Error = payload.Message
+		}
+	}
+	if entry.Error == "" {
+		entry.Error = http.StatusText(status)
+	}
+}
+
+func (h *Handler) auditConfigSnapshot() map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cfg == nil {
+		return nil
+	}
+	return audit.Snapshot(h.cfg)
+}
+
+// auditAuthSnapshot captures the stable, operator-editable fields of every auth entry.
+func (h *Handler) auditAuthSnapshot() map[string]any {
+	if h.authManager == nil {
+		return nil
+	}
+	auths := h.authManager.List()
+	snapshot := make(map[string]any, len(auths))
+	for _, auth := range auths {
+		if auth == nil || auth.ID == "" {
+			continue
+		}
+		snapshot[auth.ID] = audit.Snapshot(map[string]any{
+			"provider":   auth.Provider,
+			"label":      auth.Label,
+			"prefix":     auth.Prefix,
+			"disabled":   auth.Disabled,
+			"proxy_url":  auth.ProxyURL,
+			"attributes": auth.Attributes,
+			"metadata":   auth.Metadata,
+		})
+	}
+	return snapshot
+}
+
+// GetAuditLog returns audited management mutations, newest first. Filters: principal,
+// method, route (prefix), result, since and until (RFC3339 or unix seconds), and limit.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	filter := audit.Filter{
+		Principal:   strings.TrimSpace(c.Query("principal")),
+		Method:      strings.TrimSpace(c.Query("method")),
+		RoutePrefix: strings.TrimSpace(c.Query("route")),
+		Result:      strings.TrimSpace(c.Query("result")),
+		Limit:       defaultAuditLimit,
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	if limit > 0 {
+		filter.Limit = min(limit, maxAuditLimit)
+	}
+	var errTime error
+	if filter.Since, errTime = parseAuditTime(c.Query("since")); errTime != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid since: %v", errTime)})
+		return
+	}
+	if filter.Until, errTime = parseAuditTime(c.Query("until")); errTime != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid until: %v", errTime)})
+		return
+	}
+
+	logger := audit.Default()
+	entries, errQuery := logger.Query(filter)
+	if errQuery != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read audit log: %v", errQuery)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": logger.Enabled(), "entries": entries})
+}
+
+func parseAuditTime(raw string) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, errInt := strconv.ParseInt(value, 10, 64); errInt == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	parsed, errParse := time.Parse(time.RFC3339, value)
+	if errParse != nil {
+		return time.Time{}, fmt.Errorf("must be RFC3339 or unix seconds")
+	}
+	return parsed, nil
+}
@@ -0,0 +1,98 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMiddlewareRecordsAuditEntries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	audit.Configure(&config.Config{AuditLog: config.AuditLogConfig{Enable: true, Dir: t.TempDir()}})
+	t.Cleanup(func() { audit.Configure(nil) })
+
+	viewerHash, errHash := bcrypt.GenerateFromPassword([]byte("viewer-key"), bcrypt.MinCost)
+	if errHash != nil {
+		t.Fatalf("hash: %v", errHash)
+	}
+	h := &Handler{
+		cfg: &config.Config{RemoteManagement: config.RemoteManagement{
+			AllowRemote: true,
+			Keys:        []config.ManagementKey{{Name: "dashboard", Key: string(viewerHash), Role: config.ManagementRoleViewer}},
+		}},
+		configFilePath: writeTestConfigFile(t),
+		failedAttempts: make(map[string]*attemptInfo),
+		envSecret:      "env-secret",
+	}
+	engine := gin.New()
+	mgmt := engine.Group("/v0/management", h.Middleware())
+	mgmt.PUT("/debug", h.PutDebug)
+	mgmt.PUT("/request-retry", h.PutRequestRetry)
+	mgmt.GET("/audit", h.GetAuditLog)
+
+	serve := func(method, path, key, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:12345"
+		// No trusted proxy is configured, so the forwarded address must not be recorded.
+		req.Header.Set("X-Forwarded-For", "203.0.113.9")
+		req.Header.Set("X-Management-Key", key)
+		req.Header.Set("Content-Type", "application/json")
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodPut, "/v0/management/debug", "env-secret", `{"value":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT debug status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodPut, "/v0/management/debug", "viewer-key", `{"value":false}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer PUT debug status = %d", rec.Code)
+	}
+	if rec := serve(http.MethodPut, "/v0/management/request-retry", "env-secret", `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid PUT request-retry status = %d", rec.Code)
+	}
+
+	rec := serve(http.MethodGet, "/v0/management/audit?limit=10", "viewer-key", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET audit status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Enabled bool          `json:"enabled"`
+		Entries []audit.Entry `json:"entries"`
+	}
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &payload); errUnmarshal != nil {
+		t.Fatalf("decode audit response: %v", errUnmarshal)
+	}
+	if !payload.Enabled || len(payload.Entries) != 3 {
+		t.Fatalf("audit response = %+v, want 3 entries", payload)
+	}
+
+	invalid, denied, changed := payload.Entries[0], payload.Entries[1], payload.Entries[2]
+	if invalid.Route != "/request-retry" || invalid.Result != audit.ResultFailure || invalid.Error != "invalid body" || len(invalid.Changes) != 0 {
+		t.Fatalf("invalid entry = %+v", invalid)
+	}
+	if denied.Principal != "dashboard" || denied.Status != http.StatusForbidden || denied.Result != audit.ResultFailure {
+		t.Fatalf("denied entry = %+v", denied)
+	}
+	if changed.Principal != "env" || changed.SourceIP != "127.0.0.1" || changed.Result != audit.ResultSuccess {
+		t.Fatalf("changed entry = %+v", changed)
+	}
+	if len(changed.Changes) != 1 || changed.Changes[0].Section != "debug" || changed.Changes[0].After != true {
+		t.Fatalf("changed entry changes = %+v, want debug false -> true", changed.Changes)
+	}
+
+	rec = serve(http.MethodGet, "/v0/management/audit?principal=dashboard", "viewer-key", "")
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &payload); errUnmarshal != nil || len(payload.Entries) != 1 {
+		t.Fatalf("principal filter: entries=%+v err=%v", payload.Entries, errUnmarshal)
+	}
+	if rec = serve(http.MethodGet, "/v0/management/audit?since=yesterday", "viewer-key", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid since status = %d", rec.Code)
+	}
+}
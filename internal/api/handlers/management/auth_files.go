@@ -344,6 +344,11 @@ func (h *Handler) buildAuthFileEntryLocked(auth *coreauth.Auth) gin.H {
 	entry["success"] = auth.Success
 	entry["failed"] = auth.Failed
 	entry["recent_requests"] = auth.RecentRequestsSnapshot(time.Now())
+	if h.authManager != nil {
+		if history := h.authManager.ProbeHistory(auth.ID); len(history) > 0 {
+			entry["probe_history"] = history
+		}
+	}
 	if email := authEmail(auth); email != "" {
 		entry["email"] = email
 	}
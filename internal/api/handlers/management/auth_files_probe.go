@@ -0,0 +1,79 @@
+package management
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// ProbeAuthFiles runs an on-demand health probe. With a name or auth_index only that
+// credential is probed; an empty body probes every enabled credential.
+func (h *Handler) ProbeAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+
+	var req struct {
+		Name      string `json:"name"`
+		AuthIndex string `json:"auth_index"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	authIndex := strings.TrimSpace(req.AuthIndex)
+
+	var targets []*coreauth.Auth
+	switch {
+	case name != "":
+		auth, _ := h.lookupAuthFile(name, authIndex)
+		if auth == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+			return
+		}
+		targets = append(targets, auth)
+	case authIndex != "":
+		auth := h.authByIndex(authIndex)
+		if auth == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+			return
+		}
+		targets = append(targets, auth)
+	default:
+		for _, auth := range h.authManager.List() {
+			if auth != nil && !auth.Disabled && auth.Status != coreauth.StatusDisabled {
+				targets = append(targets, auth)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(targets))
+	for _, auth := range targets {
+		ids = append(ids, auth.ID)
+	}
+	probed := h.authManager.ProbeAuths(c.Request.Context(), ids)
+
+	results := make([]gin.H, 0, len(targets))
+	for _, auth := range targets {
+		auth.EnsureIndex()
+		entry := gin.H{
+			"id":         auth.ID,
+			"auth_index": auth.Index,
+			"name":       strings.TrimSpace(auth.FileName),
+			"provider":   strings.TrimSpace(auth.Provider),
+		}
+		if result, ok := probed[auth.ID]; ok {
+			entry["result"] = result
+		} else {
+			entry["error"] = "probe not run"
+		}
+		results = append(results, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
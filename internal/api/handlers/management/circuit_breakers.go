@@ -0,0 +1,35 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// GetCircuitBreakers returns the state of every tracked upstream endpoint circuit breaker.
+func (h *Handler) GetCircuitBreakers(c *gin.Context) {
+	enabled := false
+	if h != nil && h.cfg != nil {
+		enabled = h.cfg.CircuitBreaker.Enable
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":          enabled,
+		"circuit-breakers": coreauth.CircuitBreakerSnapshots(),
+	})
+}
+
+// DeleteCircuitBreaker closes the breaker for the endpoint given by the "endpoint" query parameter.
+func (h *Handler) DeleteCircuitBreaker(c *gin.Context) {
+	endpoint := strings.TrimSpace(c.Query("endpoint"))
+	if endpoint == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "endpoint is required"})
+		return
+	}
+	if !coreauth.ResetCircuitBreaker(endpoint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "circuit breaker not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,63 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+)
+
+type clientBudgetRequest struct {
+	Key    string  `json:"key"`
+	Period string  `json:"period"`
+	Amount float64 `json:"amount"`
+}
+
+// GetClientBudgets returns consumption for every client key with a budget.
+func (h *Handler) GetClientBudgets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"client-budgets": budget.Default().Snapshots()})
+}
+
+// ResetClientBudget clears consumption for a client key. The key is referenced by budget
+// id, name, or value; an empty period resets every window.
+func (h *Handler) ResetClientBudget(c *gin.Context) {
+	var body clientBudgetRequest
+	if errBind := c.ShouldBindJSON(&body); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if strings.TrimSpace(body.Key) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+	errReset := budget.Default().Reset(body.Key, budget.Period(strings.ToLower(strings.TrimSpace(body.Period))))
+	writeClientBudgetResult(c, errReset)
+}
+
+// TopUpClientBudget adds budget to the current window of a client key.
+func (h *Handler) TopUpClientBudget(c *gin.Context) {
+	var body clientBudgetRequest
+	if errBind := c.ShouldBindJSON(&body); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if strings.TrimSpace(body.Key) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+	errTopUp := budget.Default().TopUp(body.Key, budget.Period(strings.ToLower(strings.TrimSpace(body.Period))), body.Amount)
+	writeClientBudgetResult(c, errTopUp)
+}
+
+func writeClientBudgetResult(c *gin.Context, err error) {
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	case errors.Is(err, budget.ErrKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,76 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestClientBudgetEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		SDKConfig: config.SDKConfig{ClientAPIKeys: []config.ClientAPIKey{{
+			Name:   "team-a",
+			Key:    "team-a-key",
+			Budget: &config.ClientBudget{Daily: 100},
+		}}},
+		ClientBudgets: config.ClientBudgetConfig{StateFile: filepath.Join(t.TempDir(), "budgets.state")},
+	}
+	budget.Configure(cfg)
+	t.Cleanup(func() { budget.Configure(&config.Config{}) })
+	budget.Default().HandleUsage(context.Background(), coreusage.Record{
+		APIKey: "team-a-key",
+		Detail: coreusage.Detail{TokenBreakdown: coreusage.NewIndependentTokenBreakdown(120, 0, 0, 0, 0, 120)},
+	})
+
+	h := &Handler{cfg: cfg}
+	router := gin.New()
+	router.GET("/client-budgets", h.GetClientBudgets)
+	router.POST("/client-budgets/reset", h.ResetClientBudget)
+	router.POST("/client-budgets/top-up", h.TopUpClientBudget)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return recorder
+	}
+
+	listed := do(http.MethodGet, "/client-budgets", "")
+	if listed.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, body %s", listed.Code, listed.Body.String())
+	}
+	entry := gjson.Get(listed.Body.String(), "client-budgets.0")
+	if entry.Get("name").String() != "team-a" || entry.Get("periods.0.used").Float() != 120 || entry.Get("periods.0.remaining").Float() != 0 {
+		t.Fatalf("unexpected budget listing %s", listed.Body.String())
+	}
+	if strings.Contains(listed.Body.String(), "team-a-key") {
+		t.Fatalf("budget listing leaks the key value: %s", listed.Body.String())
+	}
+
+	if topUp := do(http.MethodPost, "/client-budgets/top-up", `{"key":"team-a","period":"daily","amount":50}`); topUp.Code != http.StatusOK {
+		t.Fatalf("top-up status = %d, body %s", topUp.Code, topUp.Body.String())
+	}
+	if !budget.Default().Check("team-a-key").Allowed {
+		t.Fatal("key still rejected after top-up")
+	}
+	if missing := do(http.MethodPost, "/client-budgets/reset", `{"key":"nobody"}`); missing.Code != http.StatusNotFound {
+		t.Fatalf("reset unknown key status = %d, want 404", missing.Code)
+	}
+	if invalid := do(http.MethodPost, "/client-budgets/top-up", `{"key":"team-a","period":"yearly","amount":5}`); invalid.Code != http.StatusBadRequest {
+		t.Fatalf("top-up invalid period status = %d, want 400", invalid.Code)
+	}
+	if reset := do(http.MethodPost, "/client-budgets/reset", `{"key":"team-a"}`); reset.Code != http.StatusOK {
+		t.Fatalf("reset status = %d, body %s", reset.Code, reset.Body.String())
+	}
+	if used := budget.Default().Snapshots()[0].Periods[0].Used; used != 0 {
+		t.Fatalf("used after reset = %v, want 0", used)
+	}
+}
@@ -0,0 +1,98 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+type mintClientKeyRequest struct {
+	Name            string            `json:"name"`
+	Hash            string            `json:"hash"`
+	AllowedModels   []string          `json:"allowed-models"`
+	DeniedModels    []string          `json:"denied-models"`
+	AllowedPrefixes []string          `json:"allowed-prefixes"`
+	AllowedCIDRs    []string          `json:"allowed-cidrs"`
+	ExpiresAt       *time.Time        `json:"expires-at"`
+	Labels          map[string]string `json:"labels"`
+}
+
+// MintClientKey generates a client key and stores only its hash. Without a name or policy
+// the hash is appended to api-keys, otherwise a client-api-keys entry is created. The
+// plaintext key is returned in this response only.
+func (h *Handler) MintClientKey(c *gin.Context) {
+	var body mintClientKeyRequest
+	data, errRead := c.GetRawData()
+	if errRead != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if errUnmarshal := json.Unmarshal(data, &body); errUnmarshal != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	scheme := strings.ToLower(strings.TrimSpace(body.Hash))
+	if scheme == "" {
+		scheme = config.ClientKeyHashSHA256
+	}
+	if scheme != config.ClientKeyHashSHA256 && scheme != config.ClientKeyHashArgon2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hash must be sha256 or argon2"})
+		return
+	}
+	if _, errCIDRs := config.ParseNetworkPrefixes(body.AllowedCIDRs); errCIDRs != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "allowed-cidrs: " + errCIDRs.Error()})
+		return
+	}
+
+	plaintext, errGenerate := config.GenerateClientKey()
+	if errGenerate != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate key"})
+		return
+	}
+	hashed, errHash := config.HashClientKey(plaintext, scheme)
+	if errHash != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash key"})
+		return
+	}
+
+	entry := config.ClientAPIKey{
+		Name:            strings.TrimSpace(body.Name),
+		Key:             hashed,
+		AllowedModels:   body.AllowedModels,
+		DeniedModels:    body.DeniedModels,
+		AllowedPrefixes: body.AllowedPrefixes,
+		AllowedCIDRs:    body.AllowedCIDRs,
+		Labels:          body.Labels,
+	}
+	if body.ExpiresAt != nil {
+		entry.ExpiresAt = body.ExpiresAt.UTC()
+	}
+	structured := entry.Name != "" || len(entry.AllowedModels) > 0 || len(entry.DeniedModels) > 0 ||
+		len(entry.AllowedPrefixes) > 0 || len(entry.AllowedCIDRs) > 0 || !entry.ExpiresAt.IsZero() || len(entry.Labels) > 0
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	list := "api-keys"
+	if structured {
+		list = "client-api-keys"
+		h.cfg.ClientAPIKeys = append(h.cfg.ClientAPIKeys, entry)
+		h.cfg.SanitizeClientAPIKeys()
+	} else {
+		h.cfg.APIKeys = append(h.cfg.APIKeys, hashed)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	h.persistLockedWithResponse(c, gin.H{
+		"status": "ok",
+		"key":    plaintext,
+		"hash":   hashed,
+		"name":   entry.Name,
+		"list":   list,
+	})
+}
@@ -0,0 +1,72 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestMintClientKey_StoresOnlyHash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := writeTestConfigFile(t)
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+
+	mint := func(body string) map[string]string {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/api-keys/mint", strings.NewReader(body))
+		h.MintClientKey(c)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("MintClientKey(%s) status = %d, body %s", body, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Cache-Control") != "no-store" {
+			t.Fatalf("Cache-Control = %q, want no-store", rec.Header().Get("Cache-Control"))
+		}
+		var response map[string]string
+		if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &response); errUnmarshal != nil {
+			t.Fatalf("unmarshal response: %v", errUnmarshal)
+		}
+		return response
+	}
+
+	plain := mint("")
+	if plain["list"] != "api-keys" || !config.VerifyClientKey(plain["hash"], plain["key"]) {
+		t.Fatalf("mint plain = %v", plain)
+	}
+	if len(h.cfg.APIKeys) != 1 || h.cfg.APIKeys[0] != plain["hash"] {
+		t.Fatalf("api-keys = %v, want only the hash", h.cfg.APIKeys)
+	}
+
+	team := mint(`{"name":"team-a","hash":"argon2","allowed-models":["gpt-5*"]}`)
+	if team["list"] != "client-api-keys" || !strings.HasPrefix(team["hash"], "argon2:") {
+		t.Fatalf("mint team = %v", team)
+	}
+	if len(h.cfg.ClientAPIKeys) != 1 || h.cfg.ClientAPIKeys[0].Key != team["hash"] || h.cfg.ClientAPIKeys[0].Name != "team-a" {
+		t.Fatalf("client-api-keys = %+v", h.cfg.ClientAPIKeys)
+	}
+
+	saved, errRead := os.ReadFile(configPath)
+	if errRead != nil {
+		t.Fatalf("read config: %v", errRead)
+	}
+	if strings.Contains(string(saved), plain["key"]) || strings.Contains(string(saved), team["key"]) {
+		t.Fatal("saved config contains a plaintext key")
+	}
+	if !strings.Contains(string(saved), plain["hash"]) {
+		t.Fatal("saved config is missing the minted hash")
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/api-keys/mint", strings.NewReader(`{"hash":"md5"}`))
+	h.MintClientKey(c)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("MintClientKey(md5) status = %d, want 400", rec.Code)
+	}
+}
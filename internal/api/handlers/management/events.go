@@ -0,0 +1,86 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+)
+
+const eventStreamHeartbeat = 15 * time.Second
+
+// StreamEvents pushes live activity events as Server-Sent Events. The optional type,
+// model, provider and principal query parameters filter the stream; each accepts a
+// comma-separated list or may be repeated.
+func (h *Handler) StreamEvents(c *gin.Context) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+		return
+	}
+	filter := events.Filter{
+		Types:      queryList(c, "type"),
+		Models:     queryList(c, "model"),
+		Providers:  queryList(c, "provider"),
+		Principals: queryList(c, "principal"),
+	}
+	sub := events.Default().Subscribe(filter, 0)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = fmt.Fprint(c.Writer, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	var reportedDrops uint64
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, errWrite := fmt.Fprint(c.Writer, ": ping\n\n"); errWrite != nil {
+				return
+			}
+		case event, open := <-sub.Events():
+			if !open {
+				return
+			}
+			if dropped := sub.Dropped(); dropped > reportedDrops {
+				reportedDrops = dropped
+				if _, errWrite := fmt.Fprintf(c.Writer, "event: dropped\ndata: {\"dropped\":%d}\n\n", dropped); errWrite != nil {
+					return
+				}
+			}
+			payload, errMarshal := json.Marshal(event)
+			if errMarshal != nil {
+				continue
+			}
+			if _, errWrite := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, payload); errWrite != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
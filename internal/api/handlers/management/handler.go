@@ -56,10 +56,12 @@ type Handler struct {
 	postAuthPersistHook     coreauth.PostAuthHook
 	pluginHost              *pluginhost.Host
 	configReloadHook        func(context.Context, *config.Config)
+	replayHandler           http.Handler
 	pluginStoreRegistryURL  string
 	pluginStoreHTTPClient   pluginstore.HTTPDoer
 	pluginReleaseCacheMu    sync.Mutex
 	pluginReleaseCache      map[string]pluginReleaseCacheEntry
+	managementKeyCache      sync.Map // sha256 of a provided key -> matched remote-management.keys hash
 }
 
 type configReloadSnapshot struct {
@@ -150,6 +152,16 @@ func (h *Handler) SetPluginHost(host *pluginhost.Host) {
 	h.mu.Unlock()
 }
 
+// SetReplayHandler sets the HTTP handler that serves replayed request logs.
+func (h *Handler) SetReplayHandler(handler http.Handler) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.replayHandler = handler
+	h.mu.Unlock()
+}
+
 // SetConfigReloadHook updates the callback used after management saves config changes.
 func (h *Handler) SetConfigReloadHook(hook func(context.Context, *config.Config)) {
 	if h == nil {
@@ -286,11 +298,29 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			provided = c.GetHeader("X-Management-Key")
 		}
 
-		allowed, statusCode, errMsg := h.AuthenticateManagementKey(clientIP, localClient, provided)
-		if !allowed {
+		principal, statusCode, errMsg := h.authenticateManagementPrincipal(clientIP, localClient, provided)
+		if principal == nil {
 			c.AbortWithStatusJSON(statusCode, gin.H{"error": errMsg})
 			return
 		}
+		c.Set(managementPrincipalKey, principal)
+		c.Header(ManagementPrincipalHeader, principal.Name)
+		method, route := c.Request.Method, managementRoute(c)
+		mutation := method != http.MethodGet && method != http.MethodHead
+		if !principal.Allows(method, route) {
+			log.Warnf("management: %s denied %s %s", principal.Name, method, route)
+			errMsg = fmt.Sprintf("management principal %q is not allowed to call %s %s", principal.Name, method, route)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errMsg})
+			if mutation {
+				recordDeniedMutation(c, principal, http.StatusForbidden, errMsg)
+			}
+			return
+		}
+		if mutation {
+			log.Infof("management: %s %s by %s", method, route, principal.Name)
+			h.serveAudited(c, principal)
+			return
+		}
 		c.Next()
 	}
 }
@@ -298,21 +328,44 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 // AuthenticateManagementKey verifies the provided management key for the given client.
 // It mirrors the behaviour of Middleware() so non-HTTP callers can reuse the same logic.
 func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, provided string) (bool, int, string) {
+	principal, statusCode, errMsg := h.authenticateManagementPrincipal(clientIP, localClient, provided)
+	return principal != nil, statusCode, errMsg
+}
+
+// AuthenticateManagementRoute verifies the provided key like AuthenticateManagementKey and
+// additionally requires the principal to be allowed to call method on route.
+func (h *Handler) AuthenticateManagementRoute(clientIP string, localClient bool, provided, method, route string) (bool, int, string) {
+	principal, statusCode, errMsg := h.authenticateManagementPrincipal(clientIP, localClient, provided)
+	if principal == nil {
+		return false, statusCode, errMsg
+	}
+	if !principal.Allows(method, route) {
+		return false, http.StatusForbidden, fmt.Sprintf("management principal %q is not allowed to call %s %s", principal.Name, method, route)
+	}
+	return true, 0, ""
+}
+
+// authenticateManagementPrincipal resolves the provided key to a principal. The local
+// password, MANAGEMENT_PASSWORD, and secret-key are admins; remote-management.keys carry
+// their configured role or scopes.
+func (h *Handler) authenticateManagementPrincipal(clientIP string, localClient bool, provided string) (*ManagementPrincipal, int, string) {
 	const maxFailures = 5
 	const banDuration = 30 * time.Minute
 
 	if h == nil {
-		return false, http.StatusForbidden, "remote management disabled"
+		return nil, http.StatusForbidden, "remote management disabled"
 	}
 
 	cfg := h.cfg
 	var (
 		allowRemote bool
 		secretHash  string
+		keys        []config.ManagementKey
 	)
 	if cfg != nil {
 		allowRemote = cfg.RemoteManagement.AllowRemote
 		secretHash = cfg.RemoteManagement.SecretKey
+		keys = cfg.RemoteManagement.Keys
 	}
 	if h.allowRemoteOverride {
 		allowRemote = true
@@ -326,7 +379,7 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 		if now.Before(ai.blockedUntil) {
 			remaining := ai.blockedUntil.Sub(now).Round(time.Second)
 			h.attemptsMu.Unlock()
-			return false, http.StatusForbidden, fmt.Sprintf("IP banned due to too many failed attempts. Try again in %s", remaining)
+			return nil, http.StatusForbidden, fmt.Sprintf("IP banned due to too many failed attempts. Try again in %s", remaining)
 		}
 		// Ban expired, reset state
 		ai.blockedUntil = time.Time{}
@@ -335,7 +388,7 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 	h.attemptsMu.Unlock()
 
 	if !localClient && !allowRemote {
-		return false, http.StatusForbidden, "remote management disabled"
+		return nil, http.StatusForbidden, "remote management disabled"
 	}
 
 	fail := func() {
@@ -363,37 +416,41 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 		h.attemptsMu.Unlock()
 	}
 
-	if secretHash == "" && envSecret == "" {
-		return false, http.StatusForbidden, "remote management key not set"
+	if secretHash == "" && envSecret == "" && len(keys) == 0 {
+		return nil, http.StatusForbidden, "remote management key not set"
 	}
 
 	if provided == "" {
 		fail()
-		return false, http.StatusUnauthorized, "missing management key"
+		return nil, http.StatusUnauthorized, "missing management key"
 	}
 
 	if localClient {
 		if lp := h.localPassword; lp != "" {
 			if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
 				reset()
-				return true, 0, ""
+				return &ManagementPrincipal{Name: "local", Role: config.ManagementRoleAdmin}, 0, ""
 			}
 		}
 	}
 
 	if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
 		reset()
-		return true, 0, ""
+		return &ManagementPrincipal{Name: "env", Role: config.ManagementRoleAdmin}, 0, ""
 	}
 
-	if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
-		fail()
-		return false, http.StatusUnauthorized, "invalid management key"
+	if secretHash != "" && bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) == nil {
+		reset()
+		return &ManagementPrincipal{Name: "secret-key", Role: config.ManagementRoleAdmin}, 0, ""
 	}
 
-	reset()
+	if principal := h.matchManagementKey(keys, provided); principal != nil {
+		reset()
+		return principal, 0, ""
+	}
 
-	return true, 0, ""
+	fail()
+	return nil, http.StatusUnauthorized, "invalid management key"
 }
 
 // persist saves the current in-memory config to disk.
@@ -406,13 +463,19 @@ func (h *Handler) persist(c *gin.Context) bool {
 // persistLocked saves the current in-memory config to disk.
 // It expects the caller to hold h.mu.
 func (h *Handler) persistLocked(c *gin.Context) bool {
+	return h.persistLockedWithResponse(c, gin.H{"status": "ok"})
+}
+
+// persistLockedWithResponse saves the config like persistLocked but replies with response.
+// It expects the caller to hold h.mu.
+func (h *Handler) persistLockedWithResponse(c *gin.Context, response gin.H) bool {
 	// Preserve comments when writing
 	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
 		return false
 	}
 	snapshot := h.reloadSnapshotConfigLocked()
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	c.JSON(http.StatusOK, response)
 	var reqCtx context.Context
 	if c != nil && c.Request != nil {
 		reqCtx = c.Request.Context()
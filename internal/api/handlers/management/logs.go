@@ -287,14 +287,24 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 		return
 	}
 
+	fullPath, status, errFind := findRequestLogFile(dir, requestID)
+	if errFind != nil {
+		c.JSON(status, gin.H{"error": errFind.Error()})
+		return
+	}
+
+	c.FileAttachment(fullPath, filepath.Base(fullPath))
+}
+
+// findRequestLogFile locates the request log for requestID under dir. The returned status
+// is the HTTP status to report when err is non-nil.
+func findRequestLogFile(dir, requestID string) (string, int, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "log directory not found"})
-			return
+			return "", http.StatusNotFound, fmt.Errorf("log directory not found")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list log directory: %v", err)})
-		return
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to list log directory: %v", err)
 	}
 
 	suffix := "-" + requestID + ".log"
@@ -311,37 +321,30 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	}
 
 	if matchedFile == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
-		return
+		return "", http.StatusNotFound, fmt.Errorf("log file not found for the given request ID")
 	}
 
 	dirAbs, errAbs := filepath.Abs(dir)
 	if errAbs != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to resolve log directory: %v", errAbs)})
-		return
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to resolve log directory: %v", errAbs)
 	}
 	fullPath := filepath.Clean(filepath.Join(dirAbs, matchedFile))
 	prefix := dirAbs + string(os.PathSeparator)
 	if !strings.HasPrefix(fullPath, prefix) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file path"})
-		return
+		return "", http.StatusBadRequest, fmt.Errorf("invalid log file path")
 	}
 
 	info, errStat := os.Stat(fullPath)
 	if errStat != nil {
 		if os.IsNotExist(errStat) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "log file not found"})
-			return
+			return "", http.StatusNotFound, fmt.Errorf("log file not found")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errStat)})
-		return
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to read log file: %v", errStat)
 	}
 	if info.IsDir() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file"})
-		return
+		return "", http.StatusBadRequest, fmt.Errorf("invalid log file")
 	}
-
-	c.FileAttachment(fullPath, matchedFile)
+	return fullPath, http.StatusOK, nil
 }
 
 // DownloadRequestErrorLog downloads a specific error request log file by name.
@@ -0,0 +1,182 @@
+package management
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/wildcard"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// managementPrincipalKey stores the *ManagementPrincipal of a request in the gin context.
+	managementPrincipalKey = "managementPrincipal"
+
+	// ManagementPrincipalHeader names the principal of a management request in responses.
+	ManagementPrincipalHeader = "X-CPA-Management-Principal"
+)
+
+// ManagementPrincipal is the authenticated caller of a management request.
+type ManagementPrincipal struct {
+	Name   string
+	Role   string
+	Scopes []string
+}
+
+// adminOnlyRoutes return credentials, config or plugin settings that viewers and operators
+// may not read. Writes need no entry here: operators may only write operatorWriteRoutes.
+var adminOnlyRoutes = []string{
+	"GET /auth-files/download",
+	"GET /config",
+	"GET /config.yaml",
+	"GET /api-keys",
+	"GET /api-key-usage",
+	"GET /*-api-key",
+	"GET /openai-compatibility",
+	"GET /proxy-url",
+	"GET /plugins/*/config",
+}
+
+// operatorReadRoutes are GET routes with side effects, so viewers may not call them.
+var operatorReadRoutes = []string{
+	"GET /*-auth-url",
+	"GET /usage-queue",
+}
+
+// operatorWriteRoutes are the writes operators may make: runtime settings, quota and
+// breaker resets, and credential status. Anything else that writes is admin-only, since it
+// could mint client keys, point a stored credential at another host, run plugin code or
+// send upstream requests with stored credentials.
+var operatorWriteRoutes = []string{
+	"POST /auth-files",
+	"PATCH /auth-files/status",
+	"POST /auth-files/probe",
+	"POST /vertex/import",
+	"DELETE /oauth-session",
+	"DELETE /circuit-breakers",
+	"POST /client-budgets/*",
+	"POST /reset-quota",
+	"DELETE /logs",
+	"* /debug",
+	"* /logging-to-file",
+	"* /logs-max-total-size-mb",
+	"* /error-logs-max-files",
+	"* /usage-statistics-enabled",
+	"* /request-log",
+	"* /request-retry",
+	"* /max-retry-credentials",
+	"* /max-retry-interval",
+	"* /routing/strategy",
+	"* /force-model-prefix",
+	"* /quota-exceeded/*",
+	"* /oauth-excluded-models",
+	"* /oauth-model-alias",
+	"* /oauth-request-scoped-errors",
+}
+
+// Allows reports whether the principal may call method on route, a path relative to
+// /v0/management. Explicit scopes replace the role.
+func (p *ManagementPrincipal) Allows(method, route string) bool {
+	if p == nil {
+		return false
+	}
+	method = strings.ToUpper(method)
+	if len(p.Scopes) > 0 {
+		return matchRouteScopes(p.Scopes, method, route)
+	}
+	switch p.Role {
+	case config.ManagementRoleAdmin:
+		return true
+	case config.ManagementRoleOperator:
+		if matchRouteScopes(adminOnlyRoutes, method, route) {
+			return false
+		}
+		if method == http.MethodGet || method == http.MethodHead {
+			return true
+		}
+		return matchRouteScopes(operatorWriteRoutes, method, route)
+	case config.ManagementRoleViewer:
+		if method != http.MethodGet && method != http.MethodHead {
+			return false
+		}
+		return !matchRouteScopes(adminOnlyRoutes, method, route) && !matchRouteScopes(operatorReadRoutes, method, route)
+	default:
+		return false
+	}
+}
+
+// matchRouteScopes matches "[METHOD] /path" patterns. A missing or "*" method matches any
+// method; '*' in the path matches any substring.
+func matchRouteScopes(scopes []string, method, route string) bool {
+	if route == "" {
+		route = "/"
+	}
+	for _, scope := range scopes {
+		scopeMethod, scopePath := "*", strings.TrimSpace(scope)
+		if fields := strings.Fields(scopePath); len(fields) == 2 {
+			scopeMethod, scopePath = strings.ToUpper(fields[0]), fields[1]
+		}
+		if scopeMethod != "*" && scopeMethod != method && !(scopeMethod == http.MethodGet && method == http.MethodHead) {
+			continue
+		}
+		if wildcard.Match(scopePath, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// managementRoute returns the request path relative to /v0/management.
+func managementRoute(c *gin.Context) string {
+	if c == nil || c.Request == nil || c.Request.URL == nil {
+		return "/"
+	}
+	route := strings.TrimPrefix(c.Request.URL.Path, "/v0/management")
+	if route == "" {
+		return "/"
+	}
+	return route
+}
+
+// CurrentManagementPrincipal returns the principal of an authenticated management request.
+func CurrentManagementPrincipal(c *gin.Context) *ManagementPrincipal {
+	if c == nil {
+		return nil
+	}
+	if value, ok := c.Get(managementPrincipalKey); ok {
+		if principal, okPrincipal := value.(*ManagementPrincipal); okPrincipal {
+			return principal
+		}
+	}
+	return nil
+}
+
+// matchManagementKey finds the named management key matching provided. bcrypt is slow, so
+// successful matches are cached by the digest of the provided key and stored hash.
+func (h *Handler) matchManagementKey(keys []config.ManagementKey, provided string) *ManagementPrincipal {
+	if len(keys) == 0 || provided == "" {
+		return nil
+	}
+	digest := sha256.Sum256([]byte(provided))
+	if cached, ok := h.managementKeyCache.Load(digest); ok {
+		for _, key := range keys {
+			if key.Key == cached.(string) {
+				return principalForKey(key)
+			}
+		}
+	}
+	for _, key := range keys {
+		if bcrypt.CompareHashAndPassword([]byte(key.Key), []byte(provided)) == nil {
+			h.managementKeyCache.Store(digest, key.Key)
+			return principalForKey(key)
+		}
+	}
+	return nil
+}
+
+func principalForKey(key config.ManagementKey) *ManagementPrincipal {
+	return &ManagementPrincipal{Name: key.Name, Role: key.NormalizedRole(), Scopes: key.Scopes}
+}
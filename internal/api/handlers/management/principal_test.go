@@ -0,0 +1,147 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestManagementPrincipalAllows(t *testing.T) {
+	viewer := &ManagementPrincipal{Name: "dash", Role: config.ManagementRoleViewer}
+	operator := &ManagementPrincipal{Name: "ops", Role: config.ManagementRoleOperator}
+	admin := &ManagementPrincipal{Name: "root", Role: config.ManagementRoleAdmin}
+	scoped := &ManagementPrincipal{Name: "ci", Scopes: []string{"GET /usage*", "PUT /debug"}}
+
+	cases := []struct {
+		principal *ManagementPrincipal
+		method    string
+		route     string
+		want      bool
+	}{
+		{viewer, http.MethodGet, "/usage", true},
+		{viewer, http.MethodGet, "/auth-files", true},
+		{viewer, http.MethodPut, "/debug", false},
+		{viewer, http.MethodGet, "/config.yaml", false},
+		{viewer, http.MethodGet, "/codex-auth-url", false},
+		{viewer, http.MethodGet, "/usage-queue", false},
+		{operator, http.MethodPut, "/debug", true},
+		{operator, http.MethodGet, "/codex-auth-url", true},
+		{operator, http.MethodDelete, "/auth-files", false},
+		{operator, http.MethodPut, "/config.yaml", false},
+		{operator, http.MethodPost, "/plugin-store/demo/install", false},
+		{viewer, http.MethodGet, "/config", false},
+		{viewer, http.MethodGet, "/api-keys", false},
+		{viewer, http.MethodGet, "/api-key-usage", false},
+		{viewer, http.MethodGet, "/claude-api-key", false},
+		{viewer, http.MethodGet, "/openai-compatibility", false},
+		{viewer, http.MethodGet, "/proxy-url", false},
+		{operator, http.MethodGet, "/config", false},
+		{operator, http.MethodPatch, "/codex-api-key", false},
+		{operator, http.MethodPut, "/proxy-url", false},
+		{operator, http.MethodPost, "/api-call", false},
+		{operator, http.MethodPost, "/request-log-replay", false},
+		{operator, http.MethodPost, "/api-keys/mint", false},
+		{operator, http.MethodPatch, "/api-keys", false},
+		{operator, http.MethodPatch, "/auth-files/fields", false},
+		{operator, http.MethodPut, "/plugins/demo/config", false},
+		{operator, http.MethodPatch, "/plugins/demo/enabled", false},
+		{operator, http.MethodPut, "/ws-auth", false},
+		{operator, http.MethodPatch, "/auth-files/status", true},
+		{operator, http.MethodPost, "/client-budgets/reset", true},
+		{admin, http.MethodDelete, "/auth-files", true},
+		{admin, http.MethodPut, "/config.yaml", true},
+		{scoped, http.MethodGet, "/usage/export", true},
+		{scoped, http.MethodHead, "/usage", true},
+		{scoped, http.MethodPut, "/debug", true},
+		{scoped, http.MethodPost, "/usage/import", false},
+		{scoped, http.MethodGet, "/config", false},
+		{nil, http.MethodGet, "/usage", false},
+	}
+	for _, tc := range cases {
+		name := "<nil>"
+		if tc.principal != nil {
+			name = tc.principal.Name
+		}
+		if got := tc.principal.Allows(tc.method, tc.route); got != tc.want {
+			t.Errorf("%s.Allows(%s %s) = %v, want %v", name, tc.method, tc.route, got, tc.want)
+		}
+	}
+}
+
+func TestMiddlewareEnforcesManagementKeyRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viewerHash, errHash := bcrypt.GenerateFromPassword([]byte("viewer-key"), bcrypt.MinCost)
+	if errHash != nil {
+		t.Fatalf("hash: %v", errHash)
+	}
+	h := &Handler{
+		cfg: &config.Config{RemoteManagement: config.RemoteManagement{
+			Keys: []config.ManagementKey{{Name: "dashboard", Key: string(viewerHash), Role: config.ManagementRoleViewer}},
+		}},
+		failedAttempts: make(map[string]*attemptInfo),
+		envSecret:      "env-secret",
+	}
+	engine := gin.New()
+	handler := func(c *gin.Context) {
+		principal := CurrentManagementPrincipal(c)
+		c.String(http.StatusOK, principal.Role)
+	}
+	engine.GET("/v0/management/usage", h.Middleware(), handler)
+	engine.PUT("/v0/management/debug", h.Middleware(), handler)
+
+	serve := func(method, path, key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		req.Header.Set("X-Management-Key", key)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		rec := serve(http.MethodGet, "/v0/management/usage", "viewer-key")
+		if rec.Code != http.StatusOK || rec.Body.String() != config.ManagementRoleViewer {
+			t.Fatalf("viewer GET: status=%d body=%q", rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get(ManagementPrincipalHeader); got != "dashboard" {
+			t.Fatalf("%s = %q, want dashboard", ManagementPrincipalHeader, got)
+		}
+	}
+
+	if rec := serve(http.MethodPut, "/v0/management/debug", "viewer-key"); rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer PUT status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec := serve(http.MethodPut, "/v0/management/debug", "env-secret")
+	if rec.Code != http.StatusOK || rec.Body.String() != config.ManagementRoleAdmin {
+		t.Fatalf("env secret PUT: status=%d body=%q", rec.Code, rec.Body.String())
+	}
+
+	if rec = serve(http.MethodGet, "/v0/management/usage", "unknown"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthenticateManagementRouteChecksRole(t *testing.T) {
+	hash, errHash := bcrypt.GenerateFromPassword([]byte("viewer-key"), bcrypt.MinCost)
+	if errHash != nil {
+		t.Fatalf("hash: %v", errHash)
+	}
+	h := &Handler{
+		cfg: &config.Config{RemoteManagement: config.RemoteManagement{
+			Keys: []config.ManagementKey{{Name: "dashboard", Key: string(hash), Role: config.ManagementRoleViewer}},
+		}},
+		failedAttempts: make(map[string]*attemptInfo),
+	}
+	allowed, statusCode, _ := h.AuthenticateManagementRoute("127.0.0.1", true, "viewer-key", http.MethodGet, "/usage-queue")
+	if allowed || statusCode != http.StatusForbidden {
+		t.Fatalf("viewer usage-queue: allowed=%v status=%d, want forbidden", allowed, statusCode)
+	}
+	if allowed, _, errMsg := h.AuthenticateManagementKey("127.0.0.1", true, "viewer-key"); !allowed {
+		t.Fatalf("viewer key rejected: %s", errMsg)
+	}
+}
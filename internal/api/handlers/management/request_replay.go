@@ -0,0 +1,94 @@
+package management
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
+)
+
+type replayRequestBody struct {
+	// RequestID selects a stored request log; Log supplies the log text directly.
+	RequestID     string `json:"request_id"`
+	Log           string `json:"log"`
+	AuthIndex     string `json:"auth_index"`
+	Model         string `json:"model"`
+	TranslateOnly bool   `json:"translate_only"`
+}
+
+// ReplayRequestLog re-executes the downstream request from a stored request log through
+// the current pipeline and returns diffs against the logged upstream request, upstream
+// response and client response.
+func (h *Handler) ReplayRequestLog(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	h.mu.Lock()
+	handler := h.replayHandler
+	h.mu.Unlock()
+	if handler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "replay unavailable"})
+		return
+	}
+
+	var body replayRequestBody
+	if errBind := c.ShouldBindJSON(&body); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	logText := []byte(body.Log)
+	if strings.TrimSpace(body.Log) == "" {
+		requestID := strings.TrimSpace(body.RequestID)
+		if requestID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "request_id or log is required"})
+			return
+		}
+		if strings.ContainsAny(requestID, "/\\") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+			return
+		}
+		dir := h.logDirectory()
+		if strings.TrimSpace(dir) == "" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+			return
+		}
+		path, status, errFind := findRequestLogFile(dir, requestID)
+		if errFind != nil {
+			c.JSON(status, gin.H{"error": errFind.Error()})
+			return
+		}
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read log file"})
+			return
+		}
+		logText = data
+	}
+
+	original, errParse := replay.Parse(logText)
+	if errParse != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": errParse.Error()})
+		return
+	}
+
+	opts := replay.Options{Model: body.Model, TranslateOnly: body.TranslateOnly}
+	if authIndex := strings.TrimSpace(body.AuthIndex); authIndex != "" {
+		auth := h.authByIndex(authIndex)
+		if auth == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "auth not found for auth_index"})
+			return
+		}
+		opts.AuthID = auth.ID
+	}
+
+	result, errReplay := replay.Run(c.Request.Context(), handler, original, opts)
+	if errReplay != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": errReplay.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
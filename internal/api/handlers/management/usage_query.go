@@ -0,0 +1,146 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+)
+
+const (
+	defaultUsageQueryWindow = 24 * time.Hour
+	defaultUsageQueryLimit  = 1000
+	maxUsageQueryLimit      = 10000
+)
+
+// GetUsageQuery aggregates the durable usage ledger into time buckets.
+//
+// Query parameters: from and to (RFC 3339 or Unix seconds, default the last 24 hours),
+// bucket (a duration such as 15m, 1h or 1d; "none" for one bucket, default 1h),
+// group_by (comma-separated dimensions), limit, and one filter per dimension name.
+func (h *Handler) GetUsageQuery(c *gin.Context) {
+	to := time.Now().UTC()
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		parsed, errParse := parseUsageQueryTime(raw)
+		if errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + errParse.Error()})
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultUsageQueryWindow)
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		parsed, errParse := parseUsageQueryTime(raw)
+		if errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + errParse.Error()})
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+	bucket, errBucket := parseUsageQueryBucket(c.Query("bucket"))
+	if errBucket != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bucket: " + errBucket.Error()})
+		return
+	}
+	limit := defaultUsageQueryLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, errLimit := strconv.Atoi(raw)
+		if errLimit != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(parsed, maxUsageQueryLimit)
+	}
+
+	var groupBy []string
+	for _, name := range strings.Split(c.Query("group_by"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !usageledger.IsDimension(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown group_by dimension: " + name})
+			return
+		}
+		groupBy = append(groupBy, name)
+	}
+	filters := make(map[string]string)
+	for name, values := range c.Request.URL.Query() {
+		if !usageledger.IsDimension(name) || len(values) == 0 {
+			continue
+		}
+		if value := strings.TrimSpace(values[0]); value != "" {
+			filters[name] = value
+		}
+	}
+
+	rows, errQuery := usageledger.Default().Query(c.Request.Context(), usageledger.Query{
+		From:    from,
+		To:      to,
+		Bucket:  bucket,
+		GroupBy: groupBy,
+		Filters: filters,
+		Limit:   limit,
+	})
+	if errors.Is(errQuery, usageledger.ErrDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": errQuery.Error()})
+		return
+	}
+	if errQuery != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errQuery.Error()})
+		return
+	}
+	bucketLabel := "none"
+	if bucket > 0 {
+		bucketLabel = bucket.String()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"bucket":   bucketLabel,
+		"group_by": groupBy,
+		"rows":     rows,
+	})
+}
+
+func parseUsageQueryTime(raw string) (time.Time, error) {
+	if seconds, errInt := strconv.ParseInt(raw, 10, 64); errInt == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	parsed, errParse := time.Parse(time.RFC3339, raw)
+	if errParse != nil {
+		return time.Time{}, errors.New("must be RFC 3339 or Unix seconds")
+	}
+	return parsed.UTC(), nil
+}
+
+// parseUsageQueryBucket accepts Go durations plus a "d" suffix for days.
+func parseUsageQueryBucket(raw string) (time.Duration, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	switch raw {
+	case "":
+		return time.Hour, nil
+	case "none", "0":
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		count, errDays := strconv.Atoi(days)
+		if errDays != nil || count <= 0 {
+			return 0, errors.New("invalid day count")
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	bucket, errParse := time.ParseDuration(raw)
+	if errParse != nil || bucket < time.Minute {
+		return 0, errors.New("must be at least 1m")
+	}
+	return bucket, nil
+}
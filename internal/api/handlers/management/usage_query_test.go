@@ -0,0 +1,73 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func serveUsageQuery(target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(rec)
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	(&Handler{}).GetUsageQuery(ginCtx)
+	return rec
+}
+
+func TestGetUsageQueryAggregatesLedger(t *testing.T) {
+	if rec := serveUsageQuery("/v0/management/usage/query"); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled ledger: status %d, want 404", rec.Code)
+	}
+
+	usageledger.Configure(&config.Config{UsageLedger: config.UsageLedgerConfig{
+		Enable: true,
+		Path:   filepath.Join(t.TempDir(), "usage.db"),
+	}})
+	t.Cleanup(func() { usageledger.Configure(nil) })
+	now := time.Now().UTC()
+	for _, model := range []string{"gpt-5", "gpt-5", "claude-sonnet-4"} {
+		usageledger.Default().HandleUsage(t.Context(), coreusage.Record{
+			Model:       model,
+			Provider:    "codex",
+			RequestedAt: now.Add(-time.Minute),
+			Detail:      coreusage.Detail{InputTokens: 10, TotalTokens: 10},
+		})
+	}
+
+	rec := serveUsageQuery("/v0/management/usage/query?bucket=1d&group_by=model&provider=codex")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Bucket string            `json:"bucket"`
+		Rows   []usageledger.Row `json:"rows"`
+	}
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &payload); errUnmarshal != nil {
+		t.Fatalf("unmarshal: %v", errUnmarshal)
+	}
+	requests := make(map[string]int64)
+	for _, row := range payload.Rows {
+		requests[row.Dimensions["model"]] += row.Requests
+	}
+	if payload.Bucket != "24h0m0s" || requests["gpt-5"] != 2 || requests["claude-sonnet-4"] != 1 {
+		t.Fatalf("payload = %s", rec.Body.String())
+	}
+
+	for _, target := range []string{
+		"/v0/management/usage/query?group_by=api_key",
+		"/v0/management/usage/query?bucket=10s",
+		"/v0/management/usage/query?from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z",
+	} {
+		if rec = serveUsageQuery(target); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d, want 400", target, rec.Code)
+		}
+	}
+}
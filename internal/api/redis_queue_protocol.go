@@ -131,7 +131,7 @@ func (s *Server) handleRedisConnection(conn net.Conn, reader *bufio.Reader) {
 				}
 				continue
 			}
-			allowed, _, errMsg := s.mgmt.AuthenticateManagementKey(clientIP, localClient, password)
+			allowed, _, errMsg := s.mgmt.AuthenticateManagementRoute(clientIP, localClient, password, http.MethodGet, "/usage-queue")
 			if !allowed {
 				_ = writeRedisError(writer, "ERR "+errMsg)
 				if !flush() {
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/accesslog"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clientnet"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+// accessLogMiddleware writes one access log line per client API request once the handler
+// returns. Execution details are collected through logging.AccessInfo on the request
+// context.
+func accessLogMiddleware(cfgFn func() *config.Config, logger *accesslog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		protocol := metrics.Protocol(c.Request.URL.Path)
+		if protocol == "" || !logger.Enabled() {
+			c.Next()
+			return
+		}
+		started := time.Now()
+		body := &countingReadCloser{ReadCloser: c.Request.Body}
+		if c.Request.Body != nil {
+			c.Request.Body = body
+		}
+		writer := &firstByteWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Request = c.Request.WithContext(logging.WithAccessInfo(c.Request.Context()))
+
+		c.Next()
+
+		info, _ := logging.GetAccessInfo(c.Request.Context())
+		entry := accesslog.Entry{
+			Time:            started.UTC(),
+			RequestID:       logging.GetGinRequestID(c),
+			TraceID:         logging.GetGinCPATraceID(c),
+			Principal:       accessPrincipal(cfgFn(), c.GetString("userApiKey"), c.GetString("accessProvider")),
+			Protocol:        protocol,
+			Method:          c.Request.Method,
+			Path:            c.Request.URL.Path,
+			ClientIP:        clientnet.Default().ClientAddress(c.Request),
+			RequestedModel:  info.RequestedModel,
+			ResolvedModel:   info.ResolvedModel,
+			Provider:        info.Provider,
+			AuthIndex:       info.AuthIndex,
+			Status:          writer.Status(),
+			DurationMs:      time.Since(started).Milliseconds(),
+			RequestBytes:    max(body.n.Load(), c.Request.ContentLength),
+			ResponseBytes:   int64(max(writer.Size(), 0)),
+			InputTokens:     info.InputTokens,
+			OutputTokens:    info.OutputTokens,
+			ReasoningTokens: info.ReasoningTokens,
+			CachedTokens:    info.CachedTokens,
+			TotalTokens:     info.TotalTokens,
+		}
+		if info.Attempts > 1 {
+			entry.Retries = info.Attempts - 1
+		}
+		if first := writer.first.Load(); first != 0 {
+			entry.TTFBMs = time.Duration(first - started.UnixNano()).Milliseconds()
+		}
+		logger.Write(entry)
+	}
+}
+
+// accessPrincipal names the client without exposing API keys: configured client keys use
+// their display name and inline keys are masked. Other providers report their subject.
+func accessPrincipal(cfg *config.Config, principal, provider string) string {
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return ""
+	}
+	if entry, ok := findClientAPIKey(cfg, principal); ok {
+		return entry.DisplayName()
+	}
+	if provider == sdkaccess.DefaultAccessProviderName {
+		return util.HideAPIKey(principal)
+	}
+	if cfg != nil {
+		for _, key := range cfg.APIKeys {
+			if strings.TrimSpace(key) == principal {
+				return util.HideAPIKey(principal)
+			}
+		}
+	}
+	return principal
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
+
+// firstByteWriter records when the first response byte is written.
+type firstByteWriter struct {
+	gin.ResponseWriter
+	first atomic.Int64
+}
+
+func (w *firstByteWriter) mark() {
+	w.first.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+func (w *firstByteWriter) WriteHeaderNow() {
+	w.mark()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *firstByteWriter) Write(data []byte) (int, error) {
+	w.mark()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *firstByteWriter) WriteString(data string) (int, error) {
+	w.mark()
+	return w.ResponseWriter.WriteString(data)
+}
+
+func (w *firstByteWriter) Flush() {
+	w.mark()
+	w.ResponseWriter.Flush()
+}
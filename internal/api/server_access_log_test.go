@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/accesslog"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+)
+
+func TestAccessLogMiddlewareWritesOneLinePerAPIRequest(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		AccessLog: config.AccessLogConfig{Enable: true, Dir: dir},
+		SDKConfig: config.SDKConfig{ClientAPIKeys: []config.ClientAPIKey{{Key: "sk-team-a-secret", Name: "team-a"}}},
+	}
+	logger := accesslog.NewLogger()
+	logger.Configure(cfg)
+	defer logger.Close()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(logging.GinLogrusLogger())
+	engine.Use(accessLogMiddleware(func() *config.Config { return cfg }, logger))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Set("userApiKey", "sk-team-a-secret")
+		ctx := c.Request.Context()
+		logging.SetAccessRequestedModel(ctx, "fast")
+		logging.RecordAccessAttempt(ctx, "codex", "1", "gpt-5.4")
+		logging.RecordAccessAttempt(ctx, "codex", "2", "gpt-5.4")
+		logging.AddAccessTokens(ctx, 100, 20, 5, 40, 120)
+		c.String(http.StatusOK, "hello")
+	})
+	engine.GET("/v0/management/config", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"fast"}`))
+	request.RemoteAddr = "198.51.100.7:40000"
+	// The peer is not a trusted proxy, so its forwarded address must be ignored.
+	request.Header.Set("X-Forwarded-For", "203.0.113.9")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v0/management/config", nil))
+
+	data, errRead := os.ReadFile(filepath.Join(dir, "access.log"))
+	if errRead != nil {
+		t.Fatalf("read access log: %v", errRead)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("access log lines = %d, want 1:\n%s", len(lines), data)
+	}
+	var entry accesslog.Entry
+	if errUnmarshal := json.Unmarshal([]byte(lines[0]), &entry); errUnmarshal != nil {
+		t.Fatalf("decode entry: %v", errUnmarshal)
+	}
+	if entry.Principal != "team-a" || entry.Protocol != "openai" || entry.RequestID == "" || entry.ClientIP != "198.51.100.7" {
+		t.Fatalf("entry identity = %+v", entry)
+	}
+	if entry.RequestedModel != "fast" || entry.ResolvedModel != "gpt-5.4" || entry.Provider != "codex" || entry.AuthIndex != "2" || entry.Retries != 1 {
+		t.Fatalf("entry execution = %+v", entry)
+	}
+	if entry.Status != http.StatusOK || entry.RequestBytes != 16 || entry.ResponseBytes != 5 || entry.TotalTokens != 120 || entry.CachedTokens != 40 {
+		t.Fatalf("entry counters = %+v", entry)
+	}
+}
+
+func TestAccessPrincipalMasksInlineKeys(t *testing.T) {
+	cfg := &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"sk-inline-0123456789"}}}
+	if got := accessPrincipal(cfg, "sk-inline-0123456789", "config-inline"); got != "sk-i...6789" {
+		t.Fatalf("inline key principal = %q", got)
+	}
+	if got := accessPrincipal(cfg, "alice@example.com", "jwt"); got != "alice@example.com" {
+		t.Fatalf("jwt principal = %q", got)
+	}
+}
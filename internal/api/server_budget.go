@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+)
+
+// clientBudgetMiddleware rejects requests from client keys whose budget is exhausted.
+// It must run after AuthMiddleware, which stores the key as "userApiKey".
+func clientBudgetMiddleware(service *budget.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if service == nil || c.Request == nil {
+			c.Next()
+			return
+		}
+		if c.Request.Method == http.MethodGet && !isWebsocketUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+		key := c.GetString("userApiKey")
+		decision := service.Check(key)
+		if !decision.Allowed {
+			seconds := retryAfterSeconds(decision.RetryAfter)
+			writeClientQuotaError(c, seconds, clientBudgetMessage(decision, seconds), "insufficient_quota", "insufficient_quota")
+			return
+		}
+		if isWebsocketUpgrade(c.Request) {
+			// Spend keeps accruing on an open socket, so every turn is checked again.
+			handlers.AddSessionTurnGate(c, func(context.Context) *interfaces.ErrorMessage {
+				turn := service.Check(key)
+				if turn.Allowed {
+					return nil
+				}
+				seconds := retryAfterSeconds(turn.RetryAfter)
+				return clientQuotaErrorMessage(seconds, clientBudgetMessage(turn, seconds), "insufficient_quota", "insufficient_quota")
+			})
+		}
+		c.Next()
+	}
+}
+
+func clientBudgetMessage(decision budget.Decision, seconds int) string {
+	return fmt.Sprintf("The %s %s budget for this API key is exhausted. Retry after %d seconds.", decision.Period, decision.Unit, seconds)
+}
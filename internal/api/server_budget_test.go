@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestClientBudgetMiddleware_RejectsExhaustedKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := budget.NewService()
+	service.Configure(&config.Config{
+		SDKConfig: config.SDKConfig{APIKeys: []string{"client-key"}},
+		ClientBudgets: config.ClientBudgetConfig{
+			StateFile: filepath.Join(t.TempDir(), "budgets.state"),
+			Default:   config.ClientBudget{Daily: 10},
+		},
+	})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", "client-key")
+		c.Next()
+	}, clientBudgetMiddleware(service))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status before usage = %d, want 200", recorder.Code)
+	}
+
+	service.HandleUsage(context.Background(), coreusage.Record{
+		APIKey: "client-key",
+		Detail: coreusage.Detail{TokenBreakdown: coreusage.NewIndependentTokenBreakdown(10, 0, 0, 5, 0, 15)},
+	})
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`)))
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status after exhaustion = %d, want 429", recorder.Code)
+	}
+	if got := gjson.Get(recorder.Body.String(), "error.code").String(); got != "insufficient_quota" {
+		t.Fatalf("error.code = %q, body %s", got, recorder.Body.String())
+	}
+	if seconds, errAtoi := strconv.Atoi(recorder.Header().Get("Retry-After")); errAtoi != nil || seconds < 1 || seconds > 86400 {
+		t.Fatalf("Retry-After = %q, want seconds until the day rolls over", recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestClientBudgetMiddleware_RechecksEachWebsocketTurn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := budget.NewService()
+	service.Configure(&config.Config{
+		SDKConfig: config.SDKConfig{APIKeys: []string{"client-key"}},
+		ClientBudgets: config.ClientBudgetConfig{
+			StateFile: filepath.Join(t.TempDir(), "budgets.state"),
+			Default:   config.ClientBudget{Daily: 10},
+		},
+	})
+
+	var turns []int
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", "client-key")
+		c.Next()
+	}, clientBudgetMiddleware(service))
+	engine.GET("/v1/responses", func(c *gin.Context) {
+		for i := 0; i < 2; i++ {
+			status := http.StatusOK
+			if errMsg := handlers.AdmitSessionTurn(c); errMsg != nil {
+				status = errMsg.StatusCode
+				if errMsg.Addon.Get("Retry-After") == "" || gjson.Get(errMsg.Error.Error(), "error.code").String() != "insufficient_quota" {
+					t.Fatalf("turn rejection = %+v", errMsg)
+				}
+			}
+			turns = append(turns, status)
+			// The first turn spends the whole daily budget.
+			service.HandleUsage(context.Background(), coreusage.Record{
+				APIKey: "client-key",
+				Detail: coreusage.Detail{TokenBreakdown: coreusage.NewIndependentTokenBreakdown(10, 0, 0, 5, 0, 15)},
+			})
+		}
+		c.Status(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/responses", nil)
+	request.Header.Set("Upgrade", "websocket")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+
+	if len(turns) != 2 || turns[0] != http.StatusOK || turns[1] != http.StatusTooManyRequests {
+		t.Fatalf("turns = %v, want [200 429]", turns)
+	}
+}
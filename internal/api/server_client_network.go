@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clientnet"
+	log "github.com/sirupsen/logrus"
+)
+
+const clientNetworkDeniedMessage = "This API key is not allowed from this network."
+
+// clientNetworkMiddleware rejects requests whose source address is outside the allowed
+// networks of the authenticated client key, or of client-network when the key has none.
+// It must run after the auth middleware, which stores the key as "userApiKey".
+func clientNetworkMiddleware(service *clientnet.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if service == nil || c.Request == nil || !service.Restricted() {
+			c.Next()
+			return
+		}
+		key := c.GetString("userApiKey")
+		addr := service.ClientIP(c.Request)
+		allowed, name := service.Allowed(key, addr)
+		if allowed {
+			c.Next()
+			return
+		}
+		log.Warnf("client network: rejected %s from %s for %s", clientNetworkKeyName(c, name), addr, c.Request.URL.Path)
+		writeClientNetworkError(c)
+	}
+}
+
+// clientNetworkKeyName describes the caller for logs without the key value.
+func clientNetworkKeyName(c *gin.Context, name string) string {
+	if metadata, ok := c.Get("accessMetadata"); ok {
+		if values, okValues := metadata.(map[string]string); okValues && values["key-name"] != "" {
+			name = values["key-name"]
+		}
+	}
+	provider := c.GetString("accessProvider")
+	switch {
+	case name != "" && provider != "":
+		return "key " + name + " (" + provider + ")"
+	case name != "":
+		return "key " + name
+	case c.GetString("userApiKey") == "":
+		return "unauthenticated request"
+	case provider != "":
+		return "unnamed key (" + provider + ")"
+	default:
+		return "unnamed key"
+	}
+}
+
+// writeClientNetworkError writes a 403 in the error shape of the client protocol.
+func writeClientNetworkError(c *gin.Context) {
+	path := c.Request.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/v1beta"):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": gin.H{
+			"code":    http.StatusForbidden,
+			"message": clientNetworkDeniedMessage,
+			"status":  "PERMISSION_DENIED",
+		}})
+	case strings.HasPrefix(path, "/v1/messages"):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"type": "error",
+			"error": gin.H{
+				"type":    "permission_error",
+				"message": clientNetworkDeniedMessage,
+			},
+		})
+	default:
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": gin.H{
+			"message": clientNetworkDeniedMessage,
+			"type":    "permission_error",
+			"param":   nil,
+			"code":    "network_not_allowed",
+		}})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clientnet"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+func TestClientNetworkMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := clientnet.NewService()
+	service.Configure(&config.Config{SDKConfig: config.SDKConfig{
+		ClientNetwork: config.ClientNetworkConfig{TrustedProxies: []string{"127.0.0.1"}},
+		ClientAPIKeys: []config.ClientAPIKey{{Name: "office", Key: "sk-office-secret-value", AllowedCIDRs: []string{"10.0.0.0/8"}}},
+	}})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", "sk-office-secret-value")
+		c.Set("accessProvider", "config-inline")
+		c.Next()
+	}, clientNetworkMiddleware(service))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	engine.POST("/v1/chat/completions", ok)
+	engine.POST("/v1/messages", ok)
+	engine.POST("/v1beta/models/*action", ok)
+
+	var logs bytes.Buffer
+	previous := log.StandardLogger().Out
+	log.SetOutput(&logs)
+	defer log.SetOutput(previous)
+
+	send := func(path, remoteAddr, forwarded string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		req.RemoteAddr = remoteAddr
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("/v1/chat/completions", "127.0.0.1:4000", "10.2.3.4"); rec.Code != http.StatusOK {
+		t.Fatalf("forwarded office address: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if rec := send("/v1/chat/completions", "203.0.113.5:4000", "10.2.3.4"); rec.Code != http.StatusForbidden {
+		t.Fatalf("spoofed forwarded address from untrusted peer: status %d", rec.Code)
+	}
+
+	rec := send("/v1/chat/completions", "127.0.0.1:4000", "198.51.100.7")
+	if rec.Code != http.StatusForbidden || gjson.Get(rec.Body.String(), "error.code").String() != "network_not_allowed" {
+		t.Fatalf("openai rejection: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	rec = send("/v1/messages", "198.51.100.7:4000", "")
+	if gjson.Get(rec.Body.String(), "error.type").String() != "permission_error" || gjson.Get(rec.Body.String(), "type").String() != "error" {
+		t.Fatalf("claude rejection body %s", rec.Body.String())
+	}
+	rec = send("/v1beta/models/gemini-2.5-pro:generateContent", "198.51.100.7:4000", "")
+	if gjson.Get(rec.Body.String(), "error.status").String() != "PERMISSION_DENIED" {
+		t.Fatalf("gemini rejection body %s", rec.Body.String())
+	}
+
+	if !strings.Contains(logs.String(), "key office") {
+		t.Fatalf("rejection log does not name the key: %s", logs.String())
+	}
+	if strings.Contains(logs.String(), "sk-office-secret-value") {
+		t.Fatalf("rejection log contains the key value: %s", logs.String())
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+)
+
+// eventsMiddleware attributes activity events to the client request and publishes
+// request.finished once the handler returns. request.started is published by the
+// handlers when the requested model is known.
+func eventsMiddleware(cfgFn func() *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if metrics.Protocol(c.Request.URL.Path) == "" {
+			c.Next()
+			return
+		}
+		principal := func() string {
+			return accessPrincipal(cfgFn(), c.GetString("userApiKey"), c.GetString("accessProvider"))
+		}
+		ctx := events.WithRequest(c.Request.Context(), logging.GetGinRequestID(c), c.Request.Method, c.Request.URL.Path, principal)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		events.RequestFinished(c.Request.Context(), c.Writer.Status())
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// metricsMiddleware counts client API requests by entry protocol and status.
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		protocol := metrics.Protocol(c.Request.URL.Path)
+		if protocol == "" {
+			c.Next()
+			return
+		}
+		started := time.Now()
+		c.Next()
+		metrics.ObserveHTTPRequest(protocol, c.Writer.Status(), time.Since(started))
+	}
+}
+
+// serveMetrics serves /metrics on the main port when metrics are enabled without a
+// dedicated listener.
+func (s *Server) serveMetrics(c *gin.Context) {
+	cfg := s.cfg
+	if cfg == nil || !cfg.Metrics.Enable || cfg.Metrics.Addr != "" {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	metrics.Handler(func() string { return cfg.Metrics.BearerToken }).ServeHTTP(c.Writer, c.Request)
+}
+
+// credentialCounts groups the credentials of manager by provider and state for the
+// credentials gauge. Disabled wins over quota, and quota over cooldowns.
+func credentialCounts(manager *auth.Manager) func() []metrics.CredentialCount {
+	return func() []metrics.CredentialCount {
+		if manager == nil {
+			return nil
+		}
+		type key struct{ provider, state string }
+		now := time.Now()
+		counts := make(map[key]int)
+		for _, entry := range manager.List() {
+			if entry == nil {
+				continue
+			}
+			counts[key{entry.Provider, credentialState(entry, now)}]++
+		}
+		out := make([]metrics.CredentialCount, 0, len(counts))
+		for k, count := range counts {
+			out = append(out, metrics.CredentialCount{Provider: k.provider, State: k.state, Count: count})
+		}
+		return out
+	}
+}
+
+func credentialState(entry *auth.Auth, now time.Time) string {
+	if entry.Disabled || entry.Status == auth.StatusDisabled {
+		return metrics.CredentialDisabled
+	}
+	if entry.Quota.Exceeded {
+		return metrics.CredentialQuotaExceeded
+	}
+	if entry.Unavailable && entry.NextRetryAfter.After(now) {
+		return metrics.CredentialCooling
+	}
+	for _, state := range entry.ModelStates {
+		if state == nil {
+			continue
+		}
+		if state.Quota.Exceeded {
+			return metrics.CredentialQuotaExceeded
+		}
+		if state.Unavailable && state.NextRetryAfter.After(now) {
+			return metrics.CredentialCooling
+		}
+	}
+	return metrics.CredentialActive
+}
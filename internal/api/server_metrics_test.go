@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestServeMetricsOnMainPort(t *testing.T) {
+	server := newTestServer(t)
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get(""); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled metrics: status %d, want 404", rec.Code)
+	}
+	server.cfg.Metrics.Enable = true
+	server.cfg.Metrics.BearerToken = "scrape"
+	if rec := get(""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("metrics without token: status %d, want 401", rec.Code)
+	}
+	rec := get("scrape")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "go_goroutines") {
+		t.Fatalf("metrics scrape: status %d, body %.200s", rec.Code, rec.Body.String())
+	}
+	server.cfg.Metrics.Addr = "127.0.0.1:9316"
+	if rec = get("scrape"); rec.Code != http.StatusNotFound {
+		t.Fatalf("metrics on a dedicated listener: main port status %d, want 404", rec.Code)
+	}
+}
+
+func TestCredentialState(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Minute)
+	tests := []struct {
+		name  string
+		entry *auth.Auth
+		want  string
+	}{
+		{name: "active", entry: &auth.Auth{Status: auth.StatusActive}, want: metrics.CredentialActive},
+		{name: "disabled", entry: &auth.Auth{Disabled: true, Quota: auth.QuotaState{Exceeded: true}}, want: metrics.CredentialDisabled},
+		{name: "quota", entry: &auth.Auth{Unavailable: true, NextRetryAfter: future, Quota: auth.QuotaState{Exceeded: true}}, want: metrics.CredentialQuotaExceeded},
+		{name: "cooling", entry: &auth.Auth{Unavailable: true, NextRetryAfter: future}, want: metrics.CredentialCooling},
+		{name: "cooldown elapsed", entry: &auth.Auth{Unavailable: true, NextRetryAfter: now.Add(-time.Minute)}, want: metrics.CredentialActive},
+		{name: "model cooling", entry: &auth.Auth{ModelStates: map[string]*auth.ModelState{"gpt-5": {Unavailable: true, NextRetryAfter: future}}}, want: metrics.CredentialCooling},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := credentialState(tt.entry, now); got != tt.want {
+				t.Fatalf("credentialState() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
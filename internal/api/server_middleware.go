@@ -9,6 +9,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/safemode"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	log "github.com/sirupsen/logrus"
@@ -158,6 +159,15 @@ func realtimeStandardAuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc
 
 func accessAuthMiddleware(manager *sdkaccess.Manager, realtimeError bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if session := replay.FromRequest(c.Request); session != nil {
+			// Replays are started in-process by the management API; they never arrive
+			// over the network and carry no client key.
+			c.Set("userApiKey", replay.Principal)
+			c.Set("accessProvider", replay.AccessProvider)
+			c.Set(replay.GinKey, session)
+			c.Next()
+			return
+		}
 		if manager == nil {
 			c.Next()
 			return
@@ -171,6 +181,9 @@ func accessAuthMiddleware(manager *sdkaccess.Manager, realtimeError bool) gin.Ha
 				if len(result.Metadata) > 0 {
 					c.Set("accessMetadata", result.Metadata)
 				}
+				if result.Models != nil {
+					c.Set("accessModelRules", result.Models)
+				}
 			}
 			c.Next()
 			return
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// applyClientCertificatePolicy configures client certificate verification from tls.client-ca
+// and tls.client-auth. Only the CA bundle is trusted; the system roots are not.
+func applyClientCertificatePolicy(tlsConfig *tls.Config, cfg config.TLSConfig) error {
+	mode := cfg.NormalizedClientAuth()
+	if mode == "" {
+		return nil
+	}
+	caPath := strings.TrimSpace(cfg.ClientCA)
+	if caPath == "" {
+		return fmt.Errorf("tls.client-auth %q requires tls.client-ca", mode)
+	}
+	caPEM, errRead := os.ReadFile(caPath)
+	if errRead != nil {
+		return fmt.Errorf("read tls.client-ca: %w", errRead)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("tls.client-ca contains no PEM certificates")
+	}
+	tlsConfig.ClientCAs = pool
+	switch mode {
+	case config.TLSClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case config.TLSClientAuthVerifyIfGiven:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return fmt.Errorf("tls.client-auth %q is invalid", cfg.ClientAuth)
+	}
+	return nil
+}
+
+type connTLSStateKey struct{}
+
+// connTLSStateContext records the TLS state of connections the protocol multiplexer wraps.
+// net/http only fills Request.TLS for *tls.Conn, so the state would otherwise be lost.
+func connTLSStateContext(ctx context.Context, conn net.Conn) context.Context {
+	if _, ok := conn.(*tls.Conn); ok {
+		return ctx
+	}
+	stater, ok := conn.(interface{ ConnectionState() tls.ConnectionState })
+	if !ok {
+		return ctx
+	}
+	state := stater.ConnectionState()
+	if !state.HandshakeComplete {
+		return ctx
+	}
+	return context.WithValue(ctx, connTLSStateKey{}, &state)
+}
+
+// withConnTLSState restores Request.TLS from connTLSStateContext before routing.
+func withConnTLSState(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			if state, ok := r.Context().Value(connTLSStateKey{}).(*tls.ConnectionState); ok {
+				r.TLS = state
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
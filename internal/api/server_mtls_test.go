@@ -0,0 +1,105 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newTestClientCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, errKey := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if errKey != nil {
+		t.Fatalf("GenerateKey() error = %v", errKey)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, errCreate := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if errCreate != nil {
+		t.Fatalf("CreateCertificate() error = %v", errCreate)
+	}
+	cert, errParse := x509.ParseCertificate(der)
+	if errParse != nil {
+		t.Fatalf("ParseCertificate() error = %v", errParse)
+	}
+	return cert, key
+}
+
+func TestClientCertificatePolicy_RequiresTrustedCertificate(t *testing.T) {
+	caCert, caKey := newTestClientCertificate(t, "mesh-ca", nil, nil)
+	clientCert, clientKey := newTestClientCertificate(t, "search-service", caCert, caKey)
+	caPath := filepath.Join(t.TempDir(), "client-ca.pem")
+	if errWrite := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0o600); errWrite != nil {
+		t.Fatalf("write client CA: %v", errWrite)
+	}
+
+	server := httptest.NewUnstartedServer(withConnTLSState(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	})))
+	server.TLS = &tls.Config{}
+	if errPolicy := applyClientCertificatePolicy(server.TLS, config.TLSConfig{ClientCA: caPath, ClientAuth: "require"}); errPolicy != nil {
+		t.Fatalf("applyClientCertificatePolicy() error = %v", errPolicy)
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	anonymous := server.Client()
+	if resp, errGet := anonymous.Get(server.URL); errGet == nil {
+		_ = resp.Body.Close()
+		t.Fatal("request without a client certificate succeeded")
+	}
+
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.Certificates = []tls.Certificate{{
+		Certificate: [][]byte{clientCert.Raw},
+		PrivateKey:  clientKey,
+	}}
+	resp, errGet := (&http.Client{Transport: transport}).Get(server.URL)
+	if errGet != nil {
+		t.Fatalf("request with client certificate error = %v", errGet)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "search-service" {
+		t.Fatalf("verified principal = %q, want search-service", body)
+	}
+}
+
+func TestClientCertificatePolicy_RejectsBadCA(t *testing.T) {
+	badPath := filepath.Join(t.TempDir(), "bad.pem")
+	if errWrite := os.WriteFile(badPath, []byte("not a certificate"), 0o600); errWrite != nil {
+		t.Fatalf("write CA: %v", errWrite)
+	}
+	if errPolicy := applyClientCertificatePolicy(&tls.Config{}, config.TLSConfig{ClientCA: badPath}); errPolicy == nil {
+		t.Fatal("applyClientCertificatePolicy() accepted a CA file without certificates")
+	}
+	tlsConfig := &tls.Config{}
+	if errPolicy := applyClientCertificatePolicy(tlsConfig, config.TLSConfig{}); errPolicy != nil || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Fatalf("applyClientCertificatePolicy() without client-ca = %v, %v", tlsConfig.ClientAuth, errPolicy)
+	}
+}
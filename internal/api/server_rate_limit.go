@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// clientRateLimitMiddleware enforces client-rate-limit for the authenticated client key.
+// It must run after AuthMiddleware, which stores the key as "userApiKey".
+func clientRateLimitMiddleware(service *ratelimit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if service == nil || c.Request == nil {
+			c.Next()
+			return
+		}
+		websocket := isWebsocketUpgrade(c.Request)
+		if c.Request.Method == http.MethodGet && !websocket {
+			c.Next()
+			return
+		}
+		key := c.GetString("userApiKey")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		stream := websocket
+		if !stream && service.LimitsFor(key).MaxConcurrentStreams > 0 {
+			stream = isStreamingRequest(c)
+		}
+		release, decision := service.Admit(c.Request.Context(), key, stream)
+		if !decision.Allowed {
+			writeClientRateLimitError(c, decision)
+			return
+		}
+		if release != nil {
+			defer release()
+		}
+		if websocket {
+			// The upgrade pays for the first turn; later turns on the socket are charged
+			// one request each and rejected once the key runs out.
+			var prepaid atomic.Bool
+			prepaid.Store(true)
+			handlers.AddSessionTurnGate(c, func(ctx context.Context) *interfaces.ErrorMessage {
+				if prepaid.Swap(false) {
+					return nil
+				}
+				if _, turn := service.Admit(ctx, key, false); !turn.Allowed {
+					seconds := retryAfterSeconds(turn.RetryAfter)
+					return clientQuotaErrorMessage(seconds, clientRateLimitMessage(turn.Reason, seconds), "rate_limit_error", "rate_limit_exceeded")
+				}
+				return nil
+			})
+		}
+		c.Next()
+	}
+}
+
+func isWebsocketUpgrade(req *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(req.Header.Get("Upgrade")), "websocket")
+}
+
+// isStreamingRequest detects streaming from the Gemini stream action or the JSON "stream"
+// flag. The body is restored for the downstream handler.
+func isStreamingRequest(c *gin.Context) bool {
+	if strings.Contains(c.Request.URL.Path, ":streamGenerateContent") {
+		return true
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return false
+	}
+	body, errRead := io.ReadAll(c.Request.Body)
+	_ = c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if errRead != nil {
+		return false
+	}
+	return gjson.GetBytes(body, "stream").Bool()
+}
+
+// writeClientRateLimitError writes a 429 shaped like the protocol the client speaks.
+func writeClientRateLimitError(c *gin.Context, decision ratelimit.Decision) {
+	seconds := retryAfterSeconds(decision.RetryAfter)
+	writeClientQuotaError(c, seconds, clientRateLimitMessage(decision.Reason, seconds), "rate_limit_error", "rate_limit_exceeded")
+}
+
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// writeClientQuotaError writes a 429 with Retry-After in the error shape of the client
+// protocol. openAIType and openAICode only apply to OpenAI-shaped errors.
+func writeClientQuotaError(c *gin.Context, retryAfter int, message, openAIType, openAICode string) {
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	path := c.Request.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/v1beta"):
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": gin.H{
+			"code":    http.StatusTooManyRequests,
+			"message": message,
+			"status":  "RESOURCE_EXHAUSTED",
+		}})
+	case strings.HasPrefix(path, "/v1/messages"):
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"type": "error",
+			"error": gin.H{
+				"type":    "rate_limit_error",
+				"message": message,
+			},
+		})
+	default:
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": gin.H{
+			"message": message,
+			"type":    openAIType,
+			"param":   nil,
+			"code":    openAICode,
+		}})
+	}
+}
+
+// clientQuotaErrorMessage is the OpenAI-shaped 429 of writeClientQuotaError for turns
+// rejected inside a websocket session, where no HTTP response can be written.
+func clientQuotaErrorMessage(retryAfter int, message, openAIType, openAICode string) *interfaces.ErrorMessage {
+	body, _ := json.Marshal(gin.H{"error": gin.H{
+		"message": message,
+		"type":    openAIType,
+		"param":   nil,
+		"code":    openAICode,
+	}})
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      errors.New(string(body)),
+		Addon:      http.Header{"Retry-After": {strconv.Itoa(retryAfter)}},
+	}
+}
+
+func clientRateLimitMessage(reason string, seconds int) string {
+	var limit string
+	switch reason {
+	case ratelimit.ReasonInputTokens:
+		limit = "input tokens per minute"
+	case ratelimit.ReasonOutputTokens:
+		limit = "output tokens per minute"
+	case ratelimit.ReasonStreams:
+		limit = "concurrent streams"
+	default:
+		limit = "requests per minute"
+	}
+	return fmt.Sprintf("Rate limit exceeded for this API key (%s). Retry after %d seconds.", limit, seconds)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+func newClientRateLimitTestEngine(limits config.ClientRateLimit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	service := ratelimit.NewService()
+	service.Configure(&config.Config{ClientRateLimit: config.ClientRateLimitConfig{Default: limits}})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", "client-key")
+		c.Next()
+	}, clientRateLimitMiddleware(service))
+	ok := func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.String(http.StatusOK, string(body))
+	}
+	engine.POST("/v1/chat/completions", ok)
+	engine.POST("/v1/messages", ok)
+	engine.POST("/v1beta/models/*action", ok)
+	engine.GET("/v1/models", ok)
+	return engine
+}
+
+func TestClientRateLimitMiddleware_ProtocolShapedRejections(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		check func(t *testing.T, body []byte)
+	}{
+		{
+			name: "openai",
+			path: "/v1/chat/completions",
+			check: func(t *testing.T, body []byte) {
+				if got := gjson.GetBytes(body, "error.code").String(); got != "rate_limit_exceeded" {
+					t.Fatalf("error.code = %q, body %s", got, body)
+				}
+				if got := gjson.GetBytes(body, "error.type").String(); got != "rate_limit_error" {
+					t.Fatalf("error.type = %q, body %s", got, body)
+				}
+			},
+		},
+		{
+			name: "claude",
+			path: "/v1/messages",
+			check: func(t *testing.T, body []byte) {
+				if gjson.GetBytes(body, "type").String() != "error" || gjson.GetBytes(body, "error.type").String() != "rate_limit_error" {
+					t.Fatalf("unexpected claude error body %s", body)
+				}
+			},
+		},
+		{
+			name: "gemini",
+			path: "/v1beta/models/gemini-2.5-pro:generateContent",
+			check: func(t *testing.T, body []byte) {
+				if gjson.GetBytes(body, "error.code").Int() != http.StatusTooManyRequests || gjson.GetBytes(body, "error.status").String() != "RESOURCE_EXHAUSTED" {
+					t.Fatalf("unexpected gemini error body %s", body)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newClientRateLimitTestEngine(config.ClientRateLimit{RequestsPerMinute: 1})
+			first := httptest.NewRecorder()
+			engine.ServeHTTP(first, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(`{}`)))
+			if first.Code != http.StatusOK {
+				t.Fatalf("first request status = %d, want 200", first.Code)
+			}
+
+			second := httptest.NewRecorder()
+			engine.ServeHTTP(second, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(`{}`)))
+			if second.Code != http.StatusTooManyRequests {
+				t.Fatalf("second request status = %d, want 429", second.Code)
+			}
+			if got := second.Header().Get("Retry-After"); got != "60" {
+				t.Fatalf("Retry-After = %q, want 60", got)
+			}
+			tt.check(t, second.Body.Bytes())
+		})
+	}
+}
+
+func TestClientRateLimitMiddleware_StreamLimitKeepsBody(t *testing.T) {
+	engine := newClientRateLimitTestEngine(config.ClientRateLimit{MaxConcurrentStreams: 1})
+	body := `{"model":"gpt-5","stream":true}`
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != body {
+		t.Fatalf("stream request = %d %q, want 200 with the original body", recorder.Code, recorder.Body.String())
+	}
+	// The slot is released when the handler returns, so a second stream is admitted.
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("second stream status = %d, want 200", recorder.Code)
+	}
+}
+
+func TestClientRateLimitMiddleware_SkipsModelListing(t *testing.T) {
+	engine := newClientRateLimitTestEngine(config.ClientRateLimit{RequestsPerMinute: 1})
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("GET /v1/models #%d status = %d, want 200", i+1, recorder.Code)
+		}
+	}
+}
+
+func TestClientRateLimitMiddleware_ChargesEachWebsocketTurn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := ratelimit.NewService()
+	service.Configure(&config.Config{ClientRateLimit: config.ClientRateLimitConfig{Default: config.ClientRateLimit{RequestsPerMinute: 2}}})
+
+	var turns []int
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", "client-key")
+		c.Next()
+	}, clientRateLimitMiddleware(service))
+	engine.GET("/v1/responses", func(c *gin.Context) {
+		for i := 0; i < 3; i++ {
+			status := http.StatusOK
+			if errMsg := handlers.AdmitSessionTurn(c); errMsg != nil {
+				status = errMsg.StatusCode
+				if errMsg.Addon.Get("Retry-After") == "" || gjson.Get(errMsg.Error.Error(), "error.code").String() != "rate_limit_exceeded" {
+					t.Fatalf("turn rejection = %+v", errMsg)
+				}
+			}
+			turns = append(turns, status)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/responses", nil)
+	request.Header.Set("Upgrade", "websocket")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+
+	// The upgrade pays for the first turn, the second uses the last request, the third is rejected.
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	if len(turns) != len(want) {
+		t.Fatalf("turns = %v, want %v", turns, want)
+	}
+	for i := range want {
+		if turns[i] != want[i] {
+			t.Fatalf("turns = %v, want %v", turns, want)
+		}
+	}
+}
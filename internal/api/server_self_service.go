@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clientusage"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	log "github.com/sirupsen/logrus"
+)
+
+type selfServiceModels struct {
+	Allowed         []string `json:"allowed"`
+	Denied          []string `json:"denied"`
+	AllowedPrefixes []string `json:"allowed_prefixes"`
+	Available       []string `json:"available"`
+}
+
+type selfServiceRemaining struct {
+	Requests     float64 `json:"requests"`
+	InputTokens  float64 `json:"input_tokens"`
+	OutputTokens float64 `json:"output_tokens"`
+	Streams      int     `json:"concurrent_streams"`
+}
+
+type selfServiceRateLimit struct {
+	RequestsPerMinute     int                   `json:"requests_per_minute"`
+	InputTokensPerMinute  int                   `json:"input_tokens_per_minute"`
+	OutputTokensPerMinute int                   `json:"output_tokens_per_minute"`
+	MaxConcurrentStreams  int                   `json:"max_concurrent_streams"`
+	Remaining             *selfServiceRemaining `json:"remaining,omitempty"`
+}
+
+type selfServiceResponse struct {
+	Object         string                `json:"object"`
+	Name           string                `json:"name"`
+	AccessProvider string                `json:"access_provider,omitempty"`
+	ExpiresAt      *time.Time            `json:"expires_at,omitempty"`
+	Models         selfServiceModels     `json:"models"`
+	RateLimit      *selfServiceRateLimit `json:"rate_limit"`
+	Budget         *budget.Snapshot      `json:"budget"`
+	Usage          clientusage.Summary   `json:"usage"`
+}
+
+// clientSelfServiceHandler serves GET /v1/me: the calling client key's name, model rules,
+// rate limit and budget state, and recent usage by model. The optional window query
+// parameter ("1h" up to "24h") bounds the usage summary.
+func clientSelfServiceHandler(cfgFn func() *config.Config, limits *ratelimit.Service, budgets *budget.Service, tracker *clientusage.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetString("userApiKey")
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{
+				"message": "This endpoint requires a client API key.",
+				"type":    "invalid_request_error",
+				"param":   nil,
+				"code":    "missing_api_key",
+			}})
+			return
+		}
+		window := clientusage.MaxWindow
+		if raw := strings.TrimSpace(c.Query("window")); raw != "" {
+			parsed, errParse := time.ParseDuration(raw)
+			if errParse != nil || parsed <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{
+					"message": "window must be a positive duration such as 1h or 24h.",
+					"type":    "invalid_request_error",
+					"param":   "window",
+					"code":    "invalid_window",
+				}})
+				return
+			}
+			window = parsed
+		}
+
+		response := selfServiceResponse{
+			Object:         "client_key",
+			AccessProvider: c.GetString("accessProvider"),
+			Usage:          tracker.Summary(key, window),
+		}
+		if metadata, ok := c.Get("accessMetadata"); ok {
+			if values, okValues := metadata.(map[string]string); okValues {
+				response.Name = values["key-name"]
+			}
+		}
+		if cfgFn != nil {
+			if entry, ok := findClientAPIKey(cfgFn(), key); ok {
+				if response.Name == "" {
+					response.Name = entry.DisplayName()
+				}
+				if !entry.ExpiresAt.IsZero() {
+					expiresAt := entry.ExpiresAt
+					response.ExpiresAt = &expiresAt
+				}
+			}
+		}
+		if response.Name == "" {
+			response.Name = config.ClientAPIKey{Key: key}.DisplayName()
+		}
+
+		var rules *sdkaccess.ModelRules
+		if value, ok := c.Get("accessModelRules"); ok {
+			rules, _ = value.(*sdkaccess.ModelRules)
+		}
+		response.Models = selfServiceModelList(rules)
+
+		if status, limited, errStatus := limits.Status(c.Request.Context(), key); limited {
+			rateLimit := &selfServiceRateLimit{
+				RequestsPerMinute:     status.Limits.RequestsPerMinute,
+				InputTokensPerMinute:  status.Limits.InputTokensPerMinute,
+				OutputTokensPerMinute: status.Limits.OutputTokensPerMinute,
+				MaxConcurrentStreams:  status.Limits.MaxConcurrentStreams,
+			}
+			if errStatus != nil {
+				log.Warnf("client rate limit: %v", errStatus)
+			} else {
+				rateLimit.Remaining = &selfServiceRemaining{
+					Requests:     status.Remaining.Requests,
+					InputTokens:  status.Remaining.InputTokens,
+					OutputTokens: status.Remaining.OutputTokens,
+					Streams:      status.Remaining.Streams,
+				}
+			}
+			response.RateLimit = rateLimit
+		}
+		if snapshot, ok := budgets.SnapshotFor(key); ok {
+			response.Budget = &snapshot
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// findClientAPIKey returns the client-api-keys entry stored as key, which is the principal
+// reported by the config access provider for plaintext and hashed keys alike.
+func findClientAPIKey(cfg *config.Config, key string) (config.ClientAPIKey, bool) {
+	if cfg == nil {
+		return config.ClientAPIKey{}, false
+	}
+	for _, entry := range cfg.ClientAPIKeys {
+		if strings.TrimSpace(entry.Key) == key {
+			return entry, true
+		}
+	}
+	return config.ClientAPIKey{}, false
+}
+
+// selfServiceModelList reports the model rules and the registered models they allow.
+func selfServiceModelList(rules *sdkaccess.ModelRules) selfServiceModels {
+	models := selfServiceModels{Allowed: []string{}, Denied: []string{}, AllowedPrefixes: []string{}, Available: []string{}}
+	if rules != nil {
+		models.Allowed = append(models.Allowed, rules.Allowed...)
+		models.Denied = append(models.Denied, rules.Denied...)
+		models.AllowedPrefixes = append(models.AllowedPrefixes, rules.AllowedPrefixes...)
+	}
+	seen := make(map[string]struct{})
+	for _, model := range registry.GetGlobalRegistry().GetAvailableModels("openai") {
+		id, _ := model["id"].(string)
+		if id == "" {
+			continue
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		if rules.Allows(id) {
+			models.Available = append(models.Available, id)
+		}
+	}
+	sort.Strings(models.Available)
+	return models
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clientusage"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestClientSelfServiceHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	expiresAt := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &config.Config{
+		SDKConfig: config.SDKConfig{ClientAPIKeys: []config.ClientAPIKey{{
+			Name:          "team-a",
+			Key:           "client-key",
+			AllowedModels: []string{"gpt-5*"},
+			ExpiresAt:     expiresAt,
+			RateLimit:     &config.ClientRateLimit{RequestsPerMinute: 60},
+			Budget:        &config.ClientBudget{Daily: 1000},
+		}}},
+		ClientBudgets: config.ClientBudgetConfig{StateFile: filepath.Join(t.TempDir(), "budgets.state")},
+	}
+	limits := ratelimit.NewService()
+	limits.Configure(cfg)
+	budgets := budget.NewService()
+	budgets.Configure(cfg)
+	tracker := clientusage.NewTracker()
+	record := coreusage.Record{
+		APIKey:      "client-key",
+		Model:       "gpt-5",
+		RequestedAt: time.Now(),
+		Detail:      coreusage.Detail{InputTokens: 40, OutputTokens: 10, TotalTokens: 50},
+	}
+	budgets.HandleUsage(context.Background(), record)
+	tracker.HandleUsage(context.Background(), record)
+
+	engine := gin.New()
+	principal := "client-key"
+	engine.Use(func(c *gin.Context) {
+		if principal != "" {
+			c.Set("userApiKey", principal)
+			c.Set("accessProvider", "config-api-key")
+			c.Set("accessModelRules", &sdkaccess.ModelRules{Allowed: []string{"gpt-5*"}})
+		}
+		c.Next()
+	})
+	engine.GET("/v1/me", clientSelfServiceHandler(func() *config.Config { return cfg }, limits, budgets, tracker))
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/me?window=2h", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", recorder.Code, recorder.Body.String())
+	}
+	body := recorder.Body.String()
+	checks := map[string]string{
+		"name":                           "team-a",
+		"access_provider":                "config-api-key",
+		"expires_at":                     "2027-01-01T00:00:00Z",
+		"models.allowed.0":               "gpt-5*",
+		"rate_limit.requests_per_minute": "60",
+		"rate_limit.remaining.requests":  "60",
+		"budget.periods.0.period":        "daily",
+		"budget.periods.0.remaining":     "950",
+		"usage.totals.total_tokens":      "50",
+		"usage.models.0.model":           "gpt-5",
+	}
+	for path, want := range checks {
+		if got := gjson.Get(body, path).String(); got != want {
+			t.Errorf("%s = %q, want %q (body %s)", path, got, want, body)
+		}
+	}
+	if body := gjson.Get(body, "models.available"); !body.IsArray() {
+		t.Errorf("models.available = %s, want an array", body.Raw)
+	}
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/me?window=soon", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("invalid window status = %d", recorder.Code)
+	}
+
+	principal = ""
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/me", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated status = %d", recorder.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+)
+
+func TestTracingMiddlewareContinuesIncomingTrace(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+	tracing.Configure(&config.Config{Tracing: config.TracingConfig{Enable: true, Endpoint: collector.URL}})
+	defer func() {
+		_ = tracing.Shutdown(context.Background())
+	}()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(tracingMiddleware())
+	var traceID, managementTraceID string
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		traceID = tracing.SpanFromContext(c.Request.Context()).SpanContext().TraceID().String()
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/v0/management/config", func(c *gin.Context) {
+		managementTraceID = tracing.SpanFromContext(c.Request.Context()).SpanContext().TraceID().String()
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("handler trace id = %q, want the incoming trace id", traceID)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v0/management/config", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if managementTraceID != "00000000000000000000000000000000" {
+		t.Fatalf("management route traced with id %q", managementTraceID)
+	}
+}
@@ -0,0 +1,351 @@
+// Package audit keeps an append-only log of management API mutations.
+//
+// Each mutation is written as one JSON line to a rotating audit.log holding the principal,
+// source IP, route, result, and a redacted before/after diff of the config sections or
+// auth metadata it changed.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// ResultSuccess marks a mutation answered with a 2xx or 3xx status.
+	ResultSuccess = "success"
+	// ResultFailure marks a mutation answered with a 4xx or 5xx status.
+	ResultFailure = "failure"
+
+	fileName = "audit.log"
+
+	// pendingConfigTTL bounds how long a mutation may label the next config commit.
+	pendingConfigTTL = time.Minute
+)
+
+// Change is the redacted before/after value of one config section or auth entry.
+type Change struct {
+	Section string `json:"section"`
+	Before  any    `json:"before,omitempty"`
+	After   any    `json:"after,omitempty"`
+}
+
+// Entry is one audited management request.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Principal string    `json:"principal"`
+	Role      string    `json:"role,omitempty"`
+	SourceIP  string    `json:"source_ip"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Status    int       `json:"status"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+	Changes   []Change  `json:"changes,omitempty"`
+}
+
+// Summary renders the entry as a one-line commit message trailer.
+func (e Entry) Summary() string {
+	return fmt.Sprintf("Audit: %s from %s %s %s", e.Principal, e.SourceIP, e.Method, e.Route)
+}
+
+// Filter selects entries returned by Query. Zero fields match everything.
+type Filter struct {
+	Principal   string
+	Method      string
+	RoutePrefix string
+	Result      string
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+func (f Filter) matches(entry Entry) bool {
+	if f.Principal != "" && !strings.EqualFold(entry.Principal, f.Principal) {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(entry.Method, f.Method) {
+		return false
+	}
+	if f.RoutePrefix != "" && !strings.HasPrefix(entry.Route, f.RoutePrefix) {
+		return false
+	}
+	if f.Result != "" && !strings.EqualFold(entry.Result, f.Result) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Logger writes audit entries to a rotating file.
+type Logger struct {
+	mu       sync.Mutex
+	settings config.AuditLogConfig
+	dir      string
+	writer   *lumberjack.Logger
+	pending  Entry
+	now      func() time.Time
+}
+
+// NewLogger creates a disabled logger.
+func NewLogger() *Logger {
+	return &Logger{now: time.Now}
+}
+
+var defaultLogger = NewLogger()
+
+// Default returns the process-wide logger used by the management API and token stores.
+func Default() *Logger {
+	return defaultLogger
+}
+
+// Configure applies cfg to the process-wide logger.
+func Configure(cfg *config.Config) {
+	defaultLogger.Configure(cfg)
+}
+
+// Configure applies audit-log settings. The file is reopened when its location or
+// rotation settings change.
+func (l *Logger) Configure(cfg *config.Config) {
+	if l == nil {
+		return
+	}
+	var settings config.AuditLogConfig
+	dir := ""
+	if cfg != nil {
+		settings = cfg.AuditLog
+		dir = strings.TrimSpace(settings.Dir)
+		if dir == "" {
+			dir = filepath.Join(logging.ResolveLogDirectory(cfg), "audit")
+		}
+	}
+	if settings.MaxSizeMB <= 0 {
+		settings.MaxSizeMB = config.DefaultAuditLogMaxSizeMB
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer != nil && (!settings.Enable || dir != l.dir || settings.MaxSizeMB != l.settings.MaxSizeMB || settings.MaxBackups != l.settings.MaxBackups) {
+		_ = l.writer.Close()
+		l.writer = nil
+	}
+	l.settings = settings
+	l.dir = dir
+	if !settings.Enable {
+		l.pending = Entry{}
+	}
+}
+
+// Enabled reports whether mutations are recorded.
+func (l *Logger) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.settings.Enable
+}
+
+// Begin marks the start of a mutation. The returned context carries the entry so token
+// stores can label commits, and the next config commit is attributed to it.
+func (l *Logger) Begin(ctx context.Context, entry Entry) context.Context {
+	if l == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.settings.Enable {
+		return ctx
+	}
+	if entry.Time.IsZero() {
+		entry.Time = l.now()
+	}
+	l.pending = entry
+	return context.WithValue(ctx, entryContextKey{}, entry)
+}
+
+// Record appends entry to the audit log. Entries that changed no config section release
+// the pending config commit attribution taken by Begin.
+func (l *Logger) Record(entry Entry) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.settings.Enable {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = l.now()
+	}
+	if !hasConfigChange(entry.Changes) && l.pending.Method == entry.Method && l.pending.Route == entry.Route {
+		l.pending = Entry{}
+	}
+	line, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		log.WithError(errMarshal).Warn("audit: failed to encode entry")
+		return
+	}
+	if l.writer == nil {
+		if errMkdir := os.MkdirAll(l.dir, 0o700); errMkdir != nil {
+			log.WithError(errMkdir).Warn("audit: failed to create audit log directory")
+			return
+		}
+		l.writer = &lumberjack.Logger{
+			Filename:   filepath.Join(l.dir, fileName),
+			MaxSize:    l.settings.MaxSizeMB,
+			MaxBackups: l.settings.MaxBackups,
+		}
+	}
+	if _, errWrite := l.writer.Write(append(line, '\n')); errWrite != nil {
+		log.WithError(errWrite).Warn("audit: failed to write entry")
+	}
+}
+
+// Query returns matching entries, newest first, reading rotated files as well.
+func (l *Logger) Query(filter Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, nil
+	}
+	l.mu.Lock()
+	dir := l.dir
+	l.mu.Unlock()
+	if dir == "" {
+		return []Entry{}, nil
+	}
+	files, errFiles := auditFiles(dir)
+	if errFiles != nil {
+		return nil, errFiles
+	}
+	matched := make([]Entry, 0)
+	for _, path := range files {
+		if errRead := readEntries(path, func(entry Entry) {
+			if filter.matches(entry) {
+				matched = append(matched, entry)
+			}
+		}); errRead != nil {
+			return nil, errRead
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Time.After(matched[j].Time) })
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	return matched, nil
+}
+
+// CommitTrailer returns the audit trailer for commits made on behalf of ctx, or "" when
+// git-commit-messages is off or ctx carries no mutation.
+func (l *Logger) CommitTrailer(ctx context.Context) string {
+	if l == nil || ctx == nil {
+		return ""
+	}
+	entry, ok := ctx.Value(entryContextKey{}).(Entry)
+	if !ok {
+		return ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.settings.Enable || !l.settings.GitCommitMessages {
+		return ""
+	}
+	return "\n\n" + entry.Summary()
+}
+
+// TakeConfigCommitTrailer returns the trailer of the mutation that last began, at most
+// once, for labelling config commits that are not made on a request context.
+func (l *Logger) TakeConfigCommitTrailer() string {
+	if l == nil {
+		return ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := l.pending
+	l.pending = Entry{}
+	if !l.settings.Enable || !l.settings.GitCommitMessages || entry.Method == "" || l.now().Sub(entry.Time) > pendingConfigTTL {
+		return ""
+	}
+	return "\n\n" + entry.Summary()
+}
+
+type entryContextKey struct{}
+
+func hasConfigChange(changes []Change) bool {
+	for _, change := range changes {
+		if !strings.HasPrefix(change.Section, AuthSectionPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditFiles lists rotated backups oldest first followed by the active file.
+func auditFiles(dir string) ([]string, error) {
+	entries, errRead := os.ReadDir(dir)
+	if errRead != nil {
+		if os.IsNotExist(errRead) {
+			return nil, nil
+		}
+		return nil, errRead
+	}
+	backups := make([]string, 0, len(entries))
+	active := ""
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		switch {
+		case name == fileName:
+			active = filepath.Join(dir, name)
+		case strings.HasPrefix(name, "audit-"):
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(backups)
+	if active != "" {
+		backups = append(backups, active)
+	}
+	return backups, nil
+}
+
+func readEntries(path string, fn func(Entry)) error {
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		if os.IsNotExist(errOpen) {
+			return nil
+		}
+		return errOpen
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &entry); errUnmarshal != nil {
+			continue
+		}
+		fn(entry)
+	}
+	return scanner.Err()
+}
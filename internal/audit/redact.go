@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
+)
+
+// AuthSectionPrefix prefixes the section of changes to auth entries, followed by the auth ID.
+const AuthSectionPrefix = "auth:"
+
+var sensitiveNameFragments = []string{"key", "secret", "token", "password", "authorization", "cookie", "credential", "private"}
+
+// Snapshot converts v to its JSON object form with credential-looking values masked.
+// It returns nil when v does not encode to a JSON object.
+func Snapshot(v any) map[string]any {
+	raw, errMarshal := json.Marshal(v)
+	if errMarshal != nil {
+		return nil
+	}
+	var decoded map[string]any
+	if errUnmarshal := json.Unmarshal(raw, &decoded); errUnmarshal != nil {
+		return nil
+	}
+	for name, value := range decoded {
+		decoded[name] = redact(name, value, isSensitiveName(name))
+	}
+	return decoded
+}
+
+// Diff reports the top-level sections that differ between before and after. Section names
+// are prefixed with prefix.
+func Diff(prefix string, before, after map[string]any) []Change {
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var changes []Change
+	for _, name := range names {
+		beforeValue, afterValue := before[name], after[name]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes = append(changes, Change{Section: prefix + name, Before: beforeValue, After: afterValue})
+	}
+	return changes
+}
+
+func redact(name string, value any, sensitive bool) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for childName, childValue := range typed {
+			typed[childName] = redact(childName, childValue, isSensitiveName(childName))
+		}
+		return typed
+	case []any:
+		for i, item := range typed {
+			typed[i] = redact(name, item, sensitive)
+		}
+		return typed
+	case string:
+		switch {
+		case typed == "":
+			return typed
+		case sensitive:
+			return util.HideAPIKey(typed)
+		case strings.Contains(strings.ToLower(name), "proxy"):
+			return proxyutil.Redact(typed)
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+func isSensitiveName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, fragment := range sensitiveNameFragments {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
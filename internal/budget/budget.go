@@ -0,0 +1,119 @@
+// Package budget enforces hard daily, weekly, and monthly budgets per client key.
+//
+// Consumption is charged from usage records, weighted per usage.TokenBreakdown bucket or
+// priced by the pricing catalog for cost budgets, and persisted to a local state file so
+// restarts do not reset budgets. The state is per node: replicas behind a load balancer
+// each enforce the full budget. Requests are rejected once any configured period is
+// exhausted until the period rolls over or an operator tops the budget up.
+package budget
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// Period identifies a budget window.
+type Period string
+
+const (
+	PeriodDaily   Period = "daily"
+	PeriodWeekly  Period = "weekly"
+	PeriodMonthly Period = "monthly"
+)
+
+// Periods lists the budget windows in display order.
+var Periods = []Period{PeriodDaily, PeriodWeekly, PeriodMonthly}
+
+// Valid reports whether p names a known period.
+func (p Period) Valid() bool {
+	switch p {
+	case PeriodDaily, PeriodWeekly, PeriodMonthly:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start returns the UTC start of the window containing now. Weeks start on Monday.
+func (p Period) Start(now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case PeriodWeekly:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonthly:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// End returns the end of the window that starts at start.
+func (p Period) End(start time.Time) time.Time {
+	switch p {
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonthly:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// limitFor returns the configured budget for period p.
+func limitFor(budget config.ClientBudget, p Period) float64 {
+	switch p {
+	case PeriodDaily:
+		return budget.Daily
+	case PeriodWeekly:
+		return budget.Weekly
+	case PeriodMonthly:
+		return budget.Monthly
+	default:
+		return 0
+	}
+}
+
+// KeyID derives the identifier used for a client key in state files and management
+// responses, so key values are never written out.
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Amount converts a usage record into budget units. Token budgets count weighted tokens.
+// Cost budgets charge the pricing catalog estimate carried on the record and fall back to
+// treating weights as prices per million tokens for models the catalog does not price.
+func Amount(budget config.ClientBudget, record coreusage.Record) float64 {
+	if budget.NormalizedUnit() == config.ClientBudgetUnitCost && record.Cost != nil {
+		return record.Cost.Total
+	}
+	detail := coreusage.EnsureTokenBreakdownForProvider(record.Detail, record.Provider, record.ExecutorType)
+	breakdown := detail.TokenBreakdown
+
+	defaultWeight := 1.0
+	if budget.NormalizedUnit() == config.ClientBudgetUnitCost {
+		defaultWeight = 0
+	}
+	weight := func(value *float64) float64 {
+		if value == nil {
+			return defaultWeight
+		}
+		return *value
+	}
+	weights := budget.Weights
+	amount := weight(weights.Input)*float64(breakdown.Input.UncachedTokens+breakdown.UnclassifiedTokens) +
+		weight(weights.CacheRead)*float64(breakdown.Input.CacheReadTokens) +
+		weight(weights.CacheWrite)*float64(breakdown.Input.CacheWriteTokens) +
+		weight(weights.Output)*float64(breakdown.Output.NonReasoningTokens) +
+		weight(weights.Reasoning)*float64(breakdown.Output.ReasoningTokens)
+	if budget.NormalizedUnit() == config.ClientBudgetUnitCost {
+		amount /= 1_000_000
+	}
+	return amount
+}
@@ -0,0 +1,455 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// flushInterval bounds how much consumption is lost when the process dies.
+const flushInterval = 5 * time.Second
+
+// ErrKeyNotFound is returned when a management reference matches no budgeted client key.
+var ErrKeyNotFound = errors.New("client key with a budget not found")
+
+func init() {
+	coreusage.RegisterNamedPlugin("client-budget", defaultService)
+}
+
+var defaultService = NewService()
+
+// Default returns the process-wide service used by the HTTP middleware and management API.
+func Default() *Service {
+	return defaultService
+}
+
+// Configure applies cfg to the process-wide service.
+func Configure(cfg *config.Config) {
+	defaultService.Configure(cfg)
+}
+
+// Decision is the result of a budget check.
+type Decision struct {
+	Allowed bool
+	// Period is the exhausted window when the request is rejected.
+	Period Period
+	// RetryAfter is the time until the exhausted window rolls over.
+	RetryAfter time.Duration
+	Unit       string
+}
+
+// PeriodSnapshot reports consumption for one window.
+type PeriodSnapshot struct {
+	Period    Period    `json:"period"`
+	Limit     float64   `json:"limit"`
+	TopUp     float64   `json:"top_up"`
+	Used      float64   `json:"used"`
+	Remaining float64   `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// Snapshot reports the budgets of one client key.
+type Snapshot struct {
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Unit    string           `json:"unit"`
+	Periods []PeriodSnapshot `json:"periods"`
+}
+
+type keyBudget struct {
+	id     string
+	name   string
+	budget config.ClientBudget
+}
+
+// Service tracks consumption of budgeted client keys.
+type Service struct {
+	mu         sync.Mutex
+	keys       map[string]keyBudget
+	thresholds []float64
+	statePath  string
+	state      map[string]*KeyState
+	dirty      bool
+	now        func() time.Time
+	flushOnce  sync.Once
+}
+
+// NewService creates a service without budgets.
+func NewService() *Service {
+	return &Service{state: make(map[string]*KeyState), now: time.Now}
+}
+
+// Configure replaces the configured budgets. State is reloaded when the state file
+// changes; pending consumption is written to the previous file first.
+func (s *Service) Configure(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	keys := make(map[string]keyBudget)
+	var settings config.ClientBudgetConfig
+	statePath := ""
+	if cfg != nil {
+		settings = cfg.ClientBudgets
+		for _, key := range cfg.APIKeys {
+			key = strings.TrimSpace(key)
+			if key == "" || settings.Default.IsZero() {
+				continue
+			}
+			keys[key] = keyBudget{id: KeyID(key), name: config.ClientAPIKey{Key: key}.DisplayName(), budget: settings.Default}
+		}
+		for _, entry := range cfg.ClientAPIKeys {
+			key := strings.TrimSpace(entry.Key)
+			if key == "" {
+				continue
+			}
+			budget := settings.Default
+			if entry.Budget != nil {
+				budget = *entry.Budget
+			}
+			if budget.IsZero() {
+				delete(keys, key)
+				continue
+			}
+			keys[key] = keyBudget{id: KeyID(key), name: entry.DisplayName(), budget: budget}
+		}
+		statePath = resolveStatePath(cfg)
+	}
+	thresholds := append([]float64(nil), settings.WarnThresholds...)
+	sort.Float64s(thresholds)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.thresholds = thresholds
+	if statePath != s.statePath {
+		if s.dirty {
+			if errSave := saveState(s.statePath, s.state); errSave != nil {
+				log.Warnf("client budget: %v", errSave)
+			}
+		}
+		loaded, errLoad := loadState(statePath)
+		if errLoad != nil {
+			log.Warnf("client budget: %v; starting from empty consumption", errLoad)
+		}
+		if loaded == nil {
+			loaded = make(map[string]*KeyState)
+		}
+		s.state = loaded
+		s.statePath = statePath
+		s.dirty = false
+	}
+	if len(keys) > 0 {
+		s.flushOnce.Do(func() { go s.flushLoop() })
+	}
+}
+
+// resolveStatePath returns client-budgets.state-file or, by default, a file in the auth
+// directory. The file belongs to this node only; it is not shared through the Redis or
+// Postgres stores, so each replica tracks its own consumption.
+func resolveStatePath(cfg *config.Config) string {
+	if path := strings.TrimSpace(cfg.ClientBudgets.StateFile); path != "" {
+		return path
+	}
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil || strings.TrimSpace(authDir) == "" {
+		return ""
+	}
+	return filepath.Join(authDir, DefaultStateFileName)
+}
+
+func (s *Service) flushLoop() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if errFlush := s.Flush(); errFlush != nil {
+			log.Warnf("client budget: %v", errFlush)
+		}
+	}
+}
+
+// Flush writes pending consumption to the state file.
+func (s *Service) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+func (s *Service) flushLocked() error {
+	if !s.dirty {
+		return nil
+	}
+	if errSave := saveState(s.statePath, s.state); errSave != nil {
+		return errSave
+	}
+	s.dirty = false
+	return nil
+}
+
+// periodLocked returns the state for the window containing now, starting a new window or
+// discarding consumption recorded in another unit when needed.
+func (s *Service) periodLocked(entry keyBudget, p Period, now time.Time) *PeriodState {
+	state, ok := s.state[entry.id]
+	unit := entry.budget.NormalizedUnit()
+	if !ok || state.Unit != unit {
+		state = &KeyState{Unit: unit, Periods: make(map[Period]*PeriodState)}
+		s.state[entry.id] = state
+		s.dirty = true
+	}
+	if state.Name != entry.name {
+		state.Name = entry.name
+		s.dirty = true
+	}
+	if state.Periods == nil {
+		state.Periods = make(map[Period]*PeriodState)
+	}
+	start := p.Start(now)
+	current, ok := state.Periods[p]
+	if !ok || !current.Start.Equal(start) {
+		current = &PeriodState{Start: start}
+		state.Periods[p] = current
+		s.dirty = true
+	}
+	return current
+}
+
+// Check reports whether key still has budget left in every configured window.
+func (s *Service) Check(key string) Decision {
+	if s == nil || key == "" {
+		return Decision{Allowed: true}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.keys[key]
+	if !ok {
+		return Decision{Allowed: true}
+	}
+	now := s.now()
+	decision := Decision{Allowed: true, Unit: entry.budget.NormalizedUnit()}
+	for _, p := range Periods {
+		limit := limitFor(entry.budget, p)
+		if limit <= 0 {
+			continue
+		}
+		state := s.periodLocked(entry, p, now)
+		if state.Used < limit+state.TopUp {
+			continue
+		}
+		// Report the window that stays exhausted the longest.
+		if wait := p.End(state.Start).Sub(now); wait > decision.RetryAfter {
+			decision.Allowed = false
+			decision.Period = p
+			decision.RetryAfter = wait
+		}
+	}
+	return decision
+}
+
+// HandleUsage implements coreusage.Plugin by charging the record to its client key.
+func (s *Service) HandleUsage(_ context.Context, record coreusage.Record) {
+	if s == nil {
+		return
+	}
+	key := strings.TrimSpace(record.APIKey)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.keys[key]
+	if !ok {
+		return
+	}
+	amount := Amount(entry.budget, record)
+	if amount <= 0 {
+		return
+	}
+	now := s.now()
+	for _, p := range Periods {
+		limit := limitFor(entry.budget, p)
+		if limit <= 0 {
+			continue
+		}
+		state := s.periodLocked(entry, p, now)
+		state.Used += amount
+		s.warnLocked(entry, p, state, limit)
+	}
+	s.dirty = true
+}
+
+// warnLocked logs the highest newly crossed threshold and the exhaustion of a window.
+func (s *Service) warnLocked(entry keyBudget, p Period, state *PeriodState, limit float64) {
+	total := limit + state.TopUp
+	if total <= 0 {
+		return
+	}
+	fraction := state.Used / total
+	crossed := 0.0
+	for _, threshold := range s.thresholds {
+		if fraction >= threshold {
+			crossed = threshold
+		}
+	}
+	if fraction >= 1 {
+		crossed = 1
+	}
+	if crossed <= state.Warned {
+		return
+	}
+	state.Warned = crossed
+	unit := entry.budget.NormalizedUnit()
+	var message string
+	if crossed >= 1 {
+		message = fmt.Sprintf("%s exhausted its %s budget (%s of %s %s); requests are rejected until %s",
+			entry.name, p, formatAmount(state.Used), formatAmount(total), unit, p.End(state.Start).Format(time.RFC3339))
+	} else {
+		message = fmt.Sprintf("%s used %.0f%% of its %s budget (%s of %s %s)",
+			entry.name, fraction*100, p, formatAmount(state.Used), formatAmount(total), unit)
+	}
+	log.Warnf("client budget: %s", message)
+	events.Default().Publish(events.Event{Type: events.TypeBudgetThreshold, Principal: entry.name, Message: message})
+}
+
+func formatAmount(value float64) string {
+	return fmt.Sprintf("%.4g", value)
+}
+
+// Snapshots reports every budgeted client key, ordered by name.
+func (s *Service) Snapshots() []Snapshot {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	out := make([]Snapshot, 0, len(s.keys))
+	for _, entry := range s.keys {
+		out = append(out, s.snapshotLocked(entry, now))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// SnapshotFor reports the budgets of the client key value key. The second result is false
+// when key has no budget.
+func (s *Service) SnapshotFor(key string) (Snapshot, bool) {
+	if s == nil {
+		return Snapshot{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.keys[strings.TrimSpace(key)]
+	if !ok {
+		return Snapshot{}, false
+	}
+	return s.snapshotLocked(entry, s.now()), true
+}
+
+func (s *Service) snapshotLocked(entry keyBudget, now time.Time) Snapshot {
+	snapshot := Snapshot{ID: entry.id, Name: entry.name, Unit: entry.budget.NormalizedUnit()}
+	for _, p := range Periods {
+		limit := limitFor(entry.budget, p)
+		if limit <= 0 {
+			continue
+		}
+		state := s.periodLocked(entry, p, now)
+		snapshot.Periods = append(snapshot.Periods, PeriodSnapshot{
+			Period:    p,
+			Limit:     limit,
+			TopUp:     state.TopUp,
+			Used:      state.Used,
+			Remaining: max(limit+state.TopUp-state.Used, 0),
+			ResetsAt:  p.End(state.Start),
+		})
+	}
+	return snapshot
+}
+
+// resolveLocked finds a budgeted key by id, name, or key value.
+func (s *Service) resolveLocked(ref string) (keyBudget, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return keyBudget{}, false
+	}
+	if entry, ok := s.keys[ref]; ok {
+		return entry, true
+	}
+	for _, entry := range s.keys {
+		if entry.id == ref || entry.name == ref {
+			return entry, true
+		}
+	}
+	return keyBudget{}, false
+}
+
+// Reset clears consumption and top-ups of the key referenced by ref. An empty period
+// resets every window.
+func (s *Service) Reset(ref string, period Period) error {
+	return s.mutate(ref, period, func(state *PeriodState) {
+		state.Used = 0
+		state.TopUp = 0
+		state.Warned = 0
+	})
+}
+
+// TopUp adds amount to the current window of period for the key referenced by ref. Top-ups
+// expire with the window.
+func (s *Service) TopUp(ref string, period Period, amount float64) error {
+	if !period.Valid() {
+		return fmt.Errorf("invalid budget period %q", period)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("top-up amount must be positive")
+	}
+	return s.mutate(ref, period, func(state *PeriodState) {
+		state.TopUp += amount
+		state.Warned = 0
+	})
+}
+
+func (s *Service) mutate(ref string, period Period, apply func(*PeriodState)) error {
+	if s == nil {
+		return ErrKeyNotFound
+	}
+	if period != "" && !period.Valid() {
+		return fmt.Errorf("invalid budget period %q", period)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.resolveLocked(ref)
+	if !ok {
+		return ErrKeyNotFound
+	}
+	now := s.now()
+	applied := false
+	for _, p := range Periods {
+		if period != "" && p != period {
+			continue
+		}
+		if limitFor(entry.budget, p) <= 0 {
+			continue
+		}
+		apply(s.periodLocked(entry, p, now))
+		applied = true
+	}
+	if !applied {
+		return fmt.Errorf("client key %s has no %s budget", entry.name, period)
+	}
+	s.dirty = true
+	return s.flushLocked()
+}
@@ -0,0 +1,224 @@
+package budget
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func newTestService(t *testing.T, cfg *config.Config, now *time.Time) *Service {
+	t.Helper()
+	if cfg.ClientBudgets.StateFile == "" {
+		cfg.ClientBudgets.StateFile = filepath.Join(t.TempDir(), DefaultStateFileName)
+	}
+	service := NewService()
+	service.now = func() time.Time { return *now }
+	service.Configure(cfg)
+	return service
+}
+
+func usageRecord(key string, input, output int64) coreusage.Record {
+	return coreusage.Record{
+		APIKey: key,
+		Detail: coreusage.Detail{
+			InputTokens:    input,
+			OutputTokens:   output,
+			TotalTokens:    input + output,
+			TokenBreakdown: coreusage.NewIndependentTokenBreakdown(input, 0, 0, output, 0, input+output),
+		},
+	}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestPeriodStart(t *testing.T) {
+	now := time.Date(2026, time.March, 19, 15, 4, 5, 0, time.UTC) // Thursday
+	if got := PeriodDaily.Start(now); !got.Equal(time.Date(2026, time.March, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("daily start = %v", got)
+	}
+	if got := PeriodWeekly.Start(now); !got.Equal(time.Date(2026, time.March, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("weekly start = %v, want Monday", got)
+	}
+	if got := PeriodMonthly.Start(now); !got.Equal(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("monthly start = %v", got)
+	}
+	if got := PeriodMonthly.End(PeriodMonthly.Start(now)); !got.Equal(time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("monthly end = %v", got)
+	}
+}
+
+func TestAmount_WeightsTokenBreakdown(t *testing.T) {
+	record := coreusage.Record{Detail: coreusage.Detail{
+		TokenBreakdown: coreusage.NewIndependentTokenBreakdown(1000, 4000, 2000, 500, 300, 7800),
+	}}
+	tokens := config.ClientBudget{Weights: config.ClientBudgetWeights{CacheRead: floatPtr(0.1), CacheWrite: floatPtr(1.25)}}
+	if got, want := Amount(tokens, record), 1000+400+2500+500+300.0; got != want {
+		t.Fatalf("token Amount() = %v, want %v", got, want)
+	}
+
+	cost := config.ClientBudget{Unit: "cost", Weights: config.ClientBudgetWeights{
+		Input:     floatPtr(3),
+		CacheRead: floatPtr(0.3),
+		Output:    floatPtr(15),
+		Reasoning: floatPtr(15),
+	}}
+	// Cache writes have no price configured, so they are free in a cost budget.
+	want := (1000*3 + 4000*0.3 + 500*15 + 300*15) / 1_000_000.0
+	if got := Amount(cost, record); got < want-1e-12 || got > want+1e-12 {
+		t.Fatalf("cost Amount() = %v, want %v", got, want)
+	}
+
+	// Priced models are charged the catalog estimate rather than the fallback weights.
+	record.Cost = &coreusage.Cost{Total: 0.25}
+	if got := Amount(cost, record); got != 0.25 {
+		t.Fatalf("priced cost Amount() = %v, want catalog total 0.25", got)
+	}
+	if got, want := Amount(tokens, record), 1000+400+2500+500+300.0; got != want {
+		t.Fatalf("priced token Amount() = %v, want %v", got, want)
+	}
+}
+
+func TestService_RejectsExhaustedBudgetUntilRollover(t *testing.T) {
+	now := time.Date(2026, time.March, 19, 23, 0, 0, 0, time.UTC)
+	service := newTestService(t, &config.Config{
+		SDKConfig:     config.SDKConfig{APIKeys: []string{"plain-key"}},
+		ClientBudgets: config.ClientBudgetConfig{Default: config.ClientBudget{Daily: 100}},
+	}, &now)
+
+	if decision := service.Check("plain-key"); !decision.Allowed {
+		t.Fatalf("Check() before usage = %+v, want allowed", decision)
+	}
+	service.HandleUsage(context.Background(), usageRecord("plain-key", 60, 40))
+	decision := service.Check("plain-key")
+	if decision.Allowed || decision.Period != PeriodDaily || decision.RetryAfter != time.Hour {
+		t.Fatalf("Check() after usage = %+v, want daily rejection for 1h", decision)
+	}
+	if other := service.Check("unknown-key"); !other.Allowed {
+		t.Fatalf("Check() for unbudgeted key = %+v, want allowed", other)
+	}
+
+	now = now.Add(time.Hour)
+	if decision = service.Check("plain-key"); !decision.Allowed {
+		t.Fatalf("Check() after rollover = %+v, want allowed", decision)
+	}
+}
+
+func TestService_ResetAndTopUp(t *testing.T) {
+	now := time.Date(2026, time.March, 19, 12, 0, 0, 0, time.UTC)
+	service := newTestService(t, &config.Config{SDKConfig: config.SDKConfig{ClientAPIKeys: []config.ClientAPIKey{{
+		Name:   "team-a",
+		Key:    "team-a-key",
+		Budget: &config.ClientBudget{Daily: 100, Monthly: 1000},
+	}}}}, &now)
+
+	service.HandleUsage(context.Background(), usageRecord("team-a-key", 100, 0))
+	if service.Check("team-a-key").Allowed {
+		t.Fatal("Check() allowed an exhausted key")
+	}
+	if errTopUp := service.TopUp("team-a", PeriodDaily, 50); errTopUp != nil {
+		t.Fatalf("TopUp() error = %v", errTopUp)
+	}
+	if decision := service.Check("team-a-key"); !decision.Allowed {
+		t.Fatalf("Check() after top-up = %+v, want allowed", decision)
+	}
+	if errTopUp := service.TopUp("team-a", PeriodWeekly, 50); errTopUp == nil {
+		t.Fatal("TopUp() of an unconfigured period succeeded")
+	}
+	if errTopUp := service.TopUp("nobody", PeriodDaily, 50); errTopUp != ErrKeyNotFound {
+		t.Fatalf("TopUp() unknown key error = %v, want ErrKeyNotFound", errTopUp)
+	}
+
+	if errReset := service.Reset(KeyID("team-a-key"), ""); errReset != nil {
+		t.Fatalf("Reset() error = %v", errReset)
+	}
+	snapshots := service.Snapshots()
+	if len(snapshots) != 1 || snapshots[0].Name != "team-a" || len(snapshots[0].Periods) != 2 {
+		t.Fatalf("Snapshots() = %+v", snapshots)
+	}
+	for _, period := range snapshots[0].Periods {
+		if period.Used != 0 || period.TopUp != 0 || period.Remaining != period.Limit {
+			t.Fatalf("period after reset = %+v, want cleared", period)
+		}
+	}
+}
+
+func TestService_PersistsConsumption(t *testing.T) {
+	now := time.Date(2026, time.March, 19, 12, 0, 0, 0, time.UTC)
+	statePath := filepath.Join(t.TempDir(), "budgets.state")
+	cfg := &config.Config{
+		SDKConfig: config.SDKConfig{APIKeys: []string{"plain-key"}},
+		ClientBudgets: config.ClientBudgetConfig{
+			StateFile: statePath,
+			Default:   config.ClientBudget{Weekly: 1000},
+		},
+	}
+	first := newTestService(t, cfg, &now)
+	first.HandleUsage(context.Background(), usageRecord("plain-key", 300, 200))
+	if errFlush := first.Flush(); errFlush != nil {
+		t.Fatalf("Flush() error = %v", errFlush)
+	}
+
+	restarted := newTestService(t, cfg, &now)
+	snapshots := restarted.Snapshots()
+	if len(snapshots) != 1 || len(snapshots[0].Periods) != 1 || snapshots[0].Periods[0].Used != 500 {
+		t.Fatalf("Snapshots() after restart = %+v, want 500 used", snapshots)
+	}
+
+	// Switching the unit discards consumption recorded in tokens.
+	cfg.ClientBudgets.Default = config.ClientBudget{Unit: "cost", Weekly: 10}
+	restarted.Configure(cfg)
+	if used := restarted.Snapshots()[0].Periods[0].Used; used != 0 {
+		t.Fatalf("used after unit change = %v, want 0", used)
+	}
+}
+
+func TestService_WarnsOncePerThreshold(t *testing.T) {
+	now := time.Date(2026, time.March, 19, 12, 0, 0, 0, time.UTC)
+	service := newTestService(t, &config.Config{
+		SDKConfig: config.SDKConfig{APIKeys: []string{"plain-key"}},
+		ClientBudgets: config.ClientBudgetConfig{
+			WarnThresholds: []float64{0.8, 0.5},
+			Default:        config.ClientBudget{Daily: 100},
+		},
+	}, &now)
+
+	service.HandleUsage(context.Background(), usageRecord("plain-key", 85, 0))
+	state := service.state[KeyID("plain-key")].Periods[PeriodDaily]
+	if state.Warned != 0.8 {
+		t.Fatalf("Warned = %v, want the highest crossed threshold 0.8", state.Warned)
+	}
+	service.HandleUsage(context.Background(), usageRecord("plain-key", 20, 0))
+	if state.Warned != 1 {
+		t.Fatalf("Warned after exhaustion = %v, want 1", state.Warned)
+	}
+}
+
+func TestServiceSnapshotFor(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	service := newTestService(t, &config.Config{
+		SDKConfig: config.SDKConfig{
+			APIKeys: []string{"plain"},
+			ClientAPIKeys: []config.ClientAPIKey{
+				{Name: "team", Key: "budgeted", Budget: &config.ClientBudget{Daily: 100}},
+			},
+		},
+	}, &now)
+	service.HandleUsage(context.Background(), usageRecord("budgeted", 30, 10))
+
+	snapshot, ok := service.SnapshotFor("budgeted")
+	if !ok || snapshot.Name != "team" || len(snapshot.Periods) != 1 {
+		t.Fatalf("SnapshotFor(budgeted) = %+v, %v", snapshot, ok)
+	}
+	if period := snapshot.Periods[0]; period.Used != 40 || period.Remaining != 60 {
+		t.Fatalf("daily period = %+v, want 40 used and 60 remaining", period)
+	}
+	if _, ok = service.SnapshotFor("plain"); ok {
+		t.Fatal("SnapshotFor(plain) found a budget for a key without one")
+	}
+}
@@ -0,0 +1,95 @@
+package budget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultStateFileName is the state file created in the auth directory when
+// client-budgets.state-file is not set.
+const DefaultStateFileName = "client-budgets.state"
+
+const stateFileVersion = 1
+
+// PeriodState is the consumption recorded for one key in one period window.
+type PeriodState struct {
+	Start time.Time `json:"start"`
+	Used  float64   `json:"used"`
+	TopUp float64   `json:"top_up,omitempty"`
+	// Warned is the highest warn threshold already reported in this window.
+	Warned float64 `json:"warned,omitempty"`
+}
+
+// KeyState is the consumption recorded for one client key.
+type KeyState struct {
+	Name    string                  `json:"name,omitempty"`
+	Unit    string                  `json:"unit"`
+	Periods map[Period]*PeriodState `json:"periods"`
+}
+
+type stateFile struct {
+	Version   int                  `json:"version"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Keys      map[string]*KeyState `json:"keys"`
+}
+
+// loadState reads the state file. A missing file is treated as empty state.
+func loadState(path string) (map[string]*KeyState, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read client budget state %s: %w", path, errRead)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var envelope stateFile
+	if errUnmarshal := json.Unmarshal(data, &envelope); errUnmarshal != nil {
+		return nil, fmt.Errorf("parse client budget state %s: %w", path, errUnmarshal)
+	}
+	return envelope.Keys, nil
+}
+
+// saveState atomically replaces the state file.
+func saveState(path string, keys map[string]*KeyState) error {
+	if path == "" {
+		return nil
+	}
+	envelope := stateFile{Version: stateFileVersion, UpdatedAt: time.Now().UTC(), Keys: keys}
+	data, errMarshal := json.MarshalIndent(envelope, "", "  ")
+	if errMarshal != nil {
+		return fmt.Errorf("marshal client budget state: %w", errMarshal)
+	}
+	data = append(data, '\n')
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return fmt.Errorf("create client budget state directory: %w", errMkdir)
+	}
+	tmp, errCreate := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if errCreate != nil {
+		return fmt.Errorf("create client budget state: %w", errCreate)
+	}
+	tmpName := tmp.Name()
+	if _, errWrite := tmp.Write(data); errWrite != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("write client budget state: %w", errWrite)
+	}
+	if errClose := tmp.Close(); errClose != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("close client budget state: %w", errClose)
+	}
+	if errRename := os.Rename(tmpName, path); errRename != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("replace client budget state: %w", errRename)
+	}
+	return nil
+}
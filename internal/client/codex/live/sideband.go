@@ -8,20 +8,26 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/clienterror"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 	xproxy "golang.org/x/net/proxy"
 )
 
@@ -496,7 +502,7 @@ func (h *Handler) HandleSideband(c *gin.Context) {
 	}
 	consumeSession = true
 
-	if errRelay := relayWebsockets(downstream, upstream); errRelay != nil && !isNormalWebsocketClose(errRelay) {
+	if errRelay := relayWebsockets(downstream, upstream, sessionTurnAdmitter(c)); errRelay != nil && !isNormalWebsocketClose(errRelay) {
 		helps.RecordAPIWebsocketError(ctx, runtimeConfig, "relay", errRelay)
 		log.WithError(errRelay).Debug("codex live sideband relay closed")
 	}
@@ -599,10 +605,20 @@ func websocketCloseFunc(name string, conn *websocket.Conn) func() error {
 	}
 }
 
-func relayWebsockets(downstream, upstream *websocket.Conn) error {
+// relayWebsockets copies messages both ways until either side closes. When admitTurn is
+// set, client events that start a response are checked first; a rejected turn is answered
+// with a Realtime error event instead of reaching the upstream.
+func relayWebsockets(downstream, upstream *websocket.Conn, admitTurn func() *interfaces.ErrorMessage) error {
+	// Rejected turns are answered on the downstream connection while upstream events are
+	// copied to it, so downstream writes are serialized.
+	var downstreamMu sync.Mutex
 	results := make(chan error, 2)
-	go func() { results <- copyWebsocket(upstream, downstream) }()
-	go func() { results <- copyWebsocket(downstream, upstream) }()
+	go func() { results <- copyWebsocket(downstream, upstream, &downstreamMu) }()
+	if admitTurn == nil {
+		go func() { results <- copyWebsocket(upstream, downstream, nil) }()
+	} else {
+		go func() { results <- copyClientEvents(upstream, downstream, &downstreamMu, admitTurn) }()
+	}
 
 	firstErr := <-results
 	closeCode, closeReason := websocketCloseDetails(firstErr)
@@ -615,27 +631,87 @@ func relayWebsockets(downstream, upstream *websocket.Conn) error {
 	return firstErr
 }
 
-func copyWebsocket(destination, source *websocket.Conn) error {
+func copyWebsocket(destination, source *websocket.Conn, destinationMu *sync.Mutex) error {
 	for {
 		messageType, reader, errReader := source.NextReader()
 		if errReader != nil {
 			return errReader
 		}
-		writer, errWriter := destination.NextWriter(messageType)
-		if errWriter != nil {
-			return errWriter
+		if destinationMu != nil {
+			destinationMu.Lock()
+		}
+		errCopy := copyWebsocketMessage(destination, messageType, reader)
+		if destinationMu != nil {
+			destinationMu.Unlock()
 		}
-		_, errCopy := io.Copy(writer, reader)
-		errClose := writer.Close()
 		if errCopy != nil {
 			return errCopy
 		}
-		if errClose != nil {
-			return errClose
+	}
+}
+
+func copyWebsocketMessage(destination *websocket.Conn, messageType int, reader io.Reader) error {
+	writer, errWriter := destination.NextWriter(messageType)
+	if errWriter != nil {
+		return errWriter
+	}
+	_, errCopy := io.Copy(writer, reader)
+	errClose := writer.Close()
+	if errCopy != nil {
+		return errCopy
+	}
+	return errClose
+}
+
+// copyClientEvents forwards client events to the upstream, checking response.create
+// events with admitTurn before they are sent.
+func copyClientEvents(upstream, client *websocket.Conn, clientMu *sync.Mutex, admitTurn func() *interfaces.ErrorMessage) error {
+	for {
+		messageType, payload, errRead := client.ReadMessage()
+		if errRead != nil {
+			return errRead
+		}
+		if messageType == websocket.TextMessage && gjson.GetBytes(payload, "type").String() == "response.create" {
+			if errMsg := admitTurn(); errMsg != nil {
+				clientMu.Lock()
+				errWrite := client.WriteMessage(websocket.TextMessage, realtimeTurnErrorEvent(errMsg, gjson.GetBytes(payload, "event_id").String()))
+				clientMu.Unlock()
+				if errWrite != nil {
+					return errWrite
+				}
+				continue
+			}
+		}
+		if errWrite := upstream.WriteMessage(messageType, payload); errWrite != nil {
+			return errWrite
 		}
 	}
 }
 
+// realtimeTurnErrorEvent renders a rejected turn as a Realtime "error" server event.
+func realtimeTurnErrorEvent(errMsg *interfaces.ErrorMessage, clientEventID string) []byte {
+	message := http.StatusText(errMsg.StatusCode)
+	if errMsg.Error != nil {
+		message = errMsg.Error.Error()
+	}
+	detail := gjson.Get(message, "error")
+	if !detail.IsObject() {
+		detail = gjson.Parse(`{"type":"rate_limit_error","code":"rate_limit_exceeded","message":` + strconv.Quote(message) + `}`)
+	}
+	event := []byte(`{"type":"error"}`)
+	event, _ = sjson.SetBytes(event, "event_id", "event_"+strings.ReplaceAll(uuid.NewString(), "-", ""))
+	event, _ = sjson.SetRawBytes(event, "error", []byte(detail.Raw))
+	if clientEventID != "" {
+		event, _ = sjson.SetBytes(event, "error.event_id", clientEventID)
+	}
+	return event
+}
+
+// sessionTurnAdmitter checks each Realtime turn against the client limits registered on c.
+func sessionTurnAdmitter(c *gin.Context) func() *interfaces.ErrorMessage {
+	return func() *interfaces.ErrorMessage { return handlers.AdmitSessionTurn(c) }
+}
+
 func websocketCloseDetails(err error) (int, string) {
 	var closeErr *websocket.CloseError
 	if errors.As(err, &closeErr) {
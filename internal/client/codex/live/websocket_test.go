@@ -2,6 +2,7 @@ package live
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,7 +12,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
 )
 
 func TestHandleDirectWebsocketRejectsClientSecretModelMismatch(t *testing.T) {
@@ -201,3 +204,66 @@ func TestHandleDirectWebsocketRelaysStandardRealtimeFrames(t *testing.T) {
 		t.Fatal("upstream event not captured")
 	}
 }
+
+func TestRelayWebsocketsRejectsTurnsRefusedByAdmitter(t *testing.T) {
+	received := make(chan string, 4)
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+		connection, errUpgrade := upgrader.Upgrade(writer, request, nil)
+		if errUpgrade != nil {
+			return
+		}
+		defer func() { _ = connection.Close() }()
+		for {
+			_, payload, errRead := connection.ReadMessage()
+			if errRead != nil {
+				return
+			}
+			received <- gjson.GetBytes(payload, "type").String()
+		}
+	}))
+	defer upstreamServer.Close()
+
+	admitter := func() *interfaces.ErrorMessage {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New(`{"error":{"type":"insufficient_quota","code":"insufficient_quota","message":"budget exhausted"}}`)}
+	}
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		upstream, _, errDial := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(upstreamServer.URL, "http"), nil)
+		if errDial != nil {
+			return
+		}
+		upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+		downstream, errUpgrade := upgrader.Upgrade(writer, request, nil)
+		if errUpgrade != nil {
+			_ = upstream.Close()
+			return
+		}
+		_ = relayWebsockets(downstream, upstream, admitter)
+	}))
+	defer proxyServer.Close()
+
+	client, _, errDial := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(proxyServer.URL, "http"), nil)
+	if errDial != nil {
+		t.Fatalf("dial proxy: %v", errDial)
+	}
+	defer func() { _ = client.Close() }()
+	_ = client.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.create","event_id":"evt_1"}`))
+	_ = client.WriteMessage(websocket.TextMessage, []byte(`{"type":"session.update"}`))
+
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, event, errRead := client.ReadMessage()
+	if errRead != nil {
+		t.Fatalf("read rejection: %v", errRead)
+	}
+	if gjson.GetBytes(event, "type").String() != "error" || gjson.GetBytes(event, "error.code").String() != "insufficient_quota" || gjson.GetBytes(event, "error.event_id").String() != "evt_1" {
+		t.Fatalf("rejection event = %s", event)
+	}
+	select {
+	case eventType := <-received:
+		if eventType != "session.update" {
+			t.Fatalf("upstream received %q, want only session.update", eventType)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream did not receive the non-turn event")
+	}
+}
@@ -0,0 +1,208 @@
+// Package clientnet restricts client API keys to source networks. It resolves the client
+// address behind trusted reverse proxies from X-Forwarded-For and X-Real-IP, and checks it
+// against the allowed-cidrs of the key or the global client-network list.
+package clientnet
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+var defaultService = NewService()
+
+// Default returns the process-wide service used by the HTTP middleware.
+func Default() *Service {
+	return defaultService
+}
+
+// Configure applies cfg to the process-wide service.
+func Configure(cfg *config.Config) {
+	defaultService.Configure(cfg)
+}
+
+// keyPolicy is the network restriction of one client-api-keys entry.
+type keyPolicy struct {
+	name     string
+	prefixes []netip.Prefix
+}
+
+// Service holds the allowed networks and trusted proxies of the current config.
+type Service struct {
+	mu      sync.RWMutex
+	global  []netip.Prefix
+	trusted []netip.Prefix
+	perKey  map[string]keyPolicy
+}
+
+// NewService creates a service that allows every source and trusts no proxy.
+func NewService() *Service {
+	return &Service{}
+}
+
+// Configure replaces the allowed networks and trusted proxies. Entries that fail to parse
+// are skipped; config loading already rejects them.
+func (s *Service) Configure(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	var global, trusted []netip.Prefix
+	var perKey map[string]keyPolicy
+	if cfg != nil {
+		global = parsePrefixes("client-network.allowed-cidrs", cfg.ClientNetwork.AllowedCIDRs)
+		trusted = parsePrefixes("client-network.trusted-proxies", cfg.ClientNetwork.TrustedProxies)
+		for i := range cfg.ClientAPIKeys {
+			entry := &cfg.ClientAPIKeys[i]
+			key := strings.TrimSpace(entry.Key)
+			if key == "" || len(entry.AllowedCIDRs) == 0 {
+				continue
+			}
+			prefixes := parsePrefixes("client-api-keys "+entry.DisplayName()+" allowed-cidrs", entry.AllowedCIDRs)
+			if len(prefixes) == 0 {
+				continue
+			}
+			if perKey == nil {
+				perKey = make(map[string]keyPolicy)
+			}
+			perKey[key] = keyPolicy{name: entry.DisplayName(), prefixes: prefixes}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.global = global
+	s.trusted = trusted
+	s.perKey = perKey
+}
+
+func parsePrefixes(field string, values []string) []netip.Prefix {
+	prefixes, errParse := config.ParseNetworkPrefixes(values)
+	if errParse != nil {
+		log.Warnf("client network: %s: %v", field, errParse)
+		return nil
+	}
+	return prefixes
+}
+
+// Restricted reports whether any source network restriction is configured.
+func (s *Service) Restricted() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.global) > 0 || len(s.perKey) > 0
+}
+
+// Allowed reports whether key may be used from addr. Keys with their own allowed-cidrs are
+// checked against those; other keys, and requests without a key, against the global list.
+// The returned name identifies the key in logs without revealing it; it is empty for keys
+// that are not listed in client-api-keys.
+func (s *Service) Allowed(key string, addr netip.Addr) (bool, string) {
+	if s == nil {
+		return true, ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	prefixes := s.global
+	var name string
+	if policy, ok := s.perKey[key]; ok && key != "" {
+		prefixes = policy.prefixes
+		name = policy.name
+	}
+	if len(prefixes) == 0 {
+		return true, name
+	}
+	if !addr.IsValid() {
+		return false, name
+	}
+	return containsAddr(prefixes, addr.Unmap()), name
+}
+
+// ClientIP returns the address of the client that sent req. When the connecting peer is a
+// trusted proxy, X-Forwarded-For is walked from the right past trusted hops, and X-Real-IP
+// is used when X-Forwarded-For is absent. Headers from untrusted peers are ignored.
+func (s *Service) ClientIP(req *http.Request) netip.Addr {
+	if req == nil {
+		return netip.Addr{}
+	}
+	remote := parseHostAddr(req.RemoteAddr)
+	if s == nil || !remote.IsValid() {
+		return remote
+	}
+	s.mu.RLock()
+	trusted := s.trusted
+	s.mu.RUnlock()
+	if !containsAddr(trusted, remote) {
+		return remote
+	}
+
+	if hops := forwardedHops(req.Header.Values("X-Forwarded-For")); len(hops) > 0 {
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := parseHostAddr(hops[i])
+			if !hop.IsValid() {
+				break
+			}
+			client = hop
+			if !containsAddr(trusted, hop) {
+				break
+			}
+		}
+		return client
+	}
+	if realIP := parseHostAddr(req.Header.Get("X-Real-IP")); realIP.IsValid() {
+		return realIP
+	}
+	return remote
+}
+
+// ClientAddress returns ClientIP as a string for logs, or "" when req has no usable address.
+func (s *Service) ClientAddress(req *http.Request) string {
+	if addr := s.ClientIP(req); addr.IsValid() {
+		return addr.Unmap().String()
+	}
+	return ""
+}
+
+func forwardedHops(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			if trimmed := strings.TrimSpace(hop); trimmed != "" {
+				hops = append(hops, trimmed)
+			}
+		}
+	}
+	return hops
+}
+
+// parseHostAddr parses an address with or without a port.
+func parseHostAddr(value string) netip.Addr {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return netip.Addr{}
+	}
+	if host, _, errSplit := net.SplitHostPort(trimmed); errSplit == nil {
+		trimmed = host
+	}
+	addr, errParse := netip.ParseAddr(strings.Trim(trimmed, "[]"))
+	if errParse != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap().WithZone("")
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package clientnet
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newTestService(network config.ClientNetworkConfig, keys ...config.ClientAPIKey) *Service {
+	service := NewService()
+	service.Configure(&config.Config{SDKConfig: config.SDKConfig{ClientNetwork: network, ClientAPIKeys: keys}})
+	return service
+}
+
+func TestClientIP(t *testing.T) {
+	service := newTestService(config.ClientNetworkConfig{TrustedProxies: []string{"10.0.0.0/24", "::1"}})
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{name: "untrusted peer ignores headers", remoteAddr: "203.0.113.9:5000", forwarded: []string{"10.9.9.9"}, realIP: "10.9.9.8", want: "203.0.113.9"},
+		{name: "trusted peer without headers", remoteAddr: "10.0.0.2:5000", want: "10.0.0.2"},
+		{name: "rightmost untrusted hop", remoteAddr: "10.0.0.2:5000", forwarded: []string{"198.51.100.1, 192.0.2.7", "10.0.0.3"}, want: "192.0.2.7"},
+		{name: "all hops trusted", remoteAddr: "10.0.0.2:5000", forwarded: []string{"10.0.0.4, 10.0.0.3"}, want: "10.0.0.4"},
+		{name: "malformed hop stops the walk", remoteAddr: "10.0.0.2:5000", forwarded: []string{"192.0.2.1, garbage, 10.0.0.3"}, want: "10.0.0.3"},
+		{name: "hop with port", remoteAddr: "10.0.0.2:5000", forwarded: []string{"[2001:db8::5]:443"}, want: "2001:db8::5"},
+		{name: "real ip from trusted peer", remoteAddr: "[::1]:5000", realIP: "192.0.2.44", want: "192.0.2.44"},
+		{name: "forwarded wins over real ip", remoteAddr: "[::1]:5000", forwarded: []string{"192.0.2.45"}, realIP: "192.0.2.44", want: "192.0.2.45"},
+		{name: "ipv4-mapped peer", remoteAddr: "[::ffff:10.0.0.2]:5000", forwarded: []string{"192.0.2.46"}, want: "192.0.2.46"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/models", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := service.ClientIP(req); got != netip.MustParseAddr(tt.want) {
+				t.Fatalf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedPerKeyReplacesGlobal(t *testing.T) {
+	service := newTestService(
+		config.ClientNetworkConfig{AllowedCIDRs: []string{"10.0.0.0/8"}},
+		config.ClientAPIKey{Name: "ci", Key: "ci-key", AllowedCIDRs: []string{"192.0.2.0/24"}},
+		config.ClientAPIKey{Name: "office", Key: "office-key"},
+	)
+	office := netip.MustParseAddr("10.1.2.3")
+	ci := netip.MustParseAddr("192.0.2.10")
+
+	if allowed, name := service.Allowed("ci-key", ci); !allowed || name != "ci" {
+		t.Fatalf("Allowed(ci-key, ci) = %t, %q", allowed, name)
+	}
+	if allowed, _ := service.Allowed("ci-key", office); allowed {
+		t.Fatal("ci-key allowed outside its own allowed-cidrs")
+	}
+	if allowed, _ := service.Allowed("office-key", office); !allowed {
+		t.Fatal("office-key rejected inside the global allowed-cidrs")
+	}
+	if allowed, _ := service.Allowed("office-key", ci); allowed {
+		t.Fatal("office-key allowed outside the global allowed-cidrs")
+	}
+	if allowed, _ := service.Allowed("", netip.Addr{}); allowed {
+		t.Fatal("request without a resolvable address allowed under a restriction")
+	}
+
+	open := newTestService(config.ClientNetworkConfig{})
+	if open.Restricted() {
+		t.Fatal("Restricted() = true without allowed-cidrs")
+	}
+	if allowed, _ := open.Allowed("any", ci); !allowed {
+		t.Fatal("unrestricted service rejected a request")
+	}
+}
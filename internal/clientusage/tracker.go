@@ -0,0 +1,192 @@
+// Package clientusage aggregates recent token usage per client key and model from usage
+// records. It backs the client self-service endpoint, so it keeps only a short window in
+// hourly buckets and forgets everything on restart.
+package clientusage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+const (
+	bucketWidth = time.Hour
+	// MaxWindow is the longest window Summary can report.
+	MaxWindow = 24 * time.Hour
+)
+
+func init() {
+	coreusage.RegisterNamedPlugin("client-usage", defaultTracker)
+}
+
+var defaultTracker = NewTracker()
+
+// Default returns the process-wide tracker fed by the usage pipeline.
+func Default() *Tracker {
+	return defaultTracker
+}
+
+// Counters are the usage totals of one model or window.
+type Counters struct {
+	Requests        int64 `json:"requests"`
+	Failed          int64 `json:"failed"`
+	InputTokens     int64 `json:"input_tokens"`
+	OutputTokens    int64 `json:"output_tokens"`
+	ReasoningTokens int64 `json:"reasoning_tokens"`
+	CachedTokens    int64 `json:"cached_tokens"`
+	TotalTokens     int64 `json:"total_tokens"`
+	// EstimatedCost sums the pricing catalog estimates of priced requests.
+	EstimatedCost float64 `json:"estimated_cost"`
+}
+
+func (c *Counters) add(other Counters) {
+	c.Requests += other.Requests
+	c.Failed += other.Failed
+	c.InputTokens += other.InputTokens
+	c.OutputTokens += other.OutputTokens
+	c.ReasoningTokens += other.ReasoningTokens
+	c.CachedTokens += other.CachedTokens
+	c.TotalTokens += other.TotalTokens
+	c.EstimatedCost += other.EstimatedCost
+}
+
+// ModelUsage is the usage of one model within the window.
+type ModelUsage struct {
+	Model string `json:"model"`
+	Counters
+}
+
+// Summary is the usage of a client key within a window.
+type Summary struct {
+	Since  time.Time    `json:"since"`
+	Totals Counters     `json:"totals"`
+	Models []ModelUsage `json:"models"`
+}
+
+// Tracker keeps hourly per-model counters for each client key.
+type Tracker struct {
+	mu   sync.Mutex
+	keys map[string]map[int64]map[string]*Counters
+	now  func() time.Time
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{keys: make(map[string]map[int64]map[string]*Counters), now: time.Now}
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (t *Tracker) HandleUsage(_ context.Context, record coreusage.Record) {
+	if t == nil {
+		return
+	}
+	key := strings.TrimSpace(record.APIKey)
+	if key == "" {
+		return
+	}
+	model := strings.TrimSpace(record.Alias)
+	if model == "" {
+		model = strings.TrimSpace(record.Model)
+	}
+	if model == "" {
+		model = "unknown"
+	}
+	detail := record.Detail
+	delta := Counters{
+		Requests:        1,
+		InputTokens:     detail.InputTokens,
+		OutputTokens:    detail.OutputTokens,
+		ReasoningTokens: detail.ReasoningTokens,
+		CachedTokens:    detail.CachedTokens,
+		TotalTokens:     detail.TotalTokens,
+	}
+	if delta.TotalTokens == 0 {
+		delta.TotalTokens = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
+	}
+	if record.Failed {
+		delta.Failed = 1
+	}
+	if record.Cost != nil {
+		delta.EstimatedCost = record.Cost.Total
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = t.now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hour := at.Truncate(bucketWidth).Unix()
+	buckets, ok := t.keys[key]
+	if !ok {
+		buckets = make(map[int64]map[string]*Counters)
+		t.keys[key] = buckets
+	}
+	models, ok := buckets[hour]
+	if !ok {
+		models = make(map[string]*Counters)
+		buckets[hour] = models
+		t.pruneLocked()
+	}
+	counters, ok := models[model]
+	if !ok {
+		counters = &Counters{}
+		models[model] = counters
+	}
+	counters.add(delta)
+}
+
+// Summary reports the usage of key over the last window, rounded up to whole hours and
+// capped at MaxWindow.
+func (t *Tracker) Summary(key string, window time.Duration) Summary {
+	if t == nil {
+		return Summary{Models: []ModelUsage{}}
+	}
+	if window <= 0 || window > MaxWindow {
+		window = MaxWindow
+	}
+	since := t.now().Add(-window).Truncate(bucketWidth)
+	summary := Summary{Since: since, Models: []ModelUsage{}}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	byModel := make(map[string]*Counters)
+	for hour, models := range t.keys[strings.TrimSpace(key)] {
+		if hour < since.Unix() {
+			continue
+		}
+		for model, counters := range models {
+			total, ok := byModel[model]
+			if !ok {
+				total = &Counters{}
+				byModel[model] = total
+			}
+			total.add(*counters)
+		}
+	}
+	for model, counters := range byModel {
+		summary.Totals.add(*counters)
+		summary.Models = append(summary.Models, ModelUsage{Model: model, Counters: *counters})
+	}
+	sort.Slice(summary.Models, func(i, j int) bool { return summary.Models[i].Model < summary.Models[j].Model })
+	return summary
+}
+
+// pruneLocked drops buckets older than MaxWindow and keys left without buckets.
+func (t *Tracker) pruneLocked() {
+	cutoff := t.now().Add(-MaxWindow - bucketWidth).Unix()
+	for key, buckets := range t.keys {
+		for hour := range buckets {
+			if hour < cutoff {
+				delete(buckets, hour)
+			}
+		}
+		if len(buckets) == 0 {
+			delete(t.keys, key)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package clientusage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestTrackerSummaryByModelAndWindow(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 30, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	record := func(key, alias, model string, at time.Time, input, output int64, failed bool) {
+		tracker.HandleUsage(ctx, coreusage.Record{
+			APIKey:      key,
+			Alias:       alias,
+			Model:       model,
+			RequestedAt: at,
+			Failed:      failed,
+			Detail:      coreusage.Detail{InputTokens: input, OutputTokens: output},
+		})
+	}
+	record("key-a", "fast", "gpt-5-mini", now.Add(-10*time.Minute), 100, 20, false)
+	record("key-a", "", "gpt-5", now.Add(-3*time.Hour), 50, 5, false)
+	record("key-a", "fast", "gpt-5-mini", now.Add(-3*time.Hour), 10, 0, true)
+	record("key-a", "", "gpt-5", now.Add(-30*time.Hour), 1000, 1000, false)
+	record("key-b", "", "gpt-5", now, 7, 7, false)
+
+	summary := tracker.Summary("key-a", 0)
+	if summary.Totals.Requests != 3 || summary.Totals.Failed != 1 || summary.Totals.TotalTokens != 185 {
+		t.Fatalf("24h totals = %+v, want 3 requests, 1 failed, 185 tokens", summary.Totals)
+	}
+	if len(summary.Models) != 2 || summary.Models[0].Model != "fast" || summary.Models[0].InputTokens != 110 {
+		t.Fatalf("24h models = %+v, want fast and gpt-5 ordered by name", summary.Models)
+	}
+
+	summary = tracker.Summary("key-a", time.Hour)
+	if !summary.Since.Equal(time.Date(2026, time.June, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("1h since = %v, want the start of the previous hour", summary.Since)
+	}
+	if summary.Totals.Requests != 1 || summary.Totals.OutputTokens != 20 {
+		t.Fatalf("1h totals = %+v, want only the recent request", summary.Totals)
+	}
+
+	if summary = tracker.Summary("unknown", time.Hour); summary.Totals.Requests != 0 || summary.Models == nil {
+		t.Fatalf("unknown key summary = %+v, want empty models", summary)
+	}
+}
+
+func TestTrackerPrunesOldBuckets(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+	tracker.HandleUsage(context.Background(), coreusage.Record{APIKey: "old", Model: "gpt-5", RequestedAt: now})
+
+	now = now.Add(48 * time.Hour)
+	tracker.HandleUsage(context.Background(), coreusage.Record{APIKey: "new", Model: "gpt-5", RequestedAt: now})
+	if _, ok := tracker.keys["old"]; ok {
+		t.Fatal("idle key was not pruned")
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestReplayOptions mirrors the options of the request-log-replay management endpoint.
+type RequestReplayOptions struct {
+	AuthIndex     string
+	Model         string
+	TranslateOnly bool
+}
+
+// DoRequestReplay asks the running server to replay a stored request log and prints the
+// diffs. target is either a request ID or the path to a request log file. The management
+// key comes from managementKey or the MANAGEMENT_PASSWORD environment variable.
+func DoRequestReplay(cfg *config.Config, managementKey string, target string, options RequestReplayOptions) {
+	target = strings.TrimSpace(target)
+	if cfg == nil || target == "" {
+		log.Error("replay: missing request ID or log file")
+		return
+	}
+	if strings.TrimSpace(managementKey) == "" {
+		managementKey = strings.TrimSpace(os.Getenv("MANAGEMENT_PASSWORD"))
+	}
+
+	payload := map[string]any{
+		"auth_index":     options.AuthIndex,
+		"model":          options.Model,
+		"translate_only": options.TranslateOnly,
+	}
+	if info, errStat := os.Stat(target); errStat == nil && !info.IsDir() {
+		data, errRead := os.ReadFile(target)
+		if errRead != nil {
+			log.Errorf("replay: read %s: %v", target, errRead)
+			return
+		}
+		payload["log"] = string(data)
+	} else {
+		payload["request_id"] = target
+	}
+	raw, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		log.Errorf("replay: encode request: %v", errMarshal)
+		return
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: 10 * time.Minute}
+	if cfg.TLS.Enable {
+		scheme = "https"
+		// The server is reached over loopback, where its certificate name rarely matches.
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	url := fmt.Sprintf("%s://127.0.0.1:%d/v0/management/request-log-replay", scheme, cfg.Port)
+	req, errRequest := http.NewRequest(http.MethodPost, url, bytes.NewReader(raw))
+	if errRequest != nil {
+		log.Errorf("replay: build request: %v", errRequest)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if managementKey != "" {
+		req.Header.Set("Authorization", "Bearer "+managementKey)
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		log.Errorf("replay: server unreachable at %s: %v", url, errDo)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		log.Errorf("replay: read response: %v", errRead)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Errorf("replay: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return
+	}
+	var result replay.Result
+	if errDecode := json.Unmarshal(body, &result); errDecode != nil {
+		log.Errorf("replay: decode response: %v", errDecode)
+		return
+	}
+	printReplayResult(os.Stdout, &result)
+}
+
+func printReplayResult(w io.Writer, result *replay.Result) {
+	if result.TranslateOnly {
+		_, _ = fmt.Fprintln(w, "Mode: translate only (upstream request not sent)")
+	} else {
+		_, _ = fmt.Fprintf(w, "Status: %d\n", result.Status)
+	}
+	if n := len(result.Upstream); n > 0 {
+		last := result.Upstream[n-1]
+		_, _ = fmt.Fprintf(w, "Upstream: %s %s (%d attempt(s))\n", last.Method, last.URL, n)
+	} else {
+		_, _ = fmt.Fprintln(w, "Upstream: no request issued")
+	}
+	sections := []struct {
+		title string
+		diff  string
+		skip  bool
+	}{
+		{"upstream request", result.Diff.UpstreamRequest, false},
+		{"upstream response", result.Diff.UpstreamResponse, result.TranslateOnly},
+		{"client response", result.Diff.Response, result.TranslateOnly},
+	}
+	for _, section := range sections {
+		if section.skip {
+			continue
+		}
+		if section.diff == "" {
+			_, _ = fmt.Fprintf(w, "\n== %s: unchanged\n", section.title)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\n== %s:\n%s", section.title, section.diff)
+	}
+	if result.TranslateOnly && len(result.Upstream) > 0 {
+		_, _ = fmt.Fprintf(w, "\n== translated upstream payload:\n%s\n", result.Upstream[len(result.Upstream)-1].Body)
+	}
+}
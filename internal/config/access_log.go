@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// AccessLogOutputFile writes the access log to a rotating file.
+	AccessLogOutputFile = "file"
+	// AccessLogOutputStdout writes the access log to standard output.
+	AccessLogOutputStdout = "stdout"
+
+	// DefaultAccessLogMaxSizeMB is the size at which access.log rotates.
+	DefaultAccessLogMaxSizeMB = 100
+)
+
+// AccessLogConfig configures the one-line-per-request JSON access log. It is independent
+// of request-log and logging-to-file.
+type AccessLogConfig struct {
+	// Enable writes one JSON line per client API request.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Output is "file" (default) or "stdout".
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+
+	// Dir holds access.log and its rotated backups. Defaults to the access directory under
+	// the logs directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// MaxSizeMB rotates access.log once it reaches this size. Defaults to 100.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// MaxBackups limits the rotated files kept. 0 keeps all of them.
+	MaxBackups int `yaml:"max-backups,omitempty" json:"max-backups,omitempty"`
+
+	// MaxAgeDays deletes rotated files older than this. 0 keeps them regardless of age.
+	MaxAgeDays int `yaml:"max-age-days,omitempty" json:"max-age-days,omitempty"`
+
+	// Compress gzips rotated files.
+	Compress bool `yaml:"compress,omitempty" json:"compress,omitempty"`
+}
+
+// NormalizedOutput returns the configured output or "file".
+func (cfg AccessLogConfig) NormalizedOutput() string {
+	if output := strings.ToLower(strings.TrimSpace(cfg.Output)); output != "" {
+		return output
+	}
+	return AccessLogOutputFile
+}
+
+// Validate verifies access-log.
+func (cfg AccessLogConfig) Validate() error {
+	switch cfg.NormalizedOutput() {
+	case AccessLogOutputFile, AccessLogOutputStdout:
+	default:
+		return fmt.Errorf("access-log.output must be %q or %q", AccessLogOutputFile, AccessLogOutputStdout)
+	}
+	if cfg.MaxSizeMB < 0 {
+		return fmt.Errorf("access-log.max-size-mb must not be negative")
+	}
+	if cfg.MaxBackups < 0 {
+		return fmt.Errorf("access-log.max-backups must not be negative")
+	}
+	if cfg.MaxAgeDays < 0 {
+		return fmt.Errorf("access-log.max-age-days must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// AlertFormatJSON posts the alert as a generic JSON object.
+	AlertFormatJSON = "json"
+	// AlertFormatSlack posts a Slack incoming-webhook payload.
+	AlertFormatSlack = "slack"
+	// AlertFormatDiscord posts a Discord webhook payload.
+	AlertFormatDiscord = "discord"
+
+	// Alert kinds that webhooks can subscribe to.
+	AlertRefreshFailed      = "refresh-failed"
+	AlertAuthUnavailable    = "auth-unavailable"
+	AlertQuotaExceeded      = "quota-exceeded"
+	AlertModelExhausted     = "model-exhausted"
+	AlertPluginCrashed      = "plugin-crashed"
+	AlertBudgetThreshold    = "budget-threshold"
+	AlertConfigReloadFailed = "config-reload-failed"
+
+	DefaultAlertThrottleSeconds         = 300
+	DefaultAlertRefreshFailureThreshold = 3
+	DefaultAlertTimeoutSeconds          = 10
+)
+
+// AlertKinds lists every alert kind in a stable order.
+var AlertKinds = []string{
+	AlertRefreshFailed,
+	AlertAuthUnavailable,
+	AlertQuotaExceeded,
+	AlertModelExhausted,
+	AlertPluginCrashed,
+	AlertBudgetThreshold,
+	AlertConfigReloadFailed,
+}
+
+// AlertingConfig configures outbound webhooks for credential, quota and runtime alerts.
+type AlertingConfig struct {
+	// ThrottleSeconds suppresses repeats of the same alert for the same subject (credential,
+	// model, plugin or client key) within this window. Default is 300.
+	ThrottleSeconds int `yaml:"throttle-seconds,omitempty" json:"throttle-seconds,omitempty"`
+
+	// EventThrottleSeconds overrides ThrottleSeconds per alert kind.
+	EventThrottleSeconds map[string]int `yaml:"event-throttle-seconds,omitempty" json:"event-throttle-seconds,omitempty"`
+
+	// RefreshFailureThreshold is the number of consecutive refresh failures of one
+	// credential before refresh-failed fires. Default is 3.
+	RefreshFailureThreshold int `yaml:"refresh-failure-threshold,omitempty" json:"refresh-failure-threshold,omitempty"`
+
+	// TimeoutSeconds bounds a single webhook delivery. Default is 10.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+
+	// Webhooks are the alert receivers. Alerting is off when the list is empty.
+	Webhooks []AlertWebhook `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+}
+
+// AlertWebhook is one alert receiver.
+type AlertWebhook struct {
+	// Name identifies the webhook in logs.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// URL is the endpoint that receives a POST per alert.
+	URL string `yaml:"url" json:"url"`
+
+	// Format is json (default), slack or discord.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// Secret signs each body with HMAC-SHA256. The hex digest of "<timestamp>.<body>" is
+	// sent in X-CPA-Signature as "sha256=<digest>" and the Unix timestamp in
+	// X-CPA-Timestamp.
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+
+	// Events limits the webhook to these alert kinds. Empty receives every kind.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Headers are added to every delivery.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// Enabled reports whether any webhook is configured.
+func (c AlertingConfig) Enabled() bool {
+	return len(c.Webhooks) > 0
+}
+
+// WithDefaults returns a copy with unset values replaced by defaults.
+func (c AlertingConfig) WithDefaults() AlertingConfig {
+	if c.ThrottleSeconds <= 0 {
+		c.ThrottleSeconds = DefaultAlertThrottleSeconds
+	}
+	if c.RefreshFailureThreshold <= 0 {
+		c.RefreshFailureThreshold = DefaultAlertRefreshFailureThreshold
+	}
+	if c.TimeoutSeconds <= 0 {
+		c.TimeoutSeconds = DefaultAlertTimeoutSeconds
+	}
+	return c
+}
+
+// Validate verifies alerting settings.
+func (c AlertingConfig) Validate() error {
+	if c.ThrottleSeconds < 0 {
+		return fmt.Errorf("alerting.throttle-seconds must not be negative")
+	}
+	if c.RefreshFailureThreshold < 0 {
+		return fmt.Errorf("alerting.refresh-failure-threshold must not be negative")
+	}
+	if c.TimeoutSeconds < 0 {
+		return fmt.Errorf("alerting.timeout-seconds must not be negative")
+	}
+	for kind, seconds := range c.EventThrottleSeconds {
+		if !isAlertKind(kind) {
+			return fmt.Errorf("alerting.event-throttle-seconds: unknown event %q", kind)
+		}
+		if seconds < 0 {
+			return fmt.Errorf("alerting.event-throttle-seconds.%s must not be negative", kind)
+		}
+	}
+	for i, webhook := range c.Webhooks {
+		parsed, errParse := url.Parse(strings.TrimSpace(webhook.URL))
+		if errParse != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("alerting.webhooks[%d].url must be an http or https URL", i)
+		}
+		switch webhook.NormalizedFormat() {
+		case AlertFormatJSON, AlertFormatSlack, AlertFormatDiscord:
+		default:
+			return fmt.Errorf("alerting.webhooks[%d].format must be json, slack or discord", i)
+		}
+		for _, kind := range webhook.Events {
+			if !isAlertKind(kind) {
+				return fmt.Errorf("alerting.webhooks[%d].events: unknown event %q", i, kind)
+			}
+		}
+	}
+	return nil
+}
+
+// ThrottleFor returns the throttle window for kind in seconds.
+func (c AlertingConfig) ThrottleFor(kind string) int {
+	if seconds, ok := c.EventThrottleSeconds[kind]; ok {
+		return seconds
+	}
+	return c.ThrottleSeconds
+}
+
+// NormalizedFormat returns the lower-cased format, defaulting to json.
+func (w AlertWebhook) NormalizedFormat() string {
+	format := strings.ToLower(strings.TrimSpace(w.Format))
+	if format == "" {
+		return AlertFormatJSON
+	}
+	return format
+}
+
+// Wants reports whether the webhook subscribes to kind.
+func (w AlertWebhook) Wants(kind string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, candidate := range w.Events {
+		if strings.EqualFold(strings.TrimSpace(candidate), kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAlertKind(kind string) bool {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	for _, candidate := range AlertKinds {
+		if candidate == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// DefaultAuditLogMaxSizeMB is the size at which the audit log rotates.
+const DefaultAuditLogMaxSizeMB = 10
+
+// AuditLogConfig configures the append-only audit log of management API mutations.
+type AuditLogConfig struct {
+	// Enable records every non-GET management request.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Dir holds audit.log and its rotated backups. Defaults to the audit directory under
+	// the logs directory, which the logs size cleaner and management log routes skip.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// MaxSizeMB rotates audit.log once it reaches this size. Defaults to 10.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// MaxBackups limits the rotated files kept. 0 keeps all of them.
+	MaxBackups int `yaml:"max-backups,omitempty" json:"max-backups,omitempty"`
+
+	// GitCommitMessages appends the principal, source IP, and route of a mutation to the
+	// commit messages written by the git token store.
+	GitCommitMessages bool `yaml:"git-commit-messages,omitempty" json:"git-commit-messages,omitempty"`
+}
+
+// ValidateAuditLog verifies audit-log.
+func (cfg *Config) ValidateAuditLog() error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.AuditLog.MaxSizeMB < 0 {
+		return fmt.Errorf("audit-log.max-size-mb must not be negative")
+	}
+	if cfg.AuditLog.MaxBackups < 0 {
+		return fmt.Errorf("audit-log.max-backups must not be negative")
+	}
+	return nil
+}
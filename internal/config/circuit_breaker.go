@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// CircuitBreakerKeyByHost groups credentials by upstream host (scheme + host:port).
+	CircuitBreakerKeyByHost = "host"
+	// CircuitBreakerKeyByBaseURL groups credentials by their full configured base URL.
+	CircuitBreakerKeyByBaseURL = "base-url"
+
+	DefaultCircuitBreakerWindowSeconds      = 60
+	DefaultCircuitBreakerMinRequests        = 10
+	DefaultCircuitBreakerErrorRateThreshold = 0.5
+	DefaultCircuitBreakerOpenSeconds        = 30
+	DefaultCircuitBreakerHalfOpenProbes     = 1
+)
+
+// CircuitBreakerConfig configures per-endpoint circuit breakers for credentials with a custom base-url.
+// While a breaker is open, every credential pointing at that endpoint is skipped during selection.
+type CircuitBreakerConfig struct {
+	// Enable turns endpoint circuit breakers on. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// KeyBy selects how credentials are grouped: "host" (default) or "base-url".
+	KeyBy string `yaml:"key-by,omitempty" json:"key-by,omitempty"`
+
+	// WindowSeconds is the rolling window used to compute the error rate. Default is 60.
+	WindowSeconds int `yaml:"window-seconds,omitempty" json:"window-seconds,omitempty"`
+
+	// MinRequests is the minimum number of requests in the window before the breaker may trip. Default is 10.
+	MinRequests int `yaml:"min-requests,omitempty" json:"min-requests,omitempty"`
+
+	// ErrorRateThreshold trips the breaker when failures/requests reaches this ratio (0-1]. Default is 0.5.
+	ErrorRateThreshold float64 `yaml:"error-rate-threshold,omitempty" json:"error-rate-threshold,omitempty"`
+
+	// OpenSeconds is how long the breaker stays open before admitting probe requests. Default is 30.
+	OpenSeconds int `yaml:"open-seconds,omitempty" json:"open-seconds,omitempty"`
+
+	// HalfOpenProbes is the number of probe requests admitted while half-open; all must succeed
+	// to close the breaker. Default is 1.
+	HalfOpenProbes int `yaml:"half-open-probes,omitempty" json:"half-open-probes,omitempty"`
+}
+
+// WithDefaults returns a copy with unset values replaced by defaults.
+func (c CircuitBreakerConfig) WithDefaults() CircuitBreakerConfig {
+	c.KeyBy = strings.ToLower(strings.TrimSpace(c.KeyBy))
+	if c.KeyBy == "" {
+		c.KeyBy = CircuitBreakerKeyByHost
+	}
+	if c.WindowSeconds <= 0 {
+		c.WindowSeconds = DefaultCircuitBreakerWindowSeconds
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = DefaultCircuitBreakerMinRequests
+	}
+	if c.ErrorRateThreshold <= 0 {
+		c.ErrorRateThreshold = DefaultCircuitBreakerErrorRateThreshold
+	}
+	if c.OpenSeconds <= 0 {
+		c.OpenSeconds = DefaultCircuitBreakerOpenSeconds
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = DefaultCircuitBreakerHalfOpenProbes
+	}
+	return c
+}
+
+// Validate verifies circuit breaker settings.
+func (c CircuitBreakerConfig) Validate() error {
+	switch strings.ToLower(strings.TrimSpace(c.KeyBy)) {
+	case "", CircuitBreakerKeyByHost, CircuitBreakerKeyByBaseURL:
+	default:
+		return fmt.Errorf("circuit-breaker.key-by must be %q or %q", CircuitBreakerKeyByHost, CircuitBreakerKeyByBaseURL)
+	}
+	if c.ErrorRateThreshold < 0 || c.ErrorRateThreshold > 1 {
+		return fmt.Errorf("circuit-breaker.error-rate-threshold must be between 0 and 1")
+	}
+	return nil
+}
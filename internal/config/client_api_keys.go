@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClientAPIKey is the extended form of a client key. Unlike plain api-keys entries, it can
+// restrict the models a client may call, expire, be disabled, and carry labels.
+type ClientAPIKey struct {
+	// Name identifies the key in logs and management output instead of the key value.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Key is the secret clients present, or its hash with a "sha256:" or "argon2:<lookup-id>:" prefix.
+	Key string `yaml:"key" json:"key"`
+
+	// AllowedModels lists model globs ('*' wildcard, case-insensitive) the key may call.
+	// Empty allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// DeniedModels lists model globs the key may not call. Denials win over AllowedModels.
+	DeniedModels []string `yaml:"denied-models,omitempty" json:"denied-models,omitempty"`
+
+	// AllowedPrefixes restricts the key to models requested with one of these credential
+	// prefixes (e.g. "teamA" for "teamA/gemini-2.5-pro"). Empty allows any prefix.
+	AllowedPrefixes []string `yaml:"allowed-prefixes,omitempty" json:"allowed-prefixes,omitempty"`
+
+	// AllowedCIDRs restricts the key to these source networks, replacing
+	// client-network.allowed-cidrs. Empty falls back to the global list.
+	AllowedCIDRs []string `yaml:"allowed-cidrs,omitempty" json:"allowed-cidrs,omitempty"`
+
+	// ExpiresAt rejects the key from this instant on. Zero never expires.
+	ExpiresAt time.Time `yaml:"expires-at,omitempty" json:"expires-at,omitempty"`
+
+	// Disabled rejects the key without removing it.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// Labels are free-form metadata attached to requests authenticated by this key.
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
+	// RateLimit overrides client-rate-limit.default for this key.
+	RateLimit *ClientRateLimit `yaml:"rate-limit,omitempty" json:"rate-limit,omitempty"`
+
+	// Budget overrides client-budgets.default for this key.
+	Budget *ClientBudget `yaml:"budget,omitempty" json:"budget,omitempty"`
+}
+
+// DisplayName returns the key name, or a masked form of the key when no name is set.
+func (k ClientAPIKey) DisplayName() string {
+	if name := strings.TrimSpace(k.Name); name != "" {
+		return name
+	}
+	key := strings.TrimSpace(k.Key)
+	if len(key) <= 8 {
+		return "***"
+	}
+	return key[:4] + "..." + key[len(key)-4:]
+}
+
+// Expired reports whether the key has expired at now.
+func (k ClientAPIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// SanitizeClientAPIKeys trims key entries and drops blank list values.
+func (cfg *SDKConfig) SanitizeClientAPIKeys() {
+	if cfg == nil {
+		return
+	}
+	for i := range cfg.ClientAPIKeys {
+		entry := &cfg.ClientAPIKeys[i]
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.Key = strings.TrimSpace(entry.Key)
+		entry.AllowedModels = NormalizeExcludedModels(entry.AllowedModels)
+		entry.DeniedModels = NormalizeExcludedModels(entry.DeniedModels)
+		entry.AllowedPrefixes = normalizeClientKeyPrefixes(entry.AllowedPrefixes)
+	}
+}
+
+// ValidateClientAPIKeys rejects client-api-keys entries without a key and keys configured twice.
+func (cfg *SDKConfig) ValidateClientAPIKeys() error {
+	if cfg == nil {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(cfg.APIKeys)+len(cfg.ClientAPIKeys))
+	for i, key := range cfg.APIKeys {
+		trimmed := strings.TrimSpace(key)
+		if trimmed == "" {
+			continue
+		}
+		if IsHashedClientKey(trimmed) {
+			if _, errParse := parseClientKeyHash(trimmed); errParse != nil {
+				return fmt.Errorf("api-keys[%d]: %w", i, errParse)
+			}
+		}
+		seen[trimmed] = struct{}{}
+	}
+	for i, entry := range cfg.ClientAPIKeys {
+		key := strings.TrimSpace(entry.Key)
+		if key == "" {
+			return fmt.Errorf("client-api-keys[%d].key is required", i)
+		}
+		if IsHashedClientKey(key) {
+			if _, errParse := parseClientKeyHash(key); errParse != nil {
+				return fmt.Errorf("client-api-keys[%d] (%s): %w", i, entry.DisplayName(), errParse)
+			}
+		}
+		if _, exists := seen[key]; exists {
+			return fmt.Errorf("client-api-keys[%d] (%s) duplicates another configured client key", i, entry.DisplayName())
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func normalizeClientKeyPrefixes(prefixes []string) []string {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(prefixes))
+	seen := make(map[string]struct{}, len(prefixes))
+	for _, prefix := range prefixes {
+		trimmed := strings.Trim(strings.TrimSpace(prefix), "/")
+		if trimmed == "" {
+			continue
+		}
+		if _, exists := seen[strings.ToLower(trimmed)]; exists {
+			continue
+		}
+		seen[strings.ToLower(trimmed)] = struct{}{}
+		out = append(out, trimmed)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
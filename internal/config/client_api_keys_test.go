@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseClientAPIKeys(t *testing.T) {
+	cfg, errParse := ParseConfigBytes([]byte(`
+api-keys:
+  - "plain-key"
+client-api-keys:
+  - name: " contractor "
+    key: " structured-key "
+    allowed-models: ["GPT-5*", ""]
+    allowed-prefixes: ["/teamA/"]
+    expires-at: "2026-12-31T23:59:59Z"
+    labels:
+      team: contractors
+`))
+	if errParse != nil {
+		t.Fatalf("ParseConfigBytes() error = %v", errParse)
+	}
+	cfg.SanitizeClientAPIKeys()
+	if errValidate := cfg.ValidateClientAPIKeys(); errValidate != nil {
+		t.Fatalf("ValidateClientAPIKeys() error = %v", errValidate)
+	}
+	if len(cfg.APIKeys) != 1 || len(cfg.ClientAPIKeys) != 1 {
+		t.Fatalf("keys = %v / %+v, want one of each", cfg.APIKeys, cfg.ClientAPIKeys)
+	}
+	entry := cfg.ClientAPIKeys[0]
+	if entry.Name != "contractor" || entry.Key != "structured-key" {
+		t.Fatalf("entry = %+v, want trimmed name and key", entry)
+	}
+	if len(entry.AllowedModels) != 1 || entry.AllowedModels[0] != "gpt-5*" {
+		t.Fatalf("allowed-models = %v, want [gpt-5*]", entry.AllowedModels)
+	}
+	if len(entry.AllowedPrefixes) != 1 || entry.AllowedPrefixes[0] != "teamA" {
+		t.Fatalf("allowed-prefixes = %v, want [teamA]", entry.AllowedPrefixes)
+	}
+	if want := time.Date(2026, time.December, 31, 23, 59, 59, 0, time.UTC); !entry.ExpiresAt.Equal(want) {
+		t.Fatalf("expires-at = %v, want %v", entry.ExpiresAt, want)
+	}
+	if entry.Labels["team"] != "contractors" {
+		t.Fatalf("labels = %v", entry.Labels)
+	}
+}
+
+func TestValidateClientAPIKeysRejectsDuplicatesAndBlankKeys(t *testing.T) {
+	duplicate := &SDKConfig{
+		APIKeys:       []string{"shared"},
+		ClientAPIKeys: []ClientAPIKey{{Name: "dup", Key: "shared"}},
+	}
+	if errValidate := duplicate.ValidateClientAPIKeys(); errValidate == nil {
+		t.Fatal("ValidateClientAPIKeys() accepted a key configured twice")
+	}
+	blank := &SDKConfig{ClientAPIKeys: []ClientAPIKey{{Name: "blank"}}}
+	if errValidate := blank.ValidateClientAPIKeys(); errValidate == nil {
+		t.Fatal("ValidateClientAPIKeys() accepted an entry without a key")
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ClientBudgetUnitTokens measures budgets in weighted tokens.
+	ClientBudgetUnitTokens = "tokens"
+	// ClientBudgetUnitCost measures budgets in estimated cost from the pricing catalog.
+	// Weights are prices per million tokens for models the catalog does not price.
+	ClientBudgetUnitCost = "cost"
+)
+
+// ClientBudgetWeights weights the usage.TokenBreakdown buckets when charging a budget.
+// In token budgets an unset weight counts tokens one-to-one; in cost budgets weights are
+// fallback prices per million tokens for unpriced models and an unset weight is free.
+type ClientBudgetWeights struct {
+	// Input weights uncached input tokens. Unclassified tokens use this weight too.
+	Input *float64 `yaml:"input,omitempty" json:"input,omitempty"`
+
+	// CacheRead weights input tokens served from the prompt cache.
+	CacheRead *float64 `yaml:"cache-read,omitempty" json:"cache-read,omitempty"`
+
+	// CacheWrite weights input tokens written to the prompt cache.
+	CacheWrite *float64 `yaml:"cache-write,omitempty" json:"cache-write,omitempty"`
+
+	// Output weights non-reasoning output tokens.
+	Output *float64 `yaml:"output,omitempty" json:"output,omitempty"`
+
+	// Reasoning weights reasoning output tokens.
+	Reasoning *float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+}
+
+// ClientBudget holds hard daily, weekly, and monthly budgets for a client key. Zero disables
+// the corresponding period. Periods follow UTC calendar days, ISO weeks, and months.
+type ClientBudget struct {
+	// Unit is "tokens" (default) or "cost".
+	Unit string `yaml:"unit,omitempty" json:"unit,omitempty"`
+
+	Daily   float64 `yaml:"daily,omitempty" json:"daily,omitempty"`
+	Weekly  float64 `yaml:"weekly,omitempty" json:"weekly,omitempty"`
+	Monthly float64 `yaml:"monthly,omitempty" json:"monthly,omitempty"`
+
+	// Weights charges token buckets differently, e.g. discounted cache reads.
+	Weights ClientBudgetWeights `yaml:"weights,omitempty" json:"weights,omitempty"`
+}
+
+// IsZero reports whether no budget period is configured.
+func (b ClientBudget) IsZero() bool {
+	return b.Daily <= 0 && b.Weekly <= 0 && b.Monthly <= 0
+}
+
+// NormalizedUnit returns the budget unit, defaulting to tokens.
+func (b ClientBudget) NormalizedUnit() string {
+	unit := strings.ToLower(strings.TrimSpace(b.Unit))
+	if unit == "" {
+		return ClientBudgetUnitTokens
+	}
+	return unit
+}
+
+func (b ClientBudget) validate(path string) error {
+	switch b.NormalizedUnit() {
+	case ClientBudgetUnitTokens, ClientBudgetUnitCost:
+	default:
+		return fmt.Errorf("%s.unit must be %q or %q", path, ClientBudgetUnitTokens, ClientBudgetUnitCost)
+	}
+	if b.Daily < 0 || b.Weekly < 0 || b.Monthly < 0 {
+		return fmt.Errorf("%s: budgets must not be negative", path)
+	}
+	for _, weight := range []*float64{b.Weights.Input, b.Weights.CacheRead, b.Weights.CacheWrite, b.Weights.Output, b.Weights.Reasoning} {
+		if weight != nil && *weight < 0 {
+			return fmt.Errorf("%s.weights must not be negative", path)
+		}
+	}
+	return nil
+}
+
+// ClientBudgetConfig configures per-client-key budgets.
+type ClientBudgetConfig struct {
+	// StateFile stores consumption across restarts. Defaults to client-budgets.state in
+	// the auth directory. The file is local to each node, so replicas do not share budgets.
+	StateFile string `yaml:"state-file,omitempty" json:"state-file,omitempty"`
+
+	// WarnThresholds are fractions of a budget (e.g. 0.8) that log a warning the first time
+	// consumption crosses them in a period.
+	WarnThresholds []float64 `yaml:"warn-thresholds,omitempty" json:"warn-thresholds,omitempty"`
+
+	// Default applies to every client key without its own budget block, including plain
+	// api-keys entries.
+	Default ClientBudget `yaml:"default,omitempty" json:"default,omitempty"`
+}
+
+// ValidateClientBudgets verifies client-budgets and per-key budget blocks.
+func (cfg *Config) ValidateClientBudgets() error {
+	if cfg == nil {
+		return nil
+	}
+	for _, threshold := range cfg.ClientBudgets.WarnThresholds {
+		if threshold <= 0 || threshold >= 1 {
+			return fmt.Errorf("client-budgets.warn-thresholds must be between 0 and 1, got %v", threshold)
+		}
+	}
+	if errDefault := cfg.ClientBudgets.Default.validate("client-budgets.default"); errDefault != nil {
+		return errDefault
+	}
+	for i, entry := range cfg.ClientAPIKeys {
+		if entry.Budget == nil {
+			continue
+		}
+		if errBudget := entry.Budget.validate(fmt.Sprintf("client-api-keys[%d].budget", i)); errBudget != nil {
+			return errBudget
+		}
+	}
+	return nil
+}
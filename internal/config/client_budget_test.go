@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateClientBudgets(t *testing.T) {
+	negative := -1.0
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "empty"},
+		{
+			name: "valid",
+			cfg: Config{
+				SDKConfig:     SDKConfig{ClientAPIKeys: []ClientAPIKey{{Key: "k", Budget: &ClientBudget{Unit: "Cost", Monthly: 20}}}},
+				ClientBudgets: ClientBudgetConfig{WarnThresholds: []float64{0.5, 0.9}, Default: ClientBudget{Daily: 1000}},
+			},
+		},
+		{
+			name:    "threshold out of range",
+			cfg:     Config{ClientBudgets: ClientBudgetConfig{WarnThresholds: []float64{80}}},
+			wantErr: "warn-thresholds",
+		},
+		{
+			name:    "unknown unit",
+			cfg:     Config{ClientBudgets: ClientBudgetConfig{Default: ClientBudget{Unit: "credits"}}},
+			wantErr: "client-budgets.default.unit",
+		},
+		{
+			name:    "negative weight on key",
+			cfg:     Config{SDKConfig: SDKConfig{ClientAPIKeys: []ClientAPIKey{{Key: "k", Budget: &ClientBudget{Weights: ClientBudgetWeights{Output: &negative}}}}}},
+			wantErr: "client-api-keys[0].budget.weights",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.ValidateClientBudgets()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateClientBudgets() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateClientBudgets() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// ClientKeyHashSHA256 stores client keys as "sha256:<salt-hex>:<digest-hex>". It suits
+	// generated high-entropy keys and is cheap to verify on every request.
+	ClientKeyHashSHA256 = "sha256"
+	// ClientKeyHashArgon2 stores client keys as "argon2:<lookup-id>:" followed by a PHC argon2id
+	// string. It suits human-chosen keys at a higher verification cost.
+	ClientKeyHashArgon2 = "argon2"
+
+	clientKeySaltBytes = 16
+
+	// clientKeyLookupIDBytes sizes the lookup ID stored beside argon2 hashes. Two bytes let a
+	// presented key skip every entry but its own without telling an attacker much about the key.
+	clientKeyLookupIDBytes = 2
+
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2KeyLen  = 32
+)
+
+// IsHashedClientKey reports whether a configured client key is stored as a hash.
+func IsHashedClientKey(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, ClientKeyHashSHA256+":") || strings.HasPrefix(value, ClientKeyHashArgon2+":")
+}
+
+// GenerateClientKey returns a new random client key.
+func GenerateClientKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, errRead := rand.Read(buf); errRead != nil {
+		return "", errRead
+	}
+	return "sk-" + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashClientKey hashes key with a random salt using scheme, which defaults to sha256.
+func HashClientKey(key, scheme string) (string, error) {
+	salt := make([]byte, clientKeySaltBytes)
+	if _, errRead := rand.Read(salt); errRead != nil {
+		return "", errRead
+	}
+	switch strings.ToLower(strings.TrimSpace(scheme)) {
+	case "", ClientKeyHashSHA256:
+		digest := sha256ClientKey(salt, key)
+		return ClientKeyHashSHA256 + ":" + hex.EncodeToString(salt) + ":" + hex.EncodeToString(digest), nil
+	case ClientKeyHashArgon2:
+		digest := argon2.IDKey([]byte(key), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("%s:%s:$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", ClientKeyHashArgon2, ClientKeyLookupID(key), argon2.Version,
+			argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(digest)), nil
+	default:
+		return "", fmt.Errorf("unsupported client key hash scheme %q", scheme)
+	}
+}
+
+// ClientKeyLookupID returns the short unsalted digest of key that argon2 hashes store so a
+// presented key is only verified against entries carrying the same ID.
+func ClientKeyLookupID(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:clientKeyLookupIDBytes])
+}
+
+// ClientKeyHashLookupID returns the lookup ID recorded in a stored argon2 hash, or "" for
+// other schemes and malformed values.
+func ClientKeyHashLookupID(stored string) string {
+	parsed, errParse := parseClientKeyHash(stored)
+	if errParse != nil {
+		return ""
+	}
+	return parsed.lookupID
+}
+
+// VerifyClientKey reports whether candidate matches the hashed key stored, in constant time.
+func VerifyClientKey(stored, candidate string) bool {
+	parsed, errParse := parseClientKeyHash(stored)
+	if errParse != nil {
+		return false
+	}
+	var digest []byte
+	switch parsed.scheme {
+	case ClientKeyHashSHA256:
+		digest = sha256ClientKey(parsed.salt, candidate)
+	case ClientKeyHashArgon2:
+		digest = argon2.IDKey([]byte(candidate), parsed.salt, parsed.time, parsed.memory, parsed.threads, uint32(len(parsed.digest)))
+	}
+	return subtle.ConstantTimeCompare(digest, parsed.digest) == 1
+}
+
+type clientKeyHash struct {
+	scheme   string
+	lookupID string
+	salt     []byte
+	digest   []byte
+	memory   uint32
+	time     uint32
+	threads  uint8
+}
+
+func sha256ClientKey(salt []byte, key string) []byte {
+	hasher := sha256.New()
+	hasher.Write(salt)
+	hasher.Write([]byte(key))
+	return hasher.Sum(nil)
+}
+
+func parseClientKeyHash(stored string) (clientKeyHash, error) {
+	stored = strings.TrimSpace(stored)
+	scheme, rest, _ := strings.Cut(stored, ":")
+	switch scheme {
+	case ClientKeyHashSHA256:
+		saltHex, digestHex, found := strings.Cut(rest, ":")
+		salt, errSalt := hex.DecodeString(saltHex)
+		digest, errDigest := hex.DecodeString(digestHex)
+		if !found || errSalt != nil || errDigest != nil || len(salt) == 0 || len(digest) != sha256.Size {
+			return clientKeyHash{}, fmt.Errorf("malformed sha256 client key hash")
+		}
+		return clientKeyHash{scheme: scheme, salt: salt, digest: digest}, nil
+	case ClientKeyHashArgon2:
+		lookupID, phc, _ := strings.Cut(rest, ":")
+		if decoded, errID := hex.DecodeString(lookupID); errID != nil || len(decoded) != clientKeyLookupIDBytes {
+			return clientKeyHash{}, fmt.Errorf("malformed argon2 client key hash (want argon2:<lookup-id>:$argon2id$...)")
+		}
+		parts := strings.Split(phc, "$")
+		if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+			return clientKeyHash{}, fmt.Errorf("malformed argon2 client key hash (want $argon2id$v=..$m=..,t=..,p=..$salt$hash)")
+		}
+		parsed := clientKeyHash{scheme: scheme, lookupID: strings.ToLower(lookupID)}
+		var version int
+		if _, errScan := fmt.Sscanf(parts[2], "v=%d", &version); errScan != nil || version != argon2.Version {
+			return clientKeyHash{}, fmt.Errorf("unsupported argon2 version in client key hash")
+		}
+		if _, errScan := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &parsed.memory, &parsed.time, &parsed.threads); errScan != nil ||
+			parsed.memory == 0 || parsed.time == 0 || parsed.threads == 0 {
+			return clientKeyHash{}, fmt.Errorf("malformed argon2 parameters in client key hash")
+		}
+		salt, errSalt := base64.RawStdEncoding.DecodeString(parts[4])
+		digest, errDigest := base64.RawStdEncoding.DecodeString(parts[5])
+		if errSalt != nil || errDigest != nil || len(salt) == 0 || len(digest) < 16 {
+			return clientKeyHash{}, fmt.Errorf("malformed argon2 salt or hash in client key hash")
+		}
+		parsed.salt, parsed.digest = salt, digest
+		return parsed, nil
+	default:
+		return clientKeyHash{}, fmt.Errorf("unknown client key hash scheme %q", scheme)
+	}
+}
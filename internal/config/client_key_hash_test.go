@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashClientKeyRoundTrip(t *testing.T) {
+	for _, scheme := range []string{"", ClientKeyHashSHA256, ClientKeyHashArgon2} {
+		hashed, errHash := HashClientKey("sk-client", scheme)
+		if errHash != nil {
+			t.Fatalf("HashClientKey(%q) error = %v", scheme, errHash)
+		}
+		if !IsHashedClientKey(hashed) || strings.Contains(hashed, "sk-client") {
+			t.Fatalf("HashClientKey(%q) = %q, want a prefixed hash without the key", scheme, hashed)
+		}
+		if !VerifyClientKey(hashed, "sk-client") {
+			t.Fatalf("VerifyClientKey(%q) rejected the original key", hashed)
+		}
+		if VerifyClientKey(hashed, "sk-other") {
+			t.Fatalf("VerifyClientKey(%q) accepted a different key", hashed)
+		}
+	}
+	first, _ := HashClientKey("sk-client", "")
+	second, _ := HashClientKey("sk-client", "")
+	if first == second {
+		t.Fatal("HashClientKey() reused a salt")
+	}
+	if _, errHash := HashClientKey("sk-client", "md5"); errHash == nil {
+		t.Fatal("HashClientKey() accepted an unknown scheme")
+	}
+}
+
+func TestValidateClientAPIKeysRejectsMalformedHashes(t *testing.T) {
+	valid, _ := HashClientKey("sk-client", ClientKeyHashSHA256)
+	cfg := &SDKConfig{APIKeys: []string{valid}, ClientAPIKeys: []ClientAPIKey{{Name: "team", Key: "plain-key"}}}
+	if errValidate := cfg.ValidateClientAPIKeys(); errValidate != nil {
+		t.Fatalf("ValidateClientAPIKeys() error = %v", errValidate)
+	}
+
+	cfg = &SDKConfig{APIKeys: []string{"sha256:zz:00"}}
+	if errValidate := cfg.ValidateClientAPIKeys(); errValidate == nil || !strings.Contains(errValidate.Error(), "api-keys[0]") {
+		t.Fatalf("ValidateClientAPIKeys() error = %v, want api-keys[0] error", errValidate)
+	}
+	cfg = &SDKConfig{ClientAPIKeys: []ClientAPIKey{{Name: "team", Key: "argon2:0000:$argon2i$v=19$m=1,t=1,p=1$c2FsdA$aGFzaA"}}}
+	if errValidate := cfg.ValidateClientAPIKeys(); errValidate == nil || !strings.Contains(errValidate.Error(), "client-api-keys[0] (team)") {
+		t.Fatalf("ValidateClientAPIKeys() error = %v, want client-api-keys[0] error", errValidate)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ClientNetworkConfig restricts the source networks client API requests may come from and
+// names the reverse proxies trusted to report the original client address.
+type ClientNetworkConfig struct {
+	// AllowedCIDRs lists the networks (CIDRs or single addresses) client requests may come
+	// from. Empty allows any source. Client keys with their own allowed-cidrs use those instead.
+	AllowedCIDRs []string `yaml:"allowed-cidrs,omitempty" json:"allowed-cidrs,omitempty"`
+
+	// TrustedProxies lists the networks of reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are honoured. Headers from any other peer are ignored.
+	TrustedProxies []string `yaml:"trusted-proxies,omitempty" json:"trusted-proxies,omitempty"`
+}
+
+// ParseNetworkPrefixes parses CIDRs and single IP addresses. Single addresses become
+// host prefixes, and IPv4-mapped IPv6 values are unmapped.
+func ParseNetworkPrefixes(values []string) ([]netip.Prefix, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		trimmed := strings.TrimSpace(value)
+		if trimmed == "" {
+			continue
+		}
+		if !strings.Contains(trimmed, "/") {
+			addr, errAddr := netip.ParseAddr(trimmed)
+			if errAddr != nil {
+				return nil, fmt.Errorf("invalid address or CIDR %q", trimmed)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, errPrefix := netip.ParsePrefix(trimmed)
+		if errPrefix != nil {
+			return nil, fmt.Errorf("invalid address or CIDR %q", trimmed)
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ValidateClientNetwork rejects malformed client-network and client-api-keys allowed-cidrs entries.
+func (cfg *SDKConfig) ValidateClientNetwork() error {
+	if cfg == nil {
+		return nil
+	}
+	if _, errParse := ParseNetworkPrefixes(cfg.ClientNetwork.AllowedCIDRs); errParse != nil {
+		return fmt.Errorf("client-network.allowed-cidrs: %w", errParse)
+	}
+	if _, errParse := ParseNetworkPrefixes(cfg.ClientNetwork.TrustedProxies); errParse != nil {
+		return fmt.Errorf("client-network.trusted-proxies: %w", errParse)
+	}
+	for i, entry := range cfg.ClientAPIKeys {
+		if _, errParse := ParseNetworkPrefixes(entry.AllowedCIDRs); errParse != nil {
+			return fmt.Errorf("client-api-keys[%d] (%s).allowed-cidrs: %w", i, entry.DisplayName(), errParse)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParseNetworkPrefixes(t *testing.T) {
+	prefixes, errParse := ParseNetworkPrefixes([]string{" 10.1.2.3/8 ", "192.168.1.7", "::ffff:172.16.0.0/108", "2001:db8::/32", ""})
+	if errParse != nil {
+		t.Fatalf("ParseNetworkPrefixes() error = %v", errParse)
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.7/32", "172.16.0.0/12", "2001:db8::/32"}
+	if len(prefixes) != len(want) {
+		t.Fatalf("ParseNetworkPrefixes() = %v, want %v", prefixes, want)
+	}
+	for i, prefix := range prefixes {
+		if prefix != netip.MustParsePrefix(want[i]) {
+			t.Fatalf("prefix[%d] = %s, want %s", i, prefix, want[i])
+		}
+	}
+}
+
+func TestValidateClientNetworkRejectsMalformedEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  SDKConfig
+	}{
+		{name: "global", cfg: SDKConfig{ClientNetwork: ClientNetworkConfig{AllowedCIDRs: []string{"10.0.0.0/33"}}}},
+		{name: "trusted proxies", cfg: SDKConfig{ClientNetwork: ClientNetworkConfig{TrustedProxies: []string{"proxy.internal"}}}},
+		{name: "client key", cfg: SDKConfig{ClientAPIKeys: []ClientAPIKey{{Name: "office", Key: "k", AllowedCIDRs: []string{"10.0.0.0/8", "nope"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errValidate := tt.cfg.ValidateClientNetwork(); errValidate == nil {
+				t.Fatal("ValidateClientNetwork() accepted a malformed entry")
+			}
+		})
+	}
+	valid := SDKConfig{
+		ClientNetwork: ClientNetworkConfig{AllowedCIDRs: []string{"10.0.0.0/8"}, TrustedProxies: []string{"127.0.0.1"}},
+		ClientAPIKeys: []ClientAPIKey{{Key: "k", AllowedCIDRs: []string{"fd00::/8"}}},
+	}
+	if errValidate := valid.ValidateClientNetwork(); errValidate != nil {
+		t.Fatalf("ValidateClientNetwork() error = %v", errValidate)
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ClientRateLimitBackendMemory keeps rate limit buckets in process memory.
+	ClientRateLimitBackendMemory = "memory"
+	// ClientRateLimitBackendRedis shares rate limit buckets across replicas through Redis.
+	ClientRateLimitBackendRedis = "redis"
+)
+
+// ClientRateLimit holds per-client-key limits. Zero disables the corresponding limit.
+type ClientRateLimit struct {
+	// RequestsPerMinute caps requests per minute.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// InputTokensPerMinute caps input tokens per minute, charged from usage records.
+	InputTokensPerMinute int `yaml:"input-tokens-per-minute,omitempty" json:"input-tokens-per-minute,omitempty"`
+
+	// OutputTokensPerMinute caps output tokens per minute, charged from usage records.
+	OutputTokensPerMinute int `yaml:"output-tokens-per-minute,omitempty" json:"output-tokens-per-minute,omitempty"`
+
+	// MaxConcurrentStreams caps streaming requests in flight at the same time.
+	MaxConcurrentStreams int `yaml:"max-concurrent-streams,omitempty" json:"max-concurrent-streams,omitempty"`
+}
+
+// IsZero reports whether no limit is configured.
+func (l ClientRateLimit) IsZero() bool {
+	return l.RequestsPerMinute <= 0 && l.InputTokensPerMinute <= 0 && l.OutputTokensPerMinute <= 0 && l.MaxConcurrentStreams <= 0
+}
+
+// ClientRateLimitConfig configures token-bucket limits applied to each client key.
+type ClientRateLimitConfig struct {
+	// Backend selects where buckets are kept: "memory" (default) or "redis".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// RedisURL is the redis:// or rediss:// URL used by the redis backend.
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+
+	// Default applies to every client key without its own rate-limit block, including
+	// plain api-keys entries.
+	Default ClientRateLimit `yaml:"default,omitempty" json:"default,omitempty"`
+}
+
+// NormalizedBackend returns the configured backend, defaulting to memory.
+func (c ClientRateLimitConfig) NormalizedBackend() string {
+	backend := strings.ToLower(strings.TrimSpace(c.Backend))
+	if backend == "" {
+		return ClientRateLimitBackendMemory
+	}
+	return backend
+}
+
+// Validate verifies the rate limit backend settings.
+func (c ClientRateLimitConfig) Validate() error {
+	switch c.NormalizedBackend() {
+	case ClientRateLimitBackendMemory:
+		return nil
+	case ClientRateLimitBackendRedis:
+		if strings.TrimSpace(c.RedisURL) == "" {
+			return fmt.Errorf("client-rate-limit.redis-url is required when client-rate-limit.backend is %q", ClientRateLimitBackendRedis)
+		}
+		return nil
+	default:
+		return fmt.Errorf("client-rate-limit.backend must be %q or %q", ClientRateLimitBackendMemory, ClientRateLimitBackendRedis)
+	}
+}
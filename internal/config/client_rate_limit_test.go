@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClientRateLimitConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ClientRateLimitConfig
+		wantErr string
+	}{
+		{name: "default memory", cfg: ClientRateLimitConfig{}},
+		{name: "redis with url", cfg: ClientRateLimitConfig{Backend: "Redis", RedisURL: "redis://localhost:6379/0"}},
+		{name: "redis without url", cfg: ClientRateLimitConfig{Backend: "redis"}, wantErr: "redis-url is required"},
+		{name: "unknown backend", cfg: ClientRateLimitConfig{Backend: "etcd"}, wantErr: "backend must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigOptional_ClientRateLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+api-keys:
+  - "plain-key"
+client-api-keys:
+  - key: "limited-key"
+    rate-limit:
+      requests-per-minute: 10
+      max-concurrent-streams: 1
+client-rate-limit:
+  default:
+    requests-per-minute: 60
+    output-tokens-per-minute: 1000
+`
+	if errWrite := os.WriteFile(path, []byte(content), 0o600); errWrite != nil {
+		t.Fatalf("write config: %v", errWrite)
+	}
+	cfg, errLoad := LoadConfigOptional(path, false)
+	if errLoad != nil {
+		t.Fatalf("LoadConfigOptional() error = %v", errLoad)
+	}
+	if len(cfg.ClientAPIKeys) != 1 || cfg.ClientAPIKeys[0].RateLimit == nil {
+		t.Fatalf("client-api-keys rate-limit not loaded: %+v", cfg.ClientAPIKeys)
+	}
+	if got := *cfg.ClientAPIKeys[0].RateLimit; got.RequestsPerMinute != 10 || got.MaxConcurrentStreams != 1 || got.OutputTokensPerMinute != 0 {
+		t.Fatalf("client-api-keys[0].rate-limit = %+v", got)
+	}
+	if got := cfg.ClientRateLimit.Default; got.RequestsPerMinute != 60 || got.OutputTokensPerMinute != 1000 {
+		t.Fatalf("client-rate-limit.default = %+v", got)
+	}
+}
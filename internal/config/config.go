@@ -40,6 +40,12 @@ type Config struct {
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
+	// Metrics controls the Prometheus metrics endpoint.
+	Metrics MetricsConfig `yaml:"metrics,omitempty" json:"metrics,omitempty"`
+
+	// Tracing configures OpenTelemetry tracing.
+	Tracing TracingConfig `yaml:"tracing,omitempty" json:"tracing,omitempty"`
+
 	// CommercialMode disables high-overhead request logging and HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -54,9 +60,24 @@ type Config struct {
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// RequestLogRedaction scrubs headers, JSON fields and sensitive text from request logs.
+	RequestLogRedaction RequestLogRedactionConfig `yaml:"request-log-redaction,omitempty" json:"request-log-redaction,omitempty"`
+
+	// AuditLog records management API mutations to an append-only rotating log.
+	AuditLog AuditLogConfig `yaml:"audit-log,omitempty" json:"audit-log,omitempty"`
+
+	// AccessLog writes one JSON line per client API request.
+	AccessLog AccessLogConfig `yaml:"access-log,omitempty" json:"access-log,omitempty"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// Pricing estimates the cost of each request from its token breakdown.
+	Pricing PricingConfig `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+
+	// UsageLedger stores usage records durably for reporting.
+	UsageLedger UsageLedgerConfig `yaml:"usage-ledger,omitempty" json:"usage-ledger,omitempty"`
+
 	// RedisUsageQueueRetentionSeconds controls how long usage queue items are retained
 	// in memory for Management API consumers.
 	// Default: 60. Max: 3600.
@@ -95,6 +116,21 @@ type Config struct {
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// CircuitBreaker trips per-endpoint breakers when a custom upstream base URL keeps failing.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit-breaker" json:"circuit-breaker"`
+
+	// HealthProbe periodically checks every credential with a synthetic request.
+	HealthProbe HealthProbeConfig `yaml:"health-probe,omitempty" json:"health-probe,omitempty"`
+
+	// Alerting posts credential, quota and runtime alerts to webhooks.
+	Alerting AlertingConfig `yaml:"alerting,omitempty" json:"alerting,omitempty"`
+
+	// ClientRateLimit applies per-client-key request, token, and stream limits.
+	ClientRateLimit ClientRateLimitConfig `yaml:"client-rate-limit,omitempty" json:"client-rate-limit,omitempty"`
+
+	// ClientBudgets applies hard daily, weekly, and monthly budgets per client key.
+	ClientBudgets ClientBudgetConfig `yaml:"client-budgets,omitempty" json:"client-budgets,omitempty"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -98,6 +98,66 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	if errValidate := cfg.ValidateCredentialWeights(); errValidate != nil {
 		return nil, errValidate
 	}
+	if errValidate := cfg.Shadow.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.ResponseCache.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.CircuitBreaker.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	cfg.CircuitBreaker = cfg.CircuitBreaker.WithDefaults()
+	if errValidate := cfg.HealthProbe.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	cfg.HealthProbe = cfg.HealthProbe.WithDefaults()
+	if errValidate := cfg.Alerting.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	cfg.Alerting = cfg.Alerting.WithDefaults()
+	if errValidate := cfg.Routing.ValidateSessionAffinityStore(); errValidate != nil {
+		return nil, errValidate
+	}
+	cfg.SanitizeClientAPIKeys()
+	if errValidate := cfg.ValidateClientAPIKeys(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.ValidateClientNetwork(); errValidate != nil {
+		return nil, errValidate
+	}
+	cfg.SanitizeJWTAuth()
+	if errValidate := cfg.ValidateJWTAuth(); errValidate != nil {
+		return nil, errValidate
+	}
+	cfg.SanitizeMTLSAuth()
+	if errValidate := cfg.ValidateMTLS(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.ClientRateLimit.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.ValidateClientBudgets(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.RequestLogRedaction.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.ValidateAuditLog(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.AccessLog.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.Tracing.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.UsageLedger.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
+	if errValidate := cfg.Pricing.Validate(); errValidate != nil {
+		return nil, errValidate
+	}
 
 	// Hash remote management key if plaintext is detected (nested)
 	// We consider a value to be already hashed if it looks like a bcrypt hash ($2a$, $2b$, or $2y$ prefix).
@@ -113,6 +173,10 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
 	}
 
+	if errKeys := cfg.sanitizeManagementKeys(); errKeys != nil {
+		return nil, errKeys
+	}
+
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
 		cfg.RemoteManagement.PanelGitHubRepository = DefaultPanelGitHubRepository
@@ -122,6 +186,8 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	if cfg.Pprof.Addr == "" {
 		cfg.Pprof.Addr = DefaultPprofAddr
 	}
+	cfg.Metrics.Addr = strings.TrimSpace(cfg.Metrics.Addr)
+	cfg.Metrics.BearerToken = strings.TrimSpace(cfg.Metrics.BearerToken)
 
 	if cfg.LogsMaxTotalSizeMB < 0 {
 		cfg.LogsMaxTotalSizeMB = 0
@@ -186,6 +252,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize shadow traffic rules.
+	cfg.SanitizeShadow()
+
 	// Return the populated configuration struct.
 	return &cfg, nil
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	sdkpluginstore "github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginstore"
@@ -188,6 +189,11 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// ClientCA is the path to a PEM bundle of CAs trusted to sign client certificates.
+	ClientCA string `yaml:"client-ca,omitempty" json:"client-ca,omitempty"`
+	// ClientAuth is "require" or "verify-if-given". Defaults to "verify-if-given" when
+	// ClientCA is set. Client certificate settings apply when the server starts.
+	ClientAuth string `yaml:"client-auth,omitempty" json:"client-auth,omitempty"`
 }
 
 // PprofConfig holds pprof HTTP server settings.
@@ -198,12 +204,26 @@ type PprofConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+// MetricsConfig holds Prometheus metrics endpoint settings.
+type MetricsConfig struct {
+	// Enable toggles the metrics endpoint.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Addr is the host:port address of a dedicated metrics listener. Empty serves
+	// /metrics on the main API port.
+	Addr string `yaml:"addr,omitempty" json:"addr,omitempty"`
+	// BearerToken, when set, must be presented as "Authorization: Bearer <token>" to scrape.
+	BearerToken string `yaml:"bearer-token,omitempty" json:"bearer-token,omitempty"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
 	AllowRemote bool `yaml:"allow-remote"`
 	// SecretKey is the management key (plaintext or bcrypt hashed). YAML key intentionally 'secret-key'.
 	SecretKey string `yaml:"secret-key"`
+	// Keys lists additional named management keys with a role or route scopes. The
+	// secret-key above keeps full admin access.
+	Keys []ManagementKey `yaml:"keys,omitempty"`
 	// DisableControlPanel skips serving and syncing the bundled management UI when true.
 	DisableControlPanel bool `yaml:"disable-control-panel"`
 	// DisableAutoUpdatePanel disables automatic periodic background updates of the management panel asset from GitHub.
@@ -245,6 +265,39 @@ type RoutingConfig struct {
 	// SessionAffinityTTL specifies how long session-to-auth bindings are retained.
 	// Default: 1h. Accepts duration strings like "30m", "1h", "2h30m".
 	SessionAffinityTTL string `yaml:"session-affinity-ttl,omitempty" json:"session-affinity-ttl,omitempty"`
+
+	// SessionAffinityStore selects where session bindings are kept so they survive restarts
+	// and are shared by replicas: "memory" (default), "redis", or "postgres" (requires the
+	// PostgreSQL token store).
+	SessionAffinityStore string `yaml:"session-affinity-store,omitempty" json:"session-affinity-store,omitempty"`
+
+	// SessionAffinityRedisURL is the Redis URL used when SessionAffinityStore is "redis",
+	// for example "redis://:password@localhost:6379/0".
+	SessionAffinityRedisURL string `yaml:"session-affinity-redis-url,omitempty" json:"session-affinity-redis-url,omitempty"`
+}
+
+const (
+	// SessionAffinityStoreMemory keeps session bindings in process memory.
+	SessionAffinityStoreMemory = "memory"
+	// SessionAffinityStoreRedis keeps session bindings in Redis.
+	SessionAffinityStoreRedis = "redis"
+	// SessionAffinityStorePostgres keeps session bindings in the PostgreSQL token store database.
+	SessionAffinityStorePostgres = "postgres"
+)
+
+// ValidateSessionAffinityStore verifies the session-affinity binding store settings.
+func (r RoutingConfig) ValidateSessionAffinityStore() error {
+	switch strings.ToLower(strings.TrimSpace(r.SessionAffinityStore)) {
+	case "", SessionAffinityStoreMemory, SessionAffinityStorePostgres:
+		return nil
+	case SessionAffinityStoreRedis:
+		if strings.TrimSpace(r.SessionAffinityRedisURL) == "" {
+			return fmt.Errorf("routing.session-affinity-redis-url is required when routing.session-affinity-store is %q", SessionAffinityStoreRedis)
+		}
+		return nil
+	default:
+		return fmt.Errorf("routing.session-affinity-store must be %q, %q, or %q", SessionAffinityStoreMemory, SessionAffinityStoreRedis, SessionAffinityStorePostgres)
+	}
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -426,7 +479,8 @@ type ClaudeModel struct {
 	// DisplayName is the optional human-readable name shown in model catalogs.
 	DisplayName string `yaml:"display-name,omitempty" json:"display-name,omitempty"`
 
-	// MaxContextLength overrides the context window advertised to Codex clients.
+	// MaxContextLength overrides the context window listed for this Claude model. Requests
+	// whose estimated prompt exceeds it skip this Claude key during selection.
 	MaxContextLength int `yaml:"max-context-length,omitempty" json:"max-context-length,omitempty"`
 
 	// ForceMapping rewrites upstream response model fields back to Alias.
@@ -522,7 +576,8 @@ type CodexModel struct {
 	// DisplayName is the optional human-readable name shown in model catalogs.
 	DisplayName string `yaml:"display-name,omitempty" json:"display-name,omitempty"`
 
-	// MaxContextLength overrides the context window advertised to Codex clients.
+	// MaxContextLength overrides the context window advertised to Codex clients for this
+	// model. Requests whose estimated prompt exceeds it skip this Codex key during selection.
 	MaxContextLength int `yaml:"max-context-length,omitempty" json:"max-context-length,omitempty"`
 
 	// ForceMapping rewrites upstream response model fields back to Alias.
@@ -619,7 +674,8 @@ type GeminiModel struct {
 	// DisplayName is the optional human-readable name shown in model catalogs.
 	DisplayName string `yaml:"display-name,omitempty" json:"display-name,omitempty"`
 
-	// MaxContextLength overrides the context window advertised to Codex clients.
+	// MaxContextLength overrides the context window listed for this Gemini model. Requests
+	// whose estimated prompt exceeds it skip this Gemini key during selection.
 	MaxContextLength int `yaml:"max-context-length,omitempty" json:"max-context-length,omitempty"`
 
 	// ForceMapping rewrites upstream response model fields back to Alias.
@@ -712,7 +768,8 @@ type OpenAICompatibilityModel struct {
 	// DisplayName is the optional human-readable name shown in model catalogs.
 	DisplayName string `yaml:"display-name,omitempty" json:"display-name,omitempty"`
 
-	// MaxContextLength overrides the context window advertised to Codex clients.
+	// MaxContextLength sets the context window listed for this compatible model. Requests
+	// whose estimated prompt exceeds it skip this provider during selection.
 	MaxContextLength int `yaml:"max-context-length,omitempty" json:"max-context-length,omitempty"`
 
 	// ForceMapping rewrites upstream response model fields back to Alias.
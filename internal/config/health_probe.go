@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultHealthProbeIntervalSeconds = 900
+	DefaultHealthProbeTimeoutSeconds  = 30
+	DefaultHealthProbeConcurrency     = 4
+	DefaultHealthProbeHistorySize     = 10
+)
+
+// HealthProbeConfig configures scheduled synthetic probes that check every credential with
+// a minimal upstream request, or a free provider endpoint where the executor has one.
+type HealthProbeConfig struct {
+	// Enable turns scheduled probes on. On-demand probes through the management API work
+	// regardless. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// IntervalSeconds is the time between probes of the same credential. Default is 900.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+
+	// TimeoutSeconds bounds a single probe. Default is 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+
+	// Concurrency is the number of credentials probed in parallel. Default is 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
+	// HistorySize is the number of probe results kept per credential. Default is 10.
+	HistorySize int `yaml:"history-size,omitempty" json:"history-size,omitempty"`
+
+	// Providers limits scheduled probes to these providers. Empty probes all of them.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// Models sets the probe model per provider. Providers without an entry use the first
+	// model registered for the credential.
+	Models map[string]string `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// WithDefaults returns a copy with unset values replaced by defaults.
+func (c HealthProbeConfig) WithDefaults() HealthProbeConfig {
+	if c.IntervalSeconds <= 0 {
+		c.IntervalSeconds = DefaultHealthProbeIntervalSeconds
+	}
+	if c.TimeoutSeconds <= 0 {
+		c.TimeoutSeconds = DefaultHealthProbeTimeoutSeconds
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = DefaultHealthProbeConcurrency
+	}
+	if c.HistorySize <= 0 {
+		c.HistorySize = DefaultHealthProbeHistorySize
+	}
+	return c
+}
+
+// Validate verifies health probe settings.
+func (c HealthProbeConfig) Validate() error {
+	if c.IntervalSeconds < 0 {
+		return fmt.Errorf("health-probe.interval-seconds must not be negative")
+	}
+	if c.TimeoutSeconds < 0 {
+		return fmt.Errorf("health-probe.timeout-seconds must not be negative")
+	}
+	if c.Concurrency < 0 {
+		return fmt.Errorf("health-probe.concurrency must not be negative")
+	}
+	if c.HistorySize < 0 {
+		return fmt.Errorf("health-probe.history-size must not be negative")
+	}
+	return nil
+}
+
+// ProbesProvider reports whether scheduled probes cover provider.
+func (c HealthProbeConfig) ProbesProvider(provider string) bool {
+	if len(c.Providers) == 0 {
+		return true
+	}
+	for _, candidate := range c.Providers {
+		if strings.EqualFold(strings.TrimSpace(candidate), provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelFor returns the configured probe model for provider, if any.
+func (c HealthProbeConfig) ModelFor(provider string) string {
+	for key, model := range c.Models {
+		if strings.EqualFold(strings.TrimSpace(key), provider) {
+			return strings.TrimSpace(model)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JWTAuthConfig configures the jwt access provider, which authenticates clients with
+// bearer tokens issued by an SSO / OIDC identity provider.
+type JWTAuthConfig struct {
+	// Enable turns the provider on.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// JWKSURL is the identity provider's JSON Web Key Set endpoint.
+	JWKSURL string `yaml:"jwks-url,omitempty" json:"jwks-url,omitempty"`
+
+	// JWKSFile reads the key set from a local JWKS document instead of a URL.
+	JWKSFile string `yaml:"jwks-file,omitempty" json:"jwks-file,omitempty"`
+
+	// StaticKeys lists PEM-encoded public keys trusted in addition to the key set.
+	StaticKeys []JWTStaticKey `yaml:"static-keys,omitempty" json:"static-keys,omitempty"`
+
+	// RefreshIntervalSeconds controls how often the key set is reloaded. Defaults to 3600.
+	// Tokens signed by an unknown key id also trigger a reload, at most every 30 seconds.
+	RefreshIntervalSeconds int `yaml:"refresh-interval-seconds,omitempty" json:"refresh-interval-seconds,omitempty"`
+
+	// Issuer must match the "iss" claim when set.
+	Issuer string `yaml:"issuer,omitempty" json:"issuer,omitempty"`
+
+	// Audience lists accepted "aud" values. A token must carry at least one when set.
+	Audience []string `yaml:"audience,omitempty" json:"audience,omitempty"`
+
+	// ClockSkewSeconds tolerates clock drift when checking exp and nbf. Defaults to 60.
+	ClockSkewSeconds int `yaml:"clock-skew-seconds,omitempty" json:"clock-skew-seconds,omitempty"`
+
+	// PrincipalClaim names the claim used as the request principal. Defaults to "sub".
+	PrincipalClaim string `yaml:"principal-claim,omitempty" json:"principal-claim,omitempty"`
+
+	// GroupsClaim names the claim holding the user's groups. Defaults to "groups".
+	GroupsClaim string `yaml:"groups-claim,omitempty" json:"groups-claim,omitempty"`
+
+	// GroupRules restrict models by group. The first rule whose group the token carries
+	// applies; tokens matching no rule are unrestricted unless RequireGroupMatch is set.
+	GroupRules []JWTGroupRule `yaml:"group-rules,omitempty" json:"group-rules,omitempty"`
+
+	// RequireGroupMatch rejects tokens that match none of the group rules.
+	RequireGroupMatch bool `yaml:"require-group-match,omitempty" json:"require-group-match,omitempty"`
+}
+
+// JWTStaticKey is a PEM-encoded public key trusted by the jwt access provider.
+type JWTStaticKey struct {
+	// KeyID matches the token header "kid". Empty matches tokens without a kid.
+	KeyID string `yaml:"kid,omitempty" json:"kid,omitempty"`
+
+	// PublicKey is an RSA, ECDSA, or Ed25519 public key in PEM form.
+	PublicKey string `yaml:"public-key" json:"public-key"`
+}
+
+// JWTGroupRule restricts the models available to members of a group.
+type JWTGroupRule struct {
+	Group           string   `yaml:"group" json:"group"`
+	AllowedModels   []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+	DeniedModels    []string `yaml:"denied-models,omitempty" json:"denied-models,omitempty"`
+	AllowedPrefixes []string `yaml:"allowed-prefixes,omitempty" json:"allowed-prefixes,omitempty"`
+}
+
+// SanitizeJWTAuth trims jwt-auth values and normalizes group rule model lists.
+func (cfg *SDKConfig) SanitizeJWTAuth() {
+	if cfg == nil {
+		return
+	}
+	jwtCfg := &cfg.JWTAuth
+	jwtCfg.JWKSURL = strings.TrimSpace(jwtCfg.JWKSURL)
+	jwtCfg.JWKSFile = strings.TrimSpace(jwtCfg.JWKSFile)
+	jwtCfg.Issuer = strings.TrimSpace(jwtCfg.Issuer)
+	jwtCfg.PrincipalClaim = strings.TrimSpace(jwtCfg.PrincipalClaim)
+	jwtCfg.GroupsClaim = strings.TrimSpace(jwtCfg.GroupsClaim)
+	audience := jwtCfg.Audience[:0]
+	for _, value := range jwtCfg.Audience {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			audience = append(audience, trimmed)
+		}
+	}
+	jwtCfg.Audience = audience
+	for i := range jwtCfg.GroupRules {
+		rule := &jwtCfg.GroupRules[i]
+		rule.Group = strings.TrimSpace(rule.Group)
+		rule.AllowedModels = NormalizeExcludedModels(rule.AllowedModels)
+		rule.DeniedModels = NormalizeExcludedModels(rule.DeniedModels)
+		rule.AllowedPrefixes = normalizeClientKeyPrefixes(rule.AllowedPrefixes)
+	}
+}
+
+// ValidateJWTAuth verifies jwt-auth when it is enabled.
+func (cfg *SDKConfig) ValidateJWTAuth() error {
+	if cfg == nil || !cfg.JWTAuth.Enable {
+		return nil
+	}
+	jwtCfg := cfg.JWTAuth
+	if jwtCfg.JWKSURL == "" && jwtCfg.JWKSFile == "" && len(jwtCfg.StaticKeys) == 0 {
+		return fmt.Errorf("jwt-auth requires jwks-url, jwks-file, or static-keys")
+	}
+	if jwtCfg.JWKSURL != "" && jwtCfg.JWKSFile != "" {
+		return fmt.Errorf("jwt-auth.jwks-url and jwt-auth.jwks-file are mutually exclusive")
+	}
+	for i, key := range jwtCfg.StaticKeys {
+		if strings.TrimSpace(key.PublicKey) == "" {
+			return fmt.Errorf("jwt-auth.static-keys[%d].public-key is required", i)
+		}
+	}
+	for i, rule := range jwtCfg.GroupRules {
+		if rule.Group == "" {
+			return fmt.Errorf("jwt-auth.group-rules[%d].group is required", i)
+		}
+	}
+	if jwtCfg.RefreshIntervalSeconds < 0 || jwtCfg.ClockSkewSeconds < 0 {
+		return fmt.Errorf("jwt-auth.refresh-interval-seconds and clock-skew-seconds must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateJWTAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     JWTAuthConfig
+		wantErr string
+	}{
+		{name: "disabled without keys", cfg: JWTAuthConfig{}},
+		{name: "valid", cfg: JWTAuthConfig{Enable: true, JWKSURL: "https://sso.example.com/jwks", GroupRules: []JWTGroupRule{{Group: "staff"}}}},
+		{name: "no key source", cfg: JWTAuthConfig{Enable: true}, wantErr: "requires jwks-url"},
+		{
+			name:    "url and file",
+			cfg:     JWTAuthConfig{Enable: true, JWKSURL: "https://sso.example.com/jwks", JWKSFile: "jwks.json"},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:    "rule without group",
+			cfg:     JWTAuthConfig{Enable: true, JWKSFile: "jwks.json", GroupRules: []JWTGroupRule{{AllowedModels: []string{"gpt-5"}}}},
+			wantErr: "group-rules[0].group",
+		},
+		{
+			name:    "static key without pem",
+			cfg:     JWTAuthConfig{Enable: true, StaticKeys: []JWTStaticKey{{KeyID: "k1"}}},
+			wantErr: "static-keys[0].public-key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &SDKConfig{JWTAuth: tt.cfg}
+			cfg.SanitizeJWTAuth()
+			err := cfg.ValidateJWTAuth()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateJWTAuth() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateJWTAuth() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ManagementRoleViewer may call read-only management routes.
+	ManagementRoleViewer = "viewer"
+	// ManagementRoleOperator may call every management route except admin-only ones.
+	ManagementRoleOperator = "operator"
+	// ManagementRoleAdmin may call every management route.
+	ManagementRoleAdmin = "admin"
+)
+
+// ManagementKey is a named management credential with a role or an explicit scope list.
+type ManagementKey struct {
+	// Name identifies the principal in responses and logs.
+	Name string `yaml:"name" json:"name"`
+
+	// Key is the management key, plaintext or bcrypt hashed. Plaintext keys are hashed in
+	// memory on load; store a bcrypt hash to keep the plaintext out of config.yaml.
+	Key string `yaml:"key" json:"-"`
+
+	// Role is "viewer", "operator", or "admin". Ignored when Scopes is set.
+	Role string `yaml:"role,omitempty" json:"role,omitempty"`
+
+	// Scopes lists the routes the key may call as "[METHOD] /path" patterns relative to
+	// /v0/management, e.g. "GET /logs*" or "/usage*". '*' matches any substring.
+	Scopes []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+// HasCredentials reports whether any management credential is configured.
+func (r RemoteManagement) HasCredentials() bool {
+	return r.SecretKey != "" || len(r.Keys) > 0
+}
+
+// NormalizedRole returns the lower-cased role.
+func (k ManagementKey) NormalizedRole() string {
+	return strings.ToLower(strings.TrimSpace(k.Role))
+}
+
+// sanitizeManagementKeys validates remote-management.keys and hashes plaintext keys.
+func (cfg *Config) sanitizeManagementKeys() error {
+	seen := make(map[string]struct{}, len(cfg.RemoteManagement.Keys))
+	for i := range cfg.RemoteManagement.Keys {
+		entry := &cfg.RemoteManagement.Keys[i]
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.Key = strings.TrimSpace(entry.Key)
+		entry.Role = entry.NormalizedRole()
+		scopes := entry.Scopes[:0]
+		for _, scope := range entry.Scopes {
+			if trimmed := strings.TrimSpace(scope); trimmed != "" {
+				scopes = append(scopes, trimmed)
+			}
+		}
+		entry.Scopes = scopes
+
+		if entry.Name == "" {
+			return fmt.Errorf("remote-management.keys[%d].name is required", i)
+		}
+		if _, exists := seen[entry.Name]; exists {
+			return fmt.Errorf("remote-management.keys[%d].name %q is duplicated", i, entry.Name)
+		}
+		seen[entry.Name] = struct{}{}
+		if entry.Key == "" {
+			return fmt.Errorf("remote-management.keys[%d] (%s): key is required", i, entry.Name)
+		}
+		if len(entry.Scopes) == 0 {
+			switch entry.Role {
+			case ManagementRoleViewer, ManagementRoleOperator, ManagementRoleAdmin:
+			case "":
+				return fmt.Errorf("remote-management.keys[%d] (%s): role or scopes is required", i, entry.Name)
+			default:
+				return fmt.Errorf("remote-management.keys[%d] (%s): role %q is invalid", i, entry.Name, entry.Role)
+			}
+		}
+		if !looksLikeBcrypt(entry.Key) {
+			hashed, errHash := hashSecret(entry.Key)
+			if errHash != nil {
+				return fmt.Errorf("failed to hash remote-management.keys[%d]: %w", i, errHash)
+			}
+			entry.Key = hashed
+		}
+	}
+	return nil
+}
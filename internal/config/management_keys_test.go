@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSanitizeManagementKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []ManagementKey
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "role", keys: []ManagementKey{{Name: "dash", Key: "k1", Role: " Viewer "}}},
+		{name: "scopes without role", keys: []ManagementKey{{Name: "ci", Key: "k1", Scopes: []string{"GET /usage*"}}}},
+		{name: "missing name", keys: []ManagementKey{{Key: "k1", Role: "admin"}}, wantErr: "name is required"},
+		{name: "missing key", keys: []ManagementKey{{Name: "dash", Role: "admin"}}, wantErr: "key is required"},
+		{name: "missing role", keys: []ManagementKey{{Name: "dash", Key: "k1"}}, wantErr: "role or scopes"},
+		{name: "unknown role", keys: []ManagementKey{{Name: "dash", Key: "k1", Role: "owner"}}, wantErr: "invalid"},
+		{
+			name:    "duplicate name",
+			keys:    []ManagementKey{{Name: "dash", Key: "k1", Role: "viewer"}, {Name: " dash", Key: "k2", Role: "admin"}},
+			wantErr: "duplicated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{RemoteManagement: RemoteManagement{Keys: tt.keys}}
+			err := cfg.sanitizeManagementKeys()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSanitizeManagementKeysHashesPlaintext(t *testing.T) {
+	cfg := &Config{RemoteManagement: RemoteManagement{Keys: []ManagementKey{{Name: "dash", Key: "plain-key", Role: "VIEWER"}}}}
+	if err := cfg.sanitizeManagementKeys(); err != nil {
+		t.Fatalf("sanitize: %v", err)
+	}
+	key := cfg.RemoteManagement.Keys[0]
+	if key.Role != ManagementRoleViewer {
+		t.Fatalf("role = %q, want %q", key.Role, ManagementRoleViewer)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(key.Key), []byte("plain-key")) != nil {
+		t.Fatalf("key was not bcrypt hashed: %q", key.Key)
+	}
+	if !cfg.RemoteManagement.HasCredentials() {
+		t.Fatal("HasCredentials() = false with keys configured")
+	}
+
+	hashed := key.Key
+	if err := cfg.sanitizeManagementKeys(); err != nil {
+		t.Fatalf("second sanitize: %v", err)
+	}
+	if cfg.RemoteManagement.Keys[0].Key != hashed {
+		t.Fatal("hashed key was re-hashed")
+	}
+}
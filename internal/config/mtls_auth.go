@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TLSClientAuthRequire rejects TLS handshakes without a client certificate signed by tls.client-ca.
+	TLSClientAuthRequire = "require"
+	// TLSClientAuthVerifyIfGiven verifies client certificates when presented but accepts handshakes without one.
+	TLSClientAuthVerifyIfGiven = "verify-if-given"
+
+	// MTLSPrincipalSourceCN uses the certificate subject common name, falling back to the first SAN.
+	MTLSPrincipalSourceCN = "cn"
+	// MTLSPrincipalSourceSANURI uses the first URI SAN, such as a SPIFFE ID.
+	MTLSPrincipalSourceSANURI = "san-uri"
+	// MTLSPrincipalSourceSANDNS uses the first DNS SAN.
+	MTLSPrincipalSourceSANDNS = "san-dns"
+	// MTLSPrincipalSourceSANEmail uses the first email SAN.
+	MTLSPrincipalSourceSANEmail = "san-email"
+)
+
+// MTLSAuthConfig configures the mtls access provider, which authenticates clients by the
+// certificate they presented during the TLS handshake. It requires tls.client-ca.
+type MTLSAuthConfig struct {
+	// Enable turns the provider on.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// PrincipalSource selects the certificate field used as the principal: "cn" (default),
+	// "san-uri", "san-dns", or "san-email".
+	PrincipalSource string `yaml:"principal-source,omitempty" json:"principal-source,omitempty"`
+
+	// Principals attaches model rules to individual principals.
+	Principals []MTLSPrincipal `yaml:"principals,omitempty" json:"principals,omitempty"`
+
+	// RequireKnownPrincipal rejects certificates whose principal is not listed in Principals.
+	RequireKnownPrincipal bool `yaml:"require-known-principal,omitempty" json:"require-known-principal,omitempty"`
+}
+
+// MTLSPrincipal restricts the models available to one certificate principal.
+type MTLSPrincipal struct {
+	Name            string   `yaml:"name" json:"name"`
+	AllowedModels   []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+	DeniedModels    []string `yaml:"denied-models,omitempty" json:"denied-models,omitempty"`
+	AllowedPrefixes []string `yaml:"allowed-prefixes,omitempty" json:"allowed-prefixes,omitempty"`
+}
+
+// NormalizedPrincipalSource returns the configured principal source or the "cn" default.
+func (cfg MTLSAuthConfig) NormalizedPrincipalSource() string {
+	source := strings.ToLower(strings.TrimSpace(cfg.PrincipalSource))
+	if source == "" {
+		return MTLSPrincipalSourceCN
+	}
+	return source
+}
+
+// NormalizedClientAuth returns the client certificate policy. A client CA without an
+// explicit policy verifies certificates when given.
+func (cfg TLSConfig) NormalizedClientAuth() string {
+	mode := strings.ToLower(strings.TrimSpace(cfg.ClientAuth))
+	if mode == "" && strings.TrimSpace(cfg.ClientCA) != "" {
+		return TLSClientAuthVerifyIfGiven
+	}
+	return mode
+}
+
+// SanitizeMTLSAuth trims mtls-auth values and normalizes principal model lists.
+func (cfg *SDKConfig) SanitizeMTLSAuth() {
+	if cfg == nil {
+		return
+	}
+	for i := range cfg.MTLSAuth.Principals {
+		principal := &cfg.MTLSAuth.Principals[i]
+		principal.Name = strings.TrimSpace(principal.Name)
+		principal.AllowedModels = NormalizeExcludedModels(principal.AllowedModels)
+		principal.DeniedModels = NormalizeExcludedModels(principal.DeniedModels)
+		principal.AllowedPrefixes = normalizeClientKeyPrefixes(principal.AllowedPrefixes)
+	}
+}
+
+// ValidateMTLS verifies tls.client-auth, tls.client-ca, and mtls-auth together.
+func (cfg *Config) ValidateMTLS() error {
+	if cfg == nil {
+		return nil
+	}
+	clientCA := strings.TrimSpace(cfg.TLS.ClientCA)
+	switch mode := cfg.TLS.NormalizedClientAuth(); mode {
+	case "":
+	case TLSClientAuthRequire, TLSClientAuthVerifyIfGiven:
+		if clientCA == "" {
+			return fmt.Errorf("tls.client-auth %q requires tls.client-ca", mode)
+		}
+	default:
+		return fmt.Errorf("tls.client-auth %q is invalid (use %q or %q)", cfg.TLS.ClientAuth, TLSClientAuthRequire, TLSClientAuthVerifyIfGiven)
+	}
+
+	mtls := cfg.MTLSAuth
+	if !mtls.Enable {
+		return nil
+	}
+	if !cfg.TLS.Enable || clientCA == "" {
+		return fmt.Errorf("mtls-auth requires tls.enable and tls.client-ca")
+	}
+	switch source := mtls.NormalizedPrincipalSource(); source {
+	case MTLSPrincipalSourceCN, MTLSPrincipalSourceSANURI, MTLSPrincipalSourceSANDNS, MTLSPrincipalSourceSANEmail:
+	default:
+		return fmt.Errorf("mtls-auth.principal-source %q is invalid", mtls.PrincipalSource)
+	}
+	seen := make(map[string]struct{}, len(mtls.Principals))
+	for i, principal := range mtls.Principals {
+		if principal.Name == "" {
+			return fmt.Errorf("mtls-auth.principals[%d].name is required", i)
+		}
+		if _, exists := seen[principal.Name]; exists {
+			return fmt.Errorf("mtls-auth.principals[%d].name %q is duplicated", i, principal.Name)
+		}
+		seen[principal.Name] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMTLS(t *testing.T) {
+	serverTLS := TLSConfig{Enable: true, Cert: "server.pem", Key: "server.key", ClientCA: "ca.pem"}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "empty"},
+		{
+			name: "valid",
+			cfg: Config{
+				TLS:       TLSConfig{Enable: true, ClientCA: "ca.pem", ClientAuth: "Require"},
+				SDKConfig: SDKConfig{MTLSAuth: MTLSAuthConfig{Enable: true, PrincipalSource: "san-uri", Principals: []MTLSPrincipal{{Name: "svc"}}}},
+			},
+		},
+		{name: "client-auth without ca", cfg: Config{TLS: TLSConfig{ClientAuth: "require"}}, wantErr: "requires tls.client-ca"},
+		{name: "unknown client-auth", cfg: Config{TLS: TLSConfig{ClientCA: "ca.pem", ClientAuth: "optional"}}, wantErr: "tls.client-auth"},
+		{name: "provider without tls", cfg: Config{SDKConfig: SDKConfig{MTLSAuth: MTLSAuthConfig{Enable: true}}}, wantErr: "requires tls.enable"},
+		{
+			name:    "unknown principal source",
+			cfg:     Config{TLS: serverTLS, SDKConfig: SDKConfig{MTLSAuth: MTLSAuthConfig{Enable: true, PrincipalSource: "serial"}}},
+			wantErr: "principal-source",
+		},
+		{
+			name:    "duplicate principal",
+			cfg:     Config{TLS: serverTLS, SDKConfig: SDKConfig{MTLSAuth: MTLSAuthConfig{Enable: true, Principals: []MTLSPrincipal{{Name: "svc"}, {Name: " svc "}}}}},
+			wantErr: "duplicated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.SanitizeMTLSAuth()
+			err := tt.cfg.ValidateMTLS()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateMTLS() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateMTLS() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
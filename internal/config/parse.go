@@ -58,6 +58,9 @@ func ParseConfigBytes(data []byte) (*Config, error) {
 		}
 		cfg.RemoteManagement.SecretKey = string(hashed)
 	}
+	if errKeys := cfg.sanitizeManagementKeys(); errKeys != nil {
+		return nil, errKeys
+	}
 
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
@@ -68,6 +71,8 @@ func ParseConfigBytes(data []byte) (*Config, error) {
 	if cfg.Pprof.Addr == "" {
 		cfg.Pprof.Addr = DefaultPprofAddr
 	}
+	cfg.Metrics.Addr = strings.TrimSpace(cfg.Metrics.Addr)
+	cfg.Metrics.BearerToken = strings.TrimSpace(cfg.Metrics.BearerToken)
 
 	if cfg.LogsMaxTotalSizeMB < 0 {
 		cfg.LogsMaxTotalSizeMB = 0
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultPricingCurrency labels estimated costs when pricing.currency is not set.
+const DefaultPricingCurrency = "USD"
+
+// PricingConfig configures the pricing catalog used to estimate request costs. Rates are
+// per million tokens.
+type PricingConfig struct {
+	// Currency labels estimated costs. Defaults to USD, the currency of the embedded rates.
+	Currency string `yaml:"currency,omitempty" json:"currency,omitempty"`
+
+	// DisableDefaults ignores the embedded rates so only Models are priced.
+	DisableDefaults bool `yaml:"disable-defaults,omitempty" json:"disable-defaults,omitempty"`
+
+	// ServiceTiers multiplies every rate for requests served on a tier, e.g. flex: 0.5.
+	// Entries replace the embedded multipliers of the same tier.
+	ServiceTiers map[string]float64 `yaml:"service-tiers,omitempty" json:"service-tiers,omitempty"`
+
+	// Models adds or overrides rates and always wins over the embedded defaults. An exact
+	// match beats a wildcard, and among wildcards the longest pattern wins.
+	Models []ModelPrice `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// ModelPrice holds the rates of a model or alias, per million tokens.
+type ModelPrice struct {
+	// Match is a model or alias name; a trailing or embedded "*" matches any substring.
+	Match string `yaml:"match" json:"match"`
+
+	Input *float64 `yaml:"input,omitempty" json:"input,omitempty"`
+	// CacheRead defaults to Input.
+	CacheRead *float64 `yaml:"cache-read,omitempty" json:"cache-read,omitempty"`
+	// CacheWrite defaults to Input.
+	CacheWrite *float64 `yaml:"cache-write,omitempty" json:"cache-write,omitempty"`
+	Output     *float64 `yaml:"output,omitempty" json:"output,omitempty"`
+	// Reasoning defaults to Output.
+	Reasoning *float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+
+	// ServiceTiers overrides the global tier multipliers for this entry.
+	ServiceTiers map[string]float64 `yaml:"service-tiers,omitempty" json:"service-tiers,omitempty"`
+}
+
+// NormalizedCurrency returns the configured currency label or USD.
+func (cfg PricingConfig) NormalizedCurrency() string {
+	if currency := strings.TrimSpace(cfg.Currency); currency != "" {
+		return currency
+	}
+	return DefaultPricingCurrency
+}
+
+// Validate verifies pricing.
+func (cfg PricingConfig) Validate() error {
+	if err := validateTierMultipliers("pricing.service-tiers", cfg.ServiceTiers); err != nil {
+		return err
+	}
+	for i, entry := range cfg.Models {
+		path := fmt.Sprintf("pricing.models[%d]", i)
+		if strings.TrimSpace(entry.Match) == "" {
+			return fmt.Errorf("%s.match is required", path)
+		}
+		for _, rate := range []*float64{entry.Input, entry.CacheRead, entry.CacheWrite, entry.Output, entry.Reasoning} {
+			if rate != nil && *rate < 0 {
+				return fmt.Errorf("%s: rates must not be negative", path)
+			}
+		}
+		if err := validateTierMultipliers(path+".service-tiers", entry.ServiceTiers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTierMultipliers(path string, tiers map[string]float64) error {
+	for tier, multiplier := range tiers {
+		if strings.TrimSpace(tier) == "" {
+			return fmt.Errorf("%s: tier name must not be empty", path)
+		}
+		if multiplier < 0 {
+			return fmt.Errorf("%s.%s must not be negative", path, tier)
+		}
+	}
+	return nil
+}
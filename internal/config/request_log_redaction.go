@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Built-in request-log redaction pattern names.
+const (
+	RedactionPatternEmail  = "email"
+	RedactionPatternCard   = "card-number"
+	RedactionPatternAPIKey = "api-key"
+)
+
+// RequestLogRedactionConfig scrubs request logs before they are written to disk or forwarded
+// to Home. Rules apply to the downstream and upstream sections and to websocket timelines.
+type RequestLogRedactionConfig struct {
+	// Headers lists header names whose values are replaced entirely. Matching is
+	// case-insensitive. Authorization and API key headers are always partially masked.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// DropJSONPaths removes fields from JSON bodies, SSE data lines and websocket frames.
+	// Paths are dot separated; "*" matches any object key or array index,
+	// e.g. "messages.*.content".
+	DropJSONPaths []string `yaml:"drop-json-paths,omitempty" json:"drop-json-paths,omitempty"`
+
+	// HashJSONPaths replaces fields with a SHA-256 digest so values stay correlatable
+	// across logs without being readable. Uses the same syntax as DropJSONPaths.
+	HashJSONPaths []string `yaml:"hash-json-paths,omitempty" json:"hash-json-paths,omitempty"`
+
+	// Patterns masks matching text anywhere in the log. Entries are either a built-in name
+	// ("email", "card-number", "api-key") or a regular expression.
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// MaxBase64Length truncates base64 runs, such as inline image payloads, to this many
+	// characters. Only runs of at least 64 characters are considered. 0 disables truncation.
+	MaxBase64Length int `yaml:"max-base64-length,omitempty" json:"max-base64-length,omitempty"`
+}
+
+// IsBuiltinRedactionPattern reports whether name refers to a built-in pattern.
+func IsBuiltinRedactionPattern(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case RedactionPatternEmail, RedactionPatternCard, RedactionPatternAPIKey:
+		return true
+	}
+	return false
+}
+
+// Active reports whether any redaction rule is configured.
+func (cfg RequestLogRedactionConfig) Active() bool {
+	return len(cfg.Headers) > 0 || len(cfg.DropJSONPaths) > 0 || len(cfg.HashJSONPaths) > 0 ||
+		len(cfg.Patterns) > 0 || cfg.MaxBase64Length > 0
+}
+
+// Validate verifies request-log-redaction.
+func (cfg RequestLogRedactionConfig) Validate() error {
+	if cfg.MaxBase64Length < 0 {
+		return fmt.Errorf("request-log-redaction.max-base64-length must not be negative")
+	}
+	for _, path := range append(append([]string(nil), cfg.DropJSONPaths...), cfg.HashJSONPaths...) {
+		path = strings.TrimSpace(path)
+		if path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+			return fmt.Errorf("request-log-redaction: invalid JSON path %q", path)
+		}
+	}
+	for _, pattern := range cfg.Patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("request-log-redaction.patterns must not contain empty entries")
+		}
+		if IsBuiltinRedactionPattern(pattern) {
+			continue
+		}
+		if _, errCompile := regexp.Compile(pattern); errCompile != nil {
+			return fmt.Errorf("request-log-redaction: invalid pattern %q: %w", pattern, errCompile)
+		}
+	}
+	return nil
+}
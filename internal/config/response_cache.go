@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/wildcard"
+)
+
+const (
+	// ResponseCacheBackendMemory keeps cached responses in an in-process LRU.
+	ResponseCacheBackendMemory = "memory"
+	// ResponseCacheBackendDisk stores cached responses as files under response-cache.dir.
+	ResponseCacheBackendDisk = "disk"
+	// ResponseCacheBackendRedis shares cached responses across replicas through Redis.
+	ResponseCacheBackendRedis = "redis"
+
+	// DefaultResponseCacheTTLSeconds is the entry lifetime when response-cache.ttl-seconds is unset.
+	DefaultResponseCacheTTLSeconds = 3600
+	// DefaultResponseCacheMaxEntries caps the memory and disk backends when
+	// response-cache.max-entries is unset.
+	DefaultResponseCacheMaxEntries = 1000
+	// DefaultResponseCacheMaxBytes caps the disk backend when response-cache.max-bytes is unset.
+	DefaultResponseCacheMaxBytes = 256 << 20
+	// DefaultResponseCacheMaxEntryBytes skips caching responses larger than this when
+	// response-cache.max-entry-bytes is unset.
+	DefaultResponseCacheMaxEntryBytes = 1 << 20
+)
+
+// ResponseCacheConfig configures the exact-match response cache. Identical requests from
+// the same client key are answered from the cache instead of the upstream. Only
+// deterministic requests are eligible by default: temperature 0 or top-k 1, a single
+// choice, and no tools. Clients opt a single request in with "X-CPA-Response-Cache: allow"
+// and bypass the cache with "Cache-Control: no-cache" or "no-store".
+type ResponseCacheConfig struct {
+	// Enable turns the cache on. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Backend is "memory" (default), "disk" or "redis".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// TTLSeconds is how long an entry is served. <= 0 uses the default of 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries caps the memory and disk backends. The memory backend evicts the least
+	// recently used entry first; the disk backend evicts the entries that expire soonest.
+	// <= 0 uses the default of 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// MaxBytes caps the total size of the disk backend's files. <= 0 uses the default of 256 MiB.
+	MaxBytes int64 `yaml:"max-bytes,omitempty" json:"max-bytes,omitempty"`
+
+	// MaxEntryBytes skips caching responses larger than this. <= 0 uses the default of 1 MiB.
+	MaxEntryBytes int `yaml:"max-entry-bytes,omitempty" json:"max-entry-bytes,omitempty"`
+
+	// Dir is the directory used by the disk backend.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// RedisURL is the redis:// or rediss:// URL used by the redis backend.
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+
+	// CacheSampled also caches requests that sample or declare tools. Replaying them
+	// returns one fixed answer and repeats its tool calls. Default is false.
+	CacheSampled bool `yaml:"cache-sampled,omitempty" json:"cache-sampled,omitempty"`
+
+	// Models limits caching to these client-requested models. Empty caches every model.
+	Models []ResponseCacheModel `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// ResponseCacheModel enables caching for one model name or pattern.
+type ResponseCacheModel struct {
+	// Name is the client-requested model (case-insensitive). "*" matches any run of characters.
+	Name string `yaml:"name" json:"name"`
+
+	// TTLSeconds overrides response-cache.ttl-seconds for this model when > 0.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+}
+
+// NormalizedBackend returns the configured backend, defaulting to memory.
+func (c ResponseCacheConfig) NormalizedBackend() string {
+	backend := strings.ToLower(strings.TrimSpace(c.Backend))
+	if backend == "" {
+		return ResponseCacheBackendMemory
+	}
+	return backend
+}
+
+// Validate verifies response cache settings.
+func (c ResponseCacheConfig) Validate() error {
+	switch c.NormalizedBackend() {
+	case ResponseCacheBackendMemory:
+	case ResponseCacheBackendDisk:
+		if c.Enable && strings.TrimSpace(c.Dir) == "" {
+			return fmt.Errorf("response-cache.dir is required when response-cache.backend is %q", ResponseCacheBackendDisk)
+		}
+	case ResponseCacheBackendRedis:
+		if c.Enable && strings.TrimSpace(c.RedisURL) == "" {
+			return fmt.Errorf("response-cache.redis-url is required when response-cache.backend is %q", ResponseCacheBackendRedis)
+		}
+	default:
+		return fmt.Errorf("response-cache.backend must be %q, %q or %q", ResponseCacheBackendMemory, ResponseCacheBackendDisk, ResponseCacheBackendRedis)
+	}
+	for i, model := range c.Models {
+		if strings.TrimSpace(model.Name) == "" {
+			return fmt.Errorf("response-cache.models[%d].name is required", i)
+		}
+	}
+	return nil
+}
+
+// TTLFor reports whether responses for model are cached and for how many seconds.
+func (c ResponseCacheConfig) TTLFor(model string) (int, bool) {
+	ttl := c.TTLSeconds
+	if ttl <= 0 {
+		ttl = DefaultResponseCacheTTLSeconds
+	}
+	if !c.Enable {
+		return 0, false
+	}
+	if len(c.Models) == 0 {
+		return ttl, true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, entry := range c.Models {
+		pattern := strings.ToLower(strings.TrimSpace(entry.Name))
+		if !wildcard.Match(pattern, model) {
+			continue
+		}
+		if entry.TTLSeconds > 0 {
+			return entry.TTLSeconds, true
+		}
+		return ttl, true
+	}
+	return 0, false
+}
@@ -48,9 +48,23 @@ type SDKConfig struct {
 	// ClaudeCode configures Claude Code compatibility behavior.
 	ClaudeCode ClaudeCodeConfig `yaml:"claude-code" json:"claude-code"`
 
-	// APIKeys is a list of keys for authenticating clients to this proxy server.
+	// APIKeys is a list of keys for authenticating clients to this proxy server. Entries with
+	// a "sha256:" or "argon2:" prefix are hashes verified against the presented key.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// ClientAPIKeys lists client keys in the extended form with per-key model rules,
+	// expiry, and labels. Keys in APIKeys remain valid and unrestricted.
+	ClientAPIKeys []ClientAPIKey `yaml:"client-api-keys,omitempty" json:"client-api-keys,omitempty"`
+
+	// JWTAuth authenticates clients with bearer JWTs from an SSO / OIDC identity provider.
+	JWTAuth JWTAuthConfig `yaml:"jwt-auth,omitempty" json:"jwt-auth,omitempty"`
+
+	// MTLSAuth authenticates clients by their TLS client certificate.
+	MTLSAuth MTLSAuthConfig `yaml:"mtls-auth,omitempty" json:"mtls-auth,omitempty"`
+
+	// ClientNetwork restricts the source networks of client requests and lists trusted proxies.
+	ClientNetwork ClientNetworkConfig `yaml:"client-network,omitempty" json:"client-network,omitempty"`
+
 	// PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients.
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
@@ -61,6 +75,12 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// Shadow mirrors sampled requests to alternate models for offline output comparison.
+	Shadow ShadowConfig `yaml:"shadow,omitempty" json:"shadow,omitempty"`
+
+	// ResponseCache answers repeated identical requests from a cache instead of the upstream.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
 }
 
 // ClaudeCodeConfig configures Claude Code compatibility behavior.
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestRoutingConfigValidateSessionAffinityStore(t *testing.T) {
+	cases := []struct {
+		name    string
+		routing RoutingConfig
+		wantErr bool
+	}{
+		{name: "unset", routing: RoutingConfig{}},
+		{name: "memory", routing: RoutingConfig{SessionAffinityStore: "memory"}},
+		{name: "postgres", routing: RoutingConfig{SessionAffinityStore: " Postgres "}},
+		{name: "redis", routing: RoutingConfig{SessionAffinityStore: "redis", SessionAffinityRedisURL: "redis://localhost:6379/0"}},
+		{name: "redis without url", routing: RoutingConfig{SessionAffinityStore: "redis"}, wantErr: true},
+		{name: "unknown", routing: RoutingConfig{SessionAffinityStore: "etcd"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errValidate := tc.routing.ValidateSessionAffinityStore()
+			if (errValidate != nil) != tc.wantErr {
+				t.Fatalf("ValidateSessionAffinityStore() error = %v, wantErr %v", errValidate, tc.wantErr)
+			}
+		})
+	}
+}
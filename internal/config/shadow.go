@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultShadowLogFile is the comparison log file name used when shadow.log-file is empty.
+	DefaultShadowLogFile = "shadow-comparison.jsonl"
+	// DefaultShadowMaxConcurrency caps in-flight shadow executions when shadow.max-concurrency is unset.
+	DefaultShadowMaxConcurrency = 4
+	// DefaultShadowTimeoutSeconds bounds a single shadow execution when shadow.timeout-seconds is unset.
+	DefaultShadowTimeoutSeconds = 300
+	// DefaultShadowMaxBodyBytes caps each response body stored in a comparison record.
+	DefaultShadowMaxBodyBytes = 64 * 1024
+)
+
+// ShadowConfig mirrors sampled live requests to alternate models for offline comparison.
+// Shadow executions run asynchronously and never change the primary response.
+type ShadowConfig struct {
+	// LogFile is the JSONL comparison log path. Relative paths resolve under the logs directory.
+	// Default is "shadow-comparison.jsonl".
+	LogFile string `yaml:"log-file,omitempty" json:"log-file,omitempty"`
+
+	// MaxConcurrency caps in-flight shadow executions; sampled requests beyond the cap are skipped.
+	// <= 0 uses the default of 4.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// TimeoutSeconds bounds each shadow execution. <= 0 uses the default of 300.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+
+	// MaxBodyBytes caps the primary and shadow response bodies stored per record.
+	// <= 0 uses the default of 65536.
+	MaxBodyBytes int `yaml:"max-body-bytes,omitempty" json:"max-body-bytes,omitempty"`
+
+	// Rules lists the source models to mirror. The first matching rule wins.
+	Rules []ShadowRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// ShadowRule mirrors requests for one source model to a shadow model.
+type ShadowRule struct {
+	// SourceModel is the client-requested model name to mirror (case-insensitive).
+	SourceModel string `yaml:"source-model" json:"source-model"`
+
+	// ShadowModel is the model executed in the background for comparison.
+	ShadowModel string `yaml:"shadow-model" json:"shadow-model"`
+
+	// SampleRate is the fraction of matching requests mirrored, between 0 and 1.
+	SampleRate float64 `yaml:"sample-rate" json:"sample-rate"`
+
+	// ClientKeys optionally restricts mirroring to these callers. Each entry matches the
+	// authenticated principal, which is the plaintext key for plain api-keys, or the name
+	// of a client-api-keys entry, which also covers hashed keys.
+	ClientKeys []string `yaml:"client-keys,omitempty" json:"client-keys,omitempty"`
+}
+
+// Enabled reports whether at least one shadow rule is configured.
+func (c ShadowConfig) Enabled() bool {
+	return len(c.Rules) > 0
+}
+
+// Validate verifies shadow rules.
+func (c ShadowConfig) Validate() error {
+	for i, rule := range c.Rules {
+		if strings.TrimSpace(rule.SourceModel) == "" {
+			return fmt.Errorf("shadow.rules[%d].source-model is required", i)
+		}
+		if strings.TrimSpace(rule.ShadowModel) == "" {
+			return fmt.Errorf("shadow.rules[%d].shadow-model is required", i)
+		}
+		if rule.SampleRate < 0 || rule.SampleRate > 1 {
+			return fmt.Errorf("shadow.rules[%d].sample-rate must be between 0 and 1", i)
+		}
+	}
+	return nil
+}
+
+// SanitizeShadow trims shadow rule fields and drops rules that can never fire.
+func (cfg *Config) SanitizeShadow() {
+	if cfg == nil {
+		return
+	}
+	cfg.Shadow.LogFile = strings.TrimSpace(cfg.Shadow.LogFile)
+	if len(cfg.Shadow.Rules) == 0 {
+		return
+	}
+	rules := make([]ShadowRule, 0, len(cfg.Shadow.Rules))
+	for _, rule := range cfg.Shadow.Rules {
+		rule.SourceModel = strings.TrimSpace(rule.SourceModel)
+		rule.ShadowModel = strings.TrimSpace(rule.ShadowModel)
+		if rule.SampleRate <= 0 || strings.EqualFold(rule.SourceModel, rule.ShadowModel) {
+			continue
+		}
+		keys := make([]string, 0, len(rule.ClientKeys))
+		for _, key := range rule.ClientKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		rule.ClientKeys = keys
+		rules = append(rules, rule)
+	}
+	cfg.Shadow.Rules = rules
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigShadowRules(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte(`
+shadow:
+  log-file: " compare.jsonl "
+  rules:
+    - source-model: " gpt-5 "
+      shadow-model: "claude-sonnet-4-5"
+      sample-rate: 0.1
+      client-keys: [" key-a ", ""]
+    - source-model: "gpt-5-mini"
+      shadow-model: "gpt-5-mini"
+      sample-rate: 1
+    - source-model: "gemini-2.5-pro"
+      shadow-model: "gemini-3-pro-preview"
+      sample-rate: 0
+`)
+	if errWrite := os.WriteFile(configPath, data, 0o600); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	cfg, errLoad := LoadConfig(configPath)
+	if errLoad != nil {
+		t.Fatalf("LoadConfig() error = %v", errLoad)
+	}
+	if cfg.Shadow.LogFile != "compare.jsonl" {
+		t.Fatalf("log-file = %q, want compare.jsonl", cfg.Shadow.LogFile)
+	}
+	if len(cfg.Shadow.Rules) != 1 {
+		t.Fatalf("rules = %+v, want only the gpt-5 rule", cfg.Shadow.Rules)
+	}
+	rule := cfg.Shadow.Rules[0]
+	if rule.SourceModel != "gpt-5" || len(rule.ClientKeys) != 1 || rule.ClientKeys[0] != "key-a" {
+		t.Fatalf("rule = %+v", rule)
+	}
+}
+
+func TestShadowConfigValidateRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ShadowRule
+		want string
+	}{
+		{name: "missing source", rule: ShadowRule{ShadowModel: "b", SampleRate: 1}, want: "source-model"},
+		{name: "missing shadow", rule: ShadowRule{SourceModel: "a", SampleRate: 1}, want: "shadow-model"},
+		{name: "rate above one", rule: ShadowRule{SourceModel: "a", ShadowModel: "b", SampleRate: 1.5}, want: "sample-rate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errValidate := ShadowConfig{Rules: []ShadowRule{tt.rule}}.Validate()
+			if errValidate == nil || !strings.Contains(errValidate.Error(), tt.want) {
+				t.Fatalf("Validate() error = %v, want mention of %s", errValidate, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultTracingEndpoint is the OTLP/HTTP traces endpoint of a local collector.
+const DefaultTracingEndpoint = "http://127.0.0.1:4318/v1/traces"
+
+// TracingConfig configures OpenTelemetry tracing with an OTLP/HTTP exporter.
+type TracingConfig struct {
+	// Enable turns tracing on.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Endpoint is the OTLP/HTTP traces URL. Defaults to DefaultTracingEndpoint.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+
+	// Headers are sent with every export request, e.g. collector authentication.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `yaml:"service-name,omitempty" json:"service-name,omitempty"`
+
+	// SampleRatio is the fraction of new traces sampled, in (0, 1]. Zero samples every
+	// trace. Incoming traceparent sampling decisions are always honoured.
+	SampleRatio float64 `yaml:"sample-ratio,omitempty" json:"sample-ratio,omitempty"`
+
+	// PropagateUpstream lists the providers whose upstream requests carry traceparent.
+	// "*" propagates to every provider. Empty propagates to none.
+	PropagateUpstream []string `yaml:"propagate-upstream,omitempty" json:"propagate-upstream,omitempty"`
+}
+
+// NormalizedEndpoint returns the configured endpoint or the local collector default.
+func (cfg TracingConfig) NormalizedEndpoint() string {
+	if endpoint := strings.TrimSpace(cfg.Endpoint); endpoint != "" {
+		return endpoint
+	}
+	return DefaultTracingEndpoint
+}
+
+// NormalizedServiceName returns the configured service name or "cli-proxy-api".
+func (cfg TracingConfig) NormalizedServiceName() string {
+	if name := strings.TrimSpace(cfg.ServiceName); name != "" {
+		return name
+	}
+	return "cli-proxy-api"
+}
+
+// Validate rejects malformed endpoints and sample ratios.
+func (cfg TracingConfig) Validate() error {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample-ratio must be between 0 and 1")
+	}
+	if !cfg.Enable {
+		return nil
+	}
+	parsed, errParse := url.Parse(cfg.NormalizedEndpoint())
+	if errParse != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("tracing.endpoint must be an http(s) URL")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// UsageLedgerDriverSQLite stores the usage ledger in a local SQLite file.
+	UsageLedgerDriverSQLite = "sqlite"
+	// UsageLedgerDriverPostgres stores the usage ledger in PostgreSQL.
+	UsageLedgerDriverPostgres = "postgres"
+
+	// DefaultUsageLedgerFileName is the SQLite file created in the auth directory when
+	// usage-ledger.path is not set.
+	DefaultUsageLedgerFileName = "usage-ledger.db"
+	// DefaultUsageLedgerDownsampleAfterDays is the age at which records are rolled up
+	// into hourly buckets.
+	DefaultUsageLedgerDownsampleAfterDays = 30
+)
+
+// UsageLedgerConfig configures the durable usage ledger.
+type UsageLedgerConfig struct {
+	// Enable stores every usage record.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Driver is "sqlite" (default) or "postgres".
+	Driver string `yaml:"driver,omitempty" json:"driver,omitempty"`
+
+	// Path is the SQLite file. Defaults to usage-ledger.db in the auth directory.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// DSN is the PostgreSQL connection string. Defaults to the PGSTORE_DSN environment
+	// variable used by the Postgres token store.
+	DSN string `yaml:"dsn,omitempty" json:"dsn,omitempty"`
+
+	// DownsampleAfterDays rolls records older than this many days into hourly buckets.
+	// Defaults to 30.
+	DownsampleAfterDays int `yaml:"downsample-after-days,omitempty" json:"downsample-after-days,omitempty"`
+
+	// RetentionDays deletes records and hourly buckets older than this many days.
+	// 0 keeps them forever.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// NormalizedDriver returns the configured driver, defaulting to sqlite.
+func (cfg UsageLedgerConfig) NormalizedDriver() string {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	if driver == "" {
+		return UsageLedgerDriverSQLite
+	}
+	return driver
+}
+
+// NormalizedDownsampleAfterDays returns the downsampling age, defaulting to 30 days.
+func (cfg UsageLedgerConfig) NormalizedDownsampleAfterDays() int {
+	if cfg.DownsampleAfterDays <= 0 {
+		return DefaultUsageLedgerDownsampleAfterDays
+	}
+	return cfg.DownsampleAfterDays
+}
+
+// Validate verifies usage-ledger.
+func (cfg UsageLedgerConfig) Validate() error {
+	switch cfg.NormalizedDriver() {
+	case UsageLedgerDriverSQLite, UsageLedgerDriverPostgres:
+	default:
+		return fmt.Errorf("usage-ledger.driver must be sqlite or postgres")
+	}
+	if cfg.DownsampleAfterDays < 0 {
+		return fmt.Errorf("usage-ledger.downsample-after-days must not be negative")
+	}
+	if cfg.RetentionDays < 0 {
+		return fmt.Errorf("usage-ledger.retention-days must not be negative")
+	}
+	if cfg.RetentionDays > 0 && cfg.RetentionDays <= cfg.NormalizedDownsampleAfterDays() {
+		return fmt.Errorf("usage-ledger.retention-days must exceed downsample-after-days")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Package contextwindow estimates request prompt sizes so oversized requests can be
+// rejected before they are sent to an upstream with a smaller context window.
+package contextwindow
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// skippedKeys hold opaque or binary values that are not tokenized as prompt text.
+var skippedKeys = map[string]struct{}{
+	"model":             {},
+	"data":              {},
+	"b64_json":          {},
+	"image_url":         {},
+	"file_data":         {},
+	"signature":         {},
+	"thoughtSignature":  {},
+	"encrypted_content": {},
+}
+
+// Estimate lazily measures the prompt text of a request payload. It is safe for
+// concurrent use; the tokenizer only runs when a byte-length bound cannot decide.
+type Estimate struct {
+	text string
+
+	once   sync.Once
+	tokens int64
+}
+
+// NewEstimate collects the prompt text from a JSON request payload in any supported
+// protocol. Base64 media, signatures, and the model field are ignored, so the estimate
+// errs on the small side and never rejects a request that would fit.
+func NewEstimate(payload []byte) *Estimate {
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return &Estimate{}
+	}
+	var builder strings.Builder
+	collectText(gjson.ParseBytes(payload), &builder)
+	return &Estimate{text: builder.String()}
+}
+
+// Tokens returns the estimated prompt token count.
+func (e *Estimate) Tokens() int64 {
+	if e == nil || e.text == "" {
+		return 0
+	}
+	e.once.Do(func() {
+		enc, errEnc := tokenizer.Get(tokenizer.O200kBase)
+		if errEnc != nil {
+			// Roughly four bytes per token for English text.
+			e.tokens = int64(len(e.text) / 4)
+			return
+		}
+		count, errCount := enc.Count(e.text)
+		if errCount != nil {
+			e.tokens = int64(len(e.text) / 4)
+			return
+		}
+		e.tokens = int64(count)
+	})
+	return e.tokens
+}
+
+// Fits reports whether the prompt fits a context window of limit tokens. A
+// non-positive limit means the window is unknown and always fits.
+func (e *Estimate) Fits(limit int) bool {
+	if e == nil || limit <= 0 {
+		return true
+	}
+	// A token is never shorter than one byte, so short prompts need no tokenizer.
+	if len(e.text) <= limit {
+		return true
+	}
+	return e.Tokens() <= int64(limit)
+}
+
+func collectText(value gjson.Result, builder *strings.Builder) {
+	switch {
+	case value.IsObject():
+		value.ForEach(func(key, item gjson.Result) bool {
+			if _, skip := skippedKeys[key.String()]; skip {
+				return true
+			}
+			collectText(item, builder)
+			return true
+		})
+	case value.IsArray():
+		value.ForEach(func(_, item gjson.Result) bool {
+			collectText(item, builder)
+			return true
+		})
+	case value.Type == gjson.String:
+		text := value.String()
+		if text == "" || strings.HasPrefix(text, "data:") {
+			return
+		}
+		if builder.Len() > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteString(text)
+	}
+}
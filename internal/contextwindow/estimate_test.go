@@ -0,0 +1,39 @@
+package contextwindow
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateIgnoresMediaAndModel(t *testing.T) {
+	image := strings.Repeat("A", 4096)
+	payload := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":[{"type":"text","text":"describe this"},{"type":"image_url","image_url":{"url":"data:image/png;base64,` + image + `"}}]}]}`)
+	estimate := NewEstimate(payload)
+	if tokens := estimate.Tokens(); tokens <= 0 || tokens > 20 {
+		t.Fatalf("Tokens() = %d, want a small text-only count", tokens)
+	}
+}
+
+func TestEstimateFits(t *testing.T) {
+	payload := []byte(`{"contents":[{"role":"user","parts":[{"text":"` + strings.Repeat("lorem ipsum ", 400) + `"}]}]}`)
+	estimate := NewEstimate(payload)
+	if !estimate.Fits(0) {
+		t.Fatal("Fits(0) = false, want unknown window to fit")
+	}
+	if !estimate.Fits(100000) {
+		t.Fatal("Fits(100000) = false")
+	}
+	if estimate.Fits(100) {
+		t.Fatalf("Fits(100) = true with %d tokens", estimate.Tokens())
+	}
+}
+
+func TestEstimateInvalidPayload(t *testing.T) {
+	if tokens := NewEstimate([]byte("not json")).Tokens(); tokens != 0 {
+		t.Fatalf("Tokens() = %d, want 0", tokens)
+	}
+	var estimate *Estimate
+	if !estimate.Fits(1) {
+		t.Fatal("nil estimate should fit")
+	}
+}
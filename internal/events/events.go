@@ -0,0 +1,181 @@
+// Package events fans out structured activity events to live subscribers such as the
+// management event stream.
+//
+// Publishing is cheap when nobody listens and never blocks: a subscriber that falls behind
+// loses events and is told how many it missed.
+package events
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types.
+const (
+	TypeRequestStarted     = "request.started"
+	TypeRequestFinished    = "request.finished"
+	TypeCredentialSelected = "credential.selected"
+	TypeRetryRound         = "retry.round"
+	TypeCooldownEntered    = "cooldown.entered"
+	TypeCooldownCleared    = "cooldown.cleared"
+	TypeRefreshResult      = "refresh.result"
+	TypeProbeResult        = "probe.result"
+	TypePluginError        = "plugin.error"
+	TypeModelExhausted     = "model.exhausted"
+	TypeBudgetThreshold    = "budget.threshold"
+	TypeConfigReloadFailed = "config.reload_failed"
+)
+
+// DefaultBufferSize is the per-subscriber queue length used when none is given.
+const DefaultBufferSize = 256
+
+// Event is one activity event. Fields that do not apply to a type are left empty.
+type Event struct {
+	Seq        uint64     `json:"seq"`
+	Time       time.Time  `json:"time"`
+	Type       string     `json:"type"`
+	RequestID  string     `json:"request_id,omitempty"`
+	Principal  string     `json:"principal,omitempty"`
+	Method     string     `json:"method,omitempty"`
+	Path       string     `json:"path,omitempty"`
+	Model      string     `json:"model,omitempty"`
+	Provider   string     `json:"provider,omitempty"`
+	AuthID     string     `json:"auth_id,omitempty"`
+	AuthIndex  string     `json:"auth_index,omitempty"`
+	PluginID   string     `json:"plugin_id,omitempty"`
+	Stream     bool       `json:"stream,omitempty"`
+	Round      int        `json:"round,omitempty"`
+	Status     int        `json:"status,omitempty"`
+	DurationMs int64      `json:"duration_ms,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	Success    *bool      `json:"success,omitempty"`
+	Message    string     `json:"message,omitempty"`
+}
+
+// Filter selects events. Each non-empty list must contain the event's value; values are
+// compared case-insensitively.
+type Filter struct {
+	Types      []string
+	Models     []string
+	Providers  []string
+	Principals []string
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Event) bool {
+	return matchAny(f.Types, e.Type) &&
+		matchAny(f.Models, e.Model) &&
+		matchAny(f.Providers, e.Provider) &&
+		matchAny(f.Principals, e.Principal)
+}
+
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus delivers published events to its subscribers.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+	n    atomic.Int32
+	seq  atomic.Uint64
+}
+
+// NewBus creates a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+var defaultBus = NewBus()
+
+// Default returns the process-wide bus.
+func Default() *Bus {
+	return defaultBus
+}
+
+// Active reports whether anyone is subscribed.
+func (b *Bus) Active() bool {
+	return b != nil && b.n.Load() > 0
+}
+
+// Publish stamps e with a sequence number and time and delivers it to every matching
+// subscriber without blocking.
+func (b *Bus) Publish(e Event) {
+	if !b.Active() {
+		return
+	}
+	e.Seq = b.seq.Add(1)
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for events matching filter. buffer <= 0 uses
+// DefaultBufferSize. Callers must Close the subscription.
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBufferSize
+	}
+	sub := &Subscription{bus: b, filter: filter, ch: make(chan Event, buffer)}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.n.Store(int32(len(b.subs)))
+	b.mu.Unlock()
+	return sub
+}
+
+// Subscription is one registered listener.
+type Subscription struct {
+	bus     *Bus
+	filter  Filter
+	ch      chan Event
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Events returns the delivery channel. It is closed by Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped returns how many events were discarded because the subscriber fell behind.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unregisters the subscription and closes its channel.
+func (s *Subscription) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		b := s.bus
+		b.mu.Lock()
+		delete(b.subs, s)
+		b.n.Store(int32(len(b.subs)))
+		b.mu.Unlock()
+		close(s.ch)
+	})
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBusFiltersAndDropsWithoutBlocking(t *testing.T) {
+	bus := NewBus()
+	bus.Publish(Event{Type: TypeRetryRound})
+
+	sub := bus.Subscribe(Filter{Types: []string{TypeCredentialSelected}, Providers: []string{"Claude"}}, 1)
+	defer sub.Close()
+	if !bus.Active() {
+		t.Fatal("expected bus to be active with a subscriber")
+	}
+
+	bus.Publish(Event{Type: TypeCredentialSelected, Provider: "gemini"})
+	bus.Publish(Event{Type: TypeRetryRound, Provider: "claude"})
+	bus.Publish(Event{Type: TypeCredentialSelected, Provider: "claude", AuthIndex: "1"})
+	bus.Publish(Event{Type: TypeCredentialSelected, Provider: "claude", AuthIndex: "2"})
+
+	got := <-sub.Events()
+	if got.AuthIndex != "1" || got.Seq == 0 || got.Time.IsZero() {
+		t.Fatalf("unexpected event: %+v", got)
+	}
+	if dropped := sub.Dropped(); dropped != 1 {
+		t.Fatalf("Dropped() = %d, want 1", dropped)
+	}
+
+	sub.Close()
+	if bus.Active() {
+		t.Fatal("expected bus to be inactive after Close")
+	}
+	if _, open := <-sub.Events(); open {
+		t.Fatal("expected channel to be closed")
+	}
+}
+
+func TestPublishAttributesEventsToRequest(t *testing.T) {
+	sub := Default().Subscribe(Filter{Principals: []string{"alice"}}, 8)
+	defer sub.Close()
+
+	principal := ""
+	ctx := WithRequest(context.Background(), "req-1", "POST", "/v1/chat/completions", func() string { return principal })
+	execCtx := ContextWithRequest(context.Background(), ctx)
+	principal = "alice"
+
+	RequestStarted(execCtx, "gpt-4o", true)
+	RequestStarted(execCtx, "gpt-4o", true)
+	Publish(execCtx, Event{Type: TypeCredentialSelected, Provider: "codex", AuthIndex: "7", Model: "gpt-4o-upstream"})
+	RequestFinished(ctx, 200)
+	Publish(context.Background(), Event{Type: TypePluginError, PluginID: "p"})
+
+	want := []string{TypeRequestStarted, TypeCredentialSelected, TypeRequestFinished}
+	for _, typ := range want {
+		got := <-sub.Events()
+		if got.Type != typ || got.RequestID != "req-1" || got.Principal != "alice" {
+			t.Fatalf("unexpected event, want %s: %+v", typ, got)
+		}
+		switch typ {
+		case TypeRequestStarted:
+			if got.Model != "gpt-4o" || !got.Stream || got.Path != "/v1/chat/completions" {
+				t.Fatalf("unexpected start event: %+v", got)
+			}
+		case TypeRequestFinished:
+			if got.Model != "gpt-4o" || got.Provider != "codex" || got.AuthIndex != "7" || got.Status != 200 {
+				t.Fatalf("unexpected finish event: %+v", got)
+			}
+		}
+	}
+	select {
+	case extra := <-sub.Events():
+		t.Fatalf("unexpected extra event: %+v", extra)
+	default:
+	}
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type scopeKey struct{}
+
+// scope carries the request identity to events published during execution.
+type scope struct {
+	requestID string
+	method    string
+	path      string
+	started   time.Time
+	resolve   func() string
+
+	mu        sync.Mutex
+	principal string
+	announced bool
+	model     string
+	provider  string
+	authIndex string
+}
+
+// WithRequest returns a context whose events are attributed to one client request.
+// principal is resolved lazily because client authentication runs after the context is
+// created.
+func WithRequest(ctx context.Context, requestID, method, path string, principal func() string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, scopeKey{}, &scope{
+		requestID: requestID,
+		method:    method,
+		path:      path,
+		started:   time.Now(),
+		resolve:   principal,
+	})
+}
+
+// ContextWithRequest makes parent publish events for the request of source, for execution
+// contexts that are not derived from the request context.
+func ContextWithRequest(parent, source context.Context) context.Context {
+	if parent == nil || source == nil {
+		return parent
+	}
+	s := scopeFrom(source)
+	if s == nil || scopeFrom(parent) == s {
+		return parent
+	}
+	return context.WithValue(parent, scopeKey{}, s)
+}
+
+func scopeFrom(ctx context.Context) *scope {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(scopeKey{}).(*scope)
+	return s
+}
+
+func (s *scope) principalLocked() string {
+	if s.principal == "" && s.resolve != nil {
+		s.principal = strings.TrimSpace(s.resolve())
+	}
+	return s.principal
+}
+
+// fill copies the request identity into e and tracks the credential chosen for it.
+func (s *scope) fill(e *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.Type == TypeCredentialSelected {
+		s.provider, s.authIndex = e.Provider, e.AuthIndex
+	}
+	if e.RequestID == "" {
+		e.RequestID = s.requestID
+	}
+	if e.Principal == "" {
+		e.Principal = s.principalLocked()
+	}
+	if e.Model == "" {
+		e.Model = s.model
+	}
+}
+
+// Publish sends e on the default bus, attributed to the request carried by ctx.
+func Publish(ctx context.Context, e Event) {
+	s := scopeFrom(ctx)
+	if !defaultBus.Active() {
+		if s != nil && e.Type == TypeCredentialSelected {
+			s.fill(&e)
+		}
+		return
+	}
+	if s != nil {
+		s.fill(&e)
+	}
+	defaultBus.Publish(e)
+}
+
+// RequestStarted publishes request.started once the requested model is known. Later
+// executions of the same request are ignored.
+func RequestStarted(ctx context.Context, model string, stream bool) {
+	s := scopeFrom(ctx)
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.announced {
+		s.mu.Unlock()
+		return
+	}
+	s.announced = true
+	s.model = strings.TrimSpace(model)
+	s.mu.Unlock()
+	Publish(ctx, Event{Type: TypeRequestStarted, Method: s.method, Path: s.path, Stream: stream})
+}
+
+// RequestFinished publishes request.finished with the response status and the provider
+// and credential of the last attempt.
+func RequestFinished(ctx context.Context, status int) {
+	s := scopeFrom(ctx)
+	if s == nil || !defaultBus.Active() {
+		return
+	}
+	s.mu.Lock()
+	e := Event{
+		Type:       TypeRequestFinished,
+		Method:     s.method,
+		Path:       s.path,
+		Provider:   s.provider,
+		AuthIndex:  s.authIndex,
+		Status:     status,
+		DurationMs: time.Since(s.started).Milliseconds(),
+	}
+	s.mu.Unlock()
+	Publish(ctx, e)
+}
@@ -0,0 +1,54 @@
+package home
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const circuitBreakerReportTimeout = 5 * time.Second
+
+// CircuitBreakerReport describes an upstream endpoint circuit breaker state transition.
+type CircuitBreakerReport struct {
+	NodeID        string     `json:"node_id"`
+	Endpoint      string     `json:"endpoint"`
+	State         string     `json:"state"`
+	PreviousState string     `json:"previous_state,omitempty"`
+	Requests      int        `json:"requests"`
+	Failures      int        `json:"failures"`
+	ErrorRate     float64    `json:"error_rate"`
+	LastError     string     `json:"last_error,omitempty"`
+	OpenedAt      *time.Time `json:"opened_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// CircuitBreakerClient defines the interface for pushing circuit breaker reports.
+type CircuitBreakerClient interface {
+	RPushCircuitBreaker(ctx context.Context, payload []byte) error
+}
+
+// ReportCircuitBreaker marshals the given report, sets NodeID and UpdatedAt,
+// and pushes it to the provided client with a timeout.
+func ReportCircuitBreaker(ctx context.Context, client CircuitBreakerClient, nodeID string, report CircuitBreakerReport) error {
+	if client == nil {
+		return fmt.Errorf("home circuit breaker client is unavailable")
+	}
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return fmt.Errorf("home circuit breaker node id is empty")
+	}
+	report.NodeID = nodeID
+	report.UpdatedAt = time.Now().UTC()
+	raw, errMarshal := json.Marshal(report)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	reportCtx, cancel := context.WithTimeout(ctx, circuitBreakerReportTimeout)
+	defer cancel()
+	return client.RPushCircuitBreaker(reportCtx, raw)
+}
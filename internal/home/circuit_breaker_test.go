@@ -0,0 +1,45 @@
+package home
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type recordingCircuitBreakerClient struct {
+	payload []byte
+}
+
+func (c *recordingCircuitBreakerClient) RPushCircuitBreaker(ctx context.Context, payload []byte) error {
+	c.payload = append([]byte(nil), payload...)
+	return nil
+}
+
+func TestReportCircuitBreakerPushesNodeReport(t *testing.T) {
+	client := &recordingCircuitBreakerClient{}
+	report := CircuitBreakerReport{Endpoint: "https://api.example.com", State: "open", PreviousState: "closed", Requests: 10, Failures: 6, ErrorRate: 0.6}
+
+	if errReport := ReportCircuitBreaker(context.Background(), client, " node-1 ", report); errReport != nil {
+		t.Fatalf("ReportCircuitBreaker() error = %v", errReport)
+	}
+	var payload CircuitBreakerReport
+	if errUnmarshal := json.Unmarshal(client.payload, &payload); errUnmarshal != nil {
+		t.Fatalf("unmarshal payload: %v", errUnmarshal)
+	}
+	if payload.NodeID != "node-1" || payload.State != "open" || payload.Failures != 6 {
+		t.Fatalf("payload = %+v, want node report", payload)
+	}
+	if payload.UpdatedAt.IsZero() {
+		t.Fatal("payload UpdatedAt is zero")
+	}
+}
+
+func TestReportCircuitBreakerRequiresNodeID(t *testing.T) {
+	client := &recordingCircuitBreakerClient{}
+	if errReport := ReportCircuitBreaker(context.Background(), client, " ", CircuitBreakerReport{}); errReport == nil {
+		t.Fatal("ReportCircuitBreaker() error = nil, want missing node id error")
+	}
+	if client.payload != nil {
+		t.Fatalf("payload = %s, want nothing pushed", client.payload)
+	}
+}
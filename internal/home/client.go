@@ -37,6 +37,7 @@ const (
 	redisKeyPluginStatus       = "plugin-status"
 	redisKeyPluginTasks        = "plugin-tasks"
 	redisKeyPluginSync         = "plugin-sync"
+	redisKeyCircuitBreaker     = "circuit-breaker"
 
 	homeReconnectInterval                     = time.Second
 	homeReconnectFailoverThreshold            = 3
@@ -2008,3 +2009,14 @@ func sleepWithContext(ctx context.Context, d time.Duration) {
 		return
 	}
 }
+
+func (c *Client) RPushCircuitBreaker(ctx context.Context, payload []byte) error {
+	cmd, errClient := c.commandClient()
+	if errClient != nil {
+		return errClient
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	return cmd.RPush(ctx, redisKeyCircuitBreaker, payload).Err()
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type accessInfoKey struct{}
+
+// AccessInfo collects the execution details of one client request for the access log.
+type AccessInfo struct {
+	RequestedModel  string
+	ResolvedModel   string
+	Provider        string
+	AuthIndex       string
+	Attempts        int
+	InputTokens     int64
+	OutputTokens    int64
+	ReasoningTokens int64
+	CachedTokens    int64
+	TotalTokens     int64
+}
+
+type accessInfoHolder struct {
+	mu   sync.Mutex
+	info AccessInfo
+}
+
+// WithAccessInfo returns a context collecting AccessInfo for the request.
+func WithAccessInfo(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if holder, ok := ctx.Value(accessInfoKey{}).(*accessInfoHolder); ok && holder != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, accessInfoKey{}, &accessInfoHolder{})
+}
+
+// ContextWithAccessInfo makes parent collect into the AccessInfo of source, for execution
+// contexts that are not derived from the request context.
+func ContextWithAccessInfo(parent, source context.Context) context.Context {
+	if parent == nil || source == nil {
+		return parent
+	}
+	holder, ok := source.Value(accessInfoKey{}).(*accessInfoHolder)
+	if !ok || holder == nil {
+		return parent
+	}
+	if existing, _ := parent.Value(accessInfoKey{}).(*accessInfoHolder); existing == holder {
+		return parent
+	}
+	return context.WithValue(parent, accessInfoKey{}, holder)
+}
+
+func accessInfoFrom(ctx context.Context) *accessInfoHolder {
+	if ctx == nil {
+		return nil
+	}
+	holder, _ := ctx.Value(accessInfoKey{}).(*accessInfoHolder)
+	return holder
+}
+
+// SetAccessRequestedModel records the model named by the client. The first value wins so
+// nested executions do not replace it.
+func SetAccessRequestedModel(ctx context.Context, model string) {
+	holder := accessInfoFrom(ctx)
+	model = strings.TrimSpace(model)
+	if holder == nil || model == "" {
+		return
+	}
+	holder.mu.Lock()
+	if holder.info.RequestedModel == "" {
+		holder.info.RequestedModel = model
+	}
+	holder.mu.Unlock()
+}
+
+// RecordAccessAttempt records an upstream attempt; the last attempt names the resolved
+// model, provider and credential.
+func RecordAccessAttempt(ctx context.Context, provider, authIndex, model string) {
+	holder := accessInfoFrom(ctx)
+	if holder == nil {
+		return
+	}
+	holder.mu.Lock()
+	holder.info.Attempts++
+	holder.info.Provider = strings.TrimSpace(provider)
+	holder.info.AuthIndex = strings.TrimSpace(authIndex)
+	holder.info.ResolvedModel = strings.TrimSpace(model)
+	holder.mu.Unlock()
+}
+
+// AddAccessTokens adds the token counts of a usage record to the request.
+func AddAccessTokens(ctx context.Context, input, output, reasoning, cached, total int64) {
+	holder := accessInfoFrom(ctx)
+	if holder == nil {
+		return
+	}
+	holder.mu.Lock()
+	holder.info.InputTokens += input
+	holder.info.OutputTokens += output
+	holder.info.ReasoningTokens += reasoning
+	holder.info.CachedTokens += cached
+	holder.info.TotalTokens += total
+	holder.mu.Unlock()
+}
+
+// GetAccessInfo returns the details collected so far.
+func GetAccessInfo(ctx context.Context) (AccessInfo, bool) {
+	holder := accessInfoFrom(ctx)
+	if holder == nil {
+		return AccessInfo{}, false
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return holder.info, true
+}
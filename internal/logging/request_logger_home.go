@@ -56,6 +56,10 @@ func (l *FileRequestLogger) forwardRequestLogToHome(ctx context.Context, headers
 	if client == nil || !client.HeartbeatOK() {
 		return nil
 	}
+	if redactor := currentRequestLogRedactor(); redactor != nil {
+		headers = redactor.Headers(headers)
+		logText = string(redactor.Text([]byte(logText)))
+	}
 	payload := homeRequestLogPayload{
 		Headers:    cloneHeaders(headers),
 		RequestID:  strings.TrimSpace(requestID),
@@ -233,10 +237,16 @@ func (w *homeStreamingLogWriter) Close() error {
 		return errWrite
 	}
 
+	requestHeaders := w.requestHeaders
+	logText := buf.Bytes()
+	if redactor := currentRequestLogRedactor(); redactor != nil {
+		requestHeaders = redactor.Headers(requestHeaders)
+		logText = redactor.Text(logText)
+	}
 	payload := homeRequestLogPayload{
-		Headers:    cloneHeaders(w.requestHeaders),
+		Headers:    cloneHeaders(requestHeaders),
 		RequestID:  w.requestID,
-		RequestLog: buf.String(),
+		RequestLog: string(logText),
 	}
 	raw, errMarshal := json.Marshal(&payload)
 	if errMarshal != nil {
@@ -0,0 +1,490 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	redactedValue    = "[REDACTED]"
+	redactHashPrefix = "sha256:"
+	// minBase64Run is the shortest run treated as base64 so ordinary words, hashes and
+	// truncation markers are left alone.
+	minBase64Run = 64
+	// maxRedactJSONBlock bounds how much of a multi-line JSON document is held so JSON path
+	// rules can be applied to it as a whole.
+	maxRedactJSONBlock = 8 << 20
+)
+
+var (
+	redactHeaderLinePattern = regexp.MustCompile(`^(\s*)([A-Za-z0-9_-]+):[ \t]*(.*)$`)
+	redactHashedPattern     = regexp.MustCompile(`^sha256:[0-9a-f]{16}$`)
+	redactBuiltinPatterns   = map[string]string{
+		config.RedactionPatternEmail:  `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`,
+		config.RedactionPatternCard:   `\b(?:\d[ -]?){12,18}\d\b`,
+		config.RedactionPatternAPIKey: `\bsk-[A-Za-z0-9_-]{16,}`,
+	}
+)
+
+var activeRequestLogRedactor atomic.Pointer[requestLogRedactor]
+
+// ConfigureRequestLogRedaction installs the request-log redaction rules from cfg. Passing
+// nil or a config without rules disables redaction.
+func ConfigureRequestLogRedaction(cfg *config.Config) {
+	if cfg == nil {
+		activeRequestLogRedactor.Store(nil)
+		return
+	}
+	activeRequestLogRedactor.Store(newRequestLogRedactor(cfg.RequestLogRedaction))
+}
+
+func currentRequestLogRedactor() *requestLogRedactor {
+	return activeRequestLogRedactor.Load()
+}
+
+type redactPattern struct {
+	name string
+	re   *regexp.Regexp
+	luhn bool
+}
+
+// requestLogRedactor applies redaction rules line by line. JSON documents spread over
+// several lines, such as pretty-printed upstream responses, are gathered and compacted
+// first so JSON path rules see them whole. Every rule is idempotent, so text that was
+// already redacted on its way into a temp file can safely be redacted again when the
+// final log is assembled.
+type requestLogRedactor struct {
+	headers   map[string]struct{}
+	hashPaths [][]string
+	dropPaths [][]string
+	patterns  []redactPattern
+	maxBase64 int
+	base64    *regexp.Regexp
+}
+
+func newRequestLogRedactor(cfg config.RequestLogRedactionConfig) *requestLogRedactor {
+	if !cfg.Active() {
+		return nil
+	}
+	r := &requestLogRedactor{headers: make(map[string]struct{}, len(cfg.Headers))}
+	for _, name := range cfg.Headers {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			r.headers[name] = struct{}{}
+		}
+	}
+	for _, path := range cfg.HashJSONPaths {
+		if path = strings.TrimSpace(path); path != "" {
+			r.hashPaths = append(r.hashPaths, strings.Split(path, "."))
+		}
+	}
+	for _, path := range cfg.DropJSONPaths {
+		if path = strings.TrimSpace(path); path != "" {
+			r.dropPaths = append(r.dropPaths, strings.Split(path, "."))
+		}
+	}
+	for _, pattern := range cfg.Patterns {
+		pattern = strings.TrimSpace(pattern)
+		name := strings.ToLower(pattern)
+		if expr, ok := redactBuiltinPatterns[name]; ok {
+			r.patterns = append(r.patterns, redactPattern{name: name, re: regexp.MustCompile(expr), luhn: name == config.RedactionPatternCard})
+			continue
+		}
+		re, errCompile := regexp.Compile(pattern)
+		if errCompile != nil {
+			// Rejected by config validation; skip defensively.
+			continue
+		}
+		r.patterns = append(r.patterns, redactPattern{re: re})
+	}
+	if cfg.MaxBase64Length > 0 {
+		r.maxBase64 = cfg.MaxBase64Length
+		r.base64 = regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+/]{%d,}={0,2}`, minBase64Run))
+	}
+	return r
+}
+
+// Headers returns a copy of headers with configured names masked.
+func (r *requestLogRedactor) Headers(headers map[string][]string) map[string][]string {
+	if r == nil || len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		cloned := make([]string, len(values))
+		for i, value := range values {
+			if _, ok := r.headers[strings.ToLower(key)]; ok {
+				cloned[i] = redactedValue
+				continue
+			}
+			cloned[i] = string(r.redactText([]byte(value)))
+		}
+		out[key] = cloned
+	}
+	return out
+}
+
+// Body prepares a request body for logging. Multi-line JSON is compacted first so JSON
+// path rules see the whole document on one line.
+func (r *requestLogRedactor) Body(body []byte) []byte {
+	if r == nil || len(body) == 0 {
+		return body
+	}
+	if bytes.IndexByte(body, '\n') >= 0 && json.Valid(body) {
+		var compacted bytes.Buffer
+		if json.Compact(&compacted, body) == nil {
+			body = compacted.Bytes()
+		}
+	}
+	return r.Text(body)
+}
+
+// Text redacts every line of a log section.
+func (r *requestLogRedactor) Text(text []byte) []byte {
+	if r == nil || len(text) == 0 {
+		return text
+	}
+	var out bytes.Buffer
+	out.Grow(len(text))
+	lines := r.newLineBuffer(func(line []byte, terminated bool) error {
+		out.Write(line)
+		if terminated {
+			out.WriteByte('\n')
+		}
+		return nil
+	})
+	for len(text) > 0 {
+		idx := bytes.IndexByte(text, '\n')
+		if idx < 0 {
+			_ = lines.push(text, false)
+			break
+		}
+		_ = lines.push(text[:idx], true)
+		text = text[idx+1:]
+	}
+	_ = lines.flush()
+	return out.Bytes()
+}
+
+// Line redacts a single log line without its trailing newline.
+func (r *requestLogRedactor) Line(line []byte) []byte {
+	if r == nil || len(line) == 0 {
+		return line
+	}
+	if len(r.headers) > 0 {
+		if m := redactHeaderLinePattern.FindSubmatch(line); m != nil {
+			if _, ok := r.headers[strings.ToLower(string(m[2]))]; ok {
+				return []byte(string(m[1]) + string(m[2]) + ": " + redactedValue)
+			}
+		}
+	}
+	if len(r.hashPaths) > 0 || len(r.dropPaths) > 0 {
+		if start := bytes.IndexAny(line, "{["); start >= 0 {
+			doc := bytes.TrimRight(line[start:], "\r")
+			if gjson.ValidBytes(doc) {
+				redacted := r.redactJSON(append([]byte(nil), doc...))
+				line = append(append(append([]byte(nil), line[:start]...), redacted...), line[start+len(doc):]...)
+			}
+		}
+	}
+	return r.redactText(line)
+}
+
+// redactLineBuffer feeds lines to the redactor. When JSON path rules are configured, a
+// line that begins with a JSON document it does not close starts a block; following lines are
+// held until the document closes and the block is then compacted and redacted as one line.
+type redactLineBuffer struct {
+	redactor *requestLogRedactor
+	emit     func(line []byte, terminated bool) error
+
+	block      [][]byte
+	terminated []bool
+	size       int
+	start      int
+	depth      int
+	inString   bool
+	escaped    bool
+}
+
+var redactJSONBlockWarning sync.Once
+
+func (r *requestLogRedactor) newLineBuffer(emit func(line []byte, terminated bool) error) *redactLineBuffer {
+	return &redactLineBuffer{redactor: r, emit: emit}
+}
+
+func (b *redactLineBuffer) push(line []byte, terminated bool) error {
+	r := b.redactor
+	if len(b.block) == 0 {
+		if len(r.hashPaths) == 0 && len(r.dropPaths) == 0 {
+			return b.emit(r.Line(line), terminated)
+		}
+		start := bytes.IndexAny(line, "{[")
+		if start < 0 || len(bytes.TrimSpace(line[:start])) > 0 {
+			return b.emit(r.Line(line), terminated)
+		}
+		b.start, b.depth, b.inString, b.escaped = start, 0, false, false
+		if b.scan(line[start:]) {
+			return b.emit(r.Line(line), terminated)
+		}
+	} else if b.scan(line) {
+		b.add(line, terminated)
+		return b.emitBlock()
+	}
+	b.add(line, terminated)
+	if b.size > maxRedactJSONBlock {
+		redactJSONBlockWarning.Do(func() {
+			log.Warnf("request log redaction: JSON document over %d bytes redacted line by line; JSON path rules may not apply", maxRedactJSONBlock)
+		})
+		return b.flush()
+	}
+	return nil
+}
+
+func (b *redactLineBuffer) add(line []byte, terminated bool) {
+	b.block = append(b.block, append([]byte(nil), line...))
+	b.terminated = append(b.terminated, terminated)
+	b.size += len(line) + 1
+}
+
+// scan advances the bracket depth over text and reports whether the document closed.
+func (b *redactLineBuffer) scan(text []byte) bool {
+	for _, ch := range text {
+		switch {
+		case b.escaped:
+			b.escaped = false
+		case b.inString:
+			if ch == '\\' {
+				b.escaped = true
+			} else if ch == '"' {
+				b.inString = false
+			}
+		case ch == '"':
+			b.inString = true
+		case ch == '{' || ch == '[':
+			b.depth++
+		case ch == '}' || ch == ']':
+			b.depth--
+			if b.depth <= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// emitBlock compacts the held document and redacts it as one line. Blocks that turn out
+// not to be JSON are emitted line by line.
+func (b *redactLineBuffer) emitBlock() error {
+	joined := bytes.Join(b.block, []byte("\n"))
+	terminated := b.terminated[len(b.terminated)-1]
+	prefix := joined[:b.start]
+	end := bytes.LastIndexAny(joined, "}]") + 1
+	var compacted bytes.Buffer
+	if end <= b.start || json.Compact(&compacted, joined[b.start:end]) != nil {
+		return b.flush()
+	}
+	line := append(append(append([]byte(nil), prefix...), compacted.Bytes()...), bytes.TrimSpace(joined[end:])...)
+	b.reset()
+	return b.emit(b.redactor.Line(line), terminated)
+}
+
+// flush emits held lines one by one.
+func (b *redactLineBuffer) flush() error {
+	block, terminated := b.block, b.terminated
+	b.reset()
+	for i, line := range block {
+		if errEmit := b.emit(b.redactor.Line(line), terminated[i]); errEmit != nil {
+			return errEmit
+		}
+	}
+	return nil
+}
+
+func (b *redactLineBuffer) reset() {
+	b.block, b.terminated, b.size = nil, nil, 0
+}
+
+func (r *requestLogRedactor) redactText(text []byte) []byte {
+	if r.base64 != nil {
+		text = r.base64.ReplaceAllFunc(text, func(match []byte) []byte {
+			if len(match) <= r.maxBase64 {
+				return match
+			}
+			return []byte(fmt.Sprintf("%s...[truncated %d chars]", match[:r.maxBase64], len(match)-r.maxBase64))
+		})
+	}
+	for _, pattern := range r.patterns {
+		replacement := []byte(redactedValue)
+		if pattern.name != "" {
+			replacement = []byte("[REDACTED:" + pattern.name + "]")
+		}
+		text = pattern.re.ReplaceAllFunc(text, func(match []byte) []byte {
+			if pattern.luhn && !luhnValid(match) {
+				return match
+			}
+			return replacement
+		})
+	}
+	return text
+}
+
+func (r *requestLogRedactor) redactJSON(doc []byte) []byte {
+	for _, segments := range r.hashPaths {
+		for _, path := range expandRedactPath(doc, segments) {
+			value := gjson.GetBytes(doc, path)
+			if value.Type == gjson.Null || redactHashedPattern.MatchString(value.String()) {
+				continue
+			}
+			raw := value.Raw
+			if value.Type == gjson.String {
+				raw = value.String()
+			}
+			sum := sha256.Sum256([]byte(raw))
+			if updated, errSet := sjson.SetBytes(doc, path, redactHashPrefix+hex.EncodeToString(sum[:8])); errSet == nil {
+				doc = updated
+			}
+		}
+	}
+	for _, segments := range r.dropPaths {
+		paths := expandRedactPath(doc, segments)
+		// Delete from the back so earlier array indexes stay valid.
+		for i := len(paths) - 1; i >= 0; i-- {
+			if updated, errDelete := sjson.DeleteBytes(doc, paths[i]); errDelete == nil {
+				doc = updated
+			}
+		}
+	}
+	return doc
+}
+
+// expandRedactPath resolves "*" segments into the concrete paths present in doc.
+func expandRedactPath(doc []byte, segments []string) []string {
+	var out []string
+	var walk func(value gjson.Result, rest []string, prefix string)
+	walk = func(value gjson.Result, rest []string, prefix string) {
+		if len(rest) == 0 {
+			out = append(out, prefix)
+			return
+		}
+		join := func(key string) string {
+			if prefix == "" {
+				return key
+			}
+			return prefix + "." + key
+		}
+		if rest[0] == "*" || rest[0] == "#" {
+			index := 0
+			value.ForEach(func(key, child gjson.Result) bool {
+				name := escapeRedactPathKey(key.String())
+				if value.IsArray() {
+					name = strconv.Itoa(index)
+				}
+				index++
+				walk(child, rest[1:], join(name))
+				return true
+			})
+			return
+		}
+		key := escapeRedactPathKey(rest[0])
+		child := value.Get(key)
+		if !child.Exists() {
+			return
+		}
+		walk(child, rest[1:], join(key))
+	}
+	walk(gjson.ParseBytes(doc), segments, "")
+	return out
+}
+
+func escapeRedactPathKey(key string) string {
+	var builder strings.Builder
+	for _, ch := range key {
+		switch ch {
+		case '.', '*', '?', '|', '#', '@', '!', '\\':
+			builder.WriteByte('\\')
+		}
+		builder.WriteRune(ch)
+	}
+	return builder.String()
+}
+
+func luhnValid(match []byte) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(match) - 1; i >= 0; i-- {
+		ch := match[i]
+		if ch < '0' || ch > '9' {
+			continue
+		}
+		d := int(ch - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+// redactingWriter redacts complete lines before passing them to the underlying writer.
+// Flush must be called to emit a trailing partial line and any held JSON document.
+type redactingWriter struct {
+	dst     io.Writer
+	lines   *redactLineBuffer
+	pending []byte
+}
+
+func newRedactingWriter(dst io.Writer, redactor *requestLogRedactor) *redactingWriter {
+	w := &redactingWriter{dst: dst}
+	w.lines = redactor.newLineBuffer(func(line []byte, terminated bool) error {
+		if terminated {
+			line = append(line, '\n')
+		}
+		_, errWrite := w.dst.Write(line)
+		return errWrite
+	})
+	return w
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		idx := bytes.IndexByte(w.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		if errWrite := w.lines.push(w.pending[:idx], true); errWrite != nil {
+			return 0, errWrite
+		}
+		w.pending = w.pending[idx+1:]
+	}
+	return len(p), nil
+}
+
+func (w *redactingWriter) Flush() error {
+	if len(w.pending) > 0 {
+		pending := w.pending
+		w.pending = nil
+		if errWrite := w.lines.push(pending, false); errWrite != nil {
+			return errWrite
+		}
+	}
+	return w.lines.flush()
+}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func testRedactionConfig() *config.Config {
+	return &config.Config{RequestLogRedaction: config.RequestLogRedactionConfig{
+		Headers:         []string{"X-Upstream-Token"},
+		DropJSONPaths:   []string{"messages.*.content"},
+		HashJSONPaths:   []string{"user"},
+		Patterns:        []string{"email", "card-number", "api-key", `secret-[0-9]+`},
+		MaxBase64Length: 8,
+	}}
+}
+
+func TestRequestLogRedactorLine(t *testing.T) {
+	r := newRequestLogRedactor(testRedactionConfig().RequestLogRedaction)
+
+	got := string(r.Text([]byte("x-upstream-token: abc\nHeaders:\n  X-Upstream-Token: def")))
+	if got != "x-upstream-token: [REDACTED]\nHeaders:\n  X-Upstream-Token: [REDACTED]" {
+		t.Fatalf("headers = %q", got)
+	}
+
+	line := []byte(`data: {"user":"alice","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"yo"}],"image":"data:image/png;base64,QUJDREVGR0hJSktMTU5PUAQUJDREVGR0hJSktMTU5PUAQUJDREVGR0hJSktMTU5PUAQUJDREVGR0hJSktMTU5PUA=="}`)
+	got = string(r.Line(line))
+	if strings.Contains(got, `"content"`) || strings.Contains(got, "alice") || !strings.HasPrefix(got, `data: {"user":"sha256:`) {
+		t.Fatalf("json line = %s", got)
+	}
+	if !strings.Contains(got, `"image":"data:image/png;base64,QUJDREVG...[truncated 82 chars]"`) {
+		t.Fatalf("base64 not truncated: %s", got)
+	}
+	if again := string(r.Line([]byte(got))); again != got {
+		t.Fatalf("redaction is not idempotent:\n%s\n%s", got, again)
+	}
+
+	got = string(r.Line([]byte("mail bob@example.com card 4111 1111 1111 1111 ts 1718000000000 key sk-proj-abcdefghijklmnop1234 secret-42")))
+	want := "mail [REDACTED:email] card [REDACTED:card-number] ts 1718000000000 key [REDACTED:api-key] [REDACTED]"
+	if got != want {
+		t.Fatalf("patterns = %q, want %q", got, want)
+	}
+}
+
+func TestFileRequestLoggerAppliesRedaction(t *testing.T) {
+	ConfigureRequestLogRedaction(testRedactionConfig())
+	t.Cleanup(func() { ConfigureRequestLogRedaction(nil) })
+
+	dir := t.TempDir()
+	logger := NewFileRequestLogger(true, dir, "", 0)
+	body := []byte("{\n  \"user\": \"alice@example.com\",\n  \"messages\": [{\"role\": \"user\", \"content\": \"private\"}]\n}")
+	apiRequest := []byte("=== API REQUEST 1 ===\nHeaders:\nX-Upstream-Token: upstream-secret\nBody:\n{\"messages\":[{\"content\":\"private\"}]}\n")
+	errLog := logger.LogRequest("/v1/chat/completions", "POST", map[string][]string{"X-Upstream-Token": {"t"}}, body, 200,
+		map[string][]string{"Content-Type": {"application/json"}}, []byte(`{"reply":"contact bob@example.com"}`),
+		nil, apiRequest, nil, nil, nil, "req-1", time.Now(), time.Now())
+	if errLog != nil {
+		t.Fatalf("LogRequest: %v", errLog)
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(entries) != 1 {
+		t.Fatalf("log files = %v", entries)
+	}
+	content, errRead := os.ReadFile(entries[0])
+	if errRead != nil {
+		t.Fatal(errRead)
+	}
+	for _, leaked := range []string{"private", "alice@example.com", "bob@example.com", "upstream-secret"} {
+		if strings.Contains(string(content), leaked) {
+			t.Fatalf("log leaks %q:\n%s", leaked, content)
+		}
+	}
+}
+
+func TestFileRequestLoggerRedactsIndentedUpstreamSections(t *testing.T) {
+	ConfigureRequestLogRedaction(&config.Config{RequestLogRedaction: config.RequestLogRedactionConfig{
+		DropJSONPaths: []string{"candidates.*.content", "contents"},
+		HashJSONPaths: []string{"user"},
+	}})
+	t.Cleanup(func() { ConfigureRequestLogRedaction(nil) })
+
+	dir := t.TempDir()
+	logger := NewFileRequestLogger(true, dir, "", 0)
+	apiRequest := []byte("=== API REQUEST 1 ===\nBody:\n{\n  \"user\": \"alice\",\n  \"contents\": [\n    {\"parts\": [{\"text\": \"private prompt\"}]}\n  ]\n}\n")
+	apiResponse := []byte("=== API RESPONSE 1 ===\nBody:\n{\n  \"candidates\": [\n    {\n      \"content\": {\n        \"parts\": [{\"text\": \"private answer with \\\"quotes\\\" and }\"}]\n      },\n      \"finishReason\": \"STOP\"\n    }\n  ]\n}\nStatus: 200\n")
+	errLog := logger.LogRequest("/v1beta/models/gemini:generateContent", "POST", nil, []byte(`{}`), 200,
+		nil, []byte(`{}`), nil, apiRequest, apiResponse, nil, nil, "req-2", time.Now(), time.Now())
+	if errLog != nil {
+		t.Fatalf("LogRequest: %v", errLog)
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(entries) != 1 {
+		t.Fatalf("log files = %v", entries)
+	}
+	content, errRead := os.ReadFile(entries[0])
+	if errRead != nil {
+		t.Fatal(errRead)
+	}
+	for _, leaked := range []string{"private prompt", "private answer", "alice"} {
+		if strings.Contains(string(content), leaked) {
+			t.Fatalf("log leaks %q:\n%s", leaked, content)
+		}
+	}
+	for _, kept := range []string{`{"candidates":[{"finishReason":"STOP"}]}`, "Status: 200"} {
+		if !strings.Contains(string(content), kept) {
+			t.Fatalf("log lost %q:\n%s", kept, content)
+		}
+	}
+}
+
+func TestRequestLogRedactionConfigValidate(t *testing.T) {
+	if errValidate := (config.RequestLogRedactionConfig{Patterns: []string{"("}}).Validate(); errValidate == nil {
+		t.Fatal("invalid regexp accepted")
+	}
+	if errValidate := (config.RequestLogRedactionConfig{DropJSONPaths: []string{"a..b"}}).Validate(); errValidate == nil {
+		t.Fatal("invalid path accepted")
+	}
+	if errValidate := testRedactionConfig().RequestLogRedaction.Validate(); errValidate != nil {
+		t.Fatalf("valid config rejected: %v", errValidate)
+	}
+}
@@ -3,6 +3,7 @@ package logging
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -37,6 +38,9 @@ type FileStreamingLogWriter struct {
 	// responseBodyFile is the temp file where chunks are appended by the async writer.
 	responseBodyFile *os.File
 
+	// redactor scrubs the spooled response and the final log; nil when redaction is off.
+	redactor *requestLogRedactor
+
 	// chunkChan is a channel for receiving response chunks to spool.
 	chunkChan chan []byte
 
@@ -242,28 +246,46 @@ func (w *FileStreamingLogWriter) Close() error {
 func (w *FileStreamingLogWriter) asyncWriter() {
 	defer close(w.closeChan)
 
+	var spool io.Writer = w.responseBodyFile
+	var redacting *redactingWriter
+	if w.redactor != nil && w.responseBodyFile != nil {
+		redacting = newRedactingWriter(w.responseBodyFile, w.redactor)
+		spool = redacting
+	}
+
 	for chunk := range w.chunkChan {
 		if w.responseBodyFile == nil {
 			continue
 		}
-		if _, errWrite := w.responseBodyFile.Write(chunk); errWrite != nil {
-			select {
-			case w.errorChan <- errWrite:
-			default:
-			}
-			if errClose := w.responseBodyFile.Close(); errClose != nil {
-				select {
-				case w.errorChan <- errClose:
-				default:
-				}
-			}
-			w.responseBodyFile = nil
+		if _, errWrite := spool.Write(chunk); errWrite != nil {
+			w.failSpool(errWrite)
 		}
 	}
 
 	if w.responseBodyFile == nil {
 		return
 	}
+	if redacting != nil {
+		if errFlush := redacting.Flush(); errFlush != nil {
+			w.failSpool(errFlush)
+			return
+		}
+	}
+	if errClose := w.responseBodyFile.Close(); errClose != nil {
+		select {
+		case w.errorChan <- errClose:
+		default:
+		}
+	}
+	w.responseBodyFile = nil
+}
+
+// failSpool reports a spool write error and stops spooling further chunks.
+func (w *FileStreamingLogWriter) failSpool(errWrite error) {
+	select {
+	case w.errorChan <- errWrite:
+	default:
+	}
 	if errClose := w.responseBodyFile.Close(); errClose != nil {
 		select {
 		case w.errorChan <- errClose:
@@ -274,6 +296,17 @@ func (w *FileStreamingLogWriter) asyncWriter() {
 }
 
 func (w *FileStreamingLogWriter) writeFinalLog(logFile *os.File) error {
+	if w.redactor == nil {
+		return w.writeFinalSections(logFile)
+	}
+	redacting := newRedactingWriter(logFile, w.redactor)
+	if errWrite := w.writeFinalSections(redacting); errWrite != nil {
+		return errWrite
+	}
+	return redacting.Flush()
+}
+
+func (w *FileStreamingLogWriter) writeFinalSections(logFile io.Writer) error {
 	if errWrite := writeRequestInfoWithBody(logFile, w.url, w.method, w.requestHeaders, nil, w.requestBodyPath, w.timestamp, "http", inferUpstreamTransport(w.apiRequest, w.apiRequestSource, w.apiResponse, w.apiResponseSource, w.apiWebsocketTimeline, nil, nil), true); errWrite != nil {
 		return errWrite
 	}
@@ -67,6 +67,8 @@ func (l *FileRequestLogger) logRequestWithSources(url, method string, requestHea
 	if !l.enabled && !force {
 		return nil
 	}
+	redactor := currentRequestLogRedactor()
+	body = redactor.Body(body)
 
 	if l.homeEnabled && l.enabled {
 		responseToWrite, decompressErr := l.decompressResponse(responseHeaders, response)
@@ -139,8 +141,14 @@ func (l *FileRequestLogger) logRequestWithSources(url, method string, requestHea
 		return fmt.Errorf("failed to create log file: %w", errOpen)
 	}
 
+	var out io.Writer = logFile
+	var redacting *redactingWriter
+	if redactor != nil {
+		redacting = newRedactingWriter(logFile, redactor)
+		out = redacting
+	}
 	writeErr := l.writeNonStreamingLog(
-		logFile,
+		out,
 		url,
 		method,
 		requestHeaders,
@@ -162,6 +170,9 @@ func (l *FileRequestLogger) logRequestWithSources(url, method string, requestHea
 		requestTimestamp,
 		apiResponseTimestamp,
 	)
+	if writeErr == nil && redacting != nil {
+		writeErr = redacting.Flush()
+	}
 	if errClose := logFile.Close(); errClose != nil {
 		log.WithError(errClose).Warn("failed to close request log file")
 		if writeErr == nil {
@@ -197,6 +208,8 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 	if !l.enabled {
 		return &NoOpStreamingLogWriter{}, nil
 	}
+	redactor := currentRequestLogRedactor()
+	body = redactor.Body(body)
 
 	if l.homeEnabled {
 		client := currentHomeRequestLogClient()
@@ -247,6 +260,7 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 		chunkChan:        make(chan []byte, 100), // Buffered channel for async writes
 		closeChan:        make(chan struct{}),
 		errorChan:        make(chan error, 1),
+		redactor:         redactor,
 	}
 
 	// Start async writer goroutine
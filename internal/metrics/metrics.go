@@ -0,0 +1,246 @@
+// Package metrics exposes proxy runtime metrics in the Prometheus text format: client
+// requests, upstream attempts and their tokens, credential states, refreshes, stream
+// bootstrap retries, dropped usage ledger records, and plugin call latency.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cliproxy"
+
+// Entry protocols reported in the protocol label.
+const (
+	ProtocolOpenAI          = "openai"
+	ProtocolOpenAIResponses = "openai-responses"
+	ProtocolClaude          = "claude"
+	ProtocolGemini          = "gemini"
+	ProtocolRealtime        = "realtime"
+)
+
+// Credential states reported by the credentials gauge.
+const (
+	CredentialActive        = "active"
+	CredentialCooling       = "cooling"
+	CredentialQuotaExceeded = "quota_exceeded"
+	CredentialDisabled      = "disabled"
+)
+
+// Token buckets reported by the tokens counter, following usage.TokenBreakdown.
+const (
+	TokensInputUncached      = "input_uncached"
+	TokensInputCacheRead     = "input_cache_read"
+	TokensInputCacheWrite    = "input_cache_write"
+	TokensOutputNonReasoning = "output_non_reasoning"
+	TokensOutputReasoning    = "output_reasoning"
+	TokensUnclassified       = "unclassified"
+)
+
+// CredentialCount is the number of credentials of a provider in one state.
+type CredentialCount struct {
+	Provider string
+	State    string
+	Count    int
+}
+
+var (
+	registry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Client API requests by entry protocol and response status.",
+	}, []string{"protocol", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Client API request duration by entry protocol, including streaming.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"protocol"})
+
+	upstreamRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Upstream requests by entry protocol, model, provider and status.",
+	}, []string{"protocol", "model", "provider", "status"})
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Upstream request latency by entry protocol, model, provider and status.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"protocol", "model", "provider", "status"})
+	upstreamTTFT = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "time_to_first_token_seconds",
+		Help:      "Upstream time to first token of streamed responses by model and provider.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60},
+	}, []string{"model", "provider"})
+	tokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_total",
+		Help:      "Tokens by model, provider and accounting bucket.",
+	}, []string{"model", "provider", "bucket"})
+
+	refreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_refresh_total",
+		Help:      "Credential refreshes by provider and result.",
+	}, []string{"provider", "result"})
+	bootstrapRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_bootstrap_retries_total",
+		Help:      "Streams retried on another credential before the first byte, by model.",
+	}, []string{"model"})
+	responseCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "response_cache_total",
+		Help:      "Cacheable requests by model and result (hit, miss).",
+	}, []string{"model", "result"})
+	usageLedgerDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_ledger_dropped_records_total",
+		Help:      "Usage records dropped because the ledger buffer was full while its database was unavailable.",
+	})
+	pluginCalls = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "plugin_call_duration_seconds",
+		Help:      "Plugin call latency by plugin, method and result.",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 5},
+	}, []string{"plugin", "method", "result"})
+
+	credentialsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "credentials"),
+		"Credentials by provider and state (active, cooling, quota_exceeded, disabled).",
+		[]string{"provider", "state"}, nil,
+	)
+
+	credentialMu     sync.RWMutex
+	credentialSource func() []CredentialCount
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		upstreamRequests, upstreamDuration, upstreamTTFT, tokens,
+		refreshes, bootstrapRetries, responseCache, usageLedgerDropped, pluginCalls,
+		credentialCollector{},
+	)
+}
+
+// Handler serves the registry in the Prometheus text format. When token returns a
+// non-empty value, scrapes must present it as a bearer token.
+func Handler(token func() string) http.Handler {
+	inner := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != nil {
+			if expected := token(); expected != "" {
+				provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(expected)) != 1 {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+		}
+		inner.ServeHTTP(w, r)
+	})
+}
+
+// SetCredentialSource installs the function that counts credentials by provider and
+// state on each scrape. A nil source reports no credentials.
+func SetCredentialSource(source func() []CredentialCount) {
+	credentialMu.Lock()
+	credentialSource = source
+	credentialMu.Unlock()
+}
+
+type credentialCollector struct{}
+
+func (credentialCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- credentialsDesc
+}
+
+func (credentialCollector) Collect(ch chan<- prometheus.Metric) {
+	credentialMu.RLock()
+	source := credentialSource
+	credentialMu.RUnlock()
+	if source == nil {
+		return
+	}
+	for _, count := range source() {
+		ch <- prometheus.MustNewConstMetric(credentialsDesc, prometheus.GaugeValue, float64(count.Count), label(count.Provider), count.State)
+	}
+}
+
+// Protocol maps a client API path to its entry protocol, or "" for paths that are not
+// client API routes.
+func Protocol(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/v1beta"):
+		return ProtocolGemini
+	case strings.HasPrefix(path, "/v1/messages"):
+		return ProtocolClaude
+	case strings.HasPrefix(path, "/v1/responses"), strings.HasPrefix(path, "/backend-api/codex"):
+		return ProtocolOpenAIResponses
+	case strings.HasPrefix(path, "/v1/realtime"), strings.HasPrefix(path, "/v1/live"):
+		return ProtocolRealtime
+	case strings.HasPrefix(path, "/v1/"), strings.HasPrefix(path, "/openai/v1/"):
+		return ProtocolOpenAI
+	default:
+		return ""
+	}
+}
+
+// ObserveHTTPRequest records a finished client API request.
+func ObserveHTTPRequest(protocol string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(label(protocol), strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(label(protocol)).Observe(duration.Seconds())
+}
+
+// ObserveRefresh records a credential refresh attempt.
+func ObserveRefresh(provider string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	refreshes.WithLabelValues(label(provider), result).Inc()
+}
+
+// IncStreamBootstrapRetry records a stream retried before its first byte.
+func IncStreamBootstrapRetry(model string) {
+	bootstrapRetries.WithLabelValues(label(model)).Inc()
+}
+
+// AddUsageLedgerDropped records usage records the ledger could not keep.
+func AddUsageLedgerDropped(count int) {
+	if count > 0 {
+		usageLedgerDropped.Add(float64(count))
+	}
+}
+
+// ObservePluginCall records the latency of one plugin call.
+func ObservePluginCall(plugin, method string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	pluginCalls.WithLabelValues(label(plugin), label(method), result).Observe(duration.Seconds())
+}
+
+func label(value string) string {
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		return trimmed
+	}
+	return "unknown"
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func scrape(t *testing.T, token string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	Handler(func() string { return "scrape-token" }).ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape status %d: %s", rec.Code, body)
+	}
+	return string(body)
+}
+
+func TestHandlerRequiresBearerToken(t *testing.T) {
+	for _, header := range []string{"", "Bearer wrong", "scrape-token"} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		Handler(func() string { return "scrape-token" }).ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: status %d, want 401", header, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	Handler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("open handler status %d", rec.Code)
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	tests := map[string]string{
+		"/v1/chat/completions":                          ProtocolOpenAI,
+		"/openai/v1/videos":                             ProtocolOpenAI,
+		"/v1/messages":                                  ProtocolClaude,
+		"/v1/responses/compact":                         ProtocolOpenAIResponses,
+		"/backend-api/codex/responses":                  ProtocolOpenAIResponses,
+		"/v1beta/models/gemini-2.5-pro:generateContent": ProtocolGemini,
+		"/v1/realtime/calls":                            ProtocolRealtime,
+		"/v0/management/config":                         "",
+		"/healthz":                                      "",
+	}
+	for path, want := range tests {
+		if got := Protocol(path); got != want {
+			t.Errorf("Protocol(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestUsagePluginRecordsRequestsAndTokenBuckets(t *testing.T) {
+	ctx := internallogging.WithEndpoint(context.Background(), "POST /v1/messages")
+	usagePlugin{}.HandleUsage(ctx, coreusage.Record{
+		Provider: "claude",
+		Model:    "claude-sonnet-4-5",
+		Alias:    "metrics-test-sonnet",
+		Latency:  1500 * time.Millisecond,
+		TTFT:     300 * time.Millisecond,
+		Detail: coreusage.Detail{
+			TokenBreakdown: coreusage.NewIndependentTokenBreakdown(100, 40, 10, 50, 5, 205),
+		},
+	})
+	usagePlugin{}.HandleUsage(ctx, coreusage.Record{
+		Provider: "claude",
+		Alias:    "metrics-test-sonnet",
+		Failed:   true,
+		Fail:     coreusage.Failure{StatusCode: http.StatusTooManyRequests},
+	})
+
+	body := scrape(t, "scrape-token")
+	for _, want := range []string{
+		`cliproxy_requests_total{model="metrics-test-sonnet",protocol="claude",provider="claude",status="200"} 1`,
+		`cliproxy_requests_total{model="metrics-test-sonnet",protocol="claude",provider="claude",status="429"} 1`,
+		`cliproxy_request_duration_seconds_count{model="metrics-test-sonnet",protocol="claude",provider="claude",status="200"} 1`,
+		`cliproxy_time_to_first_token_seconds_count{model="metrics-test-sonnet",provider="claude"} 1`,
+		`cliproxy_tokens_total{bucket="input_uncached",model="metrics-test-sonnet",provider="claude"} 100`,
+		`cliproxy_tokens_total{bucket="input_cache_read",model="metrics-test-sonnet",provider="claude"} 40`,
+		`cliproxy_tokens_total{bucket="input_cache_write",model="metrics-test-sonnet",provider="claude"} 10`,
+		`cliproxy_tokens_total{bucket="output_non_reasoning",model="metrics-test-sonnet",provider="claude"} 50`,
+		`cliproxy_tokens_total{bucket="output_reasoning",model="metrics-test-sonnet",provider="claude"} 5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+}
+
+func TestCountersAndCredentialGauge(t *testing.T) {
+	ObserveRefresh("metrics-test-provider", nil)
+	ObserveRefresh("metrics-test-provider", errors.New("invalid_grant"))
+	IncStreamBootstrapRetry("metrics-test-model")
+	ObservePluginCall("metrics-test-plugin", "usage.handle", 20*time.Millisecond, nil)
+	ObserveHTTPRequest(ProtocolGemini, http.StatusForbidden, time.Second)
+	AddUsageLedgerDropped(2)
+	SetCredentialSource(func() []CredentialCount {
+		return []CredentialCount{{Provider: "metrics-test-provider", State: CredentialCooling, Count: 3}}
+	})
+	defer SetCredentialSource(nil)
+
+	body := scrape(t, "scrape-token")
+	for _, want := range []string{
+		`cliproxy_auth_refresh_total{provider="metrics-test-provider",result="success"} 1`,
+		`cliproxy_auth_refresh_total{provider="metrics-test-provider",result="failure"} 1`,
+		`cliproxy_stream_bootstrap_retries_total{model="metrics-test-model"} 1`,
+		`cliproxy_plugin_call_duration_seconds_count{method="usage.handle",plugin="metrics-test-plugin",result="success"} 1`,
+		`cliproxy_http_requests_total{protocol="gemini",status="403"} 1`,
+		`cliproxy_usage_ledger_dropped_records_total 2`,
+		`cliproxy_credentials{provider="metrics-test-provider",state="cooling"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func init() {
+	coreusage.RegisterNamedPlugin("metrics", usagePlugin{})
+}
+
+// usagePlugin records upstream requests and their tokens from usage records.
+type usagePlugin struct{}
+
+// HandleUsage implements coreusage.Plugin.
+func (usagePlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	model := strings.TrimSpace(record.Alias)
+	if model == "" {
+		model = record.Model
+	}
+	model = label(model)
+	if record.ResponseCache != "" {
+		responseCache.WithLabelValues(model, record.ResponseCache).Inc()
+		if record.ResponseCache == coreusage.ResponseCacheHit {
+			// Hits never reached an upstream.
+			return
+		}
+	}
+	provider := label(record.Provider)
+	protocol := label(Protocol(endpointPath(internallogging.GetEndpoint(ctx))))
+	status := strconv.Itoa(recordStatus(ctx, record))
+
+	upstreamRequests.WithLabelValues(protocol, model, provider, status).Inc()
+	if record.Latency > 0 {
+		upstreamDuration.WithLabelValues(protocol, model, provider, status).Observe(record.Latency.Seconds())
+	}
+	if record.TTFT > 0 {
+		upstreamTTFT.WithLabelValues(model, provider).Observe(record.TTFT.Seconds())
+	}
+
+	breakdown := coreusage.EnsureTokenBreakdownForProvider(record.Detail, record.Provider, record.ExecutorType).TokenBreakdown
+	for bucket, count := range map[string]int64{
+		TokensInputUncached:      breakdown.Input.UncachedTokens,
+		TokensInputCacheRead:     breakdown.Input.CacheReadTokens,
+		TokensInputCacheWrite:    breakdown.Input.CacheWriteTokens,
+		TokensOutputNonReasoning: breakdown.Output.NonReasoningTokens,
+		TokensOutputReasoning:    breakdown.Output.ReasoningTokens,
+		TokensUnclassified:       breakdown.UnclassifiedTokens,
+	} {
+		if count > 0 {
+			tokens.WithLabelValues(model, provider, bucket).Add(float64(count))
+		}
+	}
+}
+
+// endpointPath strips the method from a "METHOD /path" endpoint.
+func endpointPath(endpoint string) string {
+	endpoint = strings.TrimSpace(endpoint)
+	if _, path, ok := strings.Cut(endpoint, " "); ok {
+		return strings.TrimSpace(path)
+	}
+	return endpoint
+}
+
+// recordStatus resolves the upstream status of a record the way the usage queue does.
+func recordStatus(ctx context.Context, record coreusage.Record) int {
+	if !record.Failed {
+		if status := internallogging.GetResponseStatus(ctx); status >= 400 {
+			return status
+		}
+		return 200
+	}
+	if record.Fail.StatusCode > 0 {
+		return record.Fail.StatusCode
+	}
+	if status := internallogging.GetResponseStatus(ctx); status > 0 {
+		return status
+	}
+	return 500
+}
@@ -7,6 +7,7 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
@@ -96,11 +97,13 @@ func (h *Host) fusePlugin(id, method string, recovered any) {
 	if h == nil {
 		return
 	}
+	reason := fmt.Sprintf("%s panic: %v", method, recovered)
 	h.mu.Lock()
-	h.fused[id] = fmt.Sprintf("%s panic: %v", method, recovered)
+	h.fused[id] = reason
 	h.mu.Unlock()
 	thinking.UnregisterPluginProviders(id)
 	log.WithField("plugin_id", id).WithField("method", method).Errorf("pluginhost: plugin panic recovered: %v\n%s", recovered, debug.Stack())
+	events.Publish(context.Background(), events.Event{Type: events.TypePluginError, PluginID: id, Message: reason})
 }
 
 func (h *Host) isPluginFused(id string) bool {
@@ -171,10 +174,28 @@ func (a *usageAdapter) HandleUsage(ctx context.Context, record coreusage.Record)
 			CacheCreationTokens: record.Detail.CacheCreationTokens,
 			TotalTokens:         record.Detail.TotalTokens,
 		},
+		Cost:            usageCost(record.Cost),
 		ResponseHeaders: cloneHeader(record.ResponseHeaders),
 	})
 }
 
+func usageCost(cost *coreusage.Cost) *pluginapi.UsageCost {
+	if cost == nil {
+		return nil
+	}
+	return &pluginapi.UsageCost{
+		Currency:   cost.Currency,
+		Input:      cost.Input,
+		CacheRead:  cost.CacheRead,
+		CacheWrite: cost.CacheWrite,
+		Output:     cost.Output,
+		Reasoning:  cost.Reasoning,
+		Total:      cost.Total,
+		Multiplier: cost.Multiplier,
+		PricedAs:   cost.PricedAs,
+	}
+}
+
 func (a *thinkingAdapter) Apply(body []byte, config thinking.ThinkingConfig, modelInfo *registry.ModelInfo) (out []byte, err error) {
 	if a == nil || a.applier == nil || a.host == nil || a.host.isPluginFused(a.pluginID) || !a.host.pluginIdentityCurrent(a.pluginID, a.path, a.version) {
 		return bytes.Clone(body), nil
@@ -4,9 +4,14 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
 )
 
 type guardedPluginClient struct {
+	// id labels call latency metrics with the plugin ID.
+	id           string
 	mu           sync.Mutex
 	cond         *sync.Cond
 	inner        pluginClient
@@ -37,7 +42,9 @@ func (c *guardedPluginClient) Call(ctx context.Context, method string, request [
 				result <- guardedPluginCallResult{recovered: recovered}
 			}
 		}()
+		started := time.Now()
 		response, errCall := inner.Call(ctx, method, request)
+		metrics.ObservePluginCall(c.id, method, time.Since(started), errCall)
 		result <- guardedPluginCallResult{response: response, err: errCall}
 	}()
 	select {
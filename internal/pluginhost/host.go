@@ -388,11 +388,13 @@ func (h *Host) startPluginLoad(ctx context.Context, file pluginFile, item runtim
 			request.result <- pluginLoadResult{err: fmt.Errorf("plugin loader returned nil client")}
 			return
 		}
+		guarded := newGuardedPluginClient(client)
+		guarded.id = file.ID
 		loaded := &loadedPlugin{
 			id:      file.ID,
 			path:    file.Path,
 			version: file.Version,
-			client:  newGuardedPluginClient(client),
+			client:  guarded,
 		}
 		plugin, okCall := h.callRegister(ctx, loaded, item)
 		request.result <- pluginLoadResult{loaded: loaded, plugin: plugin, initialized: okCall}
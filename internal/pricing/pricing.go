@@ -0,0 +1,262 @@
+// Package pricing estimates request costs from token breakdowns.
+//
+// The catalog combines embedded list prices with per-model or per-alias overrides from
+// config. Rates are per million tokens and cover uncached input, cache reads, cache writes,
+// output and reasoning separately, so cache discounts are priced correctly. Service-tier
+// multipliers scale every rate.
+package pricing
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/wildcard"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed defaults.json
+var defaultsJSON []byte
+
+const perMillion = 1_000_000
+
+// Rates are prices per million tokens.
+type Rates struct {
+	Input      float64 `json:"input"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+	Output     float64 `json:"output"`
+	Reasoning  float64 `json:"reasoning"`
+}
+
+type entry struct {
+	pattern string
+	// literal counts the non-wildcard characters; longer patterns are more specific.
+	literal int
+	rates   Rates
+	tiers   map[string]float64
+}
+
+// Catalog resolves models to rates.
+type Catalog struct {
+	currency  string
+	tiers     map[string]float64
+	overrides []entry
+	defaults  []entry
+}
+
+var current atomic.Pointer[Catalog]
+
+func init() {
+	catalog, errCatalog := NewCatalog(config.PricingConfig{})
+	if errCatalog != nil {
+		panic(fmt.Sprintf("pricing: embedded defaults: %v", errCatalog))
+	}
+	current.Store(catalog)
+	coreusage.SetCostEstimator(func(ctx context.Context, record coreusage.Record) *coreusage.Cost {
+		return Default().Estimate(ctx, record)
+	})
+}
+
+// Default returns the catalog used for usage records.
+func Default() *Catalog {
+	return current.Load()
+}
+
+// Configure rebuilds the default catalog from cfg.Pricing. An invalid configuration keeps
+// the previous catalog.
+func Configure(cfg *config.Config) {
+	var settings config.PricingConfig
+	if cfg != nil {
+		settings = cfg.Pricing
+	}
+	catalog, errCatalog := NewCatalog(settings)
+	if errCatalog != nil {
+		log.Errorf("pricing: %v", errCatalog)
+		return
+	}
+	current.Store(catalog)
+}
+
+// NewCatalog builds a catalog from the embedded defaults and settings.
+func NewCatalog(settings config.PricingConfig) (*Catalog, error) {
+	var embedded config.PricingConfig
+	if errUnmarshal := json.Unmarshal(defaultsJSON, &embedded); errUnmarshal != nil {
+		return nil, fmt.Errorf("decode defaults: %w", errUnmarshal)
+	}
+	catalog := &Catalog{
+		currency: settings.NormalizedCurrency(),
+		tiers:    make(map[string]float64),
+	}
+	for tier, multiplier := range embedded.ServiceTiers {
+		catalog.tiers[normalizeTier(tier)] = multiplier
+	}
+	for tier, multiplier := range settings.ServiceTiers {
+		catalog.tiers[normalizeTier(tier)] = multiplier
+	}
+	if !settings.DisableDefaults {
+		catalog.defaults = buildEntries(embedded.Models)
+	}
+	catalog.overrides = buildEntries(settings.Models)
+	return catalog, nil
+}
+
+func buildEntries(models []config.ModelPrice) []entry {
+	out := make([]entry, 0, len(models))
+	for _, model := range models {
+		pattern := normalizeModel(model.Match)
+		if pattern == "" {
+			continue
+		}
+		rates := Rates{Input: value(model.Input, 0), Output: value(model.Output, 0)}
+		rates.CacheRead = value(model.CacheRead, rates.Input)
+		rates.CacheWrite = value(model.CacheWrite, rates.Input)
+		rates.Reasoning = value(model.Reasoning, rates.Output)
+		var tiers map[string]float64
+		if len(model.ServiceTiers) > 0 {
+			tiers = make(map[string]float64, len(model.ServiceTiers))
+			for tier, multiplier := range model.ServiceTiers {
+				tiers[normalizeTier(tier)] = multiplier
+			}
+		}
+		out = append(out, entry{
+			pattern: pattern,
+			literal: len(strings.ReplaceAll(pattern, "*", "")),
+			rates:   rates,
+			tiers:   tiers,
+		})
+	}
+	return out
+}
+
+func value(rate *float64, fallback float64) float64 {
+	if rate == nil {
+		return fallback
+	}
+	return *rate
+}
+
+// normalizeModel lowercases name and drops "models/" prefixes and thinking suffixes.
+func normalizeModel(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimPrefix(name, "models/")
+	if !strings.Contains(name, "*") {
+		name = thinking.ParseSuffix(name).ModelName
+	}
+	return name
+}
+
+func normalizeTier(tier string) string {
+	return strings.ToLower(strings.TrimSpace(tier))
+}
+
+// lookup returns the best entry for name: an exact match first, then the most specific
+// wildcard. Ties keep the earlier entry.
+func lookup(entries []entry, name string) (entry, bool) {
+	if name == "" {
+		return entry{}, false
+	}
+	best := -1
+	for i, candidate := range entries {
+		if !wildcard.Match(candidate.pattern, name) {
+			continue
+		}
+		if candidate.pattern == name {
+			return candidate, true
+		}
+		if best < 0 || candidate.literal > entries[best].literal {
+			best = i
+		}
+	}
+	if best < 0 {
+		return entry{}, false
+	}
+	return entries[best], true
+}
+
+// Lookup returns the rates for a request. Overrides are tried by alias and then model
+// before the embedded defaults.
+func (c *Catalog) Lookup(model, alias string) (Rates, string, bool) {
+	found, ok := c.resolve(model, alias)
+	return found.rates, found.pattern, ok
+}
+
+func (c *Catalog) resolve(model, alias string) (entry, bool) {
+	if c == nil {
+		return entry{}, false
+	}
+	model, alias = normalizeModel(model), normalizeModel(alias)
+	for _, candidate := range []struct {
+		entries []entry
+		name    string
+	}{
+		{c.overrides, alias},
+		{c.overrides, model},
+		{c.defaults, model},
+		{c.defaults, alias},
+	} {
+		if found, ok := lookup(candidate.entries, candidate.name); ok {
+			return found, true
+		}
+	}
+	return entry{}, false
+}
+
+// Multiplier returns the service-tier multiplier of an entry, falling back to the global
+// multipliers and then to 1.
+func (c *Catalog) multiplier(found entry, tier string) float64 {
+	tier = normalizeTier(tier)
+	if tier == "" {
+		return 1
+	}
+	if multiplier, ok := found.tiers[tier]; ok {
+		return multiplier
+	}
+	if multiplier, ok := c.tiers[tier]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+// Estimate prices record, or returns nil when its model is not in the catalog.
+func (c *Catalog) Estimate(ctx context.Context, record coreusage.Record) *coreusage.Cost {
+	found, ok := c.resolve(record.Model, record.Alias)
+	if !ok {
+		return nil
+	}
+	detail := coreusage.EnsureTokenBreakdownForProvider(record.Detail, record.Provider, record.ExecutorType)
+	breakdown := detail.TokenBreakdown
+	multiplier := c.multiplier(found, serviceTier(ctx, record))
+	price := func(tokens int64, rate float64) float64 {
+		return float64(tokens) * rate * multiplier / perMillion
+	}
+	cost := &coreusage.Cost{
+		Currency: c.currency,
+		// Tokens the upstream did not classify are priced as uncached input.
+		Input:      price(breakdown.Input.UncachedTokens+breakdown.UnclassifiedTokens, found.rates.Input),
+		CacheRead:  price(breakdown.Input.CacheReadTokens, found.rates.CacheRead),
+		CacheWrite: price(breakdown.Input.CacheWriteTokens, found.rates.CacheWrite),
+		Output:     price(breakdown.Output.NonReasoningTokens, found.rates.Output),
+		Reasoning:  price(breakdown.Output.ReasoningTokens, found.rates.Reasoning),
+		Multiplier: multiplier,
+		PricedAs:   found.pattern,
+	}
+	cost.Total = cost.Input + cost.CacheRead + cost.CacheWrite + cost.Output + cost.Reasoning
+	return cost
+}
+
+// serviceTier prefers the tier the upstream reported serving over the requested one.
+func serviceTier(ctx context.Context, record coreusage.Record) string {
+	for _, tier := range []string{record.ResponseServiceTier, record.Detail.ResponseServiceTier, record.ServiceTier, record.RequestServiceTier} {
+		if tier = strings.TrimSpace(tier); tier != "" {
+			return tier
+		}
+	}
+	return coreusage.ServiceTierFromContext(ctx)
+}
@@ -0,0 +1,114 @@
+package pricing
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func rate(v float64) *float64 { return &v }
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestLookupPrefersOverridesAndSpecificPatterns(t *testing.T) {
+	catalog, errCatalog := NewCatalog(config.PricingConfig{Models: []config.ModelPrice{
+		{Match: "fast", Input: rate(9), Output: rate(9)},
+		{Match: "claude-sonnet-4-6", Input: rate(2), Output: rate(10)},
+	}})
+	if errCatalog != nil {
+		t.Fatal(errCatalog)
+	}
+	cases := []struct {
+		model, alias, pricedAs string
+	}{
+		{"gpt-5.4-mini", "", "gpt-5*mini*"},
+		{"gpt-5.4", "", "gpt-5*"},
+		{"models/Gemini-2.5-Flash-Lite", "", "gemini-2.5-flash-lite*"},
+		{"claude-sonnet-4-6(high)", "", "claude-sonnet-4-6"},
+		{"gpt-5.4", "fast", "fast"},
+		{"custom-model", "gpt-5.4-mini", "gpt-5*mini*"},
+	}
+	for _, tc := range cases {
+		if _, pricedAs, ok := catalog.Lookup(tc.model, tc.alias); !ok || pricedAs != tc.pricedAs {
+			t.Errorf("Lookup(%q, %q) = %q, %v; want %q", tc.model, tc.alias, pricedAs, ok, tc.pricedAs)
+		}
+	}
+	if _, _, ok := catalog.Lookup("unknown-model", ""); ok {
+		t.Error("unknown model priced")
+	}
+}
+
+func TestEstimatePricesEachTokenBucket(t *testing.T) {
+	catalog, errCatalog := NewCatalog(config.PricingConfig{DisableDefaults: true, Models: []config.ModelPrice{
+		{Match: "m", Input: rate(2), CacheRead: rate(0.2), CacheWrite: rate(2.5), Output: rate(10), Reasoning: rate(20)},
+	}})
+	if errCatalog != nil {
+		t.Fatal(errCatalog)
+	}
+	record := coreusage.Record{Model: "m", Detail: coreusage.Detail{
+		TokenBreakdown: coreusage.NewIndependentTokenBreakdown(1_000_000, 1_000_000, 1_000_000, 1_000_000, 1_000_000, 5_000_000),
+	}}
+	cost := catalog.Estimate(context.Background(), record)
+	if cost == nil {
+		t.Fatal("cost = nil")
+	}
+	if !approx(cost.Input, 2) || !approx(cost.CacheRead, 0.2) || !approx(cost.CacheWrite, 2.5) ||
+		!approx(cost.Output, 10) || !approx(cost.Reasoning, 20) || !approx(cost.Total, 34.7) {
+		t.Fatalf("cost = %+v", cost)
+	}
+	if cost.Currency != config.DefaultPricingCurrency || cost.Multiplier != 1 || cost.PricedAs != "m" {
+		t.Fatalf("cost metadata = %+v", cost)
+	}
+}
+
+func TestEstimateAppliesServiceTierMultipliers(t *testing.T) {
+	catalog, errCatalog := NewCatalog(config.PricingConfig{
+		ServiceTiers: map[string]float64{"priority": 3},
+		Models: []config.ModelPrice{
+			{Match: "gpt-5.4", Input: rate(1), Output: rate(1), ServiceTiers: map[string]float64{"flex": 0.25}},
+		},
+	})
+	if errCatalog != nil {
+		t.Fatal(errCatalog)
+	}
+	record := coreusage.Record{Model: "gpt-5.4", Detail: coreusage.Detail{
+		TokenBreakdown: coreusage.NewIndependentTokenBreakdown(1_000_000, 0, 0, 0, 0, 1_000_000),
+	}}
+
+	cases := []struct {
+		ctxTier, responseTier string
+		want                  float64
+	}{
+		{"", "", 1},
+		{"priority", "", 3},
+		{"flex", "", 0.25},
+		{"priority", "default", 1},
+	}
+	for _, tc := range cases {
+		ctx := coreusage.WithServiceTier(context.Background(), tc.ctxTier)
+		record.ResponseServiceTier = tc.responseTier
+		if cost := catalog.Estimate(ctx, record); cost == nil || !approx(cost.Total, tc.want) {
+			t.Errorf("tier %q/%q: cost = %+v, want %v", tc.ctxTier, tc.responseTier, cost, tc.want)
+		}
+	}
+
+	record.Model, record.ResponseServiceTier = "gpt-5.4-mini", ""
+	if cost := catalog.Estimate(coreusage.WithServiceTier(context.Background(), "flex"), record); cost == nil || cost.Multiplier != 0.5 {
+		t.Fatalf("embedded flex multiplier = %+v, want 0.5", cost)
+	}
+}
+
+func TestEmbeddedDefaultsAreValid(t *testing.T) {
+	catalog := Default()
+	if catalog == nil || len(catalog.defaults) == 0 {
+		t.Fatal("embedded defaults missing")
+	}
+	for _, entry := range catalog.defaults {
+		if entry.rates.Input <= 0 || entry.rates.Output <= 0 || entry.rates.CacheRead > entry.rates.Input {
+			t.Errorf("default %q has rates %+v", entry.pattern, entry.rates)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package ratelimit enforces per-client-key request, token, and concurrent stream limits.
+//
+// Requests and tokens use token buckets that refill continuously over one minute. Requests
+// consume one token on admission; input and output tokens are charged after the fact from
+// usage records, so a key may overdraw its token buckets by one response and is rejected
+// until they refill.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// Reason values identify which limit rejected a request.
+const (
+	ReasonRequests     = "requests"
+	ReasonInputTokens  = "input_tokens"
+	ReasonOutputTokens = "output_tokens"
+	ReasonStreams      = "concurrent_streams"
+)
+
+// streamRetryAfter is the Retry-After hint returned when the stream limit rejects a request.
+const streamRetryAfter = time.Second
+
+// Decision is the result of an admission check.
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	Reason     string
+}
+
+func allow() Decision {
+	return Decision{Allowed: true}
+}
+
+// Limiter keeps bucket state for client keys.
+type Limiter interface {
+	// Admit consumes one request token when the request, input-token, and output-token
+	// buckets all have capacity left. Nothing is consumed when the request is rejected.
+	Admit(ctx context.Context, key string, limits config.ClientRateLimit) (Decision, error)
+	// Charge subtracts token usage from the input and output token buckets.
+	Charge(ctx context.Context, key string, limits config.ClientRateLimit, inputTokens, outputTokens int64) error
+	// AcquireStream reserves a concurrent stream slot. The returned release func must be
+	// called once the stream ends; it is nil when no slot was reserved.
+	AcquireStream(ctx context.Context, key string, limits config.ClientRateLimit) (func(), Decision, error)
+	// Peek reports the capacity left in the buckets of key without consuming any.
+	Peek(ctx context.Context, key string, limits config.ClientRateLimit) (Remaining, error)
+	// Close releases resources held by the limiter.
+	Close() error
+}
+
+// Remaining is the capacity left for a key. Token buckets go negative while a key repays
+// usage charged after the fact. Fields of unlimited buckets are zero.
+type Remaining struct {
+	Requests     float64
+	InputTokens  float64
+	OutputTokens float64
+	Streams      int
+}
+
+// perSecond converts a per-minute limit into a refill rate.
+func perSecond(limit int) float64 {
+	return float64(limit) / 60
+}
+
+// retryAfter returns how long a bucket holding tokens needs to refill to one token.
+func retryAfter(tokens float64, limit int) time.Duration {
+	rate := perSecond(limit)
+	if rate <= 0 || tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / rate * float64(time.Second))
+}
@@ -0,0 +1,191 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// memorySweepInterval bounds how often idle buckets are dropped.
+const memorySweepInterval = time.Minute
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type memoryKeyState struct {
+	requests memoryBucket
+	input    memoryBucket
+	output   memoryBucket
+	streams  int
+	seen     time.Time
+}
+
+// MemoryLimiter keeps buckets in process memory. Limits are enforced per replica.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	keys      map[string]*memoryKeyState
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter creates an empty in-memory limiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{keys: make(map[string]*memoryKeyState), now: time.Now}
+}
+
+func (l *MemoryLimiter) stateLocked(key string, now time.Time) *memoryKeyState {
+	state, ok := l.keys[key]
+	if !ok {
+		state = &memoryKeyState{}
+		l.keys[key] = state
+	}
+	state.seen = now
+	return state
+}
+
+// refill tops up a bucket for the time elapsed since its last update. A bucket that has
+// never been used starts full.
+func (b *memoryBucket) refill(limit int, now time.Time) {
+	capacity := float64(limit)
+	if b.updated.IsZero() {
+		b.tokens = capacity
+		b.updated = now
+		return
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * perSecond(limit)
+		if b.tokens > capacity {
+			b.tokens = capacity
+		}
+	}
+	b.updated = now
+}
+
+// Admit implements Limiter.
+func (l *MemoryLimiter) Admit(_ context.Context, key string, limits config.ClientRateLimit) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweepLocked(now)
+	state := l.stateLocked(key, now)
+
+	decision := allow()
+	check := func(bucket *memoryBucket, limit int, reason string) {
+		if limit <= 0 {
+			return
+		}
+		bucket.refill(limit, now)
+		if wait := retryAfter(bucket.tokens, limit); bucket.tokens < 1 && wait >= decision.RetryAfter {
+			decision = Decision{RetryAfter: wait, Reason: reason}
+		}
+	}
+	check(&state.requests, limits.RequestsPerMinute, ReasonRequests)
+	check(&state.input, limits.InputTokensPerMinute, ReasonInputTokens)
+	check(&state.output, limits.OutputTokensPerMinute, ReasonOutputTokens)
+	if !decision.Allowed {
+		return decision, nil
+	}
+	if limits.RequestsPerMinute > 0 {
+		state.requests.tokens--
+	}
+	return decision, nil
+}
+
+// Charge implements Limiter.
+func (l *MemoryLimiter) Charge(_ context.Context, key string, limits config.ClientRateLimit, inputTokens, outputTokens int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state := l.stateLocked(key, now)
+	charge := func(bucket *memoryBucket, limit int, amount int64) {
+		if limit <= 0 || amount <= 0 {
+			return
+		}
+		bucket.refill(limit, now)
+		bucket.tokens -= float64(amount)
+		// Cap the debt at one minute of budget so a single huge response cannot lock a
+		// key out for longer than the window.
+		if floor := -float64(limit); bucket.tokens < floor {
+			bucket.tokens = floor
+		}
+	}
+	charge(&state.input, limits.InputTokensPerMinute, inputTokens)
+	charge(&state.output, limits.OutputTokensPerMinute, outputTokens)
+	return nil
+}
+
+// AcquireStream implements Limiter.
+func (l *MemoryLimiter) AcquireStream(_ context.Context, key string, limits config.ClientRateLimit) (func(), Decision, error) {
+	if limits.MaxConcurrentStreams <= 0 {
+		return nil, allow(), nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.stateLocked(key, l.now())
+	if state.streams >= limits.MaxConcurrentStreams {
+		return nil, Decision{RetryAfter: streamRetryAfter, Reason: ReasonStreams}, nil
+	}
+	state.streams++
+	release := sync.OnceFunc(func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if current, ok := l.keys[key]; ok && current.streams > 0 {
+			current.streams--
+		}
+	})
+	return release, allow(), nil
+}
+
+// Peek implements Limiter.
+func (l *MemoryLimiter) Peek(_ context.Context, key string, limits config.ClientRateLimit) (Remaining, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state, ok := l.keys[key]
+	if !ok {
+		state = &memoryKeyState{}
+	}
+	peek := func(bucket memoryBucket, limit int) float64 {
+		if limit <= 0 {
+			return 0
+		}
+		bucket.refill(limit, now)
+		return bucket.tokens
+	}
+	remaining := Remaining{
+		Requests:     peek(state.requests, limits.RequestsPerMinute),
+		InputTokens:  peek(state.input, limits.InputTokensPerMinute),
+		OutputTokens: peek(state.output, limits.OutputTokensPerMinute),
+	}
+	if limits.MaxConcurrentStreams > 0 {
+		remaining.Streams = max(limits.MaxConcurrentStreams-state.streams, 0)
+	}
+	return remaining, nil
+}
+
+// sweepLocked drops keys that have been idle long enough for every bucket to refill.
+func (l *MemoryLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < memorySweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, state := range l.keys {
+		// Buckets may hold up to one minute of debt, so two minutes refills any bucket.
+		if state.streams == 0 && now.Sub(state.seen) > 2*time.Minute {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// Close implements Limiter.
+func (l *MemoryLimiter) Close() error {
+	return nil
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newTestMemoryLimiter(now *time.Time) *MemoryLimiter {
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestMemoryLimiter_RequestsPerMinute(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newTestMemoryLimiter(&now)
+	limits := config.ClientRateLimit{RequestsPerMinute: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		decision, errAdmit := limiter.Admit(ctx, "key", limits)
+		if errAdmit != nil || !decision.Allowed {
+			t.Fatalf("Admit() #%d = %+v, %v; want allowed", i+1, decision, errAdmit)
+		}
+	}
+	decision, _ := limiter.Admit(ctx, "key", limits)
+	if decision.Allowed || decision.Reason != ReasonRequests {
+		t.Fatalf("Admit() over limit = %+v, want requests rejection", decision)
+	}
+	if decision.RetryAfter != 30*time.Second {
+		t.Fatalf("RetryAfter = %v, want 30s", decision.RetryAfter)
+	}
+	if other, _ := limiter.Admit(ctx, "other", limits); !other.Allowed {
+		t.Fatalf("Admit() for another key = %+v, want allowed", other)
+	}
+
+	now = now.Add(30 * time.Second)
+	if decision, _ = limiter.Admit(ctx, "key", limits); !decision.Allowed {
+		t.Fatalf("Admit() after refill = %+v, want allowed", decision)
+	}
+}
+
+func TestMemoryLimiter_TokenChargesBlockUntilRefilled(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newTestMemoryLimiter(&now)
+	limits := config.ClientRateLimit{InputTokensPerMinute: 600, OutputTokensPerMinute: 60}
+	ctx := context.Background()
+
+	if decision, _ := limiter.Admit(ctx, "key", limits); !decision.Allowed {
+		t.Fatalf("Admit() = %+v, want allowed", decision)
+	}
+	// Overdraw the output bucket; debt is capped at one minute of budget.
+	if errCharge := limiter.Charge(ctx, "key", limits, 100, 1000); errCharge != nil {
+		t.Fatalf("Charge() error = %v", errCharge)
+	}
+	decision, _ := limiter.Admit(ctx, "key", limits)
+	if decision.Allowed || decision.Reason != ReasonOutputTokens {
+		t.Fatalf("Admit() after charge = %+v, want output token rejection", decision)
+	}
+	if decision.RetryAfter != 61*time.Second {
+		t.Fatalf("RetryAfter = %v, want 61s", decision.RetryAfter)
+	}
+
+	now = now.Add(61 * time.Second)
+	if decision, _ = limiter.Admit(ctx, "key", limits); !decision.Allowed {
+		t.Fatalf("Admit() after refill = %+v, want allowed", decision)
+	}
+}
+
+func TestMemoryLimiter_ConcurrentStreams(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newTestMemoryLimiter(&now)
+	limits := config.ClientRateLimit{MaxConcurrentStreams: 1}
+	ctx := context.Background()
+
+	release, decision, _ := limiter.AcquireStream(ctx, "key", limits)
+	if !decision.Allowed || release == nil {
+		t.Fatalf("AcquireStream() = %+v, want allowed with release", decision)
+	}
+	if _, decision, _ = limiter.AcquireStream(ctx, "key", limits); decision.Allowed || decision.Reason != ReasonStreams {
+		t.Fatalf("second AcquireStream() = %+v, want stream rejection", decision)
+	}
+	release()
+	release()
+	second, decision, _ := limiter.AcquireStream(ctx, "key", limits)
+	if !decision.Allowed {
+		t.Fatalf("AcquireStream() after release = %+v, want allowed", decision)
+	}
+	second()
+	if got := limiter.keys["key"].streams; got != 0 {
+		t.Fatalf("streams after double release = %d, want 0", got)
+	}
+}
+
+func TestMemoryLimiter_PeekDoesNotConsume(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newTestMemoryLimiter(&now)
+	limits := config.ClientRateLimit{RequestsPerMinute: 10, InputTokensPerMinute: 600, MaxConcurrentStreams: 2}
+	ctx := context.Background()
+
+	remaining, errPeek := limiter.Peek(ctx, "key", limits)
+	if errPeek != nil || remaining.Requests != 10 || remaining.InputTokens != 600 || remaining.OutputTokens != 0 || remaining.Streams != 2 {
+		t.Fatalf("Peek() on unseen key = %+v, %v; want full buckets", remaining, errPeek)
+	}
+	if _, errAdmit := limiter.Admit(ctx, "key", limits); errAdmit != nil {
+		t.Fatalf("Admit() error = %v", errAdmit)
+	}
+	_ = limiter.Charge(ctx, "key", limits, 900, 0)
+	release, _, _ := limiter.AcquireStream(ctx, "key", limits)
+	defer release()
+
+	for i := 0; i < 2; i++ {
+		remaining, _ = limiter.Peek(ctx, "key", limits)
+		if remaining.Requests != 9 || remaining.InputTokens != -300 || remaining.Streams != 1 {
+			t.Fatalf("Peek() #%d = %+v, want 9 requests, -300 input tokens, 1 stream", i+1, remaining)
+		}
+	}
+	now = now.Add(30 * time.Second)
+	if remaining, _ = limiter.Peek(ctx, "key", limits); remaining.InputTokens != 0 || remaining.Requests != 10 {
+		t.Fatalf("Peek() after refill = %+v, want refilled buckets", remaining)
+	}
+}
@@ -0,0 +1,292 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// DefaultRedisKeyPrefix namespaces rate limit keys in a shared Redis instance.
+const DefaultRedisKeyPrefix = "cliproxy:ratelimit:"
+
+const (
+	redisTimeout = 2 * time.Second
+	// redisBucketTTL expires idle buckets; they would be full again by then anyway.
+	redisBucketTTL = 3 * time.Minute
+	// redisStreamTTL bounds how long a stream slot leaks when a replica dies mid-stream.
+	redisStreamTTL = time.Hour
+)
+
+// The bucket scripts use the Redis server clock so replicas with skewed clocks agree.
+// Each bucket is a hash with the token count "t" and the last update in milliseconds "u".
+const redisBucketPrelude = `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local function refill(key, capacity)
+	local values = redis.call('HMGET', key, 't', 'u')
+	local tokens = tonumber(values[1])
+	local updated = tonumber(values[2])
+	if tokens == nil or updated == nil then
+		return capacity
+	end
+	local elapsed = now - updated
+	if elapsed < 0 then elapsed = 0 end
+	tokens = tokens + elapsed * capacity / 60000
+	if tokens > capacity then tokens = capacity end
+	return tokens
+end
+`
+
+// redisAdmitScript checks KEYS[1..3] (requests, input, output) against the capacities in
+// ARGV[1..3] and consumes one request token when all have capacity. It returns
+// {allowed, retry_after_ms, reason_index}.
+var redisAdmitScript = redis.NewScript(redisBucketPrelude + `
+local ttl = tonumber(ARGV[4])
+local tokens = {}
+local wait = 0
+local reason = 0
+for i = 1, 3 do
+	local capacity = tonumber(ARGV[i])
+	if capacity > 0 then
+		tokens[i] = refill(KEYS[i], capacity)
+		if tokens[i] < 1 then
+			local needed = math.ceil((1 - tokens[i]) * 60000 / capacity)
+			if needed >= wait then
+				wait = needed
+				reason = i
+			end
+		end
+	end
+end
+if reason > 0 then
+	return {0, wait, reason}
+end
+for i = 1, 3 do
+	local capacity = tonumber(ARGV[i])
+	if capacity > 0 then
+		local value = tokens[i]
+		if i == 1 then value = value - 1 end
+		redis.call('HSET', KEYS[i], 't', tostring(value), 'u', now)
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+return {1, 0, 0}
+`)
+
+// redisChargeScript subtracts ARGV[3] and ARGV[4] tokens from KEYS[1] and KEYS[2], whose
+// capacities are ARGV[1] and ARGV[2]. Debt is capped at one minute of budget.
+var redisChargeScript = redis.NewScript(redisBucketPrelude + `
+local ttl = tonumber(ARGV[5])
+for i = 1, 2 do
+	local capacity = tonumber(ARGV[i])
+	local amount = tonumber(ARGV[i + 2])
+	if capacity > 0 and amount > 0 then
+		local value = refill(KEYS[i], capacity) - amount
+		if value < -capacity then value = -capacity end
+		redis.call('HSET', KEYS[i], 't', tostring(value), 'u', now)
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+return 1
+`)
+
+// redisPeekScript returns the refilled tokens of KEYS[1..3] for the capacities in
+// ARGV[1..3] and the active stream count in KEYS[4], without writing anything.
+var redisPeekScript = redis.NewScript(redisBucketPrelude + `
+local out = {}
+for i = 1, 3 do
+	local capacity = tonumber(ARGV[i])
+	local tokens = 0
+	if capacity > 0 then tokens = refill(KEYS[i], capacity) end
+	out[i] = tostring(tokens)
+end
+out[4] = tostring(tonumber(redis.call('GET', KEYS[4]) or '0'))
+return out
+`)
+
+var redisAcquireStreamScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count > tonumber(ARGV[1]) then
+	redis.call('DECR', KEYS[1])
+	return 0
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+var redisReleaseStreamScript = redis.NewScript(`
+local count = redis.call('DECR', KEYS[1])
+if count <= 0 then
+	redis.call('DEL', KEYS[1])
+end
+return count
+`)
+
+var redisReasons = map[int64]string{
+	1: ReasonRequests,
+	2: ReasonInputTokens,
+	3: ReasonOutputTokens,
+}
+
+// RedisLimiter keeps buckets in Redis so every replica enforces the same limits.
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLimiter connects to the Redis instance at rawURL.
+func NewRedisLimiter(rawURL, prefix string) (*RedisLimiter, error) {
+	options, errParse := redis.ParseURL(strings.TrimSpace(rawURL))
+	if errParse != nil {
+		return nil, fmt.Errorf("redis rate limiter: parse url: %w", errParse)
+	}
+	return newRedisLimiterWithClient(redis.NewClient(options), prefix), nil
+}
+
+func newRedisLimiterWithClient(client *redis.Client, prefix string) *RedisLimiter {
+	if strings.TrimSpace(prefix) == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	return &RedisLimiter{client: client, prefix: prefix}
+}
+
+// keyBase hashes the client key so secrets never appear in Redis key names.
+func (l *RedisLimiter) keyBase(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return l.prefix + hex.EncodeToString(sum[:16]) + ":"
+}
+
+// Admit implements Limiter.
+func (l *RedisLimiter) Admit(ctx context.Context, key string, limits config.ClientRateLimit) (Decision, error) {
+	if limits.RequestsPerMinute <= 0 && limits.InputTokensPerMinute <= 0 && limits.OutputTokensPerMinute <= 0 {
+		return allow(), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	base := l.keyBase(key)
+	keys := []string{base + "rpm", base + "itpm", base + "otpm"}
+	result, errRun := redisAdmitScript.Run(ctx, l.client, keys,
+		max(limits.RequestsPerMinute, 0),
+		max(limits.InputTokensPerMinute, 0),
+		max(limits.OutputTokensPerMinute, 0),
+		redisBucketTTL.Milliseconds(),
+	).Int64Slice()
+	if errRun != nil {
+		return allow(), fmt.Errorf("redis rate limiter: admit: %w", errRun)
+	}
+	if len(result) != 3 {
+		return allow(), fmt.Errorf("redis rate limiter: admit: unexpected reply length %d", len(result))
+	}
+	if result[0] == 1 {
+		return allow(), nil
+	}
+	return Decision{
+		RetryAfter: time.Duration(result[1]) * time.Millisecond,
+		Reason:     redisReasons[result[2]],
+	}, nil
+}
+
+// Charge implements Limiter.
+func (l *RedisLimiter) Charge(ctx context.Context, key string, limits config.ClientRateLimit, inputTokens, outputTokens int64) error {
+	if (limits.InputTokensPerMinute <= 0 || inputTokens <= 0) && (limits.OutputTokensPerMinute <= 0 || outputTokens <= 0) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	base := l.keyBase(key)
+	keys := []string{base + "itpm", base + "otpm"}
+	errRun := redisChargeScript.Run(ctx, l.client, keys,
+		max(limits.InputTokensPerMinute, 0),
+		max(limits.OutputTokensPerMinute, 0),
+		max(inputTokens, 0),
+		max(outputTokens, 0),
+		redisBucketTTL.Milliseconds(),
+	).Err()
+	if errRun != nil {
+		return fmt.Errorf("redis rate limiter: charge: %w", errRun)
+	}
+	return nil
+}
+
+// AcquireStream implements Limiter.
+func (l *RedisLimiter) AcquireStream(ctx context.Context, key string, limits config.ClientRateLimit) (func(), Decision, error) {
+	if limits.MaxConcurrentStreams <= 0 {
+		return nil, allow(), nil
+	}
+	runCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	streamKey := l.keyBase(key) + "streams"
+	acquired, errRun := redisAcquireStreamScript.Run(runCtx, l.client, []string{streamKey},
+		limits.MaxConcurrentStreams,
+		redisStreamTTL.Milliseconds(),
+	).Int64()
+	if errRun != nil {
+		return nil, allow(), fmt.Errorf("redis rate limiter: acquire stream: %w", errRun)
+	}
+	if acquired != 1 {
+		return nil, Decision{RetryAfter: streamRetryAfter, Reason: ReasonStreams}, nil
+	}
+	// Release on a fresh context: the request context is usually canceled by then.
+	ctxRelease := context.WithoutCancel(ctx)
+	release := func() {
+		releaseCtx, cancelRelease := context.WithTimeout(ctxRelease, redisTimeout)
+		defer cancelRelease()
+		_ = redisReleaseStreamScript.Run(releaseCtx, l.client, []string{streamKey}).Err()
+	}
+	return sync.OnceFunc(release), allow(), nil
+}
+
+// Peek implements Limiter.
+func (l *RedisLimiter) Peek(ctx context.Context, key string, limits config.ClientRateLimit) (Remaining, error) {
+	if limits.IsZero() {
+		return Remaining{}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	base := l.keyBase(key)
+	keys := []string{base + "rpm", base + "itpm", base + "otpm", base + "streams"}
+	result, errRun := redisPeekScript.Run(ctx, l.client, keys,
+		max(limits.RequestsPerMinute, 0),
+		max(limits.InputTokensPerMinute, 0),
+		max(limits.OutputTokensPerMinute, 0),
+	).StringSlice()
+	if errRun != nil {
+		return Remaining{}, fmt.Errorf("redis rate limiter: peek: %w", errRun)
+	}
+	if len(result) != 4 {
+		return Remaining{}, fmt.Errorf("redis rate limiter: peek: unexpected reply length %d", len(result))
+	}
+	values := make([]float64, len(result))
+	for i, raw := range result {
+		value, errParse := strconv.ParseFloat(raw, 64)
+		if errParse != nil {
+			return Remaining{}, fmt.Errorf("redis rate limiter: peek: %w", errParse)
+		}
+		values[i] = value
+	}
+	remaining := Remaining{Requests: values[0], InputTokens: values[1], OutputTokens: values[2]}
+	if limits.MaxConcurrentStreams > 0 {
+		remaining.Streams = max(limits.MaxConcurrentStreams-int(values[3]), 0)
+	}
+	return remaining, nil
+}
+
+// Close implements Limiter.
+func (l *RedisLimiter) Close() error {
+	if l == nil || l.client == nil {
+		return nil
+	}
+	return l.client.Close()
+}
@@ -0,0 +1,200 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+type redisScriptCall struct {
+	script string
+	keys   []string
+	args   []string
+}
+
+// newRedisScriptTestLimiter serves a fake RESP2 Redis that answers EVALSHA with NOSCRIPT and
+// EVAL with reply(call), so the tests exercise the client side of the Lua scripts.
+func newRedisScriptTestLimiter(t *testing.T, reply func(redisScriptCall) string) (*RedisLimiter, func() []redisScriptCall) {
+	t.Helper()
+
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("listen: %v", errListen)
+	}
+	var mu sync.Mutex
+	var calls []redisScriptCall
+	go func() {
+		for {
+			conn, errAccept := listener.Accept()
+			if errAccept != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() { _ = conn.Close() }()
+				reader := bufio.NewReader(conn)
+				for {
+					args, errRead := readRedisTestCommand(reader)
+					if errRead != nil {
+						return
+					}
+					response := "+OK\r\n"
+					switch strings.ToUpper(args[0]) {
+					case "HELLO":
+						response = "-ERR unknown command 'HELLO'\r\n"
+					case "EVALSHA":
+						response = "-NOSCRIPT No matching script.\r\n"
+					case "EVAL":
+						numKeys, _ := strconv.Atoi(args[2])
+						call := redisScriptCall{script: args[1], keys: args[3 : 3+numKeys], args: args[3+numKeys:]}
+						mu.Lock()
+						calls = append(calls, call)
+						mu.Unlock()
+						response = reply(call)
+					}
+					if _, errWrite := io.WriteString(conn, response); errWrite != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String(), Protocol: 2, DisableIdentity: true})
+	limiter := newRedisLimiterWithClient(client, "")
+	t.Cleanup(func() {
+		_ = limiter.Close()
+		_ = listener.Close()
+	})
+	return limiter, func() []redisScriptCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]redisScriptCall(nil), calls...)
+	}
+}
+
+func readRedisTestCommand(reader *bufio.Reader) ([]string, error) {
+	line, errRead := reader.ReadString('\n')
+	if errRead != nil {
+		return nil, errRead
+	}
+	count, errCount := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+	if errCount != nil {
+		return nil, errCount
+	}
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		header, errHeader := reader.ReadString('\n')
+		if errHeader != nil {
+			return nil, errHeader
+		}
+		size, errSize := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(header), "$"))
+		if errSize != nil {
+			return nil, errSize
+		}
+		payload := make([]byte, size+2)
+		if _, errPayload := io.ReadFull(reader, payload); errPayload != nil {
+			return nil, errPayload
+		}
+		args = append(args, string(payload[:size]))
+	}
+	return args, nil
+}
+
+func TestRedisLimiter_AdmitParsesRejection(t *testing.T) {
+	limiter, calls := newRedisScriptTestLimiter(t, func(redisScriptCall) string {
+		return "*3\r\n:0\r\n:1500\r\n:2\r\n"
+	})
+	limits := config.ClientRateLimit{RequestsPerMinute: 10, InputTokensPerMinute: 1000}
+
+	decision, errAdmit := limiter.Admit(context.Background(), "sk-secret-client-key", limits)
+	if errAdmit != nil {
+		t.Fatalf("Admit() error = %v", errAdmit)
+	}
+	if decision.Allowed || decision.Reason != ReasonInputTokens || decision.RetryAfter != 1500*time.Millisecond {
+		t.Fatalf("Admit() = %+v, want input token rejection after 1.5s", decision)
+	}
+
+	recorded := calls()
+	if len(recorded) != 1 {
+		t.Fatalf("EVAL calls = %d, want 1", len(recorded))
+	}
+	call := recorded[0]
+	if len(call.keys) != 3 || !strings.HasSuffix(call.keys[0], ":rpm") {
+		t.Fatalf("admit keys = %v, want rpm/itpm/otpm buckets", call.keys)
+	}
+	for _, key := range call.keys {
+		if !strings.HasPrefix(key, DefaultRedisKeyPrefix) || strings.Contains(key, "sk-secret-client-key") {
+			t.Fatalf("redis key %q leaks the client key or lacks the prefix", key)
+		}
+	}
+	if want := []string{"10", "1000", "0", fmt.Sprint(redisBucketTTL.Milliseconds())}; strings.Join(call.args, ",") != strings.Join(want, ",") {
+		t.Fatalf("admit args = %v, want %v", call.args, want)
+	}
+}
+
+func TestRedisLimiter_PeekParsesBuckets(t *testing.T) {
+	limiter, calls := newRedisScriptTestLimiter(t, func(redisScriptCall) string {
+		return "*4\r\n$3\r\n9.5\r\n$4\r\n-120\r\n$1\r\n0\r\n$1\r\n1\r\n"
+	})
+	limits := config.ClientRateLimit{RequestsPerMinute: 10, InputTokensPerMinute: 1000, MaxConcurrentStreams: 3}
+
+	remaining, errPeek := limiter.Peek(context.Background(), "key", limits)
+	if errPeek != nil {
+		t.Fatalf("Peek() error = %v", errPeek)
+	}
+	if remaining.Requests != 9.5 || remaining.InputTokens != -120 || remaining.OutputTokens != 0 || remaining.Streams != 2 {
+		t.Fatalf("Peek() = %+v, want 9.5 requests, -120 input tokens, 2 streams", remaining)
+	}
+	recorded := calls()
+	if len(recorded) != 1 || len(recorded[0].keys) != 4 || !strings.HasSuffix(recorded[0].keys[3], ":streams") {
+		t.Fatalf("peek calls = %+v, want one script over the bucket and stream keys", recorded)
+	}
+	if strings.Contains(recorded[0].script, "HSET") {
+		t.Fatal("peek script writes bucket state")
+	}
+}
+
+func TestRedisLimiter_StreamSlotReleasedOnce(t *testing.T) {
+	limiter, calls := newRedisScriptTestLimiter(t, func(redisScriptCall) string {
+		return ":1\r\n"
+	})
+	limits := config.ClientRateLimit{MaxConcurrentStreams: 2}
+
+	release, decision, errAcquire := limiter.AcquireStream(context.Background(), "key", limits)
+	if errAcquire != nil || !decision.Allowed || release == nil {
+		t.Fatalf("AcquireStream() = %+v, %v; want allowed with release", decision, errAcquire)
+	}
+	release()
+	release()
+
+	recorded := calls()
+	if len(recorded) != 2 {
+		t.Fatalf("EVAL calls = %d, want acquire and a single release", len(recorded))
+	}
+	if !strings.Contains(recorded[0].script, "INCR") || !strings.Contains(recorded[1].script, "DECR") {
+		t.Fatalf("unexpected stream scripts: %q / %q", recorded[0].script, recorded[1].script)
+	}
+	if recorded[0].keys[0] != recorded[1].keys[0] || !strings.HasSuffix(recorded[0].keys[0], ":streams") {
+		t.Fatalf("stream keys = %q / %q, want the same streams key", recorded[0].keys[0], recorded[1].keys[0])
+	}
+}
+
+func TestRedisLimiter_ErrorsAllowRequest(t *testing.T) {
+	limiter, _ := newRedisScriptTestLimiter(t, func(redisScriptCall) string {
+		return "-ERR boom\r\n"
+	})
+	decision, errAdmit := limiter.Admit(context.Background(), "key", config.ClientRateLimit{RequestsPerMinute: 1})
+	if errAdmit == nil || !decision.Allowed {
+		t.Fatalf("Admit() = %+v, %v; want allowed with error", decision, errAdmit)
+	}
+}
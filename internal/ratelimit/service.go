@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	coreusage.RegisterNamedPlugin("client-rate-limit", defaultService)
+}
+
+var defaultService = NewService()
+
+// Default returns the process-wide service used by the HTTP middleware.
+func Default() *Service {
+	return defaultService
+}
+
+// Configure applies cfg to the process-wide service.
+func Configure(cfg *config.Config) {
+	defaultService.Configure(cfg)
+}
+
+// Service resolves the limits of a client key and applies them through a Limiter. It also
+// charges token usage from usage records.
+type Service struct {
+	mu       sync.RWMutex
+	limiter  Limiter
+	backend  string
+	redisURL string
+	defaults config.ClientRateLimit
+	perKey   map[string]config.ClientRateLimit
+}
+
+// NewService creates a service without limits.
+func NewService() *Service {
+	return &Service{}
+}
+
+// Configure replaces the configured limits. The limiter is rebuilt only when the backend
+// changes, so bucket state survives unrelated config reloads. A Redis backend that cannot be
+// configured falls back to the in-memory limiter.
+func (s *Service) Configure(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	var settings config.ClientRateLimitConfig
+	var perKey map[string]config.ClientRateLimit
+	if cfg != nil {
+		settings = cfg.ClientRateLimit
+		for i := range cfg.ClientAPIKeys {
+			entry := &cfg.ClientAPIKeys[i]
+			if entry.RateLimit == nil || strings.TrimSpace(entry.Key) == "" {
+				continue
+			}
+			if perKey == nil {
+				perKey = make(map[string]config.ClientRateLimit)
+			}
+			perKey[strings.TrimSpace(entry.Key)] = *entry.RateLimit
+		}
+	}
+	backend := settings.NormalizedBackend()
+	redisURL := strings.TrimSpace(settings.RedisURL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults = settings.Default
+	s.perKey = perKey
+	if s.limiter != nil && s.backend == backend && s.redisURL == redisURL {
+		return
+	}
+
+	var next Limiter
+	if backend == config.ClientRateLimitBackendRedis {
+		redisLimiter, errRedis := NewRedisLimiter(redisURL, "")
+		if errRedis != nil {
+			log.Warnf("client rate limit: %v; falling back to in-memory limiter", errRedis)
+		} else {
+			next = redisLimiter
+		}
+	}
+	if next == nil {
+		next = NewMemoryLimiter()
+	}
+	if s.limiter != nil {
+		if errClose := s.limiter.Close(); errClose != nil {
+			log.Debugf("client rate limit: close previous limiter: %v", errClose)
+		}
+	}
+	s.limiter = next
+	s.backend = backend
+	s.redisURL = redisURL
+}
+
+// LimitsFor returns the limits applied to key.
+func (s *Service) LimitsFor(key string) config.ClientRateLimit {
+	if s == nil {
+		return config.ClientRateLimit{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if limits, ok := s.perKey[key]; ok {
+		return limits
+	}
+	return s.defaults
+}
+
+func (s *Service) current(key string) (Limiter, config.ClientRateLimit) {
+	if s == nil || key == "" {
+		return nil, config.ClientRateLimit{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	limits, ok := s.perKey[key]
+	if !ok {
+		limits = s.defaults
+	}
+	return s.limiter, limits
+}
+
+// Admit checks a request from key. Streaming requests also reserve a concurrent stream slot,
+// released by calling the returned func. Limiter failures are logged and the request is
+// allowed, so a Redis outage does not take the proxy down.
+func (s *Service) Admit(ctx context.Context, key string, stream bool) (func(), Decision) {
+	limiter, limits := s.current(key)
+	if limiter == nil || limits.IsZero() {
+		return nil, allow()
+	}
+
+	var release func()
+	if stream {
+		var decision Decision
+		var errAcquire error
+		release, decision, errAcquire = limiter.AcquireStream(ctx, key, limits)
+		if errAcquire != nil {
+			log.Warnf("client rate limit: %v", errAcquire)
+		} else if !decision.Allowed {
+			return nil, decision
+		}
+	}
+	decision, errAdmit := limiter.Admit(ctx, key, limits)
+	if errAdmit != nil {
+		log.Warnf("client rate limit: %v", errAdmit)
+		return release, allow()
+	}
+	if !decision.Allowed {
+		if release != nil {
+			release()
+		}
+		return nil, decision
+	}
+	return release, decision
+}
+
+// Status is the rate limit state of a client key.
+type Status struct {
+	Limits    config.ClientRateLimit
+	Remaining Remaining
+}
+
+// Status reports the limits of key and the capacity left under them. The second result is
+// false when key has no limits.
+func (s *Service) Status(ctx context.Context, key string) (Status, bool, error) {
+	limiter, limits := s.current(key)
+	if limiter == nil || limits.IsZero() {
+		return Status{}, false, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	remaining, errPeek := limiter.Peek(ctx, key, limits)
+	return Status{Limits: limits, Remaining: remaining}, true, errPeek
+}
+
+// HandleUsage implements coreusage.Plugin by charging reported tokens to the client key.
+func (s *Service) HandleUsage(ctx context.Context, record coreusage.Record) {
+	key := strings.TrimSpace(record.APIKey)
+	limiter, limits := s.current(key)
+	if limiter == nil || (limits.InputTokensPerMinute <= 0 && limits.OutputTokensPerMinute <= 0) {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if errCharge := limiter.Charge(ctx, key, limits, record.Detail.InputTokens, record.Detail.OutputTokens); errCharge != nil {
+		log.Warnf("client rate limit: %v", errCharge)
+	}
+}
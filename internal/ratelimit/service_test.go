@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestService_PerKeyLimitsOverrideDefault(t *testing.T) {
+	service := NewService()
+	service.Configure(&config.Config{
+		SDKConfig: config.SDKConfig{
+			APIKeys: []string{"plain"},
+			ClientAPIKeys: []config.ClientAPIKey{
+				{Key: "limited", RateLimit: &config.ClientRateLimit{RequestsPerMinute: 1}},
+				{Key: "inherits"},
+			},
+		},
+		ClientRateLimit: config.ClientRateLimitConfig{Default: config.ClientRateLimit{RequestsPerMinute: 5}},
+	})
+
+	if got := service.LimitsFor("limited").RequestsPerMinute; got != 1 {
+		t.Fatalf("LimitsFor(limited) rpm = %d, want 1", got)
+	}
+	for _, key := range []string{"plain", "inherits"} {
+		if got := service.LimitsFor(key).RequestsPerMinute; got != 5 {
+			t.Fatalf("LimitsFor(%s) rpm = %d, want default 5", key, got)
+		}
+	}
+
+	if _, decision := service.Admit(context.Background(), "limited", false); !decision.Allowed {
+		t.Fatalf("first Admit() = %+v, want allowed", decision)
+	}
+	if _, decision := service.Admit(context.Background(), "limited", false); decision.Allowed {
+		t.Fatal("second Admit() allowed, want requests rejection")
+	}
+}
+
+func TestService_StreamRejectionDoesNotConsumeRequests(t *testing.T) {
+	service := NewService()
+	service.Configure(&config.Config{ClientRateLimit: config.ClientRateLimitConfig{
+		Default: config.ClientRateLimit{RequestsPerMinute: 2, MaxConcurrentStreams: 1},
+	}})
+
+	release, decision := service.Admit(context.Background(), "key", true)
+	if !decision.Allowed || release == nil {
+		t.Fatalf("Admit(stream) = %+v, want allowed with release", decision)
+	}
+	defer release()
+	if _, decision = service.Admit(context.Background(), "key", true); decision.Allowed || decision.Reason != ReasonStreams {
+		t.Fatalf("second Admit(stream) = %+v, want stream rejection", decision)
+	}
+	if _, decision = service.Admit(context.Background(), "key", false); !decision.Allowed {
+		t.Fatalf("Admit() after stream rejection = %+v, want allowed", decision)
+	}
+}
+
+func TestService_HandleUsageChargesClientKey(t *testing.T) {
+	service := NewService()
+	service.Configure(&config.Config{ClientRateLimit: config.ClientRateLimitConfig{
+		Default: config.ClientRateLimit{InputTokensPerMinute: 100},
+	}})
+
+	service.HandleUsage(context.Background(), coreusage.Record{
+		APIKey: "key",
+		Detail: coreusage.Detail{InputTokens: 150},
+	})
+	_, decision := service.Admit(context.Background(), "key", false)
+	if decision.Allowed || decision.Reason != ReasonInputTokens {
+		t.Fatalf("Admit() after usage = %+v, want input token rejection", decision)
+	}
+	if decision.RetryAfter <= 0 || decision.RetryAfter > 31*time.Second {
+		t.Fatalf("RetryAfter = %v, want about 30s", decision.RetryAfter)
+	}
+}
+
+func TestService_ConfigureKeepsLimiterForSameBackend(t *testing.T) {
+	service := NewService()
+	cfg := &config.Config{ClientRateLimit: config.ClientRateLimitConfig{Default: config.ClientRateLimit{RequestsPerMinute: 1}}}
+	service.Configure(cfg)
+	first := service.limiter
+	service.Configure(cfg)
+	if service.limiter != first {
+		t.Fatal("Configure() replaced the limiter without a backend change")
+	}
+}
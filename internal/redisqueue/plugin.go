@@ -116,6 +116,8 @@ func (p *usageQueuePlugin) HandleUsage(ctx context.Context, record coreusage.Rec
 		ReasoningEffort:     reasoningEffort,
 		ServiceTier:         serviceTier,
 		ResponseServiceTier: responseServiceTier,
+		Cost:                record.Cost,
+		ResponseCache:       record.ResponseCache,
 	})
 	if err != nil {
 		return
@@ -138,6 +140,8 @@ type queuedUsageDetail struct {
 	ReasoningEffort     string                   `json:"reasoning_effort"`
 	ServiceTier         string                   `json:"service_tier"`
 	ResponseServiceTier string                   `json:"response_service_tier,omitempty"`
+	Cost                *coreusage.Cost          `json:"cost,omitempty"`
+	ResponseCache       string                   `json:"response_cache,omitempty"`
 }
 
 type requestDetail struct {
@@ -818,6 +818,30 @@ func (r *ModelRegistry) ClientSupportsModel(clientID, modelID string) bool {
 	return false
 }
 
+// ClientModelMaxContextLength returns the configured max-context-length for modelID on
+// clientID, or 0 when the client did not register an explicit context window.
+func (r *ModelRegistry) ClientModelMaxContextLength(clientID, modelID string) int {
+	clientID = strings.TrimSpace(clientID)
+	modelID = strings.TrimSpace(modelID)
+	if clientID == "" || modelID == "" {
+		return 0
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	clientInfos := r.clientModelInfos[clientID]
+	if info, ok := clientInfos[modelID]; ok && info != nil {
+		return info.MaxContextLength
+	}
+	for id, info := range clientInfos {
+		if info != nil && strings.EqualFold(strings.TrimSpace(id), modelID) {
+			return info.MaxContextLength
+		}
+	}
+	return 0
+}
+
 // GetAvailableModels returns all models that have at least one available client
 // Parameters:
 //   - handlerType: The handler type to filter models for (e.g., "openai", "claude", "gemini")
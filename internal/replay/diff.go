@@ -0,0 +1,213 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	diffContextLines = 3
+	// maxDiffCells bounds the LCS table; larger inputs fall back to a whole-block replace.
+	maxDiffCells = 4_000_000
+)
+
+// Diff returns a unified diff of original and replayed, or "" when they match. JSON
+// documents are pretty-printed with sorted keys first so the diff shows field changes
+// rather than formatting.
+func Diff(original, replayed string) string {
+	a := splitDiffLines(normalizeForDiff(original))
+	b := splitDiffLines(normalizeForDiff(replayed))
+	ops := diffLines(a, b)
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+	var out strings.Builder
+	out.WriteString("--- original\n+++ replay\n")
+	writeHunks(&out, ops)
+	return out.String()
+}
+
+func normalizeForDiff(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return ""
+	}
+	if formatted, ok := formatJSON([]byte(trimmed)); ok {
+		return formatted
+	}
+	// SSE streams and other line-based payloads: pretty-print each JSON line.
+	lines := strings.Split(trimmed, "\n")
+	for i, line := range lines {
+		prefix, payload := line, ""
+		if idx := strings.IndexAny(line, "{["); idx >= 0 {
+			prefix, payload = line[:idx], line[idx:]
+		}
+		if payload == "" {
+			continue
+		}
+		if formatted, ok := formatJSON([]byte(payload)); ok {
+			lines[i] = prefix + formatted
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatJSON(payload []byte) (string, bool) {
+	if !json.Valid(payload) {
+		return "", false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if errDecode := decoder.Decode(&value); errDecode != nil {
+		return "", false
+	}
+	// Marshalling maps sorts keys, so field order differences do not show up as changes.
+	formatted, errMarshal := json.MarshalIndent(value, "", "  ")
+	if errMarshal != nil {
+		return "", false
+	}
+	return string(formatted), true
+}
+
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// writeHunks prints changed lines with diffContextLines of surrounding context.
+func writeHunks(out *strings.Builder, ops []diffOp) {
+	keep := make([]bool, len(ops))
+	for idx, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		for k := max(0, idx-diffContextLines); k <= min(len(ops)-1, idx+diffContextLines); k++ {
+			keep[k] = true
+		}
+	}
+	lineA, lineB := 1, 1
+	for idx := 0; idx < len(ops); {
+		if !keep[idx] {
+			lineA, lineB = advance(ops[idx], lineA, lineB)
+			idx++
+			continue
+		}
+		end := idx
+		for end < len(ops) && keep[end] {
+			end++
+		}
+		countA, countB := 0, 0
+		for _, op := range ops[idx:end] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", lineA, countA, lineB, countB)
+		for _, op := range ops[idx:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+			lineA, lineB = advance(op, lineA, lineB)
+		}
+		idx = end
+	}
+}
+
+func advance(op diffOp, lineA, lineB int) (int, int) {
+	if op.kind != '+' {
+		lineA++
+	}
+	if op.kind != '-' {
+		lineB++
+	}
+	return lineA, lineB
+}
@@ -0,0 +1,193 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var sectionHeaderPattern = regexp.MustCompile(`^=== ([A-Z ]+?)(?: (\d+))? ===$`)
+
+// Log is the replayable content of a stored request log.
+type Log struct {
+	URL       string
+	Method    string
+	Transport string
+	Headers   http.Header
+	Body      []byte
+
+	// Upstream holds the upstream attempts in order; the last one produced the response.
+	Upstream []Exchange
+
+	Status          int
+	ResponseHeaders http.Header
+	Response        []byte
+}
+
+// Exchange is one upstream request and, when available, its response.
+type Exchange struct {
+	URL      string `json:"url,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Body     string `json:"body,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Response string `json:"response,omitempty"`
+}
+
+type section struct {
+	name  string
+	index int
+	lines []string
+}
+
+// Parse reads a request log written by the file request logger.
+func Parse(data []byte) (*Log, error) {
+	sections := splitSections(data)
+	entry := &Log{Headers: make(http.Header), ResponseHeaders: make(http.Header)}
+	upstream := make(map[int]*Exchange)
+	var order []int
+	attempt := func(index int) *Exchange {
+		if existing, ok := upstream[index]; ok {
+			return existing
+		}
+		exchange := &Exchange{}
+		upstream[index] = exchange
+		order = append(order, index)
+		return exchange
+	}
+	seenInfo := false
+	for _, sec := range sections {
+		switch sec.name {
+		case "REQUEST INFO":
+			seenInfo = true
+			for _, line := range sec.lines {
+				key, value, ok := strings.Cut(line, ": ")
+				if !ok {
+					continue
+				}
+				switch key {
+				case "URL":
+					entry.URL = value
+				case "Method":
+					entry.Method = value
+				case "Downstream Transport":
+					entry.Transport = value
+				}
+			}
+		case "HEADERS":
+			for _, line := range sec.lines {
+				if key, value, ok := strings.Cut(line, ": "); ok {
+					entry.Headers.Add(key, value)
+				}
+			}
+		case "REQUEST BODY":
+			entry.Body = []byte(joinBody(sec.lines))
+		case "API REQUEST":
+			if sec.index == 0 {
+				continue
+			}
+			exchange := attempt(sec.index)
+			for i, line := range sec.lines {
+				if line == "Body:" {
+					exchange.Body = joinBody(sec.lines[i+1:])
+					break
+				}
+				if value, ok := strings.CutPrefix(line, "Upstream URL: "); ok {
+					exchange.URL = value
+				} else if value, ok = strings.CutPrefix(line, "HTTP Method: "); ok {
+					exchange.Method = value
+				}
+			}
+		case "API RESPONSE":
+			if sec.index == 0 {
+				continue
+			}
+			exchange := attempt(sec.index)
+			for i, line := range sec.lines {
+				if line == "Body:" {
+					exchange.Response = joinBody(sec.lines[i+1:])
+					break
+				}
+				if value, ok := strings.CutPrefix(line, "Status: "); ok {
+					exchange.Status, _ = strconv.Atoi(value)
+				} else if value, ok = strings.CutPrefix(line, "Error: "); ok {
+					exchange.Response = value
+				}
+			}
+		case "RESPONSE":
+			parseResponse(entry, sec.lines)
+		}
+	}
+	if !seenInfo {
+		return nil, fmt.Errorf("not a request log: missing REQUEST INFO section")
+	}
+	if entry.URL == "" || entry.Method == "" {
+		return nil, fmt.Errorf("request log has no URL or method")
+	}
+	for _, index := range order {
+		entry.Upstream = append(entry.Upstream, *upstream[index])
+	}
+	return entry, nil
+}
+
+// LastUpstream returns the final upstream attempt, if any.
+func (l *Log) LastUpstream() (Exchange, bool) {
+	if l == nil || len(l.Upstream) == 0 {
+		return Exchange{}, false
+	}
+	return l.Upstream[len(l.Upstream)-1], true
+}
+
+func parseResponse(entry *Log, lines []string) {
+	i := 0
+	if i < len(lines) {
+		if value, ok := strings.CutPrefix(lines[i], "Status: "); ok {
+			entry.Status, _ = strconv.Atoi(value)
+			i++
+		}
+	}
+	for ; i < len(lines); i++ {
+		if lines[i] == "" {
+			i++
+			break
+		}
+		if key, value, ok := strings.Cut(lines[i], ": "); ok {
+			entry.ResponseHeaders.Add(key, value)
+		}
+	}
+	if i < len(lines) {
+		entry.Response = []byte(joinBody(lines[i:]))
+	}
+}
+
+func splitSections(data []byte) []section {
+	var sections []section
+	current := -1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if m := sectionHeaderPattern.FindStringSubmatch(line); m != nil {
+			index, _ := strconv.Atoi(m[2])
+			sections = append(sections, section{name: m[1], index: index})
+			current = len(sections) - 1
+			continue
+		}
+		if current >= 0 {
+			sections[current].lines = append(sections[current].lines, line)
+		}
+	}
+	return sections
+}
+
+// joinBody restores a section body, dropping the blank lines used as section spacing.
+func joinBody(lines []string) string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return strings.Join(lines[:end], "\n")
+}
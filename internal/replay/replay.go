@@ -0,0 +1,320 @@
+// Package replay re-executes a request captured in a stored request log through the
+// running proxy pipeline and diffs the result against the original exchange.
+//
+// A replay is served by the same gin engine as client traffic. The request carries a
+// Session on its context, which the API server accepts in place of a client API key and
+// which executors consult to pin credentials and capture the translated upstream payload.
+// In translate-only mode the conductor runs each executor under Guard, whose context is
+// cancelled as soon as the payload is captured, so nothing reaches the upstream whatever
+// transport the executor uses.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// GinKey stores the active Session on the gin context.
+	GinKey = "cliproxy.replay"
+	// Principal is the client identity recorded for replayed requests.
+	Principal = "replay"
+	// AccessProvider is the access provider name recorded for replayed requests.
+	AccessProvider = "replay"
+
+	maxCapturedResponseBytes = 8 << 20
+)
+
+// ErrTranslateOnly is returned by the upstream transport of translate-only replays. It
+// wraps context.Canceled so the request is treated like a client abort and never counts
+// against the credential.
+var ErrTranslateOnly = fmt.Errorf("replay: translate only, upstream request not sent: %w", context.Canceled)
+
+var modelPathPattern = regexp.MustCompile(`(/models/)([^/:?]+)`)
+
+// headers that are masked in request logs or describe the original connection.
+var skippedReplayHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-api-key":           {},
+	"x-goog-api-key":      {},
+	"api-key":             {},
+	"cookie":              {},
+	"content-length":      {},
+	"accept-encoding":     {},
+	"connection":          {},
+	"host":                {},
+	"upgrade":             {},
+}
+
+// Options controls how a logged request is replayed.
+type Options struct {
+	// AuthID pins execution to one credential.
+	AuthID string
+	// Model replaces the requested model in the body and URL path.
+	Model string
+	// TranslateOnly stops before the upstream request is sent.
+	TranslateOnly bool
+}
+
+// Result describes a replay and how it differs from the logged request.
+type Result struct {
+	TranslateOnly    bool       `json:"translate_only"`
+	Status           int        `json:"status,omitempty"`
+	Response         string     `json:"response,omitempty"`
+	Upstream         []Exchange `json:"upstream"`
+	OriginalUpstream *Exchange  `json:"original_upstream,omitempty"`
+	Diff             DiffResult `json:"diff"`
+}
+
+// DiffResult holds unified diffs against the logged exchange. Empty fields mean no change.
+type DiffResult struct {
+	UpstreamRequest  string `json:"upstream_request"`
+	UpstreamResponse string `json:"upstream_response,omitempty"`
+	Response         string `json:"response,omitempty"`
+}
+
+// Session carries replay options to the pipeline and collects upstream exchanges.
+type Session struct {
+	opts   Options
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	upstream []Exchange
+	guards   []context.CancelCauseFunc
+}
+
+type sessionContextKey struct{}
+
+// AuthID returns the pinned credential ID, if any.
+func (s *Session) AuthID() string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(s.opts.AuthID)
+}
+
+// TranslateOnly reports whether upstream requests must not be sent.
+func (s *Session) TranslateOnly() bool {
+	return s != nil && s.opts.TranslateOnly
+}
+
+// RecordRequest captures a translated upstream request. In translate-only mode it also
+// cancels every guarded executor context, so the request cannot be sent.
+func (s *Session) RecordRequest(url, method string, body []byte) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.upstream = append(s.upstream, Exchange{URL: url, Method: method, Body: string(body)})
+	guards := s.guards
+	if s.opts.TranslateOnly {
+		s.guards = nil
+	}
+	s.mu.Unlock()
+	if s.opts.TranslateOnly {
+		for _, cancel := range guards {
+			cancel(ErrTranslateOnly)
+		}
+	}
+}
+
+// Guard returns the context an executor runs under. For translate-only replays it is
+// cancelled with ErrTranslateOnly once the executor captures its upstream payload, or when
+// the replay ends; otherwise ctx is returned unchanged.
+func Guard(ctx context.Context) context.Context {
+	session := FromContext(ctx)
+	if !session.TranslateOnly() {
+		return ctx
+	}
+	guarded, cancel := context.WithCancelCause(ctx)
+	session.mu.Lock()
+	session.guards = append(session.guards, cancel)
+	session.mu.Unlock()
+	return guarded
+}
+
+func (s *Session) releaseGuards() {
+	s.mu.Lock()
+	guards := s.guards
+	s.guards = nil
+	s.mu.Unlock()
+	for _, cancel := range guards {
+		cancel(ErrTranslateOnly)
+	}
+}
+
+// RecordResponseStatus records the upstream status of the latest request.
+func (s *Session) RecordResponseStatus(status int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.upstream); n > 0 && s.upstream[n-1].Status == 0 {
+		s.upstream[n-1].Status = status
+	}
+}
+
+// AppendResponse adds an upstream response chunk to the latest request.
+func (s *Session) AppendResponse(chunk []byte) {
+	chunk = bytes.TrimSpace(chunk)
+	if s == nil || len(chunk) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.upstream)
+	if n == 0 {
+		return
+	}
+	last := &s.upstream[n-1]
+	if len(last.Response)+len(chunk) > maxCapturedResponseBytes {
+		return
+	}
+	if last.Response != "" {
+		last.Response += "\n"
+	}
+	last.Response += string(chunk)
+}
+
+func (s *Session) exchanges() []Exchange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Exchange(nil), s.upstream...)
+}
+
+// FromRequest returns the session attached by Run, if any.
+func FromRequest(r *http.Request) *Session {
+	if r == nil {
+		return nil
+	}
+	session, _ := r.Context().Value(sessionContextKey{}).(*Session)
+	return session
+}
+
+// FromContext returns the session for an execution context, either directly or through
+// the gin context the API handlers store under "gin".
+func FromContext(ctx context.Context) *Session {
+	if ctx == nil {
+		return nil
+	}
+	if session, ok := ctx.Value(sessionContextKey{}).(*Session); ok {
+		return session
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if value, exists := ginCtx.Get(GinKey); exists {
+			session, _ := value.(*Session)
+			return session
+		}
+	}
+	return nil
+}
+
+// Transport returns rt unless ctx belongs to a translate-only replay, in which case the
+// returned transport aborts the replay instead of sending the request. It backs up Guard
+// for HTTP clients whose executor sends without capturing a payload first.
+func Transport(ctx context.Context, rt http.RoundTripper) http.RoundTripper {
+	session := FromContext(ctx)
+	if !session.TranslateOnly() {
+		return rt
+	}
+	return translateOnlyTransport{session: session}
+}
+
+type translateOnlyTransport struct {
+	session *Session
+}
+
+func (t translateOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req != nil && req.Body != nil {
+		_ = req.Body.Close()
+	}
+	if t.session.cancel != nil {
+		t.session.cancel()
+	}
+	return nil, ErrTranslateOnly
+}
+
+// Run replays original through handler and diffs the outcome against the log.
+func Run(ctx context.Context, handler http.Handler, original *Log, opts Options) (*Result, error) {
+	if handler == nil {
+		return nil, errors.New("replay: no request handler available")
+	}
+	if original == nil {
+		return nil, errors.New("replay: no request log")
+	}
+	if strings.Contains(strings.ToLower(original.Transport), "websocket") {
+		return nil, errors.New("replay: websocket requests cannot be replayed")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	body, target := original.Body, original.URL
+	if model := strings.TrimSpace(opts.Model); model != "" {
+		body, target = overrideModel(body, target, model)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	session := &Session{opts: opts, cancel: cancel}
+	defer session.releaseGuards()
+	req, errRequest := http.NewRequestWithContext(context.WithValue(runCtx, sessionContextKey{}, session), original.Method, target, bytes.NewReader(body))
+	if errRequest != nil {
+		return nil, fmt.Errorf("replay: build request: %w", errRequest)
+	}
+	req.RemoteAddr = "127.0.0.1:0"
+	for key, values := range original.Headers {
+		if _, skip := skippedReplayHeaders[strings.ToLower(key)]; skip {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	result := &Result{TranslateOnly: opts.TranslateOnly, Upstream: session.exchanges()}
+	var originalLast, replayLast Exchange
+	if last, ok := original.LastUpstream(); ok {
+		originalLast = last
+		result.OriginalUpstream = &last
+	}
+	if n := len(result.Upstream); n > 0 {
+		replayLast = result.Upstream[n-1]
+	}
+	result.Diff.UpstreamRequest = Diff(originalLast.Body, replayLast.Body)
+	if !opts.TranslateOnly {
+		result.Status = recorder.Code
+		result.Response = recorder.Body.String()
+		result.Diff.UpstreamResponse = Diff(originalLast.Response, replayLast.Response)
+		result.Diff.Response = Diff(string(original.Response), result.Response)
+	}
+	return result, nil
+}
+
+func overrideModel(body []byte, target, model string) ([]byte, string) {
+	if gjson.GetBytes(body, "model").Exists() {
+		if updated, errSet := sjson.SetBytes(body, "model", model); errSet == nil {
+			body = updated
+		}
+	}
+	target = modelPathPattern.ReplaceAllStringFunc(target, func(match string) string {
+		return modelPathPattern.FindStringSubmatch(match)[1] + model
+	})
+	return body, target
+}
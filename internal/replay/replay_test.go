@@ -0,0 +1,187 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const sampleLog = `=== REQUEST INFO ===
+Version: 7.0.0
+URL: /v1/chat/completions
+Method: POST
+Downstream Transport: http
+Upstream Transport: http
+Timestamp: 2026-10-01T10:00:00Z
+
+=== HEADERS ===
+Content-Type: application/json
+Authorization: Bearer sk-***
+
+=== REQUEST BODY ===
+{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}
+
+=== API REQUEST 1 ===
+Timestamp: 2026-10-01T10:00:00Z
+Upstream URL: https://upstream.example/v1/messages
+HTTP Method: POST
+Auth: provider=claude
+
+Headers:
+
+Body:
+{"model":"claude","messages":[{"role":"user","content":"hi"}]}
+
+=== API RESPONSE 1 ===
+Timestamp: 2026-10-01T10:00:01Z
+
+Status: 200
+Headers:
+
+Body:
+{"id":"msg_1","content":"hello"}
+
+=== RESPONSE ===
+Status: 200
+Content-Type: application/json
+
+{"id":"chatcmpl-1","content":"hello"}
+`
+
+func TestParse(t *testing.T) {
+	entry, errParse := Parse([]byte(sampleLog))
+	if errParse != nil {
+		t.Fatalf("Parse() error = %v", errParse)
+	}
+	if entry.URL != "/v1/chat/completions" || entry.Method != "POST" || entry.Transport != "http" {
+		t.Fatalf("unexpected request info: %+v", entry)
+	}
+	if got := entry.Headers.Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	if got := gjson.GetBytes(entry.Body, "model").String(); got != "gpt-4o" {
+		t.Fatalf("body model = %q", got)
+	}
+	last, ok := entry.LastUpstream()
+	if !ok {
+		t.Fatal("expected an upstream exchange")
+	}
+	if last.URL != "https://upstream.example/v1/messages" || last.Status != 200 {
+		t.Fatalf("unexpected upstream exchange: %+v", last)
+	}
+	if !strings.Contains(last.Response, `"msg_1"`) {
+		t.Fatalf("upstream response = %q", last.Response)
+	}
+	if entry.Status != 200 || string(entry.Response) != `{"id":"chatcmpl-1","content":"hello"}` {
+		t.Fatalf("unexpected response: %d %q", entry.Status, entry.Response)
+	}
+
+	if _, errParse = Parse([]byte("not a log")); errParse == nil {
+		t.Fatal("expected error for non-log input")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if got := Diff(`{"a":1,"b":2}`, `{"b":2,"a":1}`); got != "" {
+		t.Fatalf("key order should not produce a diff, got:\n%s", got)
+	}
+	got := Diff(`{"a":1,"b":2}`, `{"a":1,"b":3}`)
+	if !strings.Contains(got, "-  \"b\": 2") || !strings.Contains(got, "+  \"b\": 3") {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if !strings.HasPrefix(got, "--- original\n+++ replay\n@@ ") {
+		t.Fatalf("missing diff header:\n%s", got)
+	}
+}
+
+// fakePipeline mimics the executor hooks: it records the translated request, sends it
+// through the replay-aware transport and records the upstream response.
+func fakePipeline(t *testing.T, upstream http.RoundTripper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := FromRequest(r)
+		if session == nil {
+			t.Error("expected replay session on request")
+		}
+		body, _ := io.ReadAll(r.Body)
+		model := gjson.GetBytes(body, "model").String()
+		translated := `{"model":"` + model + `-upstream","messages":[{"role":"user","content":"hi"}]}`
+		session.RecordRequest("https://upstream.example/v1/messages", http.MethodPost, []byte(translated))
+
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, "https://upstream.example/v1/messages", strings.NewReader(translated))
+		resp, errDo := Transport(r.Context(), upstream).RoundTrip(req)
+		if errDo != nil {
+			if !errors.Is(errDo, context.Canceled) {
+				t.Errorf("expected a cancellation error, got %v", errDo)
+			}
+			w.WriteHeader(499)
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		payload, _ := io.ReadAll(resp.Body)
+		session.RecordResponseStatus(resp.StatusCode)
+		session.AppendResponse(payload)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","content":"bye"}`))
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestRunDiffsAgainstLog(t *testing.T) {
+	entry, errParse := Parse([]byte(sampleLog))
+	if errParse != nil {
+		t.Fatalf("Parse() error = %v", errParse)
+	}
+	upstream := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		_, _ = rec.WriteString(`{"id":"msg_1","content":"bye"}`)
+		return rec.Result(), nil
+	})
+
+	result, errRun := Run(context.Background(), fakePipeline(t, upstream), entry, Options{Model: "gpt-5", AuthID: "auth-1"})
+	if errRun != nil {
+		t.Fatalf("Run() error = %v", errRun)
+	}
+	if result.Status != http.StatusOK || len(result.Upstream) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !strings.Contains(result.Diff.UpstreamRequest, `+  "model": "gpt-5-upstream"`) {
+		t.Fatalf("upstream request diff missing model change:\n%s", result.Diff.UpstreamRequest)
+	}
+	if !strings.Contains(result.Diff.UpstreamResponse, `+  "content": "bye"`) {
+		t.Fatalf("upstream response diff missing change:\n%s", result.Diff.UpstreamResponse)
+	}
+	if result.Diff.Response == "" {
+		t.Fatal("expected client response diff")
+	}
+}
+
+func TestRunTranslateOnlyDoesNotSend(t *testing.T) {
+	entry, errParse := Parse([]byte(sampleLog))
+	if errParse != nil {
+		t.Fatalf("Parse() error = %v", errParse)
+	}
+	upstream := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Error("upstream must not be called in translate-only mode")
+		return nil, errors.New("unexpected upstream call")
+	})
+
+	result, errRun := Run(context.Background(), fakePipeline(t, upstream), entry, Options{TranslateOnly: true})
+	if errRun != nil {
+		t.Fatalf("Run() error = %v", errRun)
+	}
+	if !result.TranslateOnly || result.Status != 0 || result.Diff.Response != "" {
+		t.Fatalf("unexpected translate-only result: %+v", result)
+	}
+	if len(result.Upstream) != 1 || !strings.Contains(result.Diff.UpstreamRequest, "gpt-4o-upstream") {
+		t.Fatalf("expected translated payload diff, got %+v", result)
+	}
+}
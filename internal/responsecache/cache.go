@@ -0,0 +1,183 @@
+// Package responsecache stores complete responses of repeated identical requests so they
+// can be answered without an upstream call.
+//
+// Entries hold the response exactly as the handler produced it in the client's protocol:
+// a body for non-streaming requests and the ordered chunks for streaming ones, which are
+// replayed through the same handler to produce the original SSE framing.
+package responsecache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry is one cached response.
+type Entry struct {
+	Model     string    `json:"model"`
+	Stream    bool      `json:"stream"`
+	Body      []byte    `json:"body,omitempty"`
+	Chunks    [][]byte  `json:"chunks,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Size returns the number of payload bytes held by the entry.
+func (e *Entry) Size() int {
+	if e == nil {
+		return 0
+	}
+	size := len(e.Body)
+	for _, chunk := range e.Chunks {
+		size += len(chunk)
+	}
+	return size
+}
+
+func (e *Entry) expired(now time.Time) bool {
+	return e == nil || (!e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt))
+}
+
+// Backend stores entries by key.
+type Backend interface {
+	// Get returns the entry for key, or nil when it is missing or expired.
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Set stores entry under key until entry.ExpiresAt.
+	Set(ctx context.Context, key string, entry *Entry) error
+	// Close releases the backend's resources.
+	Close() error
+}
+
+// Cache holds the backend selected by the current settings. The backend is rebuilt only
+// when its settings change, so entries survive unrelated config reloads.
+type Cache struct {
+	mu        sync.Mutex
+	backend   Backend
+	signature string
+}
+
+var defaultCache = &Cache{}
+
+// Default returns the process-wide cache.
+func Default() *Cache {
+	return defaultCache
+}
+
+// Get looks key up in the backend selected by settings. Backend errors count as misses.
+func (c *Cache) Get(ctx context.Context, settings config.ResponseCacheConfig, key string) (*Entry, bool) {
+	backend := c.backendFor(settings)
+	if backend == nil {
+		return nil, false
+	}
+	entry, errGet := backend.Get(ctx, key)
+	if errGet != nil {
+		log.Debugf("response cache: get: %v", errGet)
+		return nil, false
+	}
+	if entry.expired(time.Now()) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set stores entry in the backend selected by settings for ttl. Entries larger than the
+// configured maximum are skipped.
+func (c *Cache) Set(ctx context.Context, settings config.ResponseCacheConfig, key string, entry *Entry, ttl time.Duration) {
+	if entry == nil || ttl <= 0 {
+		return
+	}
+	maxBytes := settings.MaxEntryBytes
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultResponseCacheMaxEntryBytes
+	}
+	if entry.Size() > maxBytes {
+		return
+	}
+	backend := c.backendFor(settings)
+	if backend == nil {
+		return
+	}
+	now := time.Now()
+	entry.CreatedAt = now.UTC()
+	entry.ExpiresAt = now.Add(ttl).UTC()
+	if errSet := backend.Set(ctx, key, entry); errSet != nil {
+		log.Warnf("response cache: store: %v", errSet)
+	}
+}
+
+// Close releases the current backend.
+func (c *Cache) Close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *Cache) closeLocked() {
+	if c.backend != nil {
+		if errClose := c.backend.Close(); errClose != nil {
+			log.Debugf("response cache: close backend: %v", errClose)
+		}
+	}
+	c.backend = nil
+	c.signature = ""
+}
+
+// backendFor returns the backend for settings, building it on first use or when the
+// backend settings changed. A Redis or disk backend that cannot be built falls back to
+// memory.
+func (c *Cache) backendFor(settings config.ResponseCacheConfig) Backend {
+	if c == nil || !settings.Enable {
+		return nil
+	}
+	maxEntries := settings.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = config.DefaultResponseCacheMaxEntries
+	}
+	maxBytes := settings.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultResponseCacheMaxBytes
+	}
+	kind := settings.NormalizedBackend()
+	dir := strings.TrimSpace(settings.Dir)
+	redisURL := strings.TrimSpace(settings.RedisURL)
+	signature := fmt.Sprintf("%s|%d|%d|%s|%s", kind, maxEntries, maxBytes, dir, redisURL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backend != nil && c.signature == signature {
+		return c.backend
+	}
+	c.closeLocked()
+
+	var next Backend
+	switch kind {
+	case config.ResponseCacheBackendDisk:
+		disk, errDisk := NewDiskBackend(dir, maxEntries, maxBytes)
+		if errDisk != nil {
+			log.Warnf("response cache: %v; falling back to in-memory cache", errDisk)
+		} else {
+			next = disk
+		}
+	case config.ResponseCacheBackendRedis:
+		redisBackend, errRedis := NewRedisBackend(redisURL, "")
+		if errRedis != nil {
+			log.Warnf("response cache: %v; falling back to in-memory cache", errRedis)
+		} else {
+			next = redisBackend
+		}
+	}
+	if next == nil {
+		next = NewMemoryBackend(maxEntries)
+	}
+	c.backend = next
+	c.signature = signature
+	return next
+}
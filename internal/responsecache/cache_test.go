@@ -0,0 +1,150 @@
+package responsecache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestKeyIgnoresFieldOrderAndTransportFields(t *testing.T) {
+	base := Request{ClientKey: "client", EntryProtocol: "openai", ResponseProtocol: "openai", Model: "gpt-5", Stream: true}
+
+	first := base
+	first.Body = []byte(`{"model":"gpt-5","temperature":0,"messages":[{"role":"user","content":"hi"}],"stream":true}`)
+	second := base
+	second.Body = []byte(`{"messages":[{"content":"hi","role":"user"}], "temperature":0, "model":"gpt-5", "stream_options":{"include_usage":true}}`)
+
+	firstKey, ok := Key(first)
+	if !ok {
+		t.Fatal("Key() rejected a JSON object")
+	}
+	secondKey, _ := Key(second)
+	if firstKey != secondKey {
+		t.Fatalf("equivalent requests produced different keys %q and %q", firstKey, secondKey)
+	}
+
+	other := first
+	other.ClientKey = "other-client"
+	if otherKey, _ := Key(other); otherKey == firstKey {
+		t.Fatal("different client keys share a cache key")
+	}
+	sampled := first
+	sampled.Body = []byte(`{"model":"gpt-5","temperature":0.0,"messages":[{"role":"user","content":"hi"}]}`)
+	if sampledKey, _ := Key(sampled); sampledKey == firstKey {
+		t.Fatal("different sampling parameter text share a cache key")
+	}
+	if _, ok = Key(Request{Body: []byte(`[1,2]`)}); ok {
+		t.Fatal("Key() accepted a non-object body")
+	}
+}
+
+func TestMemoryBackendEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend(2)
+	expires := time.Now().Add(time.Hour)
+	for _, key := range []string{"a", "b"} {
+		_ = backend.Set(ctx, key, &Entry{Body: []byte(key), ExpiresAt: expires})
+	}
+	if entry, _ := backend.Get(ctx, "a"); entry == nil {
+		t.Fatal("entry a missing")
+	}
+	_ = backend.Set(ctx, "c", &Entry{Body: []byte("c"), ExpiresAt: expires})
+
+	if entry, _ := backend.Get(ctx, "b"); entry != nil {
+		t.Fatal("least recently used entry b was not evicted")
+	}
+	if entry, _ := backend.Get(ctx, "a"); entry == nil {
+		t.Fatal("recently used entry a was evicted")
+	}
+
+	_ = backend.Set(ctx, "expired", &Entry{Body: []byte("x"), ExpiresAt: time.Now().Add(-time.Second)})
+	if entry, _ := backend.Get(ctx, "expired"); entry != nil {
+		t.Fatal("expired entry was returned")
+	}
+	if backend.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1 after dropping the expired entry", backend.Len())
+	}
+}
+
+func TestDiskBackendRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	backend, errNew := NewDiskBackend(t.TempDir(), 0, 0)
+	if errNew != nil {
+		t.Fatalf("NewDiskBackend(): %v", errNew)
+	}
+	defer backend.Close()
+	want := &Entry{Model: "gpt-5", Stream: true, Chunks: [][]byte{[]byte("data: one\n\n"), []byte("data: two\n\n")}, ExpiresAt: time.Now().Add(time.Hour)}
+	if errSet := backend.Set(ctx, "abcdef", want); errSet != nil {
+		t.Fatalf("Set(): %v", errSet)
+	}
+	got, errGet := backend.Get(ctx, "abcdef")
+	if errGet != nil || got == nil {
+		t.Fatalf("Get() = %v, %v", got, errGet)
+	}
+	if !got.Stream || len(got.Chunks) != 2 || string(got.Chunks[1]) != "data: two\n\n" {
+		t.Fatalf("Get() = %+v, want %+v", got, want)
+	}
+	if missing, _ := backend.Get(ctx, "missing"); missing != nil {
+		t.Fatal("Get() returned an entry for a missing key")
+	}
+}
+
+func TestDiskBackendSweepEnforcesLimits(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	backend, errNew := NewDiskBackend(dir, 2, 0)
+	if errNew != nil {
+		t.Fatalf("NewDiskBackend(): %v", errNew)
+	}
+	defer backend.Close()
+
+	now := time.Now()
+	_ = backend.Set(ctx, "expired", &Entry{Body: []byte("x"), ExpiresAt: now.Add(-time.Second)})
+	for i, key := range []string{"first", "second", "third"} {
+		_ = backend.Set(ctx, key, &Entry{Body: []byte("x"), ExpiresAt: now.Add(time.Duration(i+1) * time.Hour)})
+	}
+	backend.sweep(time.Now())
+
+	if _, errStat := os.Stat(backend.path("expired")); !os.IsNotExist(errStat) {
+		t.Fatalf("expired entry still on disk: %v", errStat)
+	}
+	if entry, _ := backend.Get(ctx, "first"); entry != nil {
+		t.Fatal("entry that expires soonest survived the entry limit")
+	}
+	for _, key := range []string{"second", "third"} {
+		if entry, _ := backend.Get(ctx, key); entry == nil {
+			t.Fatalf("entry %q was evicted", key)
+		}
+	}
+
+	bytesBackend, errNew := NewDiskBackend(dir, 0, 1)
+	if errNew != nil {
+		t.Fatalf("NewDiskBackend(): %v", errNew)
+	}
+	defer bytesBackend.Close()
+	if entry, _ := bytesBackend.Get(ctx, "third"); entry != nil {
+		t.Fatal("entries over the byte limit survived the startup sweep")
+	}
+}
+
+func TestCacheSkipsOversizedEntriesAndDisabledSettings(t *testing.T) {
+	ctx := context.Background()
+	cache := &Cache{}
+	defer cache.Close()
+	settings := config.ResponseCacheConfig{Enable: true, MaxEntryBytes: 4}
+
+	cache.Set(ctx, settings, "small", &Entry{Body: []byte("ok")}, time.Minute)
+	cache.Set(ctx, settings, "large", &Entry{Body: []byte("too large")}, time.Minute)
+	if _, hit := cache.Get(ctx, settings, "small"); !hit {
+		t.Fatal("small entry was not cached")
+	}
+	if _, hit := cache.Get(ctx, settings, "large"); hit {
+		t.Fatal("oversized entry was cached")
+	}
+	if _, hit := cache.Get(ctx, config.ResponseCacheConfig{}, "small"); hit {
+		t.Fatal("disabled cache served an entry")
+	}
+}
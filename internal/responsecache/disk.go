@@ -0,0 +1,240 @@
+package responsecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// diskSweepInterval is how often the disk backend removes expired entries and enforces
+// its size limits.
+const diskSweepInterval = time.Minute
+
+// diskNoExpiry is the modification time given to entries without an expiry.
+var diskNoExpiry = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DiskBackend stores each entry as a JSON file named after its key. A file's modification
+// time is set to the entry's expiry, so a background sweep can drop expired entries and,
+// once the directory holds more than maxEntries files or maxBytes bytes, the entries that
+// expire soonest without decoding them.
+type DiskBackend struct {
+	dir        string
+	maxEntries int
+	maxBytes   int64
+
+	mu      sync.Mutex
+	entries int
+	bytes   int64
+
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	closeMu sync.Once
+}
+
+// NewDiskBackend creates dir if needed and stores entries under it, keeping at most
+// maxEntries files and maxBytes bytes. A limit <= 0 is not enforced.
+func NewDiskBackend(dir string, maxEntries int, maxBytes int64) (*DiskBackend, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("disk backend: directory is required")
+	}
+	if errMkdir := os.MkdirAll(dir, 0o700); errMkdir != nil {
+		return nil, fmt.Errorf("disk backend: create %s: %w", dir, errMkdir)
+	}
+	b := &DiskBackend{
+		dir:        dir,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	b.sweep(time.Now())
+	go b.sweepLoop()
+	return b, nil
+}
+
+func (b *DiskBackend) path(key string) string {
+	shard := key
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(b.dir, shard, key+".json")
+}
+
+// Get implements Backend.
+func (b *DiskBackend) Get(_ context.Context, key string) (*Entry, error) {
+	path := b.path(key)
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errRead
+	}
+	var entry Entry
+	if errUnmarshal := json.Unmarshal(data, &entry); errUnmarshal != nil {
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("decode %s: %w", path, errUnmarshal)
+	}
+	if entry.expired(time.Now()) {
+		_ = os.Remove(path)
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Set implements Backend. The file is written to a temporary name and renamed so readers
+// never observe a partial entry.
+func (b *DiskBackend) Set(_ context.Context, key string, entry *Entry) error {
+	data, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	path := b.path(key)
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return errMkdir
+	}
+	tmp, errCreate := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if errCreate != nil {
+		return errCreate
+	}
+	tmpName := tmp.Name()
+	if _, errWrite := tmp.Write(data); errWrite != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return errWrite
+	}
+	if errClose := tmp.Close(); errClose != nil {
+		_ = os.Remove(tmpName)
+		return errClose
+	}
+	expiresAt := diskNoExpiry
+	if entry != nil && !entry.ExpiresAt.IsZero() {
+		expiresAt = entry.ExpiresAt
+	}
+	if errTimes := os.Chtimes(tmpName, time.Now(), expiresAt); errTimes != nil {
+		_ = os.Remove(tmpName)
+		return errTimes
+	}
+	if errRename := os.Rename(tmpName, path); errRename != nil {
+		_ = os.Remove(tmpName)
+		return errRename
+	}
+	b.mu.Lock()
+	b.entries++
+	b.bytes += int64(len(data))
+	over := b.overLimitLocked()
+	b.mu.Unlock()
+	if over {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close implements Backend. It stops the background sweep.
+func (b *DiskBackend) Close() error {
+	b.closeMu.Do(func() {
+		close(b.stop)
+		<-b.done
+	})
+	return nil
+}
+
+func (b *DiskBackend) overLimitLocked() bool {
+	return (b.maxEntries > 0 && b.entries > b.maxEntries) || (b.maxBytes > 0 && b.bytes > b.maxBytes)
+}
+
+func (b *DiskBackend) sweepLoop() {
+	defer close(b.done)
+	ticker := time.NewTicker(diskSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		}
+		b.sweep(time.Now())
+	}
+}
+
+type diskFile struct {
+	path      string
+	size      int64
+	expiresAt time.Time
+}
+
+// sweep removes expired entries and stale temporary files, then removes the entries that
+// expire soonest until the directory is within its limits.
+func (b *DiskBackend) sweep(now time.Time) {
+	var files []diskFile
+	var total int64
+	errWalk := filepath.WalkDir(b.dir, func(path string, d fs.DirEntry, errEntry error) error {
+		if errEntry != nil {
+			if errors.Is(errEntry, os.ErrNotExist) {
+				return nil
+			}
+			return errEntry
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, errInfo := d.Info()
+		if errInfo != nil {
+			return nil
+		}
+		name := d.Name()
+		switch {
+		case strings.HasPrefix(name, ".entry-"):
+			// A temporary file older than a sweep interval belongs to an interrupted write.
+			if now.Sub(info.ModTime()) > diskSweepInterval {
+				_ = os.Remove(path)
+			}
+			return nil
+		case !strings.HasSuffix(name, ".json"):
+			return nil
+		}
+		if !now.Before(info.ModTime()) {
+			_ = os.Remove(path)
+			return nil
+		}
+		files = append(files, diskFile{path: path, size: info.Size(), expiresAt: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if errWalk != nil {
+		log.Debugf("response cache: sweep %s: %v", b.dir, errWalk)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].expiresAt.Before(files[j].expiresAt) })
+	evicted := 0
+	for len(files)-evicted > 0 &&
+		((b.maxEntries > 0 && len(files)-evicted > b.maxEntries) || (b.maxBytes > 0 && total > b.maxBytes)) {
+		file := files[evicted]
+		if errRemove := os.Remove(file.path); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+			log.Debugf("response cache: evict %s: %v", file.path, errRemove)
+		}
+		total -= file.size
+		evicted++
+	}
+
+	b.mu.Lock()
+	b.entries = len(files) - evicted
+	b.bytes = total
+	b.mu.Unlock()
+}
@@ -0,0 +1,62 @@
+package responsecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+)
+
+// transportFields only choose how the response is delivered; the stream flag is part of the
+// key on its own.
+var transportFields = []string{"stream", "stream_options"}
+
+// Request identifies a cacheable request.
+type Request struct {
+	// ClientKey scopes entries so clients never see each other's responses.
+	ClientKey string
+	// EntryProtocol and ResponseProtocol are the handler formats of the request and response.
+	EntryProtocol    string
+	ResponseProtocol string
+	Model            string
+	Alt              string
+	Stream           bool
+	// Body is the client request body.
+	Body []byte
+}
+
+// Key returns the cache key for req. The body is normalized first, so key order and
+// whitespace do not matter. It reports false when the body is not a JSON object.
+func Key(req Request) (string, bool) {
+	normalized, ok := Normalize(req.Body)
+	if !ok {
+		return "", false
+	}
+	hash := sha256.New()
+	for _, part := range []string{req.ClientKey, req.EntryProtocol, req.ResponseProtocol, req.Model, req.Alt, strconv.FormatBool(req.Stream)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(normalized)
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// Normalize re-encodes a JSON object with sorted keys and without transport-only fields.
+// Numbers keep their original text.
+func Normalize(body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var object map[string]any
+	if errDecode := decoder.Decode(&object); errDecode != nil || object == nil {
+		return nil, false
+	}
+	for _, field := range transportFields {
+		delete(object, field)
+	}
+	normalized, errMarshal := json.Marshal(object)
+	if errMarshal != nil {
+		return nil, false
+	}
+	return normalized, true
+}
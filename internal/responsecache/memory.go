@@ -0,0 +1,81 @@
+package responsecache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBackend is an in-process LRU.
+type MemoryBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryBackend creates an LRU holding at most maxEntries entries.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+	return &MemoryBackend{maxEntries: maxEntries, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get implements Backend.
+func (b *MemoryBackend) Get(_ context.Context, key string) (*Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	element, ok := b.items[key]
+	if !ok {
+		return nil, nil
+	}
+	item := element.Value.(*memoryItem)
+	if item.entry.expired(time.Now()) {
+		b.order.Remove(element)
+		delete(b.items, key)
+		return nil, nil
+	}
+	b.order.MoveToFront(element)
+	return item.entry, nil
+}
+
+// Set implements Backend.
+func (b *MemoryBackend) Set(_ context.Context, key string, entry *Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if element, ok := b.items[key]; ok {
+		element.Value.(*memoryItem).entry = entry
+		b.order.MoveToFront(element)
+		return nil
+	}
+	b.items[key] = b.order.PushFront(&memoryItem{key: key, entry: entry})
+	for b.order.Len() > b.maxEntries {
+		oldest := b.order.Back()
+		b.order.Remove(oldest)
+		delete(b.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Len returns the number of stored entries, including expired ones not yet evicted.
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.order.Len()
+}
+
+// Close implements Backend.
+func (b *MemoryBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.order.Init()
+	b.items = make(map[string]*list.Element)
+	return nil
+}
@@ -0,0 +1,77 @@
+package responsecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKeyPrefix namespaces cache keys in a shared Redis instance.
+const DefaultRedisKeyPrefix = "cliproxy:response-cache:"
+
+const redisTimeout = 2 * time.Second
+
+// RedisBackend shares entries across replicas. Redis expires them at entry.ExpiresAt.
+type RedisBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisBackend connects to the Redis instance at rawURL.
+func NewRedisBackend(rawURL, prefix string) (*RedisBackend, error) {
+	options, errParse := redis.ParseURL(strings.TrimSpace(rawURL))
+	if errParse != nil {
+		return nil, fmt.Errorf("redis backend: parse url: %w", errParse)
+	}
+	return newRedisBackendWithClient(redis.NewClient(options), prefix), nil
+}
+
+func newRedisBackendWithClient(client *redis.Client, prefix string) *RedisBackend {
+	if strings.TrimSpace(prefix) == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	return &RedisBackend{client: client, prefix: prefix}
+}
+
+// Get implements Backend.
+func (b *RedisBackend) Get(ctx context.Context, key string) (*Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	data, errGet := b.client.Get(ctx, b.prefix+key).Bytes()
+	if errGet != nil {
+		if errors.Is(errGet, redis.Nil) {
+			return nil, nil
+		}
+		return nil, errGet
+	}
+	var entry Entry
+	if errUnmarshal := json.Unmarshal(data, &entry); errUnmarshal != nil {
+		return nil, fmt.Errorf("decode entry: %w", errUnmarshal)
+	}
+	return &entry, nil
+}
+
+// Set implements Backend.
+func (b *RedisBackend) Set(ctx context.Context, key string, entry *Entry) error {
+	ttl := time.Until(entry.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	return b.client.Set(ctx, b.prefix+key, data, ttl).Err()
+}
+
+// Close implements Backend.
+func (b *RedisBackend) Close() error {
+	return b.client.Close()
+}
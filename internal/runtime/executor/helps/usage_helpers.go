@@ -263,6 +263,8 @@ func (r *UsageReporter) EnsurePublished(ctx context.Context) {
 
 func (r *UsageReporter) publishRecord(ctx context.Context, record usage.Record) {
 	record.ResponseHeaders = internallogging.GetResponseHeaders(ctx)
+	detail := record.Detail
+	internallogging.AddAccessTokens(ctx, detail.InputTokens, detail.OutputTokens, detail.ReasoningTokens, detail.CachedTokens, detail.TotalTokens)
 	usage.PublishRecord(ctx, record)
 }
 
@@ -15,6 +15,8 @@ import (
 	internalcache "github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpwire"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
@@ -394,12 +396,13 @@ func NewUtlsHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyau
 	}
 
 	client := &http.Client{
-		Transport: &fallbackRoundTripper{
+		Transport: tracing.WrapTransport(&fallbackRoundTripper{
 			anthropic: anthropicRT,
 			chrome:    chromeRT,
 			fallback:  standardTransport,
-		},
+		}, authProvider(auth)),
 	}
+	client.Transport = replay.Transport(ctx, client.Transport)
 	if timeout > 0 {
 		client.Timeout = timeout
 	}
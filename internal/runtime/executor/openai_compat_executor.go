@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -85,6 +86,33 @@ func (e *OpenAICompatExecutor) HttpRequest(ctx context.Context, auth *cliproxyau
 	return httpClient.Do(httpReq)
 }
 
+// ProbeAuth implements cliproxyauth.HealthProber by listing the provider models, which
+// spends no tokens. Providers without a models endpoint fall back to a minimal request.
+func (e *OpenAICompatExecutor) ProbeAuth(ctx context.Context, auth *cliproxyauth.Auth) error {
+	baseURL, _ := e.resolveCredentials(auth)
+	if baseURL == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+	}
+	req, errRequest := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if errRequest != nil {
+		return errRequest
+	}
+	resp, errDo := e.HttpRequest(ctx, auth, req)
+	if errDo != nil {
+		return errDo
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return fmt.Errorf("openai compat executor: models endpoint returned %d: %w", resp.StatusCode, errors.ErrUnsupported)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return statusErr{code: resp.StatusCode, msg: strings.TrimSpace(string(body))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
 func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	if endpointPath := openAICompatImageEndpointPath(opts); endpointPath != "" {
 		return e.executeImages(ctx, auth, req, opts, endpointPath)
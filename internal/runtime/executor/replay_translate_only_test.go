@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// countingListener counts accepted connections so a test can prove nothing was dialed.
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, errAccept := l.Listener.Accept()
+	if errAccept == nil {
+		l.accepted.Add(1)
+		_ = conn.Close()
+	}
+	return conn, errAccept
+}
+
+func TestExecutorHTTPClientsDoNotDialInTranslateOnlyReplay(t *testing.T) {
+	raw, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("listen: %v", errListen)
+	}
+	listener := &countingListener{Listener: raw}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			if _, errAccept := listener.Accept(); errAccept != nil {
+				return
+			}
+		}
+	}()
+	address := "http://" + listener.Addr().String()
+
+	type builder func(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client
+	builders := map[string]builder{
+		"proxy-aware": helps.NewProxyAwareHTTPClient,
+		"utls":        helps.NewUtlsHTTPClient,
+		"antigravity": newAntigravityHTTPClient,
+	}
+	for name, build := range builders {
+		for _, proxyURL := range []string{"", address} {
+			cfg := &config.Config{}
+			cfg.ProxyURL = proxyURL
+			auth := &cliproxyauth.Auth{ID: name + "-auth", Provider: name}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				client := build(r.Context(), cfg, auth, time.Second)
+				req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, address+"/v1/messages", nil)
+				if resp, errDo := client.Do(req); errDo == nil {
+					_ = resp.Body.Close()
+				}
+				w.WriteHeader(499)
+			})
+			entry := &replay.Log{URL: "/v1/chat/completions", Method: http.MethodPost, Body: []byte(`{"model":"m"}`)}
+			if _, errRun := replay.Run(context.Background(), handler, entry, replay.Options{TranslateOnly: true}); errRun != nil {
+				t.Fatalf("%s (proxy %q): Run() error = %v", name, proxyURL, errRun)
+			}
+			if got := listener.accepted.Load(); got != 0 {
+				t.Fatalf("%s (proxy %q): translate-only replay opened %d connection(s)", name, proxyURL, got)
+			}
+		}
+	}
+}
+
+func TestCodexWebsocketsExecutorDoesNotDialInTranslateOnlyReplay(t *testing.T) {
+	raw, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("listen: %v", errListen)
+	}
+	listener := &countingListener{Listener: raw}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			if _, errAccept := listener.Accept(); errAccept != nil {
+				return
+			}
+		}
+	}()
+
+	// The conductor runs every executor under replay.Guard; the websocket executor dials
+	// with its own dialer rather than a replay-aware HTTP client.
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, opts := codexTestRequest()
+		opts.Stream = false
+		if _, errExec := executor.Execute(replay.Guard(r.Context()), codexTestAuth("http://"+listener.Addr().String()), req, opts); errExec == nil {
+			t.Error("Execute() succeeded in a translate-only replay")
+		}
+		w.WriteHeader(499)
+	})
+	entry := &replay.Log{URL: "/v1/responses", Method: http.MethodPost, Body: []byte(`{"model":"gpt-5.6-terra"}`)}
+	result, errRun := replay.Run(context.Background(), handler, entry, replay.Options{TranslateOnly: true})
+	if errRun != nil {
+		t.Fatalf("Run() error = %v", errRun)
+	}
+	if got := listener.accepted.Load(); got != 0 {
+		t.Fatalf("translate-only replay opened %d websocket connection(s)", got)
+	}
+	if len(result.Upstream) != 1 || result.Upstream[0].Method != "WEBSOCKET" {
+		t.Fatalf("captured upstream = %+v, want the websocket payload", result.Upstream)
+	}
+}
@@ -0,0 +1,329 @@
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Comparison is one JSONL line in the shadow comparison log.
+type Comparison struct {
+	Timestamp     time.Time `json:"timestamp"`
+	RequestID     string    `json:"request_id,omitempty"`
+	EntryProtocol string    `json:"entry_protocol"`
+	Stream        bool      `json:"stream"`
+	SampleRate    float64   `json:"sample_rate"`
+	Primary       Result    `json:"primary"`
+	Shadow        Result    `json:"shadow"`
+}
+
+// Result captures one side of a comparison.
+type Result struct {
+	Model         string `json:"model"`
+	Status        int    `json:"status"`
+	Error         string `json:"error,omitempty"`
+	LatencyMs     int64  `json:"latency_ms"`
+	InputTokens   int64  `json:"input_tokens"`
+	OutputTokens  int64  `json:"output_tokens"`
+	TotalTokens   int64  `json:"total_tokens"`
+	Body          string `json:"body,omitempty"`
+	BodyTruncated bool   `json:"body_truncated,omitempty"`
+}
+
+// Pair collects the primary and shadow sides of one mirrored request and appends the
+// comparison to the log once both sides have finished. All methods are safe on a nil Pair.
+type Pair struct {
+	mu         sync.Mutex
+	comparison Comparison
+	logFile    string
+	startedAt  time.Time
+	primary    capture
+	shadow     capture
+	pending    int
+}
+
+// NewPair starts a comparison between the primary model and the shadow rule target.
+func NewPair(cfg config.ShadowConfig, rule config.ShadowRule, requestID, entryProtocol, primaryModel string, stream bool) *Pair {
+	maxBody := cfg.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = config.DefaultShadowMaxBodyBytes
+	}
+	return &Pair{
+		comparison: Comparison{
+			RequestID:     strings.TrimSpace(requestID),
+			EntryProtocol: entryProtocol,
+			Stream:        stream,
+			SampleRate:    rule.SampleRate,
+			Primary:       Result{Model: primaryModel},
+			Shadow:        Result{Model: rule.ShadowModel},
+		},
+		logFile:   cfg.LogFile,
+		startedAt: time.Now(),
+		primary:   capture{maxBody: maxBody, stream: stream},
+		shadow:    capture{maxBody: maxBody, stream: stream},
+		pending:   2,
+	}
+}
+
+// PrimaryChunk records a chunk of the primary response body.
+func (p *Pair) PrimaryChunk(chunk []byte) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.primary.write(chunk)
+	p.mu.Unlock()
+}
+
+// PrimaryDone marks the primary side finished with the downstream status.
+func (p *Pair) PrimaryDone(status int, err error) {
+	if p == nil {
+		return
+	}
+	p.finish(&p.comparison.Primary, &p.primary, status, err)
+}
+
+// ShadowChunk records a chunk of the shadow response body.
+func (p *Pair) ShadowChunk(chunk []byte) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.shadow.write(chunk)
+	p.mu.Unlock()
+}
+
+// ShadowDone marks the shadow side finished with its upstream status.
+func (p *Pair) ShadowDone(status int, err error) {
+	if p == nil {
+		return
+	}
+	p.finish(&p.comparison.Shadow, &p.shadow, status, err)
+}
+
+func (p *Pair) finish(result *Result, side *capture, status int, err error) {
+	p.mu.Lock()
+	if side.done {
+		p.mu.Unlock()
+		return
+	}
+	side.done = true
+	side.flush()
+	if status == 0 && err == nil {
+		status = http.StatusOK
+	}
+	result.Status = status
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.LatencyMs = time.Since(p.startedAt).Milliseconds()
+	result.InputTokens = side.tokens.Input
+	result.OutputTokens = side.tokens.Output
+	result.TotalTokens = side.tokens.total()
+	result.Body = string(side.body)
+	result.BodyTruncated = side.truncated
+	p.pending--
+	if p.pending > 0 {
+		p.mu.Unlock()
+		return
+	}
+	comparison := p.comparison
+	comparison.Timestamp = time.Now().UTC()
+	logFile := p.logFile
+	p.mu.Unlock()
+
+	enqueueComparison(queuedComparison{logFile: logFile, comparison: comparison})
+}
+
+type capture struct {
+	maxBody   int
+	stream    bool
+	body      []byte
+	truncated bool
+	pending   []byte
+	tokens    Tokens
+	done      bool
+}
+
+func (c *capture) write(chunk []byte) {
+	if c.done || len(chunk) == 0 {
+		return
+	}
+	if remaining := c.maxBody - len(c.body); remaining > 0 {
+		if len(chunk) > remaining {
+			c.body = append(c.body, chunk[:remaining]...)
+			c.truncated = true
+		} else {
+			c.body = append(c.body, chunk...)
+		}
+	} else {
+		c.truncated = true
+	}
+	if !c.stream {
+		c.tokens.ObserveJSON(chunk)
+		return
+	}
+	c.pending = append(c.pending, chunk...)
+	for {
+		idx := bytes.IndexByte(c.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		c.tokens.ObserveLine(c.pending[:idx])
+		c.pending = c.pending[idx+1:]
+	}
+}
+
+func (c *capture) flush() {
+	if len(c.pending) > 0 {
+		c.tokens.ObserveLine(c.pending)
+		c.pending = nil
+	}
+}
+
+const (
+	// comparisonQueueSize bounds comparisons waiting for the background writer. Records
+	// beyond it are dropped so a slow disk never delays client requests.
+	comparisonQueueSize = 256
+	// comparisonLogMaxSizeMB, comparisonLogMaxBackups and comparisonLogMaxAgeDays bound the
+	// rotated comparison log files kept on disk.
+	comparisonLogMaxSizeMB  = 10
+	comparisonLogMaxBackups = 5
+	comparisonLogMaxAgeDays = 30
+)
+
+var (
+	writerMu   sync.Mutex
+	logDir     = "logs"
+	writer     *lumberjack.Logger
+	writerPath string
+
+	queueOnce sync.Once
+	queue     chan queuedComparison
+	dropped   atomic.Int64
+)
+
+// queuedComparison is a comparison waiting for the background writer. A non-nil flushed
+// channel marks a flush request instead of a record.
+type queuedComparison struct {
+	logFile    string
+	comparison Comparison
+	flushed    chan struct{}
+}
+
+// SetLogDirectory sets the directory used to resolve relative comparison log paths.
+func SetLogDirectory(dir string) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return
+	}
+	writerMu.Lock()
+	logDir = dir
+	writerMu.Unlock()
+}
+
+// Flush waits until every queued comparison has been written.
+func Flush() {
+	flushed := make(chan struct{})
+	startWriter()
+	queue <- queuedComparison{flushed: flushed}
+	<-flushed
+}
+
+// Close writes queued comparisons and releases the comparison log file handle, if any.
+func Close() {
+	Flush()
+	writerMu.Lock()
+	defer writerMu.Unlock()
+	if writer != nil {
+		_ = writer.Close()
+		writer = nil
+		writerPath = ""
+	}
+}
+
+func startWriter() {
+	queueOnce.Do(func() {
+		queue = make(chan queuedComparison, comparisonQueueSize)
+		go runWriter()
+	})
+}
+
+func runWriter() {
+	for item := range queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if errWrite := writeComparison(item.logFile, item.comparison); errWrite != nil {
+			log.WithError(errWrite).Warn("shadow: failed to write comparison record")
+		}
+	}
+}
+
+// enqueueComparison hands a comparison to the background writer without blocking.
+func enqueueComparison(item queuedComparison) {
+	startWriter()
+	select {
+	case queue <- item:
+	default:
+		if total := dropped.Add(1); total == 1 || total%100 == 0 {
+			log.Warnf("shadow: comparison queue full, dropped %d records so far", total)
+		}
+	}
+}
+
+func writeComparison(logFile string, comparison Comparison) error {
+	line, errMarshal := json.Marshal(comparison)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	line = append(line, '\n')
+
+	writerMu.Lock()
+	defer writerMu.Unlock()
+	path := resolveLogPath(logFile)
+	if path == "" {
+		return errors.New("shadow log path is empty")
+	}
+	if writer == nil || writerPath != path {
+		if writer != nil {
+			_ = writer.Close()
+		}
+		if errMkdir := os.MkdirAll(filepath.Dir(path), 0o755); errMkdir != nil {
+			return errMkdir
+		}
+		writer = &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    comparisonLogMaxSizeMB,
+			MaxBackups: comparisonLogMaxBackups,
+			MaxAge:     comparisonLogMaxAgeDays,
+			Compress:   false,
+		}
+		writerPath = path
+	}
+	_, errWrite := writer.Write(line)
+	return errWrite
+}
+
+func resolveLogPath(logFile string) string {
+	logFile = strings.TrimSpace(logFile)
+	if logFile == "" {
+		logFile = config.DefaultShadowLogFile
+	}
+	if filepath.IsAbs(logFile) {
+		return logFile
+	}
+	return filepath.Join(logDir, logFile)
+}
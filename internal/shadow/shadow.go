@@ -0,0 +1,77 @@
+// Package shadow mirrors sampled live requests to alternate models and records
+// side-by-side comparisons of the primary and shadow responses.
+//
+// Shadow executions are best effort: sampling, concurrency limits, and log writes
+// never block or fail the primary request.
+package shadow
+
+import (
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// randFloat is swapped in tests to make sampling deterministic.
+var randFloat = rand.Float64
+
+var inFlight atomic.Int64
+
+// Select returns the first rule matching the requested model and caller, applying the
+// rule's sample rate. A rule's client-keys match the caller's principal or key name, so
+// rules keep working when the configured key is stored as a hash. It reports false when
+// the request should not be mirrored.
+func Select(cfg config.ShadowConfig, model, principal, keyName string) (config.ShadowRule, bool) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return config.ShadowRule{}, false
+	}
+	for _, rule := range cfg.Rules {
+		if !strings.EqualFold(strings.TrimSpace(rule.SourceModel), model) {
+			continue
+		}
+		if strings.TrimSpace(rule.ShadowModel) == "" || rule.SampleRate <= 0 {
+			continue
+		}
+		if len(rule.ClientKeys) > 0 && !containsKey(rule.ClientKeys, principal) && !containsKey(rule.ClientKeys, keyName) {
+			continue
+		}
+		if rule.SampleRate < 1 && randFloat() >= rule.SampleRate {
+			return config.ShadowRule{}, false
+		}
+		return rule, true
+	}
+	return config.ShadowRule{}, false
+}
+
+// Acquire reserves one shadow execution slot. The returned release func must be called
+// exactly once when ok is true. Requests beyond limit are rejected rather than queued.
+func Acquire(limit int) (release func(), ok bool) {
+	if limit <= 0 {
+		limit = config.DefaultShadowMaxConcurrency
+	}
+	if inFlight.Add(1) > int64(limit) {
+		inFlight.Add(-1)
+		return nil, false
+	}
+	var released atomic.Bool
+	return func() {
+		if released.CompareAndSwap(false, true) {
+			inFlight.Add(-1)
+		}
+	}, true
+}
+
+func containsKey(keys []string, key string) bool {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return false
+	}
+	for _, candidate := range keys {
+		if candidate == key {
+			return true
+		}
+	}
+	return false
+}
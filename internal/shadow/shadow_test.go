@@ -0,0 +1,173 @@
+package shadow
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestSelectAppliesModelClientKeyAndSampleRate(t *testing.T) {
+	previous := randFloat
+	t.Cleanup(func() { randFloat = previous })
+	randFloat = func() float64 { return 0.5 }
+
+	cfg := config.ShadowConfig{Rules: []config.ShadowRule{
+		{SourceModel: "gpt-5", ShadowModel: "claude-sonnet-4-5", SampleRate: 1, ClientKeys: []string{"team-a"}},
+		{SourceModel: "gemini-2.5-pro", ShadowModel: "gemini-3-pro-preview", SampleRate: 0.4},
+		{SourceModel: "gemini-2.5-flash", ShadowModel: "gemini-3-flash-preview", SampleRate: 0.6},
+	}}
+
+	if rule, ok := Select(cfg, "GPT-5", "team-a", ""); !ok || rule.ShadowModel != "claude-sonnet-4-5" {
+		t.Fatalf("Select(gpt-5, team-a) = %+v, %v; want claude-sonnet-4-5", rule, ok)
+	}
+	if rule, ok := Select(cfg, "gpt-5", "argon2:0000:$argon2id$hash", "team-a"); !ok || rule.ShadowModel != "claude-sonnet-4-5" {
+		t.Fatalf("Select(gpt-5, key name team-a) = %+v, %v; want claude-sonnet-4-5", rule, ok)
+	}
+	if _, ok := Select(cfg, "gpt-5", "team-b", "team-b"); ok {
+		t.Fatal("Select(gpt-5, team-b) selected a rule restricted to team-a")
+	}
+	if _, ok := Select(cfg, "gemini-2.5-pro", "", ""); ok {
+		t.Fatal("Select(gemini-2.5-pro) sampled with draw 0.5 above rate 0.4")
+	}
+	if _, ok := Select(cfg, "gemini-2.5-flash", "", ""); !ok {
+		t.Fatal("Select(gemini-2.5-flash) skipped with draw 0.5 below rate 0.6")
+	}
+	if _, ok := Select(cfg, "unknown", "", ""); ok {
+		t.Fatal("Select(unknown) matched a rule")
+	}
+}
+
+func TestAcquireRejectsBeyondLimit(t *testing.T) {
+	releaseFirst, ok := Acquire(1)
+	if !ok {
+		t.Fatal("first Acquire() rejected")
+	}
+	if _, ok = Acquire(1); ok {
+		t.Fatal("second Acquire() accepted beyond limit")
+	}
+	releaseFirst()
+	releaseFirst()
+	releaseSecond, ok := Acquire(1)
+	if !ok {
+		t.Fatal("Acquire() rejected after release")
+	}
+	releaseSecond()
+}
+
+func TestTokensObserveProtocols(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+		body   string
+		want   Tokens
+	}{
+		{
+			name: "openai chat",
+			body: `{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":5,"total_tokens":17}}`,
+			want: Tokens{Input: 12, Output: 5, Total: 17},
+		},
+		{
+			name: "gemini",
+			body: `{"candidates":[],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":3,"totalTokenCount":10}}`,
+			want: Tokens{Input: 7, Output: 3, Total: 10},
+		},
+		{
+			name:   "claude stream",
+			stream: true,
+			body: "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":20,\"output_tokens\":1}}}\n\n" +
+				"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":42}}\n\n",
+			want: Tokens{Input: 20, Output: 42},
+		},
+		{
+			name:   "responses stream",
+			stream: true,
+			body:   "data: {\"type\":\"response.completed\",\"response\":{\"usage\":{\"input_tokens\":9,\"output_tokens\":4,\"total_tokens\":13}}}\n\ndata: [DONE]\n\n",
+			want:   Tokens{Input: 9, Output: 4, Total: 13},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := capture{maxBody: 16, stream: tt.stream}
+			// Split the body to exercise partial SSE lines.
+			mid := len(tt.body) / 2
+			c.write([]byte(tt.body[:mid]))
+			c.write([]byte(tt.body[mid:]))
+			if !tt.stream {
+				c = capture{maxBody: 16}
+				c.write([]byte(tt.body))
+			}
+			c.flush()
+			if c.tokens != tt.want {
+				t.Fatalf("tokens = %+v, want %+v", c.tokens, tt.want)
+			}
+			if !c.truncated || len(c.body) != 16 {
+				t.Fatalf("body len = %d truncated = %v, want 16 and true", len(c.body), c.truncated)
+			}
+		})
+	}
+}
+
+func TestPairWritesComparisonAfterBothSidesFinish(t *testing.T) {
+	dir := t.TempDir()
+	SetLogDirectory(dir)
+	t.Cleanup(Close)
+
+	cfg := config.ShadowConfig{LogFile: "compare.jsonl"}
+	rule := config.ShadowRule{SourceModel: "gpt-5", ShadowModel: "claude-sonnet-4-5", SampleRate: 0.25}
+	pair := NewPair(cfg, rule, "req-1", "openai", "gpt-5", false)
+
+	pair.ShadowChunk([]byte(`{"usage":{"input_tokens":3,"output_tokens":2}}`))
+	pair.ShadowDone(http.StatusOK, nil)
+	Flush()
+	logPath := filepath.Join(dir, "compare.jsonl")
+	if _, errStat := os.Stat(logPath); !errors.Is(errStat, os.ErrNotExist) {
+		t.Fatalf("comparison written before primary finished: %v", errStat)
+	}
+
+	pair.PrimaryChunk([]byte(`{"usage":{"prompt_tokens":3,"completion_tokens":8}}`))
+	pair.PrimaryDone(http.StatusOK, nil)
+	pair.PrimaryDone(http.StatusBadGateway, errors.New("ignored"))
+	Flush()
+
+	file, errOpen := os.Open(logPath)
+	if errOpen != nil {
+		t.Fatalf("open comparison log: %v", errOpen)
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	var lines []Comparison
+	for scanner.Scan() {
+		var comparison Comparison
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &comparison); errUnmarshal != nil {
+			t.Fatalf("unmarshal comparison: %v", errUnmarshal)
+		}
+		lines = append(lines, comparison)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("comparison lines = %d, want 1", len(lines))
+	}
+	got := lines[0]
+	if got.RequestID != "req-1" || got.SampleRate != 0.25 {
+		t.Fatalf("comparison = %+v", got)
+	}
+	if got.Primary.Model != "gpt-5" || got.Primary.Status != http.StatusOK || got.Primary.OutputTokens != 8 || got.Primary.TotalTokens != 11 {
+		t.Fatalf("primary = %+v", got.Primary)
+	}
+	if got.Shadow.Model != "claude-sonnet-4-5" || got.Shadow.InputTokens != 3 || got.Shadow.OutputTokens != 2 {
+		t.Fatalf("shadow = %+v", got.Shadow)
+	}
+}
+
+func TestNilPairIsNoop(t *testing.T) {
+	var pair *Pair
+	pair.PrimaryChunk([]byte("x"))
+	pair.PrimaryDone(http.StatusOK, nil)
+	pair.ShadowChunk([]byte("x"))
+	pair.ShadowDone(http.StatusOK, nil)
+}
@@ -0,0 +1,82 @@
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/tidwall/gjson"
+)
+
+// Tokens holds the token counts observed in a response body.
+type Tokens struct {
+	Input  int64
+	Output int64
+	Total  int64
+}
+
+// usagePaths lists usage locations across the supported response protocols. Streaming
+// protocols report cumulative counts, so the largest observed value wins.
+var usagePaths = []struct {
+	input  string
+	output string
+	total  string
+}{
+	{input: "usage.prompt_tokens", output: "usage.completion_tokens", total: "usage.total_tokens"},
+	{input: "usage.input_tokens", output: "usage.output_tokens", total: "usage.total_tokens"},
+	{input: "response.usage.input_tokens", output: "response.usage.output_tokens", total: "response.usage.total_tokens"},
+	{input: "message.usage.input_tokens", output: "message.usage.output_tokens"},
+	{input: "usageMetadata.promptTokenCount", output: "usageMetadata.candidatesTokenCount", total: "usageMetadata.totalTokenCount"},
+	{input: "response.usageMetadata.promptTokenCount", output: "response.usageMetadata.candidatesTokenCount", total: "response.usageMetadata.totalTokenCount"},
+}
+
+// ObserveLine inspects one SSE line (with or without the "data:" prefix).
+func (t *Tokens) ObserveLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if bytes.HasPrefix(line, []byte("data:")) {
+		line = bytes.TrimSpace(line[len("data:"):])
+	}
+	if len(line) == 0 || (line[0] != '{' && line[0] != '[') {
+		return
+	}
+	t.ObserveJSON(line)
+}
+
+// ObserveJSON inspects a JSON document for usage counts.
+func (t *Tokens) ObserveJSON(payload []byte) {
+	if t == nil || !json.Valid(payload) {
+		return
+	}
+	parsed := gjson.ParseBytes(payload)
+	if parsed.IsArray() {
+		parsed.ForEach(func(_, item gjson.Result) bool {
+			t.observe(item)
+			return true
+		})
+		return
+	}
+	t.observe(parsed)
+}
+
+func (t *Tokens) observe(doc gjson.Result) {
+	for _, path := range usagePaths {
+		t.Input = maxInt64(t.Input, doc.Get(path.input).Int())
+		t.Output = maxInt64(t.Output, doc.Get(path.output).Int())
+		if path.total != "" {
+			t.Total = maxInt64(t.Total, doc.Get(path.total).Int())
+		}
+	}
+}
+
+func (t Tokens) total() int64 {
+	if t.Total > 0 {
+		return t.Total
+	}
+	return t.Input + t.Output
+}
+
+func maxInt64(a, b int64) int64 {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/transport"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
 	"github.com/go-git/go-git/v6/storage/filesystem/dotgit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 )
 
@@ -366,7 +367,7 @@ func (s *GitTokenStore) ensureRepositoryLocked() error {
 }
 
 // Save persists token storage and metadata to the resolved auth file path.
-func (s *GitTokenStore) Save(_ context.Context, auth *cliproxyauth.Auth) (string, error) {
+func (s *GitTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
 	if auth == nil {
 		return "", fmt.Errorf("auth filestore: auth is nil")
 	}
@@ -454,7 +455,7 @@ func (s *GitTokenStore) Save(_ context.Context, auth *cliproxyauth.Auth) (string
 	if strings.TrimSpace(messageID) == "" {
 		messageID = filepath.Base(path)
 	}
-	if errCommit := s.commitAndPushLocked(fmt.Sprintf("Update auth %s", strings.TrimSpace(messageID)), relPath); errCommit != nil {
+	if errCommit := s.commitAndPushLocked(fmt.Sprintf("Update auth %s", strings.TrimSpace(messageID))+audit.Default().CommitTrailer(ctx), relPath); errCommit != nil {
 		return "", errCommit
 	}
 
@@ -500,7 +501,7 @@ func (s *GitTokenStore) List(_ context.Context) ([]*cliproxyauth.Auth, error) {
 }
 
 // Delete removes the auth file.
-func (s *GitTokenStore) Delete(_ context.Context, id string) error {
+func (s *GitTokenStore) Delete(ctx context.Context, id string) error {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return fmt.Errorf("auth filestore: id is empty")
@@ -524,7 +525,7 @@ func (s *GitTokenStore) Delete(_ context.Context, id string) error {
 		return fmt.Errorf("auth filestore: delete failed: %w", err)
 	}
 	messageID := id
-	if errCommit := s.commitAndPushLocked(fmt.Sprintf("Delete auth %s", messageID), rel); errCommit != nil {
+	if errCommit := s.commitAndPushLocked(fmt.Sprintf("Delete auth %s", messageID)+audit.Default().CommitTrailer(ctx), rel); errCommit != nil {
 		return errCommit
 	}
 	return nil
@@ -1816,7 +1817,7 @@ func (s *GitTokenStore) PersistConfig(_ context.Context) error {
 	if err != nil {
 		return err
 	}
-	return s.commitAndPushLocked("Update config", rel)
+	return s.commitAndPushLocked("Update config"+audit.Default().TakeConfigCommitTrailer(), rel)
 }
 
 func ensureEmptyFile(path string) error {
@@ -218,6 +218,9 @@ func TestPostgresCooldownStateStore_SaveLoad(t *testing.T) {
 	if !strings.Contains(queries, `CREATE TABLE IF NOT EXISTS "cooldown_store"`) {
 		t.Fatalf("EnsureSchema() did not create cooldown table; queries:\n%s", queries)
 	}
+	if !strings.Contains(queries, `CREATE TABLE IF NOT EXISTS "session_affinity_store"`) {
+		t.Fatalf("EnsureSchema() did not create session affinity table; queries:\n%s", queries)
+	}
 }
 
 func TestPostgresCooldownStateStore_MergesConcurrentInstances(t *testing.T) {
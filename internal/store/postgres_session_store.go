@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+var _ cliproxyauth.SessionBindingStoreProvider = (*PostgresStore)(nil)
+var _ cliproxyauth.SessionBindingStore = (*postgresSessionBindingStore)(nil)
+
+type postgresSessionBindingStore struct {
+	store *PostgresStore
+}
+
+// SessionBindingStore returns the PostgreSQL-backed session-affinity binding store.
+func (s *PostgresStore) SessionBindingStore() cliproxyauth.SessionBindingStore {
+	if s == nil {
+		return nil
+	}
+	return &postgresSessionBindingStore{store: s}
+}
+
+func (s *PostgresStore) sessionTableName() string {
+	table := strings.TrimSpace(s.cfg.SessionTable)
+	if table == "" {
+		table = defaultSessionTable
+	}
+	return s.fullTableName(table)
+}
+
+func (s *postgresSessionBindingStore) ready() error {
+	if s == nil || s.store == nil || s.store.db == nil {
+		return fmt.Errorf("postgres session store: not initialized")
+	}
+	return nil
+}
+
+// Load implements cliproxyauth.SessionBindingStore.
+func (s *postgresSessionBindingStore) Load(ctx context.Context, key string) (cliproxyauth.SessionBinding, bool, error) {
+	if err := s.ready(); err != nil {
+		return cliproxyauth.SessionBinding{}, false, err
+	}
+	query := fmt.Sprintf("SELECT content FROM %s WHERE session_key = $1 AND expires_at > NOW()", s.store.sessionTableName())
+	var content []byte
+	errScan := s.store.db.QueryRowContext(ctx, query, key).Scan(&content)
+	if errors.Is(errScan, sql.ErrNoRows) {
+		return cliproxyauth.SessionBinding{}, false, nil
+	}
+	if errScan != nil {
+		return cliproxyauth.SessionBinding{}, false, fmt.Errorf("postgres session store: load binding: %w", errScan)
+	}
+	var binding cliproxyauth.SessionBinding
+	if errUnmarshal := json.Unmarshal(content, &binding); errUnmarshal != nil {
+		return cliproxyauth.SessionBinding{}, false, fmt.Errorf("postgres session store: decode binding: %w", errUnmarshal)
+	}
+	if binding.AuthID == "" || !time.Now().Before(binding.ExpiresAt) {
+		return cliproxyauth.SessionBinding{}, false, nil
+	}
+	return binding, true, nil
+}
+
+// Save implements cliproxyauth.SessionBindingStore.
+func (s *postgresSessionBindingStore) Save(ctx context.Context, binding cliproxyauth.SessionBinding) error {
+	if err := s.ready(); err != nil {
+		return err
+	}
+	if binding.AuthID == "" || len(binding.Aliases) == 0 || !time.Now().Before(binding.ExpiresAt) {
+		return nil
+	}
+	content, errMarshal := json.Marshal(binding)
+	if errMarshal != nil {
+		return fmt.Errorf("postgres session store: encode binding: %w", errMarshal)
+	}
+	tx, errBegin := s.store.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("postgres session store: begin save: %w", errBegin)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (session_key, auth_id, content, expires_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (session_key) DO UPDATE SET
+			auth_id = EXCLUDED.auth_id,
+			content = EXCLUDED.content,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+	`, s.store.sessionTableName())
+	expiresAt := binding.ExpiresAt.UTC()
+	for _, alias := range binding.Aliases {
+		if _, errExec := tx.ExecContext(ctx, query, alias, binding.AuthID, content, expiresAt); errExec != nil {
+			return rollbackPostgresSessionTransaction(tx, fmt.Errorf("postgres session store: save binding: %w", errExec))
+		}
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("postgres session store: commit save: %w", errCommit)
+	}
+	return nil
+}
+
+// Delete implements cliproxyauth.SessionBindingStore.
+func (s *postgresSessionBindingStore) Delete(ctx context.Context, keys ...string) error {
+	if err := s.ready(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	tx, errBegin := s.store.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("postgres session store: begin delete: %w", errBegin)
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE session_key = $1", s.store.sessionTableName())
+	for _, key := range keys {
+		if _, errExec := tx.ExecContext(ctx, query, key); errExec != nil {
+			return rollbackPostgresSessionTransaction(tx, fmt.Errorf("postgres session store: delete binding: %w", errExec))
+		}
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("postgres session store: commit delete: %w", errCommit)
+	}
+	return nil
+}
+
+// DeleteAuth implements cliproxyauth.SessionBindingStore.
+func (s *postgresSessionBindingStore) DeleteAuth(ctx context.Context, authID string) error {
+	if err := s.ready(); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE auth_id = $1", s.store.sessionTableName())
+	if _, errExec := s.store.db.ExecContext(ctx, query, authID); errExec != nil {
+		return fmt.Errorf("postgres session store: delete auth bindings: %w", errExec)
+	}
+	return nil
+}
+
+// PurgeExpired removes bindings whose TTL has elapsed. PostgreSQL has no native row
+// expiry, so the session cache calls this from its periodic cleanup.
+func (s *postgresSessionBindingStore) PurgeExpired(ctx context.Context) error {
+	if err := s.ready(); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at <= NOW()", s.store.sessionTableName())
+	if _, errExec := s.store.db.ExecContext(ctx, query); errExec != nil {
+		return fmt.Errorf("postgres session store: purge expired bindings: %w", errExec)
+	}
+	return nil
+}
+
+func rollbackPostgresSessionTransaction(tx *sql.Tx, operationErr error) error {
+	if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+		return errors.Join(operationErr, fmt.Errorf("postgres session store: rollback: %w", errRollback))
+	}
+	return operationErr
+}
@@ -23,6 +23,7 @@ const (
 	defaultConfigTable   = "config_store"
 	defaultAuthTable     = "auth_store"
 	defaultCooldownTable = "cooldown_store"
+	defaultSessionTable  = "session_affinity_store"
 	defaultConfigKey     = "config"
 )
 
@@ -33,6 +34,7 @@ type PostgresStoreConfig struct {
 	ConfigTable   string
 	AuthTable     string
 	CooldownTable string
+	SessionTable  string
 	SpoolDir      string
 }
 
@@ -64,6 +66,9 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.CooldownTable == "" {
 		cfg.CooldownTable = defaultCooldownTable
 	}
+	if cfg.SessionTable == "" {
+		cfg.SessionTable = defaultSessionTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -161,6 +166,18 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, cooldownTable)); err != nil {
 		return fmt.Errorf("postgres store: create cooldown table: %w", err)
 	}
+	sessionTable := s.sessionTableName()
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			session_key TEXT PRIMARY KEY,
+			auth_id TEXT NOT NULL,
+			content JSONB NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, sessionTable)); err != nil {
+		return fmt.Errorf("postgres store: create session affinity table: %w", err)
+	}
 	return nil
 }
 
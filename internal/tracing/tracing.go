@@ -0,0 +1,236 @@
+// Package tracing configures OpenTelemetry tracing for the proxy. Spans are exported to
+// an OTLP/HTTP collector; incoming traceparent headers are honoured and, for configured
+// providers, propagated to upstream requests.
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/router-for-me/CLIProxyAPI/v7"
+
+const exportTimeout = 10 * time.Second
+
+// Span attribute keys shared by proxy spans.
+const (
+	AttrProvider   = attribute.Key("cliproxy.provider")
+	AttrAuthIndex  = attribute.Key("cliproxy.auth_index")
+	AttrModel      = attribute.Key("cliproxy.model")
+	AttrRetryRound = attribute.Key("cliproxy.retry_round")
+	AttrStatus     = attribute.Key("http.response.status_code")
+)
+
+var propagator = propagation.TraceContext{}
+
+func init() {
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Warnf("tracing: %v", err)
+	}))
+}
+
+var state struct {
+	mu           sync.RWMutex
+	provider     *sdktrace.TracerProvider
+	signature    string
+	propagateAll bool
+	propagate    map[string]struct{}
+}
+
+// Configure applies cfg.Tracing. The tracer provider is rebuilt only when the tracing
+// settings change; the previous provider is flushed and shut down in the background.
+func Configure(cfg *config.Config) {
+	var settings config.TracingConfig
+	if cfg != nil {
+		settings = cfg.Tracing
+	}
+	raw, _ := json.Marshal(settings)
+	signature := string(raw)
+
+	state.mu.Lock()
+	if signature == state.signature {
+		state.mu.Unlock()
+		return
+	}
+	previous := state.provider
+	state.signature = signature
+	state.propagateAll, state.propagate = propagationSet(settings.PropagateUpstream)
+	state.provider = nil
+	if settings.Enable {
+		provider, errProvider := newProvider(settings)
+		if errProvider != nil {
+			log.Warnf("tracing: %v; tracing disabled", errProvider)
+		} else {
+			state.provider = provider
+			log.Infof("tracing enabled, exporting to %s", settings.NormalizedEndpoint())
+		}
+	}
+	if state.provider != nil {
+		otel.SetTracerProvider(state.provider)
+	} else {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	}
+	state.mu.Unlock()
+
+	if previous != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if errShutdown := previous.Shutdown(ctx); errShutdown != nil {
+				log.Debugf("tracing: shut down previous provider: %v", errShutdown)
+			}
+		}()
+	}
+}
+
+func newProvider(settings config.TracingConfig) (*sdktrace.TracerProvider, error) {
+	ratio := settings.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	exporter, errExporter := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(settings.NormalizedEndpoint()),
+		otlptracehttp.WithHeaders(settings.Headers),
+		otlptracehttp.WithTimeout(exportTimeout),
+	)
+	if errExporter != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", errExporter)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", settings.NormalizedServiceName()))),
+	), nil
+}
+
+func propagationSet(providers []string) (bool, map[string]struct{}) {
+	var set map[string]struct{}
+	for _, provider := range providers {
+		trimmed := strings.ToLower(strings.TrimSpace(provider))
+		if trimmed == "" {
+			continue
+		}
+		if trimmed == "*" {
+			return true, nil
+		}
+		if set == nil {
+			set = make(map[string]struct{})
+		}
+		set[trimmed] = struct{}{}
+	}
+	return false, set
+}
+
+// Enabled reports whether spans are recorded and exported.
+func Enabled() bool {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.provider != nil
+}
+
+// Shutdown flushes pending spans and stops the exporter.
+func Shutdown(ctx context.Context) error {
+	state.mu.Lock()
+	provider := state.provider
+	state.provider = nil
+	state.signature = ""
+	state.mu.Unlock()
+	if provider == nil {
+		return nil
+	}
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	return provider.Shutdown(ctx)
+}
+
+// Start starts a span as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err and the status code it carries, if any, and ends span.
+func End(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		if status := statusCode(err); status > 0 {
+			span.SetAttributes(AttrStatus.Int(status))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func statusCode(err error) int {
+	type statusCoder interface {
+		StatusCode() int
+	}
+	for current := err; current != nil; {
+		if coder, ok := current.(statusCoder); ok {
+			return coder.StatusCode()
+		}
+		unwrapper, ok := current.(interface{ Unwrap() error })
+		if !ok {
+			return 0
+		}
+		current = unwrapper.Unwrap()
+	}
+	return 0
+}
+
+// Extract returns ctx with the remote span context carried by the traceparent header.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// SpanFromContext returns the current span of ctx.
+func SpanFromContext(ctx context.Context) trace.Span {
+	return trace.SpanFromContext(ctx)
+}
+
+// ContextWithSpan returns parent carrying the span of source, so work started from a
+// detached context stays in the request trace.
+func ContextWithSpan(parent, source context.Context) context.Context {
+	if parent == nil || source == nil {
+		return parent
+	}
+	span := trace.SpanFromContext(source)
+	if !span.SpanContext().IsValid() || trace.SpanFromContext(parent).SpanContext().IsValid() {
+		return parent
+	}
+	return trace.ContextWithSpan(parent, span)
+}
+
+func shouldPropagate(provider string) bool {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	if state.provider == nil {
+		return false
+	}
+	if state.propagateAll {
+		return true
+	}
+	_, ok := state.propagate[strings.ToLower(strings.TrimSpace(provider))]
+	return ok
+}
@@ -0,0 +1,199 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpStub is a minimal OTLP/HTTP receiver collecting exported spans.
+type otlpStub struct {
+	mu       sync.Mutex
+	requests []*coltracepb.ExportTraceServiceRequest
+	headers  []http.Header
+}
+
+func (s *otlpStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-protobuf" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	body, errRead := io.ReadAll(r.Body)
+	if errRead != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	payload := &coltracepb.ExportTraceServiceRequest{}
+	if errDecode := proto.Unmarshal(body, payload); errDecode != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, payload)
+	s.headers = append(s.headers, r.Header.Clone())
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/x-protobuf")
+}
+
+func (s *otlpStub) spans() map[string]*tracepb.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]*tracepb.Span)
+	for _, req := range s.requests {
+		for _, rs := range req.GetResourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					out[span.GetName()] = span
+				}
+			}
+		}
+	}
+	return out
+}
+
+func attributeValue(span *tracepb.Span, key string) string {
+	for _, attr := range span.GetAttributes() {
+		if attr.GetKey() != key {
+			continue
+		}
+		switch value := attr.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			return value.StringValue
+		case *commonpb.AnyValue_IntValue:
+			return strconv.FormatInt(value.IntValue, 10)
+		}
+	}
+	return ""
+}
+
+func configureForTest(t *testing.T, endpoint string, propagate ...string) {
+	t.Helper()
+	cfg := &config.Config{Tracing: config.TracingConfig{
+		Enable:            true,
+		Endpoint:          endpoint,
+		Headers:           map[string]string{"X-Collector-Token": "secret"},
+		PropagateUpstream: propagate,
+	}}
+	Configure(cfg)
+	t.Cleanup(func() {
+		_ = Shutdown(context.Background())
+	})
+}
+
+func TestExportToOTLPReceiver(t *testing.T) {
+	stub := &otlpStub{}
+	collector := httptest.NewServer(stub)
+	defer collector.Close()
+
+	var gotTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("Traceparent")
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	configureForTest(t, collector.URL+"/v1/traces", "codex")
+
+	incoming := http.Header{}
+	incoming.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Extract(context.Background(), incoming)
+	ctx, root := Start(ctx, "request", trace.WithSpanKind(trace.SpanKindServer))
+	attemptCtx, attempt := Start(ctx, "cliproxy.upstream_attempt", trace.WithAttributes(AttrProvider.String("codex"), AttrAuthIndex.String("abc123")))
+
+	client := &http.Client{Transport: WrapTransport(nil, "codex")}
+	req, _ := http.NewRequestWithContext(attemptCtx, http.MethodPost, upstream.URL+"/v1/responses?key=hidden", strings.NewReader("{}"))
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		t.Fatalf("upstream request: %v", errDo)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	attempt.SetAttributes(AttrStatus.Int(http.StatusOK))
+	End(attempt, nil)
+	root.End()
+
+	if errShutdown := Shutdown(context.Background()); errShutdown != nil {
+		t.Fatalf("shutdown: %v", errShutdown)
+	}
+
+	if !strings.HasPrefix(gotTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Fatalf("upstream traceparent = %q, want the incoming trace id", gotTraceparent)
+	}
+	spans := stub.spans()
+	for _, name := range []string{"request", "cliproxy.upstream_attempt", "HTTP POST"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("span %q not exported; got %v", name, spans)
+		}
+		if traceID := hex.EncodeToString(span.GetTraceId()); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("span %q trace id = %s", name, traceID)
+		}
+	}
+	if parent := hex.EncodeToString(spans["request"].GetParentSpanId()); parent != "00f067aa0ba902b7" {
+		t.Fatalf("server span parent = %q, want the incoming span", parent)
+	}
+	if got := attributeValue(spans["cliproxy.upstream_attempt"], "cliproxy.auth_index"); got != "abc123" {
+		t.Fatalf("auth index attribute = %q", got)
+	}
+	clientSpan := spans["HTTP POST"]
+	if got := attributeValue(clientSpan, "http.response.status_code"); got != "200" {
+		t.Fatalf("client span status = %q", got)
+	}
+	if got := attributeValue(clientSpan, "url.path"); got != "/v1/responses" {
+		t.Fatalf("client span path = %q", got)
+	}
+	if clientSpan.GetKind() != tracepb.Span_SPAN_KIND_CLIENT || len(clientSpan.GetEvents()) == 0 {
+		t.Fatalf("client span kind %v events %d", clientSpan.GetKind(), len(clientSpan.GetEvents()))
+	}
+	if got := stub.headers[0].Get("X-Collector-Token"); got != "secret" {
+		t.Fatalf("collector header = %q", got)
+	}
+}
+
+func TestTraceparentNotPropagatedToUnlistedProvider(t *testing.T) {
+	collector := httptest.NewServer(&otlpStub{})
+	defer collector.Close()
+
+	var gotTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("Traceparent")
+	}))
+	defer upstream.Close()
+
+	configureForTest(t, collector.URL, "codex")
+	ctx, span := Start(context.Background(), "request")
+
+	client := &http.Client{Transport: WrapTransport(nil, "gemini")}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		t.Fatalf("upstream request: %v", errDo)
+	}
+	_ = resp.Body.Close()
+	span.End()
+	_ = Shutdown(context.Background())
+	if gotTraceparent != "" {
+		t.Fatalf("traceparent sent to unlisted provider: %q", gotTraceparent)
+	}
+}
+
+func TestWrapTransportDisabled(t *testing.T) {
+	_ = Shutdown(context.Background())
+	base := http.DefaultTransport
+	if got := WrapTransport(base, "codex"); got != base {
+		t.Fatal("disabled tracing should return the base transport")
+	}
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapTransport returns base wrapped so each upstream request gets a client span. The
+// traceparent header is injected only for providers listed in tracing.propagate-upstream.
+// base is returned unchanged while tracing is disabled.
+func WrapTransport(base http.RoundTripper, provider string) http.RoundTripper {
+	if !Enabled() {
+		return base
+	}
+	if _, ok := base.(*transport); ok {
+		return base
+	}
+	return &transport{base: base, provider: provider}
+}
+
+// UnwrapTransport returns the transport wrapped by WrapTransport, or rt itself.
+func UnwrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if wrapped, ok := rt.(*transport); ok {
+		return wrapped.base
+	}
+	return rt
+}
+
+type transport struct {
+	base     http.RoundTripper
+	provider string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		AttrProvider.String(t.provider),
+	}
+	if req.URL != nil {
+		// The query string is left out: some upstreams carry API keys in it.
+		attrs = append(attrs, attribute.String("server.address", req.URL.Host), attribute.String("url.path", req.URL.Path))
+	}
+	ctx, span := Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	if shouldPropagate(t.provider) {
+		req = req.Clone(ctx)
+		propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, errRoundTrip := base.RoundTrip(req)
+	if errRoundTrip != nil {
+		End(span, errRoundTrip)
+		return resp, errRoundTrip
+	}
+	span.AddEvent("response_headers")
+	span.SetAttributes(AttrStatus.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	if resp.Body == nil {
+		span.End()
+		return resp, nil
+	}
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody ends the client span once the response body is drained or closed, so
+// streamed responses are covered end to end.
+type spanBody struct {
+	io.ReadCloser
+	span trace.Span
+	once sync.Once
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *spanBody) finish() {
+	b.once.Do(func() { b.span.End() })
+}
@@ -0,0 +1,555 @@
+// Package usageledger stores usage records durably in SQLite or PostgreSQL and answers
+// time-bucketed aggregate queries over them.
+//
+// Records are buffered in memory and written in batches. Records older than the
+// downsampling age are rolled up into hourly buckets, and both are deleted once they
+// exceed the retention period.
+package usageledger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	flushInterval       = time.Second
+	maintenanceInterval = time.Hour
+	rollupWidth         = time.Hour
+	// maxPending bounds buffered records while the database is unreachable; the oldest
+	// are dropped first, logged and counted in cliproxy_usage_ledger_dropped_records_total.
+	maxPending = 100000
+)
+
+// ErrDisabled is returned by Query while the ledger is not enabled.
+var ErrDisabled = errors.New("usage ledger is not enabled")
+
+func init() {
+	coreusage.RegisterNamedPlugin("usage-ledger", defaultLedger)
+}
+
+var defaultLedger = New()
+
+// Default returns the process-wide ledger fed by the usage pipeline.
+func Default() *Ledger {
+	return defaultLedger
+}
+
+// Configure applies cfg.UsageLedger to the default ledger.
+func Configure(cfg *config.Config) {
+	defaultLedger.Configure(cfg)
+}
+
+// Ledger is a usage plugin persisting every record it receives.
+type Ledger struct {
+	mu       sync.Mutex
+	store    *store
+	target   string
+	settings config.UsageLedgerConfig
+	names    map[string]string
+	now      func() time.Time
+}
+
+// New returns a disabled ledger.
+func New() *Ledger {
+	return &Ledger{now: time.Now}
+}
+
+// store is one open database with the records not yet written to it.
+type store struct {
+	db       *sql.DB
+	postgres bool
+
+	mu      sync.Mutex
+	pending []row
+	dropped int
+
+	writeMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// row is one usage record as stored in usage_records.
+type row struct {
+	ts                  int64
+	requestID           string
+	clientID            string
+	clientName          string
+	alias               string
+	model               string
+	provider            string
+	authIndex           string
+	authType            string
+	source              string
+	endpoint            string
+	serviceTier         string
+	inputTokens         int64
+	outputTokens        int64
+	reasoningTokens     int64
+	cachedTokens        int64
+	cacheCreationTokens int64
+	totalTokens         int64
+	estimatedCost       float64
+	latencyMs           int64
+	ttftMs              int64
+	failed              int64
+	statusCode          int64
+}
+
+// Configure opens, switches or closes the database according to cfg. Retention settings
+// and client key names apply without reopening.
+func (l *Ledger) Configure(cfg *config.Config) {
+	if l == nil {
+		return
+	}
+	var settings config.UsageLedgerConfig
+	names := make(map[string]string)
+	target := ""
+	location := ""
+	if cfg != nil {
+		settings = cfg.UsageLedger
+		for _, key := range cfg.APIKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				names[budget.KeyID(key)] = config.ClientAPIKey{Key: key}.DisplayName()
+			}
+		}
+		for _, entry := range cfg.ClientAPIKeys {
+			if key := strings.TrimSpace(entry.Key); key != "" {
+				names[budget.KeyID(key)] = entry.DisplayName()
+			}
+		}
+		if settings.Enable {
+			location = resolveLocation(cfg)
+			target = settings.NormalizedDriver() + "\x00" + location
+		}
+	}
+
+	l.mu.Lock()
+	l.settings = settings
+	l.names = names
+	if target == l.target {
+		l.mu.Unlock()
+		return
+	}
+	previous := l.store
+	l.store = nil
+	l.target = target
+	l.mu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+	if target == "" {
+		return
+	}
+	opened, errOpen := openStore(settings.NormalizedDriver(), location)
+	if errOpen != nil {
+		log.Errorf("usage ledger: %v", errOpen)
+		l.mu.Lock()
+		if l.target == target {
+			l.target = ""
+		}
+		l.mu.Unlock()
+		return
+	}
+	l.mu.Lock()
+	if l.target != target {
+		l.mu.Unlock()
+		opened.close()
+		return
+	}
+	l.store = opened
+	l.mu.Unlock()
+	log.Infof("usage ledger enabled (%s)", settings.NormalizedDriver())
+	go l.run(opened)
+}
+
+func resolveLocation(cfg *config.Config) string {
+	settings := cfg.UsageLedger
+	if settings.NormalizedDriver() == config.UsageLedgerDriverPostgres {
+		if dsn := strings.TrimSpace(settings.DSN); dsn != "" {
+			return dsn
+		}
+		for _, name := range []string{"PGSTORE_DSN", "pgstore_dsn"} {
+			if dsn := strings.TrimSpace(os.Getenv(name)); dsn != "" {
+				return dsn
+			}
+		}
+		return ""
+	}
+	if path := strings.TrimSpace(settings.Path); path != "" {
+		return path
+	}
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil || strings.TrimSpace(authDir) == "" {
+		return config.DefaultUsageLedgerFileName
+	}
+	return filepath.Join(authDir, config.DefaultUsageLedgerFileName)
+}
+
+func openStore(driver, location string) (*store, error) {
+	if location == "" {
+		if driver == config.UsageLedgerDriverPostgres {
+			return nil, fmt.Errorf("postgres driver needs usage-ledger.dsn or PGSTORE_DSN")
+		}
+		return nil, fmt.Errorf("no database path")
+	}
+	var (
+		db      *sql.DB
+		errOpen error
+	)
+	if driver == config.UsageLedgerDriverPostgres {
+		db, errOpen = sql.Open("pgx", location)
+	} else {
+		if errMkdir := os.MkdirAll(filepath.Dir(location), 0o700); errMkdir != nil {
+			return nil, fmt.Errorf("create database directory: %w", errMkdir)
+		}
+		db, errOpen = sql.Open("sqlite", location+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+		if errOpen == nil {
+			// SQLite allows one writer; a single connection avoids SQLITE_BUSY.
+			db.SetMaxOpenConns(1)
+		}
+	}
+	if errOpen != nil {
+		return nil, fmt.Errorf("open database: %w", errOpen)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := &store{db: db, postgres: driver == config.UsageLedgerDriverPostgres, stop: make(chan struct{}), done: make(chan struct{})}
+	for _, statement := range schemaStatements {
+		if _, errExec := db.ExecContext(ctx, statement); errExec != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("create schema: %w", errExec)
+		}
+	}
+	return s, nil
+}
+
+var schemaStatements = []string{
+	`CREATE TABLE IF NOT EXISTS usage_records (
+	ts BIGINT NOT NULL,
+	request_id TEXT NOT NULL DEFAULT '',
+	client_id TEXT NOT NULL DEFAULT '',
+	client_name TEXT NOT NULL DEFAULT '',
+	alias TEXT NOT NULL DEFAULT '',
+	model TEXT NOT NULL DEFAULT '',
+	provider TEXT NOT NULL DEFAULT '',
+	auth_index TEXT NOT NULL DEFAULT '',
+	auth_type TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL DEFAULT '',
+	endpoint TEXT NOT NULL DEFAULT '',
+	service_tier TEXT NOT NULL DEFAULT '',
+	input_tokens BIGINT NOT NULL DEFAULT 0,
+	output_tokens BIGINT NOT NULL DEFAULT 0,
+	reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+	cached_tokens BIGINT NOT NULL DEFAULT 0,
+	cache_creation_tokens BIGINT NOT NULL DEFAULT 0,
+	total_tokens BIGINT NOT NULL DEFAULT 0,
+	estimated_cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+	latency_ms BIGINT NOT NULL DEFAULT 0,
+	ttft_ms BIGINT NOT NULL DEFAULT 0,
+	failed BIGINT NOT NULL DEFAULT 0,
+	status_code BIGINT NOT NULL DEFAULT 0
+)`,
+	`CREATE INDEX IF NOT EXISTS usage_records_ts ON usage_records (ts)`,
+	`CREATE TABLE IF NOT EXISTS usage_rollups (
+	bucket BIGINT NOT NULL,
+	client_id TEXT NOT NULL DEFAULT '',
+	client_name TEXT NOT NULL DEFAULT '',
+	alias TEXT NOT NULL DEFAULT '',
+	model TEXT NOT NULL DEFAULT '',
+	provider TEXT NOT NULL DEFAULT '',
+	auth_index TEXT NOT NULL DEFAULT '',
+	requests BIGINT NOT NULL DEFAULT 0,
+	failed BIGINT NOT NULL DEFAULT 0,
+	input_tokens BIGINT NOT NULL DEFAULT 0,
+	output_tokens BIGINT NOT NULL DEFAULT 0,
+	reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+	cached_tokens BIGINT NOT NULL DEFAULT 0,
+	cache_creation_tokens BIGINT NOT NULL DEFAULT 0,
+	total_tokens BIGINT NOT NULL DEFAULT 0,
+	estimated_cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+	latency_ms BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (bucket, client_id, alias, model, provider, auth_index)
+)`,
+}
+
+// HandleUsage implements coreusage.Plugin by buffering the record for the next write.
+func (l *Ledger) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	s := l.store
+	names := l.names
+	l.mu.Unlock()
+	if s == nil {
+		return
+	}
+	s.add(newRow(ctx, record, names, l.now()))
+}
+
+func newRow(ctx context.Context, record coreusage.Record, names map[string]string, now time.Time) row {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+	detail := coreusage.EnsureTokenBreakdownForProvider(record.Detail, record.Provider, record.ExecutorType)
+	r := row{
+		ts:                  timestamp.UnixMilli(),
+		requestID:           logging.GetRequestID(ctx),
+		alias:               strings.TrimSpace(record.Alias),
+		model:               strings.TrimSpace(record.Model),
+		provider:            strings.TrimSpace(record.Provider),
+		authIndex:           strings.TrimSpace(record.AuthIndex),
+		authType:            strings.TrimSpace(record.AuthType),
+		source:              strings.TrimSpace(record.Source),
+		endpoint:            logging.GetEndpoint(ctx),
+		serviceTier:         strings.TrimSpace(record.ServiceTier),
+		inputTokens:         detail.InputTokens,
+		outputTokens:        detail.OutputTokens,
+		reasoningTokens:     detail.ReasoningTokens,
+		cachedTokens:        detail.CachedTokens,
+		cacheCreationTokens: detail.CacheCreationTokens,
+		totalTokens:         detail.TotalTokens,
+		latencyMs:           record.Latency.Milliseconds(),
+		ttftMs:              record.TTFT.Milliseconds(),
+		statusCode:          int64(record.Fail.StatusCode),
+	}
+	if r.alias == "" {
+		r.alias = r.model
+	}
+	if key := strings.TrimSpace(record.APIKey); key != "" {
+		r.clientID = budget.KeyID(key)
+		r.clientName = names[r.clientID]
+	}
+	if record.Failed {
+		r.failed = 1
+	}
+	if record.Cost != nil {
+		r.estimatedCost = record.Cost.Total
+	}
+	return r
+}
+
+func (s *store) add(r row) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) >= maxPending {
+		s.pending = s.pending[1:]
+		if s.dropped == 0 {
+			log.Warnf("usage ledger: %d records are waiting for the database; dropping the oldest", maxPending)
+		}
+		s.dropped++
+		metrics.AddUsageLedgerDropped(1)
+	}
+	s.pending = append(s.pending, r)
+}
+
+func (l *Ledger) run(s *store) {
+	defer close(s.done)
+	flushTicker := time.NewTicker(flushInterval)
+	defer flushTicker.Stop()
+	maintenanceTicker := time.NewTicker(maintenanceInterval)
+	defer maintenanceTicker.Stop()
+	l.maintain(s)
+	for {
+		select {
+		case <-s.stop:
+			if errFlush := s.flush(context.Background()); errFlush != nil {
+				log.Warnf("usage ledger: %v", errFlush)
+			}
+			return
+		case <-flushTicker.C:
+			if errFlush := s.flush(context.Background()); errFlush != nil {
+				log.Warnf("usage ledger: %v", errFlush)
+			}
+		case <-maintenanceTicker.C:
+			l.maintain(s)
+		}
+	}
+}
+
+func (l *Ledger) maintain(s *store) {
+	l.mu.Lock()
+	settings := l.settings
+	l.mu.Unlock()
+	if errMaintain := s.maintain(context.Background(), settings, l.now()); errMaintain != nil {
+		log.Warnf("usage ledger: %v", errMaintain)
+	}
+}
+
+func (s *store) close() {
+	close(s.stop)
+	<-s.done
+	if errClose := s.db.Close(); errClose != nil {
+		log.Debugf("usage ledger: close database: %v", errClose)
+	}
+}
+
+// Flush writes buffered records to the database.
+func (l *Ledger) Flush() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	s := l.store
+	l.mu.Unlock()
+	if s == nil {
+		return nil
+	}
+	return s.flush(context.Background())
+}
+
+func (s *store) flush(ctx context.Context) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	batch := s.pending
+	dropped := s.dropped
+	s.pending = nil
+	s.dropped = 0
+	s.mu.Unlock()
+	if dropped > 0 {
+		log.Warnf("usage ledger: dropped %d records while the database was unavailable", dropped)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	if errWrite := s.write(ctx, batch); errWrite != nil {
+		// Keep the batch for the next attempt, ahead of records buffered meanwhile.
+		s.mu.Lock()
+		s.pending = append(batch, s.pending...)
+		if overflow := len(s.pending) - maxPending; overflow > 0 {
+			s.pending = s.pending[overflow:]
+			s.dropped += overflow
+			metrics.AddUsageLedgerDropped(overflow)
+		}
+		s.mu.Unlock()
+		return errWrite
+	}
+	return nil
+}
+
+const insertRecord = `INSERT INTO usage_records (ts, request_id, client_id, client_name, alias, model, provider,
+auth_index, auth_type, source, endpoint, service_tier, input_tokens, output_tokens, reasoning_tokens,
+cached_tokens, cache_creation_tokens, total_tokens, estimated_cost, latency_ms, ttft_ms, failed, status_code)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func (s *store) write(ctx context.Context, batch []row) error {
+	tx, errBegin := s.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("begin write: %w", errBegin)
+	}
+	stmt, errPrepare := tx.PrepareContext(ctx, s.rebind(insertRecord))
+	if errPrepare != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("prepare insert: %w", errPrepare)
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+	for _, r := range batch {
+		if _, errExec := stmt.ExecContext(ctx, r.ts, r.requestID, r.clientID, r.clientName, r.alias, r.model, r.provider,
+			r.authIndex, r.authType, r.source, r.endpoint, r.serviceTier, r.inputTokens, r.outputTokens, r.reasoningTokens,
+			r.cachedTokens, r.cacheCreationTokens, r.totalTokens, r.estimatedCost, r.latencyMs, r.ttftMs, r.failed, r.statusCode); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("insert record: %w", errExec)
+		}
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("commit write: %w", errCommit)
+	}
+	return nil
+}
+
+const rollupRecords = `INSERT INTO usage_rollups (bucket, client_id, client_name, alias, model, provider, auth_index,
+requests, failed, input_tokens, output_tokens, reasoning_tokens, cached_tokens, cache_creation_tokens, total_tokens, estimated_cost, latency_ms)
+SELECT (ts / %[1]d) * %[1]d, client_id, MAX(client_name), alias, model, provider, auth_index,
+COUNT(*), SUM(failed), SUM(input_tokens), SUM(output_tokens), SUM(reasoning_tokens), SUM(cached_tokens),
+SUM(cache_creation_tokens), SUM(total_tokens), SUM(estimated_cost), SUM(latency_ms)
+FROM usage_records WHERE ts < ?
+GROUP BY (ts / %[1]d) * %[1]d, client_id, alias, model, provider, auth_index
+ON CONFLICT (bucket, client_id, alias, model, provider, auth_index) DO UPDATE SET
+client_name = excluded.client_name,
+requests = usage_rollups.requests + excluded.requests,
+failed = usage_rollups.failed + excluded.failed,
+input_tokens = usage_rollups.input_tokens + excluded.input_tokens,
+output_tokens = usage_rollups.output_tokens + excluded.output_tokens,
+reasoning_tokens = usage_rollups.reasoning_tokens + excluded.reasoning_tokens,
+cached_tokens = usage_rollups.cached_tokens + excluded.cached_tokens,
+cache_creation_tokens = usage_rollups.cache_creation_tokens + excluded.cache_creation_tokens,
+total_tokens = usage_rollups.total_tokens + excluded.total_tokens,
+estimated_cost = usage_rollups.estimated_cost + excluded.estimated_cost,
+latency_ms = usage_rollups.latency_ms + excluded.latency_ms`
+
+// maintain rolls records older than the downsampling age into hourly buckets and deletes
+// data past the retention period.
+func (s *store) maintain(ctx context.Context, settings config.UsageLedgerConfig, now time.Time) error {
+	if errFlush := s.flush(ctx); errFlush != nil {
+		return errFlush
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	downsampleAfter := time.Duration(settings.NormalizedDownsampleAfterDays()) * 24 * time.Hour
+	cutoff := now.Add(-downsampleAfter).Truncate(rollupWidth).UnixMilli()
+	tx, errBegin := s.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("begin maintenance: %w", errBegin)
+	}
+	if _, errExec := tx.ExecContext(ctx, s.rebind(fmt.Sprintf(rollupRecords, rollupWidth.Milliseconds())), cutoff); errExec != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("downsample records: %w", errExec)
+	}
+	if _, errExec := tx.ExecContext(ctx, s.rebind(`DELETE FROM usage_records WHERE ts < ?`), cutoff); errExec != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("delete downsampled records: %w", errExec)
+	}
+	if settings.RetentionDays > 0 {
+		expired := now.Add(-time.Duration(settings.RetentionDays) * 24 * time.Hour).UnixMilli()
+		if _, errExec := tx.ExecContext(ctx, s.rebind(`DELETE FROM usage_rollups WHERE bucket < ?`), expired); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("delete expired buckets: %w", errExec)
+		}
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("commit maintenance: %w", errCommit)
+	}
+	return nil
+}
+
+// rebind converts '?' placeholders to the $n form PostgreSQL expects.
+func (s *store) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	var builder strings.Builder
+	builder.Grow(len(query) + 16)
+	n := 0
+	for _, ch := range query {
+		if ch == '?' {
+			n++
+			fmt.Fprintf(&builder, "$%d", n)
+			continue
+		}
+		builder.WriteRune(ch)
+	}
+	return builder.String()
+}
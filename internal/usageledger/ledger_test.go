@@ -0,0 +1,194 @@
+package usageledger
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/budget"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func newTestLedger(t *testing.T, now time.Time, settings config.UsageLedgerConfig) *Ledger {
+	t.Helper()
+	ledger := New()
+	ledger.now = func() time.Time { return now }
+	settings.Enable = true
+	settings.Path = filepath.Join(t.TempDir(), "usage.db")
+	ledger.Configure(&config.Config{
+		SDKConfig: config.SDKConfig{
+			APIKeys:       []string{"plain-key-0001"},
+			ClientAPIKeys: []config.ClientAPIKey{{Name: "team-a", Key: "team-a-key"}},
+		},
+		UsageLedger: settings,
+	})
+	t.Cleanup(func() { ledger.Configure(nil) })
+	if ledger.store == nil {
+		t.Fatal("ledger did not open")
+	}
+	return ledger
+}
+
+func record(key, alias, model string, at time.Time, input, output int64, failed bool) coreusage.Record {
+	return coreusage.Record{
+		APIKey:      key,
+		Alias:       alias,
+		Model:       model,
+		Provider:    "codex",
+		AuthIndex:   "idx1",
+		RequestedAt: at,
+		Latency:     100 * time.Millisecond,
+		Failed:      failed,
+		Detail:      coreusage.Detail{InputTokens: input, OutputTokens: output, TotalTokens: input + output},
+	}
+}
+
+func TestLedgerQueryGroupsByBucketAndDimension(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 30, 0, 0, time.UTC)
+	ledger := newTestLedger(t, now, config.UsageLedgerConfig{})
+	ctx := context.Background()
+
+	ledger.HandleUsage(ctx, record("team-a-key", "fast", "gpt-5-mini", now.Add(-10*time.Minute), 100, 20, false))
+	ledger.HandleUsage(ctx, record("team-a-key", "fast", "gpt-5-mini", now.Add(-20*time.Minute), 50, 10, true))
+	ledger.HandleUsage(ctx, record("plain-key-0001", "", "gpt-5", now.Add(-90*time.Minute), 7, 3, false))
+
+	rows, errQuery := ledger.Query(ctx, Query{
+		From:    now.Add(-24 * time.Hour),
+		To:      now.Add(time.Hour),
+		Bucket:  time.Hour,
+		GroupBy: []string{DimensionClient, DimensionAlias},
+	})
+	if errQuery != nil {
+		t.Fatalf("query: %v", errQuery)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v, want 2", rows)
+	}
+	var teamRow Row
+	for _, r := range rows {
+		if r.Dimensions[DimensionClientName] == "team-a" {
+			teamRow = r
+		}
+	}
+	if teamRow.Requests != 2 || teamRow.Failed != 1 || teamRow.InputTokens != 150 || teamRow.TotalTokens != 180 {
+		t.Fatalf("team-a row = %+v", teamRow)
+	}
+	if teamRow.Dimensions[DimensionClient] != budget.KeyID("team-a-key") || teamRow.Dimensions[DimensionAlias] != "fast" {
+		t.Fatalf("team-a dimensions = %v", teamRow.Dimensions)
+	}
+	if !teamRow.Bucket.Equal(time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)) || teamRow.AvgLatencyMs != 100 {
+		t.Fatalf("team-a bucket %v latency %v", teamRow.Bucket, teamRow.AvgLatencyMs)
+	}
+
+	rows, errQuery = ledger.Query(ctx, Query{
+		From:    now.Add(-24 * time.Hour),
+		To:      now.Add(time.Hour),
+		Filters: map[string]string{DimensionClient: "team-a"},
+	})
+	if errQuery != nil || len(rows) != 1 || rows[0].Requests != 2 {
+		t.Fatalf("filtered totals = %+v, %v", rows, errQuery)
+	}
+	if _, errQuery = ledger.Query(ctx, Query{GroupBy: []string{"api_key"}}); errQuery == nil {
+		t.Fatal("unknown dimension accepted")
+	}
+}
+
+func TestLedgerDownsamplesAndExpires(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 30, 0, 0, time.UTC)
+	ledger := newTestLedger(t, now, config.UsageLedgerConfig{DownsampleAfterDays: 1, RetentionDays: 10})
+	ctx := context.Background()
+
+	old := now.Add(-48 * time.Hour)
+	ledger.HandleUsage(ctx, record("team-a-key", "", "gpt-5", old, 10, 1, false))
+	ledger.HandleUsage(ctx, record("team-a-key", "", "gpt-5", old.Add(5*time.Minute), 20, 2, false))
+	ledger.HandleUsage(ctx, record("team-a-key", "", "gpt-5", now.Add(-20*24*time.Hour), 1000, 0, false))
+	ledger.HandleUsage(ctx, record("team-a-key", "", "gpt-5", now, 1, 1, false))
+
+	settings := config.UsageLedgerConfig{DownsampleAfterDays: 1, RetentionDays: 10}
+	if errMaintain := ledger.store.maintain(ctx, settings, now); errMaintain != nil {
+		t.Fatalf("maintain: %v", errMaintain)
+	}
+	var raw, buckets int
+	if errCount := ledger.store.db.QueryRow("SELECT COUNT(*) FROM usage_records").Scan(&raw); errCount != nil {
+		t.Fatal(errCount)
+	}
+	if errCount := ledger.store.db.QueryRow("SELECT COUNT(*) FROM usage_rollups").Scan(&buckets); errCount != nil {
+		t.Fatal(errCount)
+	}
+	if raw != 1 || buckets != 1 {
+		t.Fatalf("after maintenance: %d raw records, %d buckets; want 1 and 1", raw, buckets)
+	}
+
+	rows, errQuery := ledger.Query(ctx, Query{From: now.Add(-30 * 24 * time.Hour), To: now.Add(time.Hour)})
+	if errQuery != nil {
+		t.Fatalf("query: %v", errQuery)
+	}
+	if len(rows) != 1 || rows[0].Requests != 3 || rows[0].InputTokens != 31 {
+		t.Fatalf("totals after downsampling = %+v, want 3 requests and 31 input tokens", rows)
+	}
+
+	// Rolling up again merges into the existing bucket.
+	ledger.HandleUsage(ctx, record("team-a-key", "", "gpt-5", old.Add(10*time.Minute), 5, 0, true))
+	if errMaintain := ledger.store.maintain(ctx, settings, now); errMaintain != nil {
+		t.Fatalf("maintain: %v", errMaintain)
+	}
+	rows, _ = ledger.Query(ctx, Query{From: old.Add(-time.Hour), To: old.Add(time.Hour)})
+	if len(rows) != 1 || rows[0].Requests != 3 || rows[0].Failed != 1 || rows[0].InputTokens != 35 {
+		t.Fatalf("merged bucket = %+v", rows)
+	}
+}
+
+func TestLedgerDisabled(t *testing.T) {
+	ledger := New()
+	ledger.HandleUsage(context.Background(), record("k", "", "m", time.Now(), 1, 1, false))
+	if _, errQuery := ledger.Query(context.Background(), Query{}); errQuery != ErrDisabled {
+		t.Fatalf("query on disabled ledger = %v, want ErrDisabled", errQuery)
+	}
+}
+
+func TestStoreDropsOldestPendingRecordsBeyondLimit(t *testing.T) {
+	s := &store{}
+	for i := 0; i < maxPending+2; i++ {
+		s.add(row{ts: int64(i)})
+	}
+	if len(s.pending) != maxPending || s.dropped != 2 {
+		t.Fatalf("pending = %d, dropped = %d; want %d and 2", len(s.pending), s.dropped, maxPending)
+	}
+	if s.pending[0].ts != 2 {
+		t.Fatalf("oldest pending ts = %d, want 2", s.pending[0].ts)
+	}
+}
+
+func TestRebindPostgres(t *testing.T) {
+	s := &store{postgres: true}
+	if got := s.rebind("a = ? AND b IN (?, ?)"); got != "a = $1 AND b IN ($2, $3)" {
+		t.Fatalf("rebind = %q", got)
+	}
+}
+
+func TestLedgerSumsEstimatedCost(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 30, 0, 0, time.UTC)
+	settings := config.UsageLedgerConfig{DownsampleAfterDays: 1}
+	ledger := newTestLedger(t, now, settings)
+	ctx := context.Background()
+
+	for _, at := range []time.Time{now.Add(-48 * time.Hour), now} {
+		priced := record("team-a-key", "", "gpt-5", at, 10, 1, false)
+		priced.Cost = &coreusage.Cost{Currency: "USD", Total: 0.25}
+		ledger.HandleUsage(ctx, priced)
+	}
+	ledger.HandleUsage(ctx, record("team-a-key", "", "unpriced", now, 10, 1, false))
+	if errMaintain := ledger.store.maintain(ctx, settings, now); errMaintain != nil {
+		t.Fatalf("maintain: %v", errMaintain)
+	}
+
+	rows, errQuery := ledger.Query(ctx, Query{From: now.Add(-72 * time.Hour), To: now.Add(time.Hour)})
+	if errQuery != nil {
+		t.Fatalf("query: %v", errQuery)
+	}
+	if len(rows) != 1 || rows[0].Requests != 3 || rows[0].EstimatedCost != 0.5 {
+		t.Fatalf("totals = %+v, want 3 requests costing 0.5", rows)
+	}
+}
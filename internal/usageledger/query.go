@@ -0,0 +1,217 @@
+package usageledger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dimensions that Query can group and filter by.
+const (
+	DimensionClient     = "client"
+	DimensionClientName = "client_name"
+	DimensionAlias      = "alias"
+	DimensionModel      = "model"
+	DimensionProvider   = "provider"
+	DimensionAuthIndex  = "auth_index"
+)
+
+var dimensionColumns = map[string]string{
+	DimensionClient:     "client_id",
+	DimensionClientName: "client_name",
+	DimensionAlias:      "alias",
+	DimensionModel:      "model",
+	DimensionProvider:   "provider",
+	DimensionAuthIndex:  "auth_index",
+}
+
+// IsDimension reports whether name can be used in Query.GroupBy and Query.Filters.
+func IsDimension(name string) bool {
+	_, ok := dimensionColumns[name]
+	return ok
+}
+
+// Query selects and aggregates ledger data.
+type Query struct {
+	// From and To bound the records, inclusive and exclusive respectively.
+	From time.Time
+	To   time.Time
+	// Bucket is the width of the time buckets. Zero aggregates the whole range. Data
+	// older than the downsampling age only has hourly resolution.
+	Bucket time.Duration
+	// GroupBy lists the dimensions rows are split by.
+	GroupBy []string
+	// Filters restricts dimensions to exact values. The client filter matches the key ID
+	// or its name.
+	Filters map[string]string
+	// Limit caps the rows returned. Zero returns every row.
+	Limit int
+}
+
+// Row is one aggregated bucket.
+type Row struct {
+	Bucket              time.Time         `json:"bucket"`
+	Dimensions          map[string]string `json:"dimensions,omitempty"`
+	Requests            int64             `json:"requests"`
+	Failed              int64             `json:"failed"`
+	InputTokens         int64             `json:"input_tokens"`
+	OutputTokens        int64             `json:"output_tokens"`
+	ReasoningTokens     int64             `json:"reasoning_tokens"`
+	CachedTokens        int64             `json:"cached_tokens"`
+	CacheCreationTokens int64             `json:"cache_creation_tokens"`
+	TotalTokens         int64             `json:"total_tokens"`
+	EstimatedCost       float64           `json:"estimated_cost"`
+	AvgLatencyMs        float64           `json:"avg_latency_ms"`
+}
+
+const metricColumns = `requests, failed, input_tokens, output_tokens, reasoning_tokens, cached_tokens,
+cache_creation_tokens, total_tokens, latency_ms`
+
+// Query aggregates raw records and hourly buckets within the range of q.
+func (l *Ledger) Query(ctx context.Context, q Query) ([]Row, error) {
+	if l == nil {
+		return nil, ErrDisabled
+	}
+	l.mu.Lock()
+	s := l.store
+	l.mu.Unlock()
+	if s == nil {
+		return nil, ErrDisabled
+	}
+	if errFlush := s.flush(ctx); errFlush != nil {
+		return nil, errFlush
+	}
+	return s.query(ctx, q)
+}
+
+func (s *store) query(ctx context.Context, q Query) ([]Row, error) {
+	var filterSQL strings.Builder
+	var filterArgs []any
+	for name, value := range q.Filters {
+		column, ok := dimensionColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown dimension %q", name)
+		}
+		if name == DimensionClient {
+			filterSQL.WriteString(" AND (client_id = ? OR client_name = ?)")
+			filterArgs = append(filterArgs, value, value)
+			continue
+		}
+		filterSQL.WriteString(" AND " + column + " = ?")
+		filterArgs = append(filterArgs, value)
+	}
+
+	var selects, groups []string
+	ordinal := 0
+	bucketWidth := q.Bucket.Milliseconds()
+	if bucketWidth > 0 {
+		ordinal++
+		selects = append(selects, fmt.Sprintf("(ts / %[1]d) * %[1]d", bucketWidth))
+		groups = append(groups, fmt.Sprint(ordinal))
+	}
+	dimensions := make([]string, 0, len(q.GroupBy)+1)
+	for _, name := range q.GroupBy {
+		column, ok := dimensionColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown dimension %q", name)
+		}
+		ordinal++
+		selects = append(selects, column)
+		groups = append(groups, fmt.Sprint(ordinal))
+		dimensions = append(dimensions, name)
+	}
+	// A key's name follows it through renames, so it is reported rather than grouped.
+	reportName := containsString(q.GroupBy, DimensionClient) && !containsString(q.GroupBy, DimensionClientName)
+	if reportName {
+		selects = append(selects, "MAX(client_name)")
+	}
+	for _, column := range strings.Split(metricColumns, ",") {
+		selects = append(selects, fmt.Sprintf("COALESCE(CAST(SUM(%s) AS BIGINT), 0)", strings.TrimSpace(column)))
+	}
+	selects = append(selects, "COALESCE(SUM(estimated_cost), 0)")
+
+	statement := fmt.Sprintf(`SELECT %s FROM (
+SELECT ts, client_id, client_name, alias, model, provider, auth_index, 1 AS requests, failed, input_tokens,
+output_tokens, reasoning_tokens, cached_tokens, cache_creation_tokens, total_tokens, latency_ms, estimated_cost
+FROM usage_records WHERE ts >= ? AND ts < ?%[2]s
+UNION ALL
+SELECT bucket AS ts, client_id, client_name, alias, model, provider, auth_index, %[3]s, estimated_cost
+FROM usage_rollups WHERE bucket >= ? AND bucket < ?%[2]s
+) u`, strings.Join(selects, ", "), filterSQL.String(), metricColumns)
+	if len(groups) > 0 {
+		statement += " GROUP BY " + strings.Join(groups, ", ") + " ORDER BY " + strings.Join(groups, ", ")
+	}
+	if q.Limit > 0 {
+		statement += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+	args := []any{q.From.UnixMilli(), q.To.UnixMilli()}
+	args = append(args, filterArgs...)
+	args = append(args, q.From.UnixMilli(), q.To.UnixMilli())
+	args = append(args, filterArgs...)
+
+	rows, errQuery := s.db.QueryContext(ctx, s.rebind(statement), args...)
+	if errQuery != nil {
+		return nil, fmt.Errorf("query usage: %w", errQuery)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	out := make([]Row, 0)
+	for rows.Next() {
+		var (
+			bucketMs   int64
+			values     = make([]string, len(dimensions))
+			clientName string
+			latencyMs  int64
+			result     Row
+		)
+		targets := make([]any, 0, len(selects))
+		if bucketWidth > 0 {
+			targets = append(targets, &bucketMs)
+		}
+		for i := range values {
+			targets = append(targets, &values[i])
+		}
+		if reportName {
+			targets = append(targets, &clientName)
+		}
+		targets = append(targets, &result.Requests, &result.Failed, &result.InputTokens, &result.OutputTokens,
+			&result.ReasoningTokens, &result.CachedTokens, &result.CacheCreationTokens, &result.TotalTokens, &latencyMs,
+			&result.EstimatedCost)
+		if errScan := rows.Scan(targets...); errScan != nil {
+			return nil, fmt.Errorf("scan usage: %w", errScan)
+		}
+		if result.Requests == 0 {
+			continue
+		}
+		result.Bucket = q.From.UTC()
+		if bucketWidth > 0 {
+			result.Bucket = time.UnixMilli(bucketMs).UTC()
+		}
+		if len(dimensions) > 0 {
+			result.Dimensions = make(map[string]string, len(dimensions)+1)
+			for i, name := range dimensions {
+				result.Dimensions[name] = values[i]
+			}
+			if reportName {
+				result.Dimensions[DimensionClientName] = clientName
+			}
+		}
+		result.AvgLatencyMs = float64(latencyMs) / float64(result.Requests)
+		out = append(out, result)
+	}
+	if errRows := rows.Err(); errRows != nil {
+		return nil, fmt.Errorf("read usage: %w", errRows)
+	}
+	return out, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -34,6 +34,30 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if strings.TrimSpace(oldCfg.Pprof.Addr) != strings.TrimSpace(newCfg.Pprof.Addr) {
 		changes = append(changes, fmt.Sprintf("pprof.addr: %s -> %s", strings.TrimSpace(oldCfg.Pprof.Addr), strings.TrimSpace(newCfg.Pprof.Addr)))
 	}
+	if oldCfg.Metrics.Enable != newCfg.Metrics.Enable {
+		changes = append(changes, fmt.Sprintf("metrics.enable: %t -> %t", oldCfg.Metrics.Enable, newCfg.Metrics.Enable))
+	}
+	if strings.TrimSpace(oldCfg.Metrics.Addr) != strings.TrimSpace(newCfg.Metrics.Addr) {
+		changes = append(changes, fmt.Sprintf("metrics.addr: %s -> %s", strings.TrimSpace(oldCfg.Metrics.Addr), strings.TrimSpace(newCfg.Metrics.Addr)))
+	}
+	if oldCfg.Metrics.BearerToken != newCfg.Metrics.BearerToken {
+		changes = append(changes, "metrics.bearer-token: updated")
+	}
+	if oldCfg.Tracing.Enable != newCfg.Tracing.Enable {
+		changes = append(changes, fmt.Sprintf("tracing.enable: %t -> %t", oldCfg.Tracing.Enable, newCfg.Tracing.Enable))
+	}
+	if oldCfg.Tracing.NormalizedEndpoint() != newCfg.Tracing.NormalizedEndpoint() {
+		changes = append(changes, fmt.Sprintf("tracing.endpoint: %s -> %s", oldCfg.Tracing.NormalizedEndpoint(), newCfg.Tracing.NormalizedEndpoint()))
+	}
+	if !reflect.DeepEqual(oldCfg.Tracing.Headers, newCfg.Tracing.Headers) {
+		changes = append(changes, "tracing.headers: updated")
+	}
+	if oldCfg.Tracing.SampleRatio != newCfg.Tracing.SampleRatio {
+		changes = append(changes, fmt.Sprintf("tracing.sample-ratio: %g -> %g", oldCfg.Tracing.SampleRatio, newCfg.Tracing.SampleRatio))
+	}
+	if !reflect.DeepEqual(oldCfg.Tracing.PropagateUpstream, newCfg.Tracing.PropagateUpstream) {
+		changes = append(changes, fmt.Sprintf("tracing.propagate-upstream: %v -> %v", oldCfg.Tracing.PropagateUpstream, newCfg.Tracing.PropagateUpstream))
+	}
 	if oldCfg.LoggingToFile != newCfg.LoggingToFile {
 		changes = append(changes, fmt.Sprintf("logging-to-file: %t -> %t", oldCfg.LoggingToFile, newCfg.LoggingToFile))
 	}
@@ -43,6 +67,36 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.RedisUsageQueueRetentionSeconds != newCfg.RedisUsageQueueRetentionSeconds {
 		changes = append(changes, fmt.Sprintf("redis-usage-queue-retention-seconds: %d -> %d", oldCfg.RedisUsageQueueRetentionSeconds, newCfg.RedisUsageQueueRetentionSeconds))
 	}
+	if oldCfg.UsageLedger.Enable != newCfg.UsageLedger.Enable {
+		changes = append(changes, fmt.Sprintf("usage-ledger.enable: %t -> %t", oldCfg.UsageLedger.Enable, newCfg.UsageLedger.Enable))
+	}
+	if oldCfg.UsageLedger.NormalizedDriver() != newCfg.UsageLedger.NormalizedDriver() {
+		changes = append(changes, fmt.Sprintf("usage-ledger.driver: %s -> %s", oldCfg.UsageLedger.NormalizedDriver(), newCfg.UsageLedger.NormalizedDriver()))
+	}
+	if strings.TrimSpace(oldCfg.UsageLedger.Path) != strings.TrimSpace(newCfg.UsageLedger.Path) {
+		changes = append(changes, fmt.Sprintf("usage-ledger.path: %s -> %s", strings.TrimSpace(oldCfg.UsageLedger.Path), strings.TrimSpace(newCfg.UsageLedger.Path)))
+	}
+	if oldCfg.UsageLedger.DSN != newCfg.UsageLedger.DSN {
+		changes = append(changes, "usage-ledger.dsn: updated")
+	}
+	if oldCfg.UsageLedger.DownsampleAfterDays != newCfg.UsageLedger.DownsampleAfterDays {
+		changes = append(changes, fmt.Sprintf("usage-ledger.downsample-after-days: %d -> %d", oldCfg.UsageLedger.DownsampleAfterDays, newCfg.UsageLedger.DownsampleAfterDays))
+	}
+	if oldCfg.UsageLedger.RetentionDays != newCfg.UsageLedger.RetentionDays {
+		changes = append(changes, fmt.Sprintf("usage-ledger.retention-days: %d -> %d", oldCfg.UsageLedger.RetentionDays, newCfg.UsageLedger.RetentionDays))
+	}
+	if oldCfg.Pricing.NormalizedCurrency() != newCfg.Pricing.NormalizedCurrency() {
+		changes = append(changes, fmt.Sprintf("pricing.currency: %s -> %s", oldCfg.Pricing.NormalizedCurrency(), newCfg.Pricing.NormalizedCurrency()))
+	}
+	if oldCfg.Pricing.DisableDefaults != newCfg.Pricing.DisableDefaults {
+		changes = append(changes, fmt.Sprintf("pricing.disable-defaults: %t -> %t", oldCfg.Pricing.DisableDefaults, newCfg.Pricing.DisableDefaults))
+	}
+	if !reflect.DeepEqual(oldCfg.Pricing.ServiceTiers, newCfg.Pricing.ServiceTiers) {
+		changes = append(changes, "pricing.service-tiers: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Pricing.Models, newCfg.Pricing.Models) {
+		changes = append(changes, fmt.Sprintf("pricing.models: updated (%d -> %d entries)", len(oldCfg.Pricing.Models), len(newCfg.Pricing.Models)))
+	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
@@ -73,6 +127,24 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if !reflect.DeepEqual(oldCfg.RequestLogRedaction, newCfg.RequestLogRedaction) {
+		changes = append(changes, "request-log-redaction: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.HealthProbe, newCfg.HealthProbe) {
+		changes = append(changes, fmt.Sprintf("health-probe: updated (enable %t -> %t)", oldCfg.HealthProbe.Enable, newCfg.HealthProbe.Enable))
+	}
+	if !reflect.DeepEqual(oldCfg.Alerting, newCfg.Alerting) {
+		changes = append(changes, fmt.Sprintf("alerting: updated (%d -> %d webhooks)", len(oldCfg.Alerting.Webhooks), len(newCfg.Alerting.Webhooks)))
+	}
+	if !reflect.DeepEqual(oldCfg.ResponseCache, newCfg.ResponseCache) {
+		changes = append(changes, fmt.Sprintf("response-cache: updated (enable %t -> %t, backend %s -> %s)", oldCfg.ResponseCache.Enable, newCfg.ResponseCache.Enable, oldCfg.ResponseCache.NormalizedBackend(), newCfg.ResponseCache.NormalizedBackend()))
+	}
+	if oldCfg.AccessLog != newCfg.AccessLog {
+		changes = append(changes, fmt.Sprintf("access-log: updated (enable %t -> %t, output %s -> %s)", oldCfg.AccessLog.Enable, newCfg.AccessLog.Enable, oldCfg.AccessLog.NormalizedOutput(), newCfg.AccessLog.NormalizedOutput()))
+	}
+	if oldCfg.AuditLog != newCfg.AuditLog {
+		changes = append(changes, fmt.Sprintf("audit-log: updated (enable %t -> %t)", oldCfg.AuditLog.Enable, newCfg.AuditLog.Enable))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
@@ -161,6 +233,41 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if len(oldCfg.ClientAPIKeys) != len(newCfg.ClientAPIKeys) {
+		changes = append(changes, fmt.Sprintf("client-api-keys count: %d -> %d", len(oldCfg.ClientAPIKeys), len(newCfg.ClientAPIKeys)))
+	} else if !reflect.DeepEqual(oldCfg.ClientAPIKeys, newCfg.ClientAPIKeys) {
+		changes = append(changes, "client-api-keys: entries updated (count unchanged, redacted)")
+	}
+	if !reflect.DeepEqual(oldCfg.JWTAuth, newCfg.JWTAuth) {
+		changes = append(changes, fmt.Sprintf("jwt-auth: updated (enable %t -> %t)", oldCfg.JWTAuth.Enable, newCfg.JWTAuth.Enable))
+	}
+	if !reflect.DeepEqual(oldCfg.MTLSAuth, newCfg.MTLSAuth) {
+		changes = append(changes, fmt.Sprintf("mtls-auth: updated (enable %t -> %t)", oldCfg.MTLSAuth.Enable, newCfg.MTLSAuth.Enable))
+	}
+	if !reflect.DeepEqual(oldCfg.ClientNetwork.AllowedCIDRs, newCfg.ClientNetwork.AllowedCIDRs) {
+		changes = append(changes, fmt.Sprintf("client-network.allowed-cidrs: %v -> %v", oldCfg.ClientNetwork.AllowedCIDRs, newCfg.ClientNetwork.AllowedCIDRs))
+	}
+	if !reflect.DeepEqual(oldCfg.ClientNetwork.TrustedProxies, newCfg.ClientNetwork.TrustedProxies) {
+		changes = append(changes, fmt.Sprintf("client-network.trusted-proxies: %v -> %v", oldCfg.ClientNetwork.TrustedProxies, newCfg.ClientNetwork.TrustedProxies))
+	}
+	if oldCfg.TLS.ClientCA != newCfg.TLS.ClientCA || oldCfg.TLS.NormalizedClientAuth() != newCfg.TLS.NormalizedClientAuth() {
+		changes = append(changes, "tls client certificate settings: updated (restart required)")
+	}
+	if oldCfg.ClientRateLimit.NormalizedBackend() != newCfg.ClientRateLimit.NormalizedBackend() {
+		changes = append(changes, fmt.Sprintf("client-rate-limit.backend: %s -> %s", oldCfg.ClientRateLimit.NormalizedBackend(), newCfg.ClientRateLimit.NormalizedBackend()))
+	}
+	if strings.TrimSpace(oldCfg.ClientRateLimit.RedisURL) != strings.TrimSpace(newCfg.ClientRateLimit.RedisURL) {
+		changes = append(changes, "client-rate-limit.redis-url: updated (redacted)")
+	}
+	if oldCfg.ClientRateLimit.Default != newCfg.ClientRateLimit.Default {
+		o, n := oldCfg.ClientRateLimit.Default, newCfg.ClientRateLimit.Default
+		changes = append(changes, fmt.Sprintf("client-rate-limit.default: rpm %d -> %d, input-tpm %d -> %d, output-tpm %d -> %d, streams %d -> %d",
+			o.RequestsPerMinute, n.RequestsPerMinute, o.InputTokensPerMinute, n.InputTokensPerMinute,
+			o.OutputTokensPerMinute, n.OutputTokensPerMinute, o.MaxConcurrentStreams, n.MaxConcurrentStreams))
+	}
+	if !reflect.DeepEqual(oldCfg.ClientBudgets, newCfg.ClientBudgets) {
+		changes = append(changes, "client-budgets: updated")
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -407,6 +514,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			changes = append(changes, "remote-management.secret-key: updated")
 		}
 	}
+	if len(oldCfg.RemoteManagement.Keys) != len(newCfg.RemoteManagement.Keys) {
+		changes = append(changes, fmt.Sprintf("remote-management.keys count: %d -> %d", len(oldCfg.RemoteManagement.Keys), len(newCfg.RemoteManagement.Keys)))
+	} else {
+		for i := range oldCfg.RemoteManagement.Keys {
+			oldKey, newKey := oldCfg.RemoteManagement.Keys[i], newCfg.RemoteManagement.Keys[i]
+			// Plaintext keys are re-hashed with a fresh salt on every load, so only the
+			// principal and its permissions are compared.
+			if oldKey.Name != newKey.Name || oldKey.Role != newKey.Role || !reflect.DeepEqual(oldKey.Scopes, newKey.Scopes) {
+				changes = append(changes, fmt.Sprintf("remote-management.keys[%d]: updated", i))
+			}
+		}
+	}
 
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
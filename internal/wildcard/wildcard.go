@@ -0,0 +1,42 @@
+// Package wildcard matches the simple glob patterns used across config and routing rules.
+package wildcard
+
+import "strings"
+
+// Match reports whether value matches pattern, where '*' matches any substring, including
+// an empty one. Matching is case-sensitive; callers normalize case when they need to. An
+// empty pattern matches nothing.
+func Match(pattern, value string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+
+	parts := strings.Split(pattern, "*")
+	if prefix := parts[0]; prefix != "" {
+		if !strings.HasPrefix(value, prefix) {
+			return false
+		}
+		value = value[len(prefix):]
+	}
+	// The suffix is removed before the middle segments so they cannot consume it.
+	if suffix := parts[len(parts)-1]; suffix != "" {
+		if !strings.HasSuffix(value, suffix) {
+			return false
+		}
+		value = value[:len(value)-len(suffix)]
+	}
+	for _, segment := range parts[1 : len(parts)-1] {
+		if segment == "" {
+			continue
+		}
+		index := strings.Index(value, segment)
+		if index < 0 {
+			return false
+		}
+		value = value[index+len(segment):]
+	}
+	return true
+}
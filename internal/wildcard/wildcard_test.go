@@ -0,0 +1,30 @@
+package wildcard
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "gpt-5", value: "gpt-5", want: true},
+		{pattern: "gpt-5", value: "gpt-5-mini", want: false},
+		{pattern: "*", value: "", want: true},
+		{pattern: "", value: "", want: false},
+		{pattern: "gpt-*", value: "gpt-4o", want: true},
+		{pattern: "*-5", value: "gpt-5", want: true},
+		{pattern: "gemini-*-pro", value: "gemini-2.5-pro", want: true},
+		{pattern: "gemini-*-pro", value: "gemini-2.5-flash", want: false},
+		{pattern: "a*b*c", value: "abc", want: true},
+		{pattern: "a*b*c", value: "acb", want: false},
+		{pattern: "ab*ba", value: "aba", want: false},
+		{pattern: "GET /usage*", value: "GET /usage/export", want: true},
+		{pattern: "Claude-*", value: "claude-3", want: false},
+	}
+	for _, test := range tests {
+		if got := Match(test.pattern, test.value); got != test.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", test.pattern, test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package access
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/wildcard"
+)
+
+// ModelRules restricts which models an authenticated principal may request.
+// A nil *ModelRules allows every model.
+type ModelRules struct {
+	// Allowed lists model globs ('*' wildcard, case-insensitive). Empty allows every model.
+	Allowed []string
+	// Denied lists model globs that are rejected even when Allowed matches.
+	Denied []string
+	// AllowedPrefixes restricts requests to models addressed with one of these
+	// credential prefixes, as in "teamA/gemini-2.5-pro". Empty allows any prefix.
+	AllowedPrefixes []string
+}
+
+// Empty reports whether the rules do not restrict anything.
+func (r *ModelRules) Empty() bool {
+	return r == nil || (len(r.Allowed) == 0 && len(r.Denied) == 0 && len(r.AllowedPrefixes) == 0)
+}
+
+// Allows reports whether model may be requested. Globs are matched against both the full
+// requested name and the name without its credential prefix.
+func (r *ModelRules) Allows(model string) bool {
+	if r.Empty() {
+		return true
+	}
+	full := strings.ToLower(strings.TrimSpace(model))
+	if full == "" {
+		return true
+	}
+	prefix, bare := "", full
+	if index := strings.Index(full, "/"); index > 0 {
+		prefix, bare = full[:index], full[index+1:]
+	}
+
+	if len(r.AllowedPrefixes) > 0 {
+		allowedPrefix := false
+		for _, candidate := range r.AllowedPrefixes {
+			if strings.EqualFold(strings.Trim(strings.TrimSpace(candidate), "/"), prefix) && prefix != "" {
+				allowedPrefix = true
+				break
+			}
+		}
+		if !allowedPrefix {
+			return false
+		}
+	}
+	for _, pattern := range r.Denied {
+		if matchModelRule(pattern, full, bare) {
+			return false
+		}
+	}
+	if len(r.Allowed) == 0 {
+		return true
+	}
+	for _, pattern := range r.Allowed {
+		if matchModelRule(pattern, full, bare) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModelRule reports whether a rule pattern matches the full or bare model name. Model
+// names are lowercased by the caller, so the pattern is lowercased to match case-insensitively.
+func matchModelRule(pattern, full, bare string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	return wildcard.Match(pattern, full) || wildcard.Match(pattern, bare)
+}
@@ -0,0 +1,46 @@
+package access
+
+import "testing"
+
+func TestModelRulesAllows(t *testing.T) {
+	rules := &ModelRules{
+		Allowed:         []string{"gpt-5*", "claude-sonnet-*"},
+		Denied:          []string{"*-preview"},
+		AllowedPrefixes: nil,
+	}
+	cases := map[string]bool{
+		"gpt-5":                     true,
+		"GPT-5-Codex":               true,
+		"claude-sonnet-4-5":         true,
+		"gpt-5-preview":             false,
+		"gemini-2.5-pro":            false,
+		"teamA/gpt-5":               true,
+		"teamA/gemini-2.5-pro":      false,
+		"claude-sonnet-4-5-preview": false,
+	}
+	for model, want := range cases {
+		if got := rules.Allows(model); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestModelRulesAllowedPrefixes(t *testing.T) {
+	rules := &ModelRules{AllowedPrefixes: []string{"teamA"}}
+	if !rules.Allows("teama/gemini-2.5-pro") {
+		t.Fatal("Allows() rejected a model with an allowed prefix")
+	}
+	if rules.Allows("gemini-2.5-pro") {
+		t.Fatal("Allows() accepted an unprefixed model")
+	}
+	if rules.Allows("teamB/gemini-2.5-pro") {
+		t.Fatal("Allows() accepted a model with another prefix")
+	}
+}
+
+func TestModelRulesNilAllowsEverything(t *testing.T) {
+	var rules *ModelRules
+	if !rules.Empty() || !rules.Allows("anything") {
+		t.Fatal("nil rules must allow every model")
+	}
+}
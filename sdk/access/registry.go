@@ -18,6 +18,8 @@ type Result struct {
 	Provider  string
 	Principal string
 	Metadata  map[string]string
+	// Models optionally restricts the models the principal may request.
+	Models *ModelRules
 }
 
 var (
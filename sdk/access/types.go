@@ -28,6 +28,12 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeJWT is the built-in provider validating bearer JWTs against a key set.
+	AccessProviderTypeJWT = "jwt"
+
+	// AccessProviderTypeMTLS is the built-in provider mapping verified client certificates to principals.
+	AccessProviderTypeMTLS = "mtls"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
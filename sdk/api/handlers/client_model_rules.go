@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	"github.com/tidwall/sjson"
+)
+
+// accessModelRulesFromContext returns the model rules attached by the access middleware
+// for the authenticated client, or nil when the client is unrestricted.
+func accessModelRulesFromContext(ctx context.Context) *sdkaccess.ModelRules {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	value, exists := ginCtx.Get("accessModelRules")
+	if !exists {
+		return nil
+	}
+	rules, _ := value.(*sdkaccess.ModelRules)
+	return rules
+}
+
+// authorizeClientModel rejects models the authenticated client key may not call. The
+// "auto" model is checked once it has been resolved to a concrete model. The error is
+// shaped for entryProtocol.
+func authorizeClientModel(ctx context.Context, entryProtocol, modelName string) *interfaces.ErrorMessage {
+	rules := accessModelRulesFromContext(ctx)
+	if rules.Empty() {
+		return nil
+	}
+	baseModel := strings.TrimSpace(thinking.ParseSuffix(modelName).ModelName)
+	if baseModel == "" || baseModel == "auto" || rules.Allows(baseModel) {
+		return nil
+	}
+	return modelNotAllowedError(entryProtocol, baseModel)
+}
+
+// authorizeResolvedClientModel checks the concrete model chosen for an "auto" request.
+func authorizeResolvedClientModel(ctx context.Context, entryProtocol, requestedModel, resolvedModel string) *interfaces.ErrorMessage {
+	if strings.TrimSpace(thinking.ParseSuffix(requestedModel).ModelName) != "auto" {
+		return nil
+	}
+	rules := accessModelRulesFromContext(ctx)
+	baseModel := strings.TrimSpace(thinking.ParseSuffix(resolvedModel).ModelName)
+	if rules.Empty() || baseModel == "" || rules.Allows(baseModel) {
+		return nil
+	}
+	return modelNotAllowedError(entryProtocol, baseModel)
+}
+
+// modelNotAllowedError builds a 403 in the error format of the entry protocol, so Claude
+// and Gemini clients can parse the rejection like an upstream error.
+func modelNotAllowedError(entryProtocol, model string) *interfaces.ErrorMessage {
+	var body string
+	switch entryProtocol {
+	case constant.Gemini, constant.GeminiInteractions, constant.Interactions:
+		body = `{"error":{"code":403,"message":"","status":"PERMISSION_DENIED"}}`
+	case constant.Claude:
+		body = `{"type":"error","error":{"type":"permission_error","message":""}}`
+	default:
+		body = `{"error":{"message":"","type":"permission_error","code":"model_not_allowed","param":"model"}}`
+	}
+	// The model name is client supplied, so it is inserted through sjson.
+	updated, errSet := sjson.Set(body, "error.message", "model "+model+" is not allowed for this API key")
+	if errSet != nil {
+		updated, _ = sjson.Set(body, "error.message", "model is not allowed for this API key")
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusForbidden,
+		Error:      errors.New(updated),
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func clientModelRulesContext(rules *sdkaccess.ModelRules) context.Context {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if rules != nil {
+		ginCtx.Set("accessModelRules", rules)
+	}
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func TestAuthorizeClientModel(t *testing.T) {
+	ctx := clientModelRulesContext(&sdkaccess.ModelRules{Allowed: []string{"gpt-5*"}})
+
+	if errMsg := authorizeClientModel(ctx, "openai", "gpt-5(high)"); errMsg != nil {
+		t.Fatalf("authorizeClientModel(gpt-5(high)) = %v, want allowed", errMsg.Error)
+	}
+	errMsg := authorizeClientModel(ctx, "openai", `claude-"x"`)
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("authorizeClientModel(claude) = %+v, want 403", errMsg)
+	}
+	body := errMsg.Error.Error()
+	if !gjson.Valid(body) || gjson.Get(body, "error.code").String() != "model_not_allowed" {
+		t.Fatalf("error body = %s, want model_not_allowed JSON", body)
+	}
+	if errMsg := authorizeClientModel(ctx, "openai", "auto"); errMsg != nil {
+		t.Fatal("authorizeClientModel(auto) must defer to the resolved model")
+	}
+	if errMsg := authorizeResolvedClientModel(ctx, "openai", "auto", "gemini-2.5-pro"); errMsg == nil {
+		t.Fatal("authorizeResolvedClientModel() allowed a resolved model outside the allowlist")
+	}
+	if errMsg := authorizeClientModel(clientModelRulesContext(nil), "openai", "anything"); errMsg != nil {
+		t.Fatal("authorizeClientModel() restricted a client without model rules")
+	}
+}
+
+func TestModelNotAllowedErrorMatchesEntryProtocol(t *testing.T) {
+	cases := []struct {
+		protocol string
+		path     string
+		want     string
+	}{
+		{protocol: "openai", path: "error.code", want: "model_not_allowed"},
+		{protocol: "openai-response", path: "error.type", want: "permission_error"},
+		{protocol: "claude", path: "type", want: "error"},
+		{protocol: "claude", path: "error.type", want: "permission_error"},
+		{protocol: "gemini", path: "error.status", want: "PERMISSION_DENIED"},
+		{protocol: "gemini", path: "error.code", want: "403"},
+	}
+	for _, tc := range cases {
+		body := modelNotAllowedError(tc.protocol, "gpt-5").Error.Error()
+		if !gjson.Valid(body) || gjson.Get(body, tc.path).String() != tc.want {
+			t.Fatalf("modelNotAllowedError(%s) = %s, want %s = %q", tc.protocol, body, tc.path, tc.want)
+		}
+		if gjson.Get(body, "error.message").String() != "model gpt-5 is not allowed for this API key" {
+			t.Fatalf("modelNotAllowedError(%s) message = %s", tc.protocol, body)
+		}
+	}
+}
+
+func TestExecuteWithAuthManagerRejectsDisallowedClientModel(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+	ctx := clientModelRulesContext(&sdkaccess.ModelRules{Denied: []string{"gpt-*"}})
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "gpt-5", []byte(`{"model":"gpt-5"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("ExecuteWithAuthManager() error = %+v, want 403", errMsg)
+	}
+	_, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "gpt-5", []byte(`{"model":"gpt-5"}`), "")
+	streamErr := <-errChan
+	if streamErr == nil || streamErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("ExecuteStreamWithAuthManager() error = %+v, want 403", streamErr)
+	}
+}
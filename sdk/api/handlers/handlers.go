@@ -16,9 +16,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	coresession "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/session"
@@ -92,6 +95,8 @@ func BuildErrorResponseBody(status int, errText string) []byte {
 		if status >= http.StatusInternalServerError {
 			errType = "server_error"
 			code = "internal_server_error"
+		} else if strings.HasPrefix(trimmed, coreauth.ErrorCodeContextLengthExceeded+":") {
+			code = coreauth.ErrorCodeContextLengthExceeded
 		}
 	}
 
@@ -176,7 +181,11 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	if requestPath != "" {
 		meta[coreexecutor.RequestPathMetadataKey] = requestPath
 	}
-	if pinnedAuthID := pinnedAuthIDFromContext(ctx); pinnedAuthID != "" {
+	pinnedAuthID := pinnedAuthIDFromContext(ctx)
+	if pinnedAuthID == "" {
+		pinnedAuthID = replay.FromContext(ctx).AuthID()
+	}
+	if pinnedAuthID != "" {
 		meta[coreexecutor.PinnedAuthMetadataKey] = pinnedAuthID
 	}
 	if selectedCallback := selectedAuthIDCallbackFromContext(ctx); selectedCallback != nil {
@@ -210,6 +219,41 @@ func requestClientIP(request *http.Request) string {
 	return remoteAddr
 }
 
+// requestClientPrincipal returns the principal the access layer authenticated for the
+// request, or "" when the request carries none.
+func requestClientPrincipal(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	value, exists := ginCtx.Get("userApiKey")
+	if !exists || value == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+// requestClientKeyName returns the name of the client-api-keys entry that authenticated
+// the request, or "" for unnamed keys and other access providers.
+func requestClientKeyName(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	value, exists := ginCtx.Get("accessMetadata")
+	if !exists {
+		return ""
+	}
+	metadata, _ := value.(map[string]string)
+	return strings.TrimSpace(metadata["key-name"])
+}
+
 func requestCallerScope(ginCtx *gin.Context) string {
 	if ginCtx == nil {
 		return ""
@@ -410,6 +454,11 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil {
+		parentCtx = tracing.ContextWithSpan(parentCtx, requestCtx)
+		parentCtx = logging.ContextWithAccessInfo(parentCtx, requestCtx)
+		parentCtx = events.ContextWithRequest(parentCtx, requestCtx)
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 
 	endpoint := ""
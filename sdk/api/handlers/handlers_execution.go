@@ -41,7 +41,27 @@ func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType
 }
 
 func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if errMsg := authorizeClientModel(ctx, entryProtocol, modelName); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	lookup, cached := h.lookupResponseCache(ctx, entryProtocol, modelExecutionResponseProtocol(entryProtocol, exitProtocol), modelName, rawJSON, alt, false, execOptions)
+	if cached != nil {
+		lookup.publishHit(ctx)
+		return cloneBytes(cached.Body), nil, nil
+	}
+	ctx = lookup.missContext(ctx)
+	body, responseHeaders, errMsg := h.executeUncachedWithAuthManagerFormats(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, allowImageModel, execOptions)
+	if errMsg == nil {
+		lookup.storeBody(ctx, body)
+	}
+	return body, responseHeaders, errMsg
+}
+
+func (h *BaseAPIHandler) executeUncachedWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	originalRequestedModel := modelName
+	if errMsg := authorizeClientModel(ctx, entryProtocol, modelName); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	routeDecision := h.applyModelRouter(ctx, entryProtocol, modelName, rawJSON, false, execOptions)
 	responseProtocol := modelExecutionResponseProtocol(entryProtocol, exitProtocol)
 	if errMsg := validateNativeInteractionsExecution(entryProtocol, execOptions, routeDecision); errMsg != nil {
@@ -54,6 +74,9 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if errMsg := authorizeResolvedClientModel(ctx, entryProtocol, originalRequestedModel, normalizedModel); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers = adjustExecutionProvidersForEntryProtocol(entryProtocol, providers)
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
@@ -89,11 +112,13 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 		lifecycle.completeError(ctx, interceptErr)
 		return nil, nil, interceptErr
 	}
+	shadowPair := h.startShadow(ctx, entryProtocol, responseProtocol, originalRequestedModel, rawJSON, alt, false, execOptions)
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
 	if err != nil {
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		errMsg := executionErrorMessage(err)
 		lifecycle.completeError(ctx, errMsg)
+		shadowPair.PrimaryDone(errMsg.StatusCode, errMsg.Error)
 		return nil, nil, errMsg
 	}
 	executedReq, executedOpts := afterAuthCapture.apply(req, opts)
@@ -101,6 +126,8 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.Cfg))
 	body, responseHeaders := h.applyResponseInterceptors(ctx, lifecycle.requestID(), responseProtocol, normalizedModel, originalRequestedModel, executedOpts, rawResponseHeaders, responseHeaders, executedOpts.OriginalRequest, executedReq.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	lifecycle.complete(pluginapi.RequestCompletionSucceeded, http.StatusOK, nil)
+	shadowPair.PrimaryChunk(body)
+	shadowPair.PrimaryDone(http.StatusOK, nil)
 	return body, responseHeaders, nil
 }
 
@@ -112,6 +139,9 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 
 func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	originalRequestedModel := modelName
+	if errMsg := authorizeClientModel(ctx, handlerType, modelName); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	routeDecision := h.applyModelRouter(ctx, handlerType, modelName, rawJSON, false, execOptions)
 	if routeDecision.ExecutorPluginID != "" {
 		return h.countWithPluginExecutor(ctx, handlerType, modelName, originalRequestedModel, rawJSON, alt, routeDecision.ExecutorPluginID, execOptions)
@@ -120,6 +150,9 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if errMsg := authorizeResolvedClientModel(ctx, handlerType, originalRequestedModel, normalizedModel); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers = adjustExecutionProvidersForEntryProtocol(handlerType, providers)
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
@@ -74,6 +75,12 @@ type requestLifecycleTracker struct {
 func (h *BaseAPIHandler) newRequestLifecycleTracker(ctx context.Context, sourceFormat, model, requestedModel string, stream bool, metadata map[string]any, skipPluginID string) *requestLifecycleTracker {
 	requestID := uuid.NewString()
 	traceID := logging.GetRequestID(ctx)
+	clientModel := requestedModel
+	if clientModel == "" {
+		clientModel = model
+	}
+	logging.SetAccessRequestedModel(ctx, clientModel)
+	events.RequestStarted(ctx, clientModel, stream)
 	return &requestLifecycleTracker{
 		ctx:          ctx,
 		host:         h.interceptorHost(),
@@ -498,6 +498,10 @@ func (h *OpenAIResponsesAPIHandler) ResponsesWebsocket(c *gin.Context) {
 				allowCompactionReplayBypass,
 			)
 		}
+		if errMsg == nil && (useUpstreamWebsocketPassthrough || !shouldHandleResponsesWebsocketPrewarmLocally(payload, lastRequest, false)) {
+			// Client limits apply to every turn, not just the upgrade.
+			errMsg = handlers.AdmitSessionTurn(c)
+		}
 		if errMsg != nil {
 			h.LoggingAPIResponseError(context.WithValue(context.Background(), "gin", c), errMsg)
 			markAPIResponseTimestamp(c)
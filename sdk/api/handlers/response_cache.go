@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+// ResponseCacheHeader reports whether a cacheable response was served from the cache. On
+// a request, the value ResponseCacheOptIn makes a sampled request cacheable.
+const ResponseCacheHeader = "X-CPA-Response-Cache"
+
+// ResponseCacheOptIn is the ResponseCacheHeader request value that opts one request into
+// the cache even though it samples or declares tools.
+const ResponseCacheOptIn = "allow"
+
+// responseCacheExecutorType labels usage records of requests answered from the cache.
+const responseCacheExecutorType = "response-cache"
+
+// responseCacheLookup carries a cacheable request from lookup to store. A nil lookup means
+// the request is not cacheable; its methods are nil-safe.
+type responseCacheLookup struct {
+	settings config.ResponseCacheConfig
+	key      string
+	ttl      time.Duration
+	model    string
+}
+
+// lookupResponseCache resolves whether the request may use the response cache and returns
+// the cached entry on a hit. Only deterministic requests are cacheable unless the config or
+// the request opts in; "Cache-Control: no-cache" or "no-store" bypasses the cache entirely.
+func (h *BaseAPIHandler) lookupResponseCache(ctx context.Context, entryProtocol, responseProtocol, model string, rawJSON []byte, alt string, stream bool, execOptions modelExecutionOptions) (*responseCacheLookup, *responsecache.Entry) {
+	if ctx == nil || h == nil || h.Cfg == nil || execOptions.InternalSource || execOptions.ForcedProvider != "" {
+		return nil, nil
+	}
+	settings := h.Cfg.ResponseCache
+	ttlSeconds, ok := settings.TTLFor(model)
+	if !ok {
+		return nil, nil
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if requestBypassesCache(ginCtx) {
+		return nil, nil
+	}
+	if !settings.CacheSampled && !requestOptsIntoCache(ginCtx) && !responseCacheDeterministic(rawJSON) {
+		return nil, nil
+	}
+	key, ok := responsecache.Key(responsecache.Request{
+		ClientKey:        requestClientPrincipal(ctx),
+		EntryProtocol:    entryProtocol,
+		ResponseProtocol: responseProtocol,
+		Model:            model,
+		Alt:              alt,
+		Stream:           stream,
+		Body:             rawJSON,
+	})
+	if !ok {
+		return nil, nil
+	}
+	lookup := &responseCacheLookup{
+		settings: settings,
+		key:      key,
+		ttl:      time.Duration(ttlSeconds) * time.Second,
+		model:    model,
+	}
+	if entry, hit := responsecache.Default().Get(ctx, settings, key); hit && entry.Stream == stream {
+		setResponseCacheHeader(ginCtx, coreusage.ResponseCacheHit)
+		return lookup, entry
+	}
+	setResponseCacheHeader(ginCtx, coreusage.ResponseCacheMiss)
+	return lookup, nil
+}
+
+// missContext tags usage records of the upstream request as cache misses.
+func (l *responseCacheLookup) missContext(ctx context.Context) context.Context {
+	if l == nil {
+		return ctx
+	}
+	return coreusage.WithResponseCache(ctx, coreusage.ResponseCacheMiss)
+}
+
+// storeBody caches a successful non-streaming response.
+func (l *responseCacheLookup) storeBody(ctx context.Context, body []byte) {
+	if l == nil || len(body) == 0 {
+		return
+	}
+	responsecache.Default().Set(ctx, l.settings, l.key, &responsecache.Entry{Model: l.model, Body: cloneBytes(body)}, l.ttl)
+}
+
+// storeChunks caches a streaming response that completed without error.
+func (l *responseCacheLookup) storeChunks(ctx context.Context, chunks [][]byte) {
+	if l == nil || len(chunks) == 0 {
+		return
+	}
+	responsecache.Default().Set(ctx, l.settings, l.key, &responsecache.Entry{Model: l.model, Stream: true, Chunks: chunks}, l.ttl)
+}
+
+// publishHit records a cache hit as a usage record with no upstream tokens.
+func (l *responseCacheLookup) publishHit(ctx context.Context) {
+	if l == nil {
+		return
+	}
+	coreusage.PublishRecord(ctx, coreusage.Record{
+		Provider:      responseCacheExecutorType,
+		ExecutorType:  responseCacheExecutorType,
+		Model:         l.model,
+		Alias:         l.model,
+		APIKey:        requestClientPrincipal(ctx),
+		Generate:      coreusage.GenerateFlag(true),
+		RequestedAt:   time.Now(),
+		ResponseCache: coreusage.ResponseCacheHit,
+	})
+}
+
+// replayStream serves a cached streaming response through the regular stream channels.
+func replayStream(ctx context.Context, entry *responsecache.Entry) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage)
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		for _, chunk := range entry.Chunks {
+			select {
+			case dataChan <- cloneBytes(chunk):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return dataChan, errChan
+}
+
+// teeStream forwards a live stream unchanged and caches its chunks once it completes
+// without an error.
+func (l *responseCacheLookup) teeStream(ctx context.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	if l == nil || data == nil {
+		return data, errs
+	}
+	maxBytes := l.settings.MaxEntryBytes
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultResponseCacheMaxEntryBytes
+	}
+	dataOut := make(chan []byte)
+	errOut := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(dataOut)
+		defer close(errOut)
+		var chunks [][]byte
+		size := 0
+		cacheable := true
+		for data != nil {
+			select {
+			case chunk, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				if cacheable {
+					size += len(chunk)
+					if size > maxBytes {
+						cacheable = false
+						chunks = nil
+					} else {
+						chunks = append(chunks, cloneBytes(chunk))
+					}
+				}
+				select {
+				case dataOut <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			case errMsg, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if errMsg != nil {
+					cacheable = false
+				}
+				select {
+				case errOut <- errMsg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		// The producer closes errs before data, so any pending error is already buffered.
+		if errs != nil {
+			for errMsg := range errs {
+				if errMsg != nil {
+					cacheable = false
+					select {
+					case errOut <- errMsg:
+					default:
+					}
+					break
+				}
+			}
+		}
+		if cacheable && ctx.Err() == nil {
+			l.storeChunks(context.WithoutCancel(ctx), chunks)
+		}
+	}()
+	return dataOut, errOut
+}
+
+// responseCacheDeterministic reports whether rawJSON asks for a reproducible answer: greedy
+// decoding (temperature 0 or top-k 1, so top-p has no effect), a single choice, and no
+// tools, whose calls could repeat side effects when replayed. A missing temperature means
+// the upstream default, which samples. OpenAI, Claude and Gemini field names are checked.
+func responseCacheDeterministic(rawJSON []byte) bool {
+	root := gjson.ParseBytes(rawJSON)
+	generation := root.Get("generationConfig")
+	greedy := false
+	for _, temperature := range []gjson.Result{root.Get("temperature"), generation.Get("temperature")} {
+		if temperature.Exists() && temperature.Float() == 0 {
+			greedy = true
+		}
+	}
+	for _, topK := range []gjson.Result{root.Get("top_k"), generation.Get("topK")} {
+		if topK.Exists() && topK.Int() == 1 {
+			greedy = true
+		}
+	}
+	if !greedy {
+		return false
+	}
+	for _, choices := range []gjson.Result{root.Get("n"), generation.Get("candidateCount")} {
+		if choices.Int() > 1 {
+			return false
+		}
+	}
+	for _, tools := range []gjson.Result{root.Get("tools"), root.Get("functions")} {
+		if len(tools.Array()) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// requestBypassesCache reports whether the client asked not to use the cache for this
+// request: neither a stored answer is served nor the fresh one stored.
+func requestBypassesCache(ginCtx *gin.Context) bool {
+	if ginCtx == nil || ginCtx.Request == nil {
+		return false
+	}
+	for _, value := range ginCtx.Request.Header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-cache", "no-store":
+				return true
+			}
+		}
+	}
+	return strings.EqualFold(strings.TrimSpace(ginCtx.Request.Header.Get("Pragma")), "no-cache")
+}
+
+func requestOptsIntoCache(ginCtx *gin.Context) bool {
+	if ginCtx == nil || ginCtx.Request == nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(ginCtx.Request.Header.Get(ResponseCacheHeader)), ResponseCacheOptIn)
+}
+
+func setResponseCacheHeader(ginCtx *gin.Context, outcome string) {
+	if ginCtx == nil || ginCtx.Writer == nil || ginCtx.Writer.Written() {
+		return
+	}
+	ginCtx.Header(ResponseCacheHeader, outcome)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func responseCacheTestContext(clientKey string, headers http.Header) context.Context {
+	ctx := contextWithHeaders(headers)
+	ctx.Value("gin").(*gin.Context).Set("userApiKey", clientKey)
+	return ctx
+}
+
+func responseCacheTestHeader(ctx context.Context) string {
+	return ctx.Value("gin").(*gin.Context).Writer.Header().Get(ResponseCacheHeader)
+}
+
+func newResponseCacheTestHandler(t *testing.T, model string, calls *atomic.Int32) *BaseAPIHandler {
+	t.Helper()
+	t.Cleanup(responsecache.Default().Close)
+	executor := &interceptorCaptureExecutor{
+		execute: func(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+			calls.Add(1)
+			return coreexecutor.Response{Payload: []byte(`{"answer":"ok"}`)}, nil
+		},
+		stream: func(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+			calls.Add(1)
+			chunks := make(chan coreexecutor.StreamChunk, 2)
+			chunks <- coreexecutor.StreamChunk{Payload: []byte("data: one\n\n")}
+			chunks <- coreexecutor.StreamChunk{Payload: []byte("data: two\n\n")}
+			close(chunks)
+			return &coreexecutor.StreamResult{Chunks: chunks}, nil
+		},
+	}
+	cfg := &sdkconfig.SDKConfig{ResponseCache: config.ResponseCacheConfig{Enable: true, Models: []config.ResponseCacheModel{{Name: model}}}}
+	return newInterceptorHandler(t, model, executor, cfg)
+}
+
+func TestExecuteWithAuthManagerServesRepeatedRequestsFromResponseCache(t *testing.T) {
+	model := "response-cache-execute"
+	var calls atomic.Int32
+	handler := newResponseCacheTestHandler(t, model, &calls)
+
+	first := responseCacheTestContext("client-a", nil)
+	body, _, errMsg := handler.ExecuteWithAuthManager(first, "openai", model, []byte(`{"model":"`+model+`","temperature":0}`), "")
+	if errMsg != nil || string(body) != `{"answer":"ok"}` {
+		t.Fatalf("first response = %q, %v", body, errMsg)
+	}
+	if got := responseCacheTestHeader(first); got != "miss" {
+		t.Fatalf("first %s = %q, want miss", ResponseCacheHeader, got)
+	}
+
+	second := responseCacheTestContext("client-a", nil)
+	body, _, errMsg = handler.ExecuteWithAuthManager(second, "openai", model, []byte(`{"temperature":0, "model":"`+model+`"}`), "")
+	if errMsg != nil || string(body) != `{"answer":"ok"}` {
+		t.Fatalf("cached response = %q, %v", body, errMsg)
+	}
+	if got := responseCacheTestHeader(second); got != "hit" {
+		t.Fatalf("second %s = %q, want hit", ResponseCacheHeader, got)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("executor calls = %d, want 1", calls.Load())
+	}
+
+	if _, _, errMsg = handler.ExecuteWithAuthManager(responseCacheTestContext("client-b", nil), "openai", model, []byte(`{"model":"`+model+`","temperature":0}`), ""); errMsg != nil {
+		t.Fatalf("other client: %v", errMsg)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("executor calls after other client = %d, want 2", calls.Load())
+	}
+
+	noCache := responseCacheTestContext("client-c", http.Header{"Cache-Control": {"no-cache"}})
+	if _, _, errMsg = handler.ExecuteWithAuthManager(noCache, "openai", model, []byte(`{"model":"`+model+`","temperature":0}`), ""); errMsg != nil {
+		t.Fatalf("no-cache request: %v", errMsg)
+	}
+	if calls.Load() != 3 || responseCacheTestHeader(noCache) != "" {
+		t.Fatalf("no-cache calls = %d, header = %q", calls.Load(), responseCacheTestHeader(noCache))
+	}
+	// The bypassed answer was not stored, so the next request still goes upstream.
+	afterNoCache := responseCacheTestContext("client-c", nil)
+	if _, _, errMsg = handler.ExecuteWithAuthManager(afterNoCache, "openai", model, []byte(`{"model":"`+model+`","temperature":0}`), ""); errMsg != nil {
+		t.Fatalf("request after no-cache: %v", errMsg)
+	}
+	if calls.Load() != 4 || responseCacheTestHeader(afterNoCache) != "miss" {
+		t.Fatalf("calls after no-cache = %d, header = %q; want a miss", calls.Load(), responseCacheTestHeader(afterNoCache))
+	}
+}
+
+func TestExecuteWithAuthManagerCachesOnlyDeterministicRequests(t *testing.T) {
+	model := "response-cache-eligibility"
+	var calls atomic.Int32
+	handler := newResponseCacheTestHandler(t, model, &calls)
+
+	send := func(body string, headers http.Header) string {
+		ctx := responseCacheTestContext("client-a", headers)
+		if _, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", model, []byte(body), ""); errMsg != nil {
+			t.Fatalf("request %s: %v", body, errMsg)
+		}
+		return responseCacheTestHeader(ctx)
+	}
+	for _, body := range []string{
+		`{"model":"` + model + `"}`,
+		`{"model":"` + model + `","temperature":0.7}`,
+		`{"model":"` + model + `","temperature":0,"n":2}`,
+		`{"model":"` + model + `","temperature":0,"tools":[{"type":"function","function":{"name":"send_email"}}]}`,
+	} {
+		if got := send(body, nil); got != "" {
+			t.Fatalf("%s %s = %q, want not cacheable", body, ResponseCacheHeader, got)
+		}
+	}
+
+	optIn := http.Header{ResponseCacheHeader: {ResponseCacheOptIn}}
+	sampled := `{"model":"` + model + `","temperature":0.7}`
+	if got := send(sampled, optIn); got != "miss" {
+		t.Fatalf("opted-in first %s = %q, want miss", ResponseCacheHeader, got)
+	}
+	if got := send(sampled, optIn); got != "hit" {
+		t.Fatalf("opted-in second %s = %q, want hit", ResponseCacheHeader, got)
+	}
+	if got := send(`{"model":"`+model+`","generationConfig":{"topK":1}}`, nil); got != "miss" {
+		t.Fatalf("greedy Gemini request %s = %q, want miss", ResponseCacheHeader, got)
+	}
+}
+
+func TestExecuteStreamWithAuthManagerReplaysCachedChunks(t *testing.T) {
+	model := "response-cache-stream"
+	var calls atomic.Int32
+	handler := newResponseCacheTestHandler(t, model, &calls)
+	rawJSON := []byte(`{"model":"` + model + `","temperature":0,"stream":true}`)
+
+	collect := func() []string {
+		dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(responseCacheTestContext("client-a", nil), "openai", model, rawJSON, "")
+		var chunks []string
+		for chunk := range dataChan {
+			chunks = append(chunks, string(chunk))
+		}
+		for errMsg := range errChan {
+			if errMsg != nil {
+				t.Fatalf("stream error: %v", errMsg.Error)
+			}
+		}
+		return chunks
+	}
+
+	live := collect()
+	replayed := collect()
+	if len(live) == 0 || len(replayed) != len(live) {
+		t.Fatalf("live chunks = %q, replayed = %q", live, replayed)
+	}
+	for i := range live {
+		if live[i] != replayed[i] {
+			t.Fatalf("replayed chunk %d = %q, want %q", i, replayed[i], live[i])
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("executor calls = %d, want 1", calls.Load())
+	}
+}
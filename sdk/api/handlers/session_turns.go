@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+)
+
+// sessionTurnGatesKey stores the SessionTurnGate list of a long-lived client session.
+const sessionTurnGatesKey = "clientSessionTurnGates"
+
+// SessionTurnGate admits one turn of a long-lived client session, such as a
+// response.create on a websocket. It returns a non-nil error when the turn must not start.
+type SessionTurnGate func(ctx context.Context) *interfaces.ErrorMessage
+
+// AddSessionTurnGate registers gate for the session served by c. Middlewares that admit
+// a websocket upgrade use it so each turn on the socket is checked again.
+func AddSessionTurnGate(c *gin.Context, gate SessionTurnGate) {
+	if c == nil || gate == nil {
+		return
+	}
+	var gates []SessionTurnGate
+	if existing, ok := c.Get(sessionTurnGatesKey); ok {
+		gates, _ = existing.([]SessionTurnGate)
+	}
+	c.Set(sessionTurnGatesKey, append(gates, gate))
+}
+
+// AdmitSessionTurn runs the gates registered for c in order and returns the first rejection.
+func AdmitSessionTurn(c *gin.Context) *interfaces.ErrorMessage {
+	if c == nil {
+		return nil
+	}
+	existing, ok := c.Get(sessionTurnGatesKey)
+	if !ok {
+		return nil
+	}
+	gates, _ := existing.([]SessionTurnGate)
+	ctx := context.Background()
+	if c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	for _, gate := range gates {
+		if errMsg := gate(ctx); errMsg != nil {
+			return errMsg
+		}
+	}
+	return nil
+}
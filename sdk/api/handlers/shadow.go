@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/shadow"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// shadowRequest is a detached copy of the request state needed to mirror a request.
+// It must not reference the gin context, which is recycled once the primary returns.
+type shadowRequest struct {
+	entryProtocol    string
+	responseProtocol string
+	model            string
+	payload          []byte
+	alt              string
+	stream           bool
+	headers          http.Header
+	query            url.Values
+}
+
+// startShadow samples the request against the configured shadow rules and, when
+// selected, mirrors it to the shadow model in the background. The returned pair is
+// nil when the request is not mirrored; its methods are nil-safe.
+func (h *BaseAPIHandler) startShadow(ctx context.Context, entryProtocol, responseProtocol, requestedModel string, rawJSON []byte, alt string, stream bool, execOptions modelExecutionOptions) *shadow.Pair {
+	if h == nil || h.Cfg == nil || h.AuthManager == nil || execOptions.InternalSource || execOptions.ForcedProvider != "" {
+		return nil
+	}
+	cfg := h.Cfg.Shadow
+	if !cfg.Enabled() || len(rawJSON) == 0 {
+		return nil
+	}
+	rule, ok := shadow.Select(cfg, requestedModel, requestClientPrincipal(ctx), requestClientKeyName(ctx))
+	if !ok {
+		return nil
+	}
+	release, ok := shadow.Acquire(cfg.MaxConcurrency)
+	if !ok {
+		log.Debugf("shadow: skipped mirror of %s to %s, concurrency limit reached", requestedModel, rule.ShadowModel)
+		return nil
+	}
+	request := shadowRequest{
+		entryProtocol:    entryProtocol,
+		responseProtocol: responseProtocol,
+		model:            rule.ShadowModel,
+		payload:          shadowPayload(rawJSON, rule.ShadowModel),
+		alt:              alt,
+		stream:           stream,
+		headers:          modelExecutionHeaders(ctx, execOptions.Headers),
+		query:            modelExecutionQuery(ctx, execOptions.Query),
+	}
+	pair := shadow.NewPair(cfg, rule, logging.GetRequestID(ctx), entryProtocol, requestedModel, stream)
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = time.Duration(config.DefaultShadowTimeoutSeconds) * time.Second
+	}
+	go func() {
+		defer release()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Errorf("shadow: mirror to %s panicked: %v", request.model, recovered)
+				pair.ShadowDone(http.StatusInternalServerError, fmt.Errorf("shadow execution panicked"))
+			}
+		}()
+		shadowCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		status, errExecute := h.executeShadow(shadowCtx, request, pair)
+		pair.ShadowDone(status, errExecute)
+	}()
+	return pair
+}
+
+// executeShadow runs the mirrored request through the auth manager without plugin
+// interceptors or model routers and streams the response body into pair.
+func (h *BaseAPIHandler) executeShadow(ctx context.Context, request shadowRequest, pair *shadow.Pair) (int, error) {
+	providers, normalizedModel, errMsg := h.getRequestDetailsWithOptions(request.model, false)
+	if errMsg != nil {
+		return errMsg.StatusCode, errMsg.Error
+	}
+	providers = adjustExecutionProvidersForEntryProtocol(request.entryProtocol, providers)
+	meta := map[string]any{
+		coreexecutor.RequestedModelMetadataKey: request.model,
+		modelExecutionMetadataSourceKey:        "shadow",
+	}
+	setReasoningEffortMetadata(meta, request.entryProtocol, normalizedModel, request.payload)
+	setServiceTierMetadata(meta, request.payload)
+	setGenerateMetadata(meta, request.payload)
+	req := coreexecutor.Request{Model: normalizedModel, Payload: request.payload}
+	opts := coreexecutor.Options{
+		Stream:          request.stream,
+		Alt:             request.alt,
+		OriginalRequest: request.payload,
+		SourceFormat:    sdktranslator.FromString(request.entryProtocol),
+		ResponseFormat:  sdktranslator.FromString(request.responseProtocol),
+		Headers:         request.headers,
+		Query:           request.query,
+		Metadata:        meta,
+	}
+	if !request.stream {
+		resp, errExecute := h.AuthManager.Execute(ctx, providers, req, opts)
+		if errExecute != nil {
+			return statusFromError(errExecute), errExecute
+		}
+		pair.ShadowChunk(resp.Payload)
+		return http.StatusOK, nil
+	}
+	result, errExecute := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	if errExecute != nil {
+		return statusFromError(errExecute), errExecute
+	}
+	if result == nil || result.Chunks == nil {
+		return http.StatusOK, nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			go drainShadowStream(result.Chunks)
+			return http.StatusGatewayTimeout, ctx.Err()
+		case chunk, ok := <-result.Chunks:
+			if !ok {
+				return http.StatusOK, nil
+			}
+			if chunk.Err != nil {
+				go drainShadowStream(result.Chunks)
+				return statusFromError(chunk.Err), chunk.Err
+			}
+			pair.ShadowChunk(chunk.Payload)
+		}
+	}
+}
+
+func drainShadowStream(chunks <-chan coreexecutor.StreamChunk) {
+	for range chunks {
+	}
+}
+
+// shadowPayload rewrites the top-level model field, when present, to the shadow model.
+func shadowPayload(rawJSON []byte, model string) []byte {
+	payload := cloneBytes(rawJSON)
+	if !gjson.GetBytes(payload, "model").Exists() {
+		return payload
+	}
+	updated, errSet := sjson.SetBytes(payload, "model", model)
+	if errSet != nil {
+		return payload
+	}
+	return updated
+}
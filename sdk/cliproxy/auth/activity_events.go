@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
+
+// coolingUntil returns when auth becomes usable again for model, or the zero time when it
+// is not cooling down.
+func coolingUntil(auth *Auth, model string, now time.Time) time.Time {
+	if auth == nil {
+		return time.Time{}
+	}
+	var until time.Time
+	if auth.Unavailable && auth.NextRetryAfter.After(now) {
+		until = auth.NextRetryAfter
+	}
+	if model != "" {
+		if state := auth.ModelStates[model]; state != nil && state.Unavailable && state.NextRetryAfter.After(until) && state.NextRetryAfter.After(now) {
+			until = state.NextRetryAfter
+		}
+	}
+	return until
+}
+
+// publishCooldownTransition announces a credential entering or leaving cooldown for model.
+func publishCooldownTransition(ctx context.Context, auth *Auth, model string, before, after time.Time) {
+	if auth == nil || before.IsZero() == after.IsZero() {
+		return
+	}
+	event := events.Event{
+		Type:      events.TypeCooldownCleared,
+		Provider:  strings.TrimSpace(auth.Provider),
+		AuthID:    auth.ID,
+		AuthIndex: auth.Index,
+		Model:     model,
+	}
+	if !after.IsZero() {
+		until := after.UTC()
+		event.Type = events.TypeCooldownEntered
+		event.Until = &until
+		event.Message = strings.TrimSpace(auth.StatusMessage)
+		event.Status = cooldownStatus(auth, model)
+	}
+	events.Publish(ctx, event)
+}
+
+// cooldownStatus returns the HTTP status of the failure that put auth into cooldown.
+func cooldownStatus(auth *Auth, model string) int {
+	if state := auth.ModelStates[model]; model != "" && state != nil && state.LastError != nil {
+		return state.LastError.StatusCode()
+	}
+	if auth.LastError != nil {
+		return auth.LastError.StatusCode()
+	}
+	return 0
+}
+
+// publishModelExhausted announces that every enabled credential serving model is cooling
+// down, so requests for it cannot be served until the earliest one recovers.
+func (m *Manager) publishModelExhausted(ctx context.Context, model string) {
+	if m == nil || model == "" || !events.Default().Active() {
+		return
+	}
+	now := time.Now()
+	var earliest time.Time
+	var candidates []string
+	m.mu.RLock()
+	for id, auth := range m.auths {
+		if auth == nil || auth.Disabled || auth.Status == StatusDisabled {
+			continue
+		}
+		until := coolingUntil(auth, model, now)
+		if _, tracked := auth.ModelStates[model]; !tracked {
+			if until.IsZero() {
+				candidates = append(candidates, id)
+			}
+			continue
+		}
+		if until.IsZero() {
+			m.mu.RUnlock()
+			return
+		}
+		if earliest.IsZero() || until.Before(earliest) {
+			earliest = until
+		}
+	}
+	m.mu.RUnlock()
+	if earliest.IsZero() {
+		return
+	}
+	reg := registry.GetGlobalRegistry()
+	for _, id := range candidates {
+		if reg.ClientSupportsModel(id, model) {
+			return
+		}
+	}
+	until := earliest.UTC()
+	events.Publish(ctx, events.Event{
+		Type:    events.TypeModelExhausted,
+		Model:   model,
+		Until:   &until,
+		Message: "all credentials for " + model + " are cooling down",
+	})
+}
+
+// publishRefreshResult announces the outcome of a credential refresh.
+func publishRefreshResult(ctx context.Context, auth *Auth, err error) {
+	if auth == nil {
+		return
+	}
+	success := err == nil
+	event := events.Event{
+		Type:      events.TypeRefreshResult,
+		Provider:  strings.TrimSpace(auth.Provider),
+		AuthID:    auth.ID,
+		AuthIndex: auth.Index,
+		Success:   &success,
+	}
+	if err != nil {
+		event.Message = err.Error()
+	}
+	events.Publish(ctx, event)
+}
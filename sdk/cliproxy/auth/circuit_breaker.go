@@ -0,0 +1,433 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
+	log "github.com/sirupsen/logrus"
+)
+
+// CircuitState is the state of an upstream endpoint circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed admits all requests and tracks the rolling error rate.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen skips every credential on the endpoint until the open period ends.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen admits a limited number of probe requests.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerSnapshot describes one endpoint circuit breaker.
+type CircuitBreakerSnapshot struct {
+	Endpoint       string       `json:"endpoint"`
+	State          CircuitState `json:"state"`
+	Requests       int          `json:"requests"`
+	Failures       int          `json:"failures"`
+	ErrorRate      float64      `json:"error_rate"`
+	ProbesInFlight int          `json:"probes_in_flight,omitempty"`
+	LastError      string       `json:"last_error,omitempty"`
+	OpenedAt       *time.Time   `json:"opened_at,omitempty"`
+	NextProbeAt    *time.Time   `json:"next_probe_at,omitempty"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+type circuitOutcome int
+
+const (
+	circuitOutcomeNeutral circuitOutcome = iota
+	circuitOutcomeSuccess
+	circuitOutcomeFailure
+)
+
+type circuitTransition struct {
+	previous CircuitState
+	snapshot CircuitBreakerSnapshot
+}
+
+// endpointBreaker is guarded by its own mutex so selection checks on different endpoints
+// never contend.
+type endpointBreaker struct {
+	mu             sync.Mutex
+	state          CircuitState
+	windowStart    time.Time
+	requests       int
+	failures       int
+	openedAt       time.Time
+	probesInFlight int
+	probeStartedAt time.Time
+	probeSuccesses int
+	lastError      string
+	updatedAt      time.Time
+}
+
+// circuitBreakerRegistry maps endpoints to breakers. Its lock only guards the config and
+// the map; breaker state is updated under each breaker's own lock.
+type circuitBreakerRegistry struct {
+	mu       sync.RWMutex
+	cfg      internalconfig.CircuitBreakerConfig
+	breakers map[string]*endpointBreaker
+	now      func() time.Time
+}
+
+var circuitBreakers = newCircuitBreakerRegistry()
+
+func newCircuitBreakerRegistry() *circuitBreakerRegistry {
+	return &circuitBreakerRegistry{breakers: make(map[string]*endpointBreaker), now: time.Now}
+}
+
+// SetCircuitBreakerConfig updates endpoint circuit breaker settings globally.
+// Disabling breakers or changing how endpoints are keyed discards existing state.
+func SetCircuitBreakerConfig(cfg internalconfig.CircuitBreakerConfig) {
+	circuitBreakers.setConfig(cfg)
+}
+
+// CircuitBreakerSnapshots returns the tracked endpoint breakers sorted by endpoint.
+func CircuitBreakerSnapshots() []CircuitBreakerSnapshot {
+	return circuitBreakers.snapshots()
+}
+
+// ResetCircuitBreaker closes and clears the breaker for endpoint. It reports whether one existed.
+func ResetCircuitBreaker(endpoint string) bool {
+	return circuitBreakers.reset(endpoint)
+}
+
+func (r *circuitBreakerRegistry) setConfig(cfg internalconfig.CircuitBreakerConfig) {
+	cfg = cfg.WithDefaults()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !cfg.Enable || cfg.KeyBy != r.cfg.KeyBy {
+		r.breakers = make(map[string]*endpointBreaker)
+	}
+	r.cfg = cfg
+}
+
+func (r *circuitBreakerRegistry) enabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cfg.Enable
+}
+
+// breakerFor returns the config, endpoint and breaker for auth. A missing breaker is
+// created only when create is set. breaker is nil when breakers are disabled.
+func (r *circuitBreakerRegistry) breakerFor(auth *Auth, create bool) (internalconfig.CircuitBreakerConfig, string, *endpointBreaker) {
+	r.mu.RLock()
+	cfg := r.cfg
+	if !cfg.Enable {
+		r.mu.RUnlock()
+		return cfg, "", nil
+	}
+	endpoint := circuitBreakerEndpoint(auth, cfg.KeyBy)
+	breaker := r.breakers[endpoint]
+	r.mu.RUnlock()
+	if breaker != nil || !create || endpoint == "" {
+		return cfg, endpoint, breaker
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.cfg.Enable || r.cfg.KeyBy != cfg.KeyBy {
+		return r.cfg, endpoint, nil
+	}
+	breaker = r.breakers[endpoint]
+	if breaker == nil {
+		breaker = &endpointBreaker{state: CircuitClosed, windowStart: r.now()}
+		r.breakers[endpoint] = breaker
+	}
+	return r.cfg, endpoint, breaker
+}
+
+// circuitBreakerEndpoint returns the breaker key for auth, or "" when the auth has no base URL.
+func circuitBreakerEndpoint(auth *Auth, keyBy string) string {
+	if auth == nil || auth.Attributes == nil {
+		return ""
+	}
+	raw := strings.TrimSpace(auth.Attributes["base_url"])
+	if raw == "" {
+		return ""
+	}
+	parsed, errParse := url.Parse(raw)
+	if errParse != nil || parsed.Host == "" {
+		return strings.ToLower(strings.TrimRight(raw, "/"))
+	}
+	endpoint := strings.ToLower(parsed.Scheme) + "://" + strings.ToLower(parsed.Host)
+	if keyBy == internalconfig.CircuitBreakerKeyByBaseURL {
+		endpoint += strings.TrimRight(parsed.EscapedPath(), "/")
+	}
+	return endpoint
+}
+
+// allow reports whether auth may be selected. Open breakers reject every auth on the
+// endpoint; half-open breakers admit auths while probe slots remain. Selection still has
+// to win a slot from reserve before sending.
+func (r *circuitBreakerRegistry) allow(auth *Auth) bool {
+	cfg, _, breaker := r.breakerFor(auth, false)
+	if breaker == nil {
+		return true
+	}
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	breaker.advanceLocked(cfg, r.now())
+	switch breaker.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		return breaker.probesInFlight < cfg.HalfOpenProbes
+	default:
+		return true
+	}
+}
+
+// reserve checks the breaker for a selected auth and, when it is half-open, takes a probe
+// slot in the same step. It reports false when the breaker is open or every probe slot is
+// taken, in which case the auth must not be sent.
+func (r *circuitBreakerRegistry) reserve(auth *Auth) bool {
+	cfg, _, breaker := r.breakerFor(auth, false)
+	if breaker == nil {
+		return true
+	}
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	now := r.now()
+	breaker.advanceLocked(cfg, now)
+	switch breaker.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if breaker.probesInFlight >= cfg.HalfOpenProbes {
+			return false
+		}
+		breaker.probesInFlight++
+		breaker.probeStartedAt = now
+		return true
+	default:
+		return true
+	}
+}
+
+// observe records an outcome and returns the state transition it caused, if any.
+func (r *circuitBreakerRegistry) observe(auth *Auth, outcome circuitOutcome, message string) (circuitTransition, bool) {
+	cfg, endpoint, breaker := r.breakerFor(auth, outcome != circuitOutcomeNeutral)
+	if breaker == nil {
+		return circuitTransition{}, false
+	}
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	now := r.now()
+	breaker.advanceLocked(cfg, now)
+	previous := breaker.state
+	if outcome == circuitOutcomeFailure && message != "" {
+		breaker.lastError = message
+	}
+	switch breaker.state {
+	case CircuitClosed:
+		if outcome == circuitOutcomeNeutral {
+			return circuitTransition{}, false
+		}
+		breaker.requests++
+		if outcome == circuitOutcomeFailure {
+			breaker.failures++
+		}
+		breaker.updatedAt = now
+		if breaker.requests >= cfg.MinRequests && float64(breaker.failures)/float64(breaker.requests) >= cfg.ErrorRateThreshold {
+			breaker.openLocked(now)
+		}
+	case CircuitHalfOpen:
+		if breaker.probesInFlight > 0 {
+			breaker.probesInFlight--
+		}
+		breaker.updatedAt = now
+		switch outcome {
+		case circuitOutcomeFailure:
+			breaker.openLocked(now)
+		case circuitOutcomeSuccess:
+			breaker.probeSuccesses++
+			if breaker.probeSuccesses >= cfg.HalfOpenProbes {
+				breaker.state = CircuitClosed
+				breaker.windowStart = now
+				breaker.requests = 0
+				breaker.failures = 0
+				breaker.probeSuccesses = 0
+				breaker.probesInFlight = 0
+				breaker.openedAt = time.Time{}
+			}
+		}
+	case CircuitOpen:
+		// Results of requests started before the breaker tripped carry no new signal.
+		return circuitTransition{}, false
+	}
+	if breaker.state == previous {
+		return circuitTransition{}, false
+	}
+	return circuitTransition{previous: previous, snapshot: breaker.snapshotLocked(cfg, endpoint)}, true
+}
+
+// advanceLocked rolls the closed window and moves expired open breakers to half-open.
+func (b *endpointBreaker) advanceLocked(cfg internalconfig.CircuitBreakerConfig, now time.Time) {
+	openFor := time.Duration(cfg.OpenSeconds) * time.Second
+	switch b.state {
+	case CircuitClosed:
+		if now.Sub(b.windowStart) >= time.Duration(cfg.WindowSeconds)*time.Second {
+			b.windowStart = now
+			b.requests = 0
+			b.failures = 0
+		}
+	case CircuitOpen:
+		if now.Sub(b.openedAt) >= openFor {
+			b.state = CircuitHalfOpen
+			b.probesInFlight = 0
+			b.probeSuccesses = 0
+			b.updatedAt = now
+		}
+	case CircuitHalfOpen:
+		// Probes abandoned without a result (for example, client cancellation) must not
+		// pin the breaker half-open forever.
+		if b.probesInFlight > 0 && now.Sub(b.probeStartedAt) >= openFor {
+			b.probesInFlight = 0
+		}
+	}
+}
+
+func (b *endpointBreaker) openLocked(now time.Time) {
+	b.state = CircuitOpen
+	b.openedAt = now
+	b.probesInFlight = 0
+	b.probeSuccesses = 0
+	b.updatedAt = now
+}
+
+func (b *endpointBreaker) snapshotLocked(cfg internalconfig.CircuitBreakerConfig, endpoint string) CircuitBreakerSnapshot {
+	snapshot := CircuitBreakerSnapshot{
+		Endpoint:       endpoint,
+		State:          b.state,
+		Requests:       b.requests,
+		Failures:       b.failures,
+		ProbesInFlight: b.probesInFlight,
+		LastError:      b.lastError,
+		UpdatedAt:      b.updatedAt,
+	}
+	if b.requests > 0 {
+		snapshot.ErrorRate = float64(b.failures) / float64(b.requests)
+	}
+	if !b.openedAt.IsZero() {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+		if b.state == CircuitOpen {
+			nextProbeAt := openedAt.Add(time.Duration(cfg.OpenSeconds) * time.Second)
+			snapshot.NextProbeAt = &nextProbeAt
+		}
+	}
+	return snapshot
+}
+
+func (r *circuitBreakerRegistry) snapshots() []CircuitBreakerSnapshot {
+	r.mu.RLock()
+	cfg := r.cfg
+	breakers := make(map[string]*endpointBreaker, len(r.breakers))
+	for endpoint, breaker := range r.breakers {
+		breakers[endpoint] = breaker
+	}
+	r.mu.RUnlock()
+	now := r.now()
+	out := make([]CircuitBreakerSnapshot, 0, len(breakers))
+	for endpoint, breaker := range breakers {
+		breaker.mu.Lock()
+		breaker.advanceLocked(cfg, now)
+		out = append(out, breaker.snapshotLocked(cfg, endpoint))
+		breaker.mu.Unlock()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Endpoint < out[j].Endpoint })
+	return out
+}
+
+func (r *circuitBreakerRegistry) reset(endpoint string) bool {
+	endpoint = strings.ToLower(strings.TrimRight(strings.TrimSpace(endpoint), "/"))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.breakers[endpoint]; !ok {
+		return false
+	}
+	delete(r.breakers, endpoint)
+	return true
+}
+
+// circuitOutcomeForResult classifies a result for endpoint health. Only failures that
+// point at the endpoint itself (5xx, timeouts, transport errors) count against it;
+// client faults, quota limits, and client disconnects are neutral.
+func circuitOutcomeForResult(result Result) circuitOutcome {
+	if result.Success {
+		return circuitOutcomeSuccess
+	}
+	if result.Error == nil {
+		return circuitOutcomeFailure
+	}
+	if isRequestScopedResultError(result.Error) || isConnectionLifecycleResultError(result.Error) {
+		return circuitOutcomeNeutral
+	}
+	status := result.Error.HTTPStatus
+	switch {
+	case status == 0, status == http.StatusRequestTimeout, status >= http.StatusInternalServerError:
+		return circuitOutcomeFailure
+	default:
+		return circuitOutcomeNeutral
+	}
+}
+
+// reserveCircuitBreakerProbe checks the endpoint breaker for a selected auth and takes a
+// half-open probe slot when needed. False means the auth must be skipped.
+func reserveCircuitBreakerProbe(auth *Auth) bool {
+	return circuitBreakers.reserve(auth)
+}
+
+// recordCircuitBreakerResult feeds an execution result into the endpoint breaker and
+// reports state transitions to Home when Home mode is active.
+func (m *Manager) recordCircuitBreakerResult(result Result, auth *Auth, outcome circuitOutcome) {
+	if auth == nil || !circuitBreakers.enabled() {
+		return
+	}
+	message := ""
+	if result.Error != nil {
+		message = result.Error.Message
+	}
+	transition, changed := circuitBreakers.observe(auth, outcome, message)
+	if !changed {
+		return
+	}
+	log.Warnf("circuit breaker for %s: %s -> %s (requests=%d failures=%d)", transition.snapshot.Endpoint, transition.previous, transition.snapshot.State, transition.snapshot.Requests, transition.snapshot.Failures)
+	m.reportCircuitBreakerTransition(transition)
+}
+
+func (m *Manager) reportCircuitBreakerTransition(transition circuitTransition) {
+	if m == nil || !m.HomeEnabled() {
+		return
+	}
+	cfg := m.runtimeConfigSnapshot()
+	client := home.Current()
+	if cfg == nil || client == nil {
+		return
+	}
+	snapshot := transition.snapshot
+	report := home.CircuitBreakerReport{
+		Endpoint:      snapshot.Endpoint,
+		State:         string(snapshot.State),
+		PreviousState: string(transition.previous),
+		Requests:      snapshot.Requests,
+		Failures:      snapshot.Failures,
+		ErrorRate:     snapshot.ErrorRate,
+		LastError:     snapshot.LastError,
+		OpenedAt:      snapshot.OpenedAt,
+	}
+	nodeID := cfg.Home.NodeID
+	go func() {
+		if errReport := home.ReportCircuitBreaker(context.Background(), client, nodeID, report); errReport != nil {
+			log.Debugf("circuit breaker: failed to report %s to home: %v", report.Endpoint, errReport)
+		}
+	}()
+}
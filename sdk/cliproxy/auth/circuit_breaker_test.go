@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func newTestCircuitBreakers(t *testing.T, cfg internalconfig.CircuitBreakerConfig) (*circuitBreakerRegistry, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	registry := newCircuitBreakerRegistry()
+	registry.now = func() time.Time { return now }
+	registry.setConfig(cfg)
+	return registry, &now
+}
+
+func circuitBreakerTestAuth(id, baseURL string) *Auth {
+	return &Auth{ID: id, Provider: "openai-compatibility", Attributes: map[string]string{"base_url": baseURL}}
+}
+
+func TestCircuitBreakerEndpointKeyBy(t *testing.T) {
+	auth := circuitBreakerTestAuth("a", "HTTPS://API.Example.com:8443/v1/")
+	if got := circuitBreakerEndpoint(auth, internalconfig.CircuitBreakerKeyByHost); got != "https://api.example.com:8443" {
+		t.Fatalf("host key = %q", got)
+	}
+	if got := circuitBreakerEndpoint(auth, internalconfig.CircuitBreakerKeyByBaseURL); got != "https://api.example.com:8443/v1" {
+		t.Fatalf("base-url key = %q", got)
+	}
+	if got := circuitBreakerEndpoint(&Auth{ID: "oauth"}, internalconfig.CircuitBreakerKeyByHost); got != "" {
+		t.Fatalf("key without base_url = %q, want empty", got)
+	}
+}
+
+func TestCircuitBreakerTripsAndSkipsEveryAuthOnEndpoint(t *testing.T) {
+	registry, now := newTestCircuitBreakers(t, internalconfig.CircuitBreakerConfig{Enable: true, MinRequests: 4, ErrorRateThreshold: 0.5, OpenSeconds: 30})
+	first := circuitBreakerTestAuth("a", "https://api.example.com/v1")
+	second := circuitBreakerTestAuth("b", "https://api.example.com/v2")
+	other := circuitBreakerTestAuth("c", "https://other.example.com/v1")
+
+	registry.observe(first, circuitOutcomeSuccess, "")
+	registry.observe(first, circuitOutcomeSuccess, "")
+	registry.observe(second, circuitOutcomeFailure, "bad gateway")
+	if !registry.allow(first) {
+		t.Fatal("breaker opened before min-requests")
+	}
+	transition, changed := registry.observe(second, circuitOutcomeFailure, "bad gateway")
+	if !changed || transition.previous != CircuitClosed || transition.snapshot.State != CircuitOpen {
+		t.Fatalf("transition = %+v, %v; want closed -> open", transition, changed)
+	}
+	if registry.allow(first) || registry.allow(second) {
+		t.Fatal("open breaker admitted an auth on the endpoint")
+	}
+	if !registry.allow(other) {
+		t.Fatal("open breaker rejected an auth on another endpoint")
+	}
+
+	*now = now.Add(30 * time.Second)
+	if !registry.allow(first) {
+		t.Fatal("half-open breaker rejected the first probe")
+	}
+	if !registry.reserve(first) {
+		t.Fatal("half-open breaker refused the first probe slot")
+	}
+	if registry.allow(second) || registry.reserve(second) {
+		t.Fatal("half-open breaker admitted more probes than configured")
+	}
+	transition, changed = registry.observe(first, circuitOutcomeSuccess, "")
+	if !changed || transition.previous != CircuitHalfOpen || transition.snapshot.State != CircuitClosed {
+		t.Fatalf("transition = %+v, %v; want half-open -> closed", transition, changed)
+	}
+	if !registry.allow(second) {
+		t.Fatal("closed breaker rejected an auth")
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	registry, now := newTestCircuitBreakers(t, internalconfig.CircuitBreakerConfig{Enable: true, MinRequests: 1, OpenSeconds: 10})
+	auth := circuitBreakerTestAuth("a", "https://api.example.com")
+
+	registry.observe(auth, circuitOutcomeFailure, "timeout")
+	*now = now.Add(10 * time.Second)
+	registry.reserve(auth)
+	transition, changed := registry.observe(auth, circuitOutcomeFailure, "timeout")
+	if !changed || transition.snapshot.State != CircuitOpen || transition.snapshot.NextProbeAt == nil {
+		t.Fatalf("transition = %+v, %v; want half-open -> open", transition, changed)
+	}
+	if registry.allow(auth) {
+		t.Fatal("reopened breaker admitted an auth")
+	}
+}
+
+func TestCircuitBreakerHalfOpenReservesProbesAtomically(t *testing.T) {
+	registry, now := newTestCircuitBreakers(t, internalconfig.CircuitBreakerConfig{Enable: true, MinRequests: 1, OpenSeconds: 10, HalfOpenProbes: 2})
+	auth := circuitBreakerTestAuth("a", "https://api.example.com")
+	registry.observe(auth, circuitOutcomeFailure, "bad gateway")
+	*now = now.Add(10 * time.Second)
+
+	// Every selection passes allow before any reserves; only the configured probes may win.
+	const callers = 32
+	for i := 0; i < callers; i++ {
+		if !registry.allow(auth) {
+			t.Fatalf("allow() #%d rejected the half-open breaker before any probe", i)
+		}
+	}
+	var reserved atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if registry.reserve(auth) {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := reserved.Load(); got != 2 {
+		t.Fatalf("reserved probes = %d, want 2", got)
+	}
+}
+
+func TestCircuitBreakerWindowResetsCounts(t *testing.T) {
+	registry, now := newTestCircuitBreakers(t, internalconfig.CircuitBreakerConfig{Enable: true, MinRequests: 2, WindowSeconds: 60})
+	auth := circuitBreakerTestAuth("a", "https://api.example.com")
+
+	registry.observe(auth, circuitOutcomeFailure, "")
+	*now = now.Add(61 * time.Second)
+	if _, changed := registry.observe(auth, circuitOutcomeFailure, ""); changed {
+		t.Fatal("failures from an expired window tripped the breaker")
+	}
+	snapshots := registry.snapshots()
+	if len(snapshots) != 1 || snapshots[0].Requests != 1 || snapshots[0].State != CircuitClosed {
+		t.Fatalf("snapshots = %+v", snapshots)
+	}
+}
+
+func TestCircuitBreakerDisabledAllowsAll(t *testing.T) {
+	registry, _ := newTestCircuitBreakers(t, internalconfig.CircuitBreakerConfig{MinRequests: 1})
+	auth := circuitBreakerTestAuth("a", "https://api.example.com")
+	if _, changed := registry.observe(auth, circuitOutcomeFailure, ""); changed {
+		t.Fatal("disabled breaker recorded a transition")
+	}
+	if !registry.allow(auth) || len(registry.snapshots()) != 0 {
+		t.Fatal("disabled breaker tracked state")
+	}
+}
+
+func TestCircuitOutcomeForResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   circuitOutcome
+	}{
+		{name: "success", result: Result{Success: true}, want: circuitOutcomeSuccess},
+		{name: "server error", result: Result{Error: &Error{HTTPStatus: http.StatusBadGateway}}, want: circuitOutcomeFailure},
+		{name: "timeout", result: Result{Error: &Error{HTTPStatus: http.StatusRequestTimeout}}, want: circuitOutcomeFailure},
+		{name: "transport", result: Result{Error: &Error{Message: "dial tcp: connection refused"}}, want: circuitOutcomeFailure},
+		{name: "quota", result: Result{Error: &Error{HTTPStatus: http.StatusTooManyRequests}}, want: circuitOutcomeNeutral},
+		{name: "unauthorized", result: Result{Error: &Error{HTTPStatus: http.StatusUnauthorized}}, want: circuitOutcomeNeutral},
+		{name: "request scoped", result: Result{Error: &Error{Code: requestScopedErrorCode, HTTPStatus: http.StatusInternalServerError}}, want: circuitOutcomeNeutral},
+		{name: "client disconnect", result: Result{Error: &Error{Code: connectionLifecycleErrorCode}}, want: circuitOutcomeNeutral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := circuitOutcomeForResult(tt.result); got != tt.want {
+				t.Fatalf("circuitOutcomeForResult() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthSelectionEligibilitySkipsOpenCircuit(t *testing.T) {
+	previous := circuitBreakers
+	t.Cleanup(func() { circuitBreakers = previous })
+	circuitBreakers, _ = newTestCircuitBreakers(t, internalconfig.CircuitBreakerConfig{Enable: true, MinRequests: 1})
+
+	auth := circuitBreakerTestAuth("a", "https://api.example.com")
+	circuitBreakers.observe(auth, circuitOutcomeFailure, "bad gateway")
+	if (authSelectionEligibility{}).allows(auth) {
+		t.Fatal("selection allowed an auth behind an open circuit breaker")
+	}
+}
+
+func TestContextWindowRejectionDoesNotHoldHalfOpenProbe(t *testing.T) {
+	previous := circuitBreakers
+	t.Cleanup(func() { circuitBreakers = previous })
+	var now *time.Time
+	circuitBreakers, now = newTestCircuitBreakers(t, internalconfig.CircuitBreakerConfig{Enable: true, MinRequests: 1, OpenSeconds: 10})
+
+	alias := "half-open-small-window"
+	executor := &openAICompatPoolExecutor{id: openAICompatPoolProviderKey}
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{OpenAICompatibility: []internalconfig.OpenAICompatibility{{
+		Name:   "pool",
+		Models: []internalconfig.OpenAICompatibilityModel{{Name: "small-window", Alias: alias, MaxContextLength: 100}},
+	}}})
+	m.RegisterExecutor(executor)
+	auth := &Auth{
+		ID:       "half-open-auth",
+		Provider: openAICompatPoolProviderKey,
+		Status:   StatusActive,
+		Attributes: map[string]string{
+			"api_key":      "test-key",
+			"base_url":     "https://api.example.com/v1",
+			"compat_name":  "pool",
+			"provider_key": openAICompatPoolProviderKey,
+		},
+	}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, openAICompatPoolProviderKey, []*registry.ModelInfo{{ID: alias}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+
+	circuitBreakers.observe(auth, circuitOutcomeFailure, "bad gateway")
+	*now = now.Add(10 * time.Second)
+	if !circuitBreakers.allow(auth) {
+		t.Fatal("breaker did not move to half-open")
+	}
+
+	payload := contextWindowTestPayload(500)
+	if _, err := m.Execute(context.Background(), []string{openAICompatPoolProviderKey}, cliproxyexecutor.Request{Model: alias, Payload: payload}, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("execute succeeded for a prompt larger than the context window")
+	}
+	if _, err := m.ExecuteStream(context.Background(), []string{openAICompatPoolProviderKey}, cliproxyexecutor.Request{Model: alias, Payload: payload}, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("stream succeeded for a prompt larger than the context window")
+	}
+	if !circuitBreakers.allow(auth) {
+		t.Fatal("a request rejected before reaching the endpoint kept the half-open probe slot")
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contextwindow"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// ErrorCodeContextLengthExceeded identifies requests whose estimated prompt does not fit
+// the context window of any candidate model.
+const ErrorCodeContextLengthExceeded = "context_length_exceeded"
+
+// promptContextCheck filters execution candidates by their configured max-context-length.
+// The prompt is only measured once a candidate with a known context window is seen.
+type promptContextCheck struct {
+	payload  []byte
+	estimate *contextwindow.Estimate
+	// largestRejected is the biggest context window that was too small for the prompt.
+	largestRejected int
+}
+
+func newPromptContextCheck(req cliproxyexecutor.Request, opts cliproxyexecutor.Options) *promptContextCheck {
+	payload := opts.OriginalRequest
+	if len(payload) == 0 {
+		payload = req.Payload
+	}
+	return &promptContextCheck{payload: payload}
+}
+
+func (c *promptContextCheck) fits(limit int) bool {
+	if c == nil || limit <= 0 || len(c.payload) == 0 {
+		return true
+	}
+	if c.estimate == nil {
+		c.estimate = contextwindow.NewEstimate(c.payload)
+	}
+	if c.estimate.Fits(limit) {
+		return true
+	}
+	if limit > c.largestRejected {
+		c.largestRejected = limit
+	}
+	return false
+}
+
+// rejected reports whether any candidate was skipped because its context window was too small.
+func (c *promptContextCheck) rejected() bool {
+	return c != nil && c.largestRejected > 0
+}
+
+func (c *promptContextCheck) err(model string) *Error {
+	tokens := int64(0)
+	if c != nil && c.estimate != nil {
+		tokens = c.estimate.Tokens()
+	}
+	largest := 0
+	if c != nil {
+		largest = c.largestRejected
+	}
+	return &Error{
+		Code:       ErrorCodeContextLengthExceeded,
+		Message:    fmt.Sprintf("request is about %d tokens, which exceeds the largest available context window of %d tokens for model %s", tokens, largest, model),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+// filterModelsByContextWindow drops upstream models whose configured context window is
+// smaller than the estimated prompt.
+func (m *Manager) filterModelsByContextWindow(auth *Auth, routeModel string, models []string, check *promptContextCheck) []string {
+	if check == nil || len(models) == 0 {
+		return models
+	}
+	out := models[:0:0]
+	for _, upstreamModel := range models {
+		if check.fits(m.executionModelContextLimit(auth, routeModel, upstreamModel)) {
+			out = append(out, upstreamModel)
+		}
+	}
+	return out
+}
+
+// executionModelContextLimit returns the configured max-context-length for an upstream
+// model on auth. OpenAI-compatible alias pools are resolved per upstream model; other
+// auths use the context window registered for the route model. 0 means unknown.
+func (m *Manager) executionModelContextLimit(auth *Auth, routeModel, upstreamModel string) int {
+	if auth == nil {
+		return 0
+	}
+	if isConfiguredOpenAICompatAuth(auth) {
+		providerKey := ""
+		compatName := ""
+		if auth.Attributes != nil {
+			providerKey = strings.TrimSpace(auth.Attributes["provider_key"])
+			compatName = strings.TrimSpace(auth.Attributes["compat_name"])
+		}
+		if entry := resolveOpenAICompatConfigForAuth(m.runtimeConfigSnapshot(), auth, providerKey, compatName); entry != nil {
+			upstreamName := strings.TrimSpace(thinking.ParseSuffix(upstreamModel).ModelName)
+			for i := range entry.Models {
+				if strings.EqualFold(strings.TrimSpace(entry.Models[i].Name), upstreamName) && entry.Models[i].MaxContextLength > 0 {
+					return entry.Models[i].MaxContextLength
+				}
+			}
+		}
+	}
+	registryRef := registry.GetGlobalRegistry()
+	routeKey := canonicalModelKey(routeModel)
+	if limit := registryRef.ClientModelMaxContextLength(auth.ID, routeKey); limit > 0 {
+		return limit
+	}
+	if selectionKey := m.selectionModelKeyForAuth(auth, routeModel); selectionKey != "" && selectionKey != routeKey {
+		return registryRef.ClientModelMaxContextLength(auth.ID, selectionKey)
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func contextWindowTestPayload(words int) []byte {
+	return []byte(`{"messages":[{"role":"user","content":"` + strings.Repeat("hello world ", words) + `"}]}`)
+}
+
+func TestManagerExecute_ContextWindowSkipsSmallPoolModels(t *testing.T) {
+	alias := "mixed-window"
+	executor := &openAICompatPoolExecutor{id: openAICompatPoolProviderKey}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "small-window", Alias: alias, MaxContextLength: 100},
+		{Name: "large-window", Alias: alias, MaxContextLength: 1000000},
+	}, executor)
+
+	payload := contextWindowTestPayload(500)
+	for i := 0; i < 2; i++ {
+		if _, err := m.Execute(context.Background(), []string{openAICompatPoolProviderKey}, cliproxyexecutor.Request{Model: alias, Payload: payload}, cliproxyexecutor.Options{}); err != nil {
+			t.Fatalf("execute %d: %v", i, err)
+		}
+	}
+	for _, model := range executor.ExecuteModels() {
+		if model != "large-window" {
+			t.Fatalf("execute models = %v, want only large-window", executor.ExecuteModels())
+		}
+	}
+}
+
+func TestManagerExecute_ContextWindowExceededBeforeUpstreamCall(t *testing.T) {
+	alias := "small-only"
+	executor := &openAICompatPoolExecutor{id: openAICompatPoolProviderKey}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "small-a", Alias: alias, MaxContextLength: 100},
+		{Name: "small-b", Alias: alias, MaxContextLength: 200},
+	}, executor)
+
+	payload := contextWindowTestPayload(500)
+	_, err := m.Execute(context.Background(), []string{openAICompatPoolProviderKey}, cliproxyexecutor.Request{Model: alias, Payload: payload}, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != ErrorCodeContextLengthExceeded || authErr.HTTPStatus != http.StatusBadRequest {
+		t.Fatalf("execute error = %v, want %s", err, ErrorCodeContextLengthExceeded)
+	}
+	if !strings.Contains(authErr.Message, "200 tokens") {
+		t.Fatalf("error message = %q, want largest window", authErr.Message)
+	}
+	if _, errStream := m.ExecuteStream(context.Background(), []string{openAICompatPoolProviderKey}, cliproxyexecutor.Request{Model: alias, Payload: payload}, cliproxyexecutor.Options{}); !errors.As(errStream, &authErr) || authErr.Code != ErrorCodeContextLengthExceeded {
+		t.Fatalf("stream error = %v, want %s", errStream, ErrorCodeContextLengthExceeded)
+	}
+	if calls := append(executor.ExecuteModels(), executor.StreamModels()...); len(calls) != 0 {
+		t.Fatalf("upstream calls = %v, want none", calls)
+	}
+}
+
+func TestManagerExecute_ContextWindowAllowsPromptsThatFit(t *testing.T) {
+	alias := "small-fits"
+	executor := &openAICompatPoolExecutor{id: openAICompatPoolProviderKey}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "small-window", Alias: alias, MaxContextLength: 100},
+	}, executor)
+
+	if _, err := m.Execute(context.Background(), []string{openAICompatPoolProviderKey}, cliproxyexecutor.Request{Model: alias, Payload: contextWindowTestPayload(5)}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := executor.ExecuteModels(); len(got) != 1 || got[0] != "small-window" {
+		t.Fatalf("execute models = %v, want small-window", got)
+	}
+}
@@ -714,10 +714,12 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	setModelQuota := false
 	var authSnapshot *Auth
 	cooldownStateChanged := false
+	var coolingBefore, coolingAfter time.Time
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
+		coolingBefore = coolingUntil(auth, modelKey, now)
 		var cooldownRecordsBefore []CooldownStateRecord
 		trackCooldownState := m.cooldownStore != nil
 		if trackCooldownState {
@@ -907,6 +909,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 
 		_ = m.persist(ctx, auth)
 		authSnapshot = auth.Clone()
+		coolingAfter = coolingUntil(auth, modelKey, now)
 		if trackCooldownState {
 			cooldownRecordsAfter := m.cooldownStateRecordsForAuthLocked(auth, now)
 			cooldownStateChanged = !cooldownStateRecordsEqual(cooldownRecordsBefore, cooldownRecordsAfter)
@@ -934,6 +937,11 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 
 	m.hook.OnResult(ctx, result)
 	m.publishErrorEvent(result, authSnapshot)
+	publishCooldownTransition(ctx, authSnapshot, modelKey, coolingBefore, coolingAfter)
+	if coolingBefore.IsZero() && !coolingAfter.IsZero() {
+		m.publishModelExhausted(ctx, modelKey)
+	}
+	m.recordCircuitBreakerResult(result, authSnapshot, circuitOutcomeForResult(result))
 	m.updateSessionAffinity(result)
 }
 
@@ -967,6 +975,7 @@ func (m *Manager) reportHomeResult(ctx context.Context, result Result, auth *Aut
 	}
 	m.hook.OnResult(ctx, result)
 	m.publishErrorEvent(result, snapshot)
+	m.recordCircuitBreakerResult(result, snapshot, circuitOutcomeForResult(result))
 }
 
 func (m *Manager) recordAvailabilityNeutralResult(ctx context.Context, result Result) {
@@ -991,6 +1000,7 @@ func (m *Manager) recordAvailabilityNeutralResult(ctx context.Context, result Re
 
 	m.hook.OnResult(ctx, result)
 	m.publishErrorEvent(result, authSnapshot)
+	m.recordCircuitBreakerResult(result, authSnapshot, circuitOutcomeNeutral)
 }
 
 func ensureModelState(auth *Auth, model string) *ModelState {
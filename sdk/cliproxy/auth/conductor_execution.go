@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	cliproxysession "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/session"
@@ -54,7 +55,9 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 	var lastErr error
 	retryModel := authSelectionModelFromOptions(opts, req.Model)
 	for attempt := 0; ; attempt++ {
-		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts, maxRetryCredentials, attempt, defaultRequestRetry)
+		roundCtx, roundSpan := startRetryRoundSpan(ctx, attempt, retryModel)
+		resp, errExec := m.executeMixedOnce(roundCtx, normalized, req, opts, maxRetryCredentials, attempt, defaultRequestRetry)
+		tracing.End(roundSpan, errExec)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -101,7 +104,9 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 	var lastErr error
 	retryModel := authSelectionModelFromOptions(opts, req.Model)
 	for attempt := 0; ; attempt++ {
-		resp, errExec := m.executeCountMixedOnce(ctx, normalized, req, opts, maxRetryCredentials, attempt, defaultRequestRetry)
+		roundCtx, roundSpan := startRetryRoundSpan(ctx, attempt, retryModel)
+		resp, errExec := m.executeCountMixedOnce(roundCtx, normalized, req, opts, maxRetryCredentials, attempt, defaultRequestRetry)
+		tracing.End(roundSpan, errExec)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -146,7 +151,9 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 	retryRoundPending := false
 	retryRoundWaited := false
 	for {
-		result, errStream := m.executeStreamMixedOnce(ctx, normalized, req, opts, maxRetryCredentials, &homeRetryLimit, attempt, defaultRequestRetry)
+		roundCtx, roundSpan := startRetryRoundSpan(ctx, attempt, retryModel)
+		result, errStream := m.executeStreamMixedOnce(roundCtx, normalized, req, opts, maxRetryCredentials, &homeRetryLimit, attempt, defaultRequestRetry)
+		tracing.End(roundSpan, errStream)
 		if errStream == nil {
 			return result, nil
 		}
@@ -307,6 +314,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	routeModel := authSelectionModelFromOptions(opts, req.Model)
 	executionModel, restoreExecutionModel := executionModelForAuthSelection(opts, req.Model)
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	contextCheck := newPromptContextCheck(req, opts)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
 	tried := make(map[string]struct{})
@@ -351,10 +359,18 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		execCtx = contextWithRequestedModelAlias(execCtx, opts, routeModel)
 
 		models, pooled, aliasResult, routing := m.preparedExecutionModelsWithAlias(auth, routeModel)
+		models = m.filterModelsByContextWindow(auth, routeModel, models, contextCheck)
 		if len(models) == 0 {
+			if lastErr == nil && contextCheck.rejected() {
+				lastErr = contextCheck.err(routeModel)
+			}
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
+		// Reserve the half-open probe only once the request will reach the endpoint.
+		if !reserveCircuitBreakerProbe(auth) {
+			continue
+		}
 		var errPrepare error
 		auth, errPrepare = m.prepareRequestAuth(execCtx, executor, auth)
 		if errPrepare != nil {
@@ -385,7 +401,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				execReq = attachResolvedAPIKeyModelInfo(routing, execReq, auth, routeModel, upstreamModel)
 			}
 			startExec := time.Now()
-			resp, errExec := executor.Execute(execCtx, auth, execReq, execOpts)
+			resp, errExec := tracedExecute(execCtx, executor, provider, auth, execReq, execOpts)
 			durationExec := time.Since(startExec)
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
@@ -395,7 +411,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 					auth = refreshed
 					didRefreshOnUnauthorized = true
 					startRetry := time.Now()
-					resp, errExec = executor.Execute(execCtx, auth, execReq, execOpts)
+					resp, errExec = tracedExecute(execCtx, executor, provider, auth, execReq, execOpts)
 					durationRetry := time.Since(startRetry)
 					if errExec != nil {
 						warnLogUpstreamFailure(execCtx, entry, provider, upstreamModel, auth, durationRetry, errExec)
@@ -529,6 +545,9 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
+		if !reserveCircuitBreakerProbe(auth) {
+			continue
+		}
 		var errPrepare error
 		auth, errPrepare = m.prepareRequestAuth(execCtx, executor, auth)
 		if errPrepare != nil {
@@ -559,7 +578,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 				execReq = attachResolvedAPIKeyModelInfo(routing, execReq, auth, routeModel, upstreamModel)
 			}
 			startExec := time.Now()
-			resp, errExec := executor.CountTokens(execCtx, auth, execReq, execOpts)
+			resp, errExec := tracedCountTokens(execCtx, executor, provider, auth, execReq, execOpts)
 			durationExec := time.Since(startExec)
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
@@ -569,7 +588,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 					auth = refreshed
 					didRefreshOnUnauthorized = true
 					startRetry := time.Now()
-					resp, errExec = executor.CountTokens(execCtx, auth, execReq, execOpts)
+					resp, errExec = tracedCountTokens(execCtx, executor, provider, auth, execReq, execOpts)
 					durationRetry := time.Since(startRetry)
 					if errExec != nil {
 						warnLogUpstreamFailure(execCtx, entry, provider, upstreamModel, auth, durationRetry, errExec)
@@ -660,6 +679,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	responseAlias := requestedModelAliasFromOptions(opts, routeModel)
 	executionModel, restoreExecutionModel := executionModelForAuthSelection(opts, req.Model)
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	contextCheck := newPromptContextCheck(req, opts)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
 	tried := make(map[string]struct{})
@@ -810,10 +830,14 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		// Enrich before auth preparation so prepare-stage usage records observe the client request.
 		execCtx = contextWithRequestedModelAlias(execCtx, opts, routeModel)
 		models, pooled, aliasResult, routing := m.preparedExecutionModelsWithAlias(auth, routeModel)
+		models = m.filterModelsByContextWindow(auth, routeModel, models, contextCheck)
 		if selection != nil && aliasResult.ForceMapping && responseAlias != "" {
 			aliasResult.OriginalAlias = responseAlias
 		}
 		if len(models) == 0 {
+			if lastErr == nil && contextCheck.rejected() {
+				lastErr = contextCheck.err(routeModel)
+			}
 			if selection != nil {
 				homeExcludedAuthIDs[auth.ID] = struct{}{}
 				lastHomeAuthID = auth.ID
@@ -826,6 +850,18 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
+		if !reserveCircuitBreakerProbe(auth) {
+			if selection != nil {
+				homeExcludedAuthIDs[auth.ID] = struct{}{}
+				lastHomeAuthID = auth.ID
+				homeSameAuthRetryPending = false
+				releaseAttempt()
+				if errEnd := m.endHomeSelectionBeforeRedispatch(ctx, selection, "circuit_breaker_busy"); errEnd != nil {
+					return nil, errEnd
+				}
+			}
+			continue
+		}
 		var errPrepare error
 		if selection != nil {
 			auth, errPrepare = m.prepareHomeRequestAuth(execCtx, executor, selection)
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	"github.com/tidwall/sjson"
 )
@@ -199,15 +200,15 @@ func (m *Manager) executeHomeOnce(ctx context.Context, providers []string, req c
 				}
 				return effectiveAuth.Clone(), AccessTokenSHA256(effectiveAuth)
 			}
-			executorCtx := execCtx
+			executorCtx := replay.Guard(execCtx)
 			if countTokens {
-				executorCtx = withAccessTokenFingerprintObserver(execCtx, setEffectiveAuth)
+				executorCtx = withAccessTokenFingerprintObserver(executorCtx, setEffectiveAuth)
 			}
 			execute := func() (cliproxyexecutor.Response, error) {
 				if countTokens {
 					return selection.Executor.CountTokens(executorCtx, preparedAuth, execReq, execOpts)
 				}
-				return selection.Executor.Execute(execCtx, preparedAuth, execReq, execOpts)
+				return selection.Executor.Execute(executorCtx, preparedAuth, execReq, execOpts)
 			}
 			startHomeExec := time.Now()
 			response, errExecute = execute()
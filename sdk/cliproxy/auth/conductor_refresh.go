@@ -11,6 +11,7 @@ import (
 	"time"
 
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	log "github.com/sirupsen/logrus"
 )
@@ -394,6 +395,9 @@ func (m *Manager) tryRefreshExecutionAuthAfterUnauthorized(ctx context.Context,
 	log.Debugf("unauthorized Home response for %s (%s), refreshing credentials before redispatch", auth.Provider, auth.ID)
 	target := auth.Clone()
 	updated, errRefresh := executor.Refresh(ctx, target)
+	if errRefresh == nil || !errors.Is(errRefresh, context.Canceled) {
+		metrics.ObserveRefresh(auth.Provider, errRefresh)
+	}
 	if errRefresh != nil {
 		log.Debugf("Home credential refresh before redispatch failed for %s (%s)", auth.Provider, auth.ID)
 		return auth, false, errRefresh
@@ -534,6 +538,8 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 		log.Debugf("refresh canceled for %s, %s", auth.Provider, auth.ID)
 		return nil, err
 	}
+	metrics.ObserveRefresh(auth.Provider, err)
+	publishRefreshResult(ctx, auth, err)
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
 	if err != nil {
@@ -95,6 +95,9 @@ func (e authSelectionEligibility) allows(auth *Auth) bool {
 	if e.credentialPolicy != "" && !credentialPolicyAllows(e.credentialPolicy, auth) {
 		return false
 	}
+	if !circuitBreakers.allow(auth) {
+		return false
+	}
 	return !e.disallowFreeAuth || !isFreeCodexAuth(auth)
 }
 
@@ -1080,7 +1083,10 @@ func waitForCooldown(ctx context.Context, wait, maxWait time.Duration) error {
 	if wait <= 0 {
 		return nil
 	}
-	timer := time.NewTimer(jitteredCooldownWait(wait, maxWait))
+	delay := jitteredCooldownWait(wait, maxWait)
+	_, span := startCooldownSpan(ctx, delay)
+	defer span.End()
+	timer := time.NewTimer(delay)
 	defer timer.Stop()
 	select {
 	case <-ctx.Done():
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/replay"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startRetryRoundSpan starts the span covering one pass over the candidate credentials and
+// announces rounds after the first on the event stream.
+func startRetryRoundSpan(ctx context.Context, round int, model string) (context.Context, trace.Span) {
+	if round > 0 {
+		events.Publish(ctx, events.Event{Type: events.TypeRetryRound, Model: model, Round: round})
+	}
+	return tracing.Start(ctx, "cliproxy.retry_round", trace.WithAttributes(
+		tracing.AttrRetryRound.Int(round),
+		tracing.AttrModel.String(model),
+	))
+}
+
+// startAttemptSpan starts the span covering one upstream call with a selected credential,
+// counts the attempt for the access log and publishes the credential selection.
+func startAttemptSpan(ctx context.Context, operation, provider string, auth *Auth, model string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		tracing.AttrProvider.String(provider),
+		tracing.AttrModel.String(model),
+	}
+	authIndex := ""
+	if auth != nil {
+		authIndex = auth.EnsureIndex()
+		attrs = append(attrs, tracing.AttrAuthIndex.String(authIndex))
+	}
+	logging.RecordAccessAttempt(ctx, provider, authIndex, model)
+	selected := events.Event{Type: events.TypeCredentialSelected, Provider: provider, AuthIndex: authIndex, Model: model}
+	if auth != nil {
+		selected.AuthID = auth.ID
+	}
+	events.Publish(ctx, selected)
+	return tracing.Start(ctx, "cliproxy.upstream_attempt", trace.WithAttributes(append(attrs, attribute.String("cliproxy.operation", operation))...))
+}
+
+func endAttemptSpan(span trace.Span, err error) {
+	if err == nil {
+		span.SetAttributes(tracing.AttrStatus.Int(http.StatusOK))
+	} else if status := statusCodeFromError(err); status > 0 {
+		span.SetAttributes(tracing.AttrStatus.Int(status))
+	}
+	tracing.End(span, err)
+}
+
+// The traced helpers are the single entry point into executors, so they also apply the
+// translate-only replay guard: the executor context ends once the payload is captured.
+func tracedExecute(ctx context.Context, executor ProviderExecutor, provider string, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	ctx, span := startAttemptSpan(replay.Guard(ctx), "execute", provider, auth, req.Model)
+	resp, err := executor.Execute(ctx, auth, req, opts)
+	endAttemptSpan(span, err)
+	return resp, err
+}
+
+func tracedCountTokens(ctx context.Context, executor ProviderExecutor, provider string, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	ctx, span := startAttemptSpan(replay.Guard(ctx), "count_tokens", provider, auth, req.Model)
+	resp, err := executor.CountTokens(ctx, auth, req, opts)
+	endAttemptSpan(span, err)
+	return resp, err
+}
+
+// tracedExecuteStream ends the attempt span once the stream is established; the upstream
+// HTTP span keeps running until the body is consumed.
+func tracedExecuteStream(ctx context.Context, executor ProviderExecutor, provider string, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ctx, span := startAttemptSpan(replay.Guard(ctx), "execute_stream", provider, auth, req.Model)
+	result, err := executor.ExecuteStream(ctx, auth, req, opts)
+	endAttemptSpan(span, err)
+	return result, err
+}
+
+func startCooldownSpan(ctx context.Context, wait time.Duration) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "cliproxy.cooldown_wait", trace.WithAttributes(
+		attribute.Int64("cliproxy.wait_ms", wait.Milliseconds()),
+	))
+}
@@ -0,0 +1,394 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/events"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ProbeMethodEndpoint means the executor checked the credential with a provider endpoint.
+	ProbeMethodEndpoint = "endpoint"
+	// ProbeMethodRequest means the credential was checked with a minimal model request.
+	ProbeMethodRequest = "request"
+
+	// healthProbeStatusPrefix marks StatusMessage values set by a failed probe so a later
+	// successful probe only clears state it owns.
+	healthProbeStatusPrefix = "health probe failed: "
+
+	healthProbeTick = 30 * time.Second
+)
+
+// ErrProbeNoModel is returned when a credential has no model to probe with.
+var ErrProbeNoModel = errors.New("no probe model available")
+
+// HealthProber is implemented by executors that can check a credential without spending
+// tokens, for example through a quota or profile endpoint. An error wrapping
+// errors.ErrUnsupported falls back to a minimal model request.
+type HealthProber interface {
+	ProbeAuth(ctx context.Context, auth *Auth) error
+}
+
+// ProbeResult is the outcome of one health probe.
+type ProbeResult struct {
+	Time      time.Time `json:"time"`
+	Success   bool      `json:"success"`
+	Skipped   bool      `json:"skipped,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Status    int       `json:"status,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type healthProbeState struct {
+	mu      sync.Mutex
+	history map[string][]ProbeResult
+	cancel  context.CancelFunc
+}
+
+func (m *Manager) healthProbeConfig() internalconfig.HealthProbeConfig {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return internalconfig.HealthProbeConfig{}.WithDefaults()
+	}
+	return cfg.HealthProbe.WithDefaults()
+}
+
+// StartHealthProbes runs scheduled probes until parent is cancelled or StopHealthProbes is
+// called. The loop follows configuration reloads; while probes are disabled it stays idle.
+func (m *Manager) StartHealthProbes(parent context.Context) {
+	if m == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.probes.mu.Lock()
+	previous := m.probes.cancel
+	m.probes.cancel = cancel
+	m.probes.mu.Unlock()
+	if previous != nil {
+		previous()
+	}
+	go m.runHealthProbes(ctx)
+}
+
+// StopHealthProbes cancels the scheduled probe loop, if running.
+func (m *Manager) StopHealthProbes() {
+	if m == nil {
+		return
+	}
+	m.probes.mu.Lock()
+	cancel := m.probes.cancel
+	m.probes.cancel = nil
+	m.probes.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (m *Manager) runHealthProbes(ctx context.Context) {
+	ticker := time.NewTicker(healthProbeTick)
+	defer ticker.Stop()
+	for {
+		if cfg := m.healthProbeConfig(); cfg.Enable && !m.HomeEnabled() {
+			m.probeDueAuths(ctx, cfg, time.Now())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeDueAuths probes every eligible credential whose last probe is older than the interval.
+func (m *Manager) probeDueAuths(ctx context.Context, cfg internalconfig.HealthProbeConfig, now time.Time) {
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	var due []string
+	m.mu.RLock()
+	for id, auth := range m.auths {
+		if auth == nil || auth.Disabled || auth.Status == StatusDisabled || !cfg.ProbesProvider(auth.Provider) {
+			continue
+		}
+		if last, ok := m.lastProbe(id); ok && now.Sub(last.Time) < interval {
+			continue
+		}
+		due = append(due, id)
+	}
+	m.mu.RUnlock()
+	if len(due) > 0 {
+		m.ProbeAuths(ctx, due)
+	}
+}
+
+// ProbeAuths probes the given credentials with the configured concurrency and returns the
+// results by auth ID. Credentials that could not be probed are left out.
+func (m *Manager) ProbeAuths(ctx context.Context, ids []string) map[string]ProbeResult {
+	results := make(map[string]ProbeResult, len(ids))
+	if m == nil || len(ids) == 0 {
+		return results
+	}
+	var mu sync.Mutex
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < min(m.healthProbeConfig().Concurrency, len(ids)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				result, errProbe := m.ProbeAuth(ctx, id)
+				if errProbe != nil {
+					if !errors.Is(errProbe, context.Canceled) {
+						log.Debugf("health probe %s: %v", id, errProbe)
+					}
+					continue
+				}
+				mu.Lock()
+				results[id] = result
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, id := range ids {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// ProbeAuth checks one credential now, records the result in its probe history and updates
+// its availability: credential failures mark it unavailable and a success clears an earlier
+// probe failure. The returned error describes why the probe could not run at all.
+func (m *Manager) ProbeAuth(ctx context.Context, id string) (ProbeResult, error) {
+	if m == nil {
+		return ProbeResult{}, errors.New("auth manager is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.mu.RLock()
+	auth := m.auths[strings.TrimSpace(id)]
+	var executor ProviderExecutor
+	if auth != nil {
+		executor = m.executors[executorKeyFromAuth(auth)]
+		auth = auth.Clone()
+	}
+	m.mu.RUnlock()
+	if auth == nil || executor == nil {
+		return ProbeResult{}, errors.New("auth or executor not found")
+	}
+
+	cfg := m.healthProbeConfig()
+	probeCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+	started := time.Now()
+	result := m.runProbe(probeCtx, executor, auth, cfg)
+	result.Time = started.UTC()
+	result.LatencyMs = time.Since(started).Milliseconds()
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return result, ctx.Err()
+	}
+
+	m.recordProbe(auth.ID, result, cfg.HistorySize)
+	m.applyProbeResult(ctx, auth.ID, result, time.Duration(cfg.IntervalSeconds)*time.Second)
+	success := result.Success
+	events.Publish(ctx, events.Event{
+		Type:      events.TypeProbeResult,
+		Provider:  strings.TrimSpace(auth.Provider),
+		AuthID:    auth.ID,
+		AuthIndex: auth.Index,
+		Model:     result.Model,
+		Status:    result.Status,
+		Success:   &success,
+		Message:   result.Error,
+	})
+	return result, nil
+}
+
+func (m *Manager) runProbe(ctx context.Context, executor ProviderExecutor, auth *Auth, cfg internalconfig.HealthProbeConfig) ProbeResult {
+	// Probe with the same refreshed credential a real request would use, so an expired
+	// access token is not mistaken for a revoked credential.
+	auth, errPrepare := m.prepareRequestAuth(ctx, executor, auth)
+	if errPrepare != nil {
+		return probeResultFromError("", "", errPrepare)
+	}
+	if prober, ok := executor.(HealthProber); ok {
+		errProbe := prober.ProbeAuth(ctx, auth)
+		if !errors.Is(errProbe, errors.ErrUnsupported) {
+			return probeResultFromError(ProbeMethodEndpoint, "", errProbe)
+		}
+	}
+
+	model := cfg.ModelFor(auth.Provider)
+	if model == "" {
+		for _, info := range registry.GetGlobalRegistry().GetModelsForClient(auth.ID) {
+			if info != nil && strings.TrimSpace(info.ID) != "" {
+				model = info.ID
+				break
+			}
+		}
+	}
+	if model == "" {
+		return ProbeResult{Skipped: true, Method: ProbeMethodRequest, Error: ErrProbeNoModel.Error()}
+	}
+	upstreamModel := model
+	if models := m.prepareExecutionModels(auth, model); len(models) > 0 {
+		upstreamModel = models[0]
+	}
+	payload := fmt.Appendf(nil, `{"model":%q,"messages":[{"role":"user","content":"ping"}],"max_tokens":1,"stream":false}`, upstreamModel)
+	_, errExec := executor.Execute(ctx, auth, cliproxyexecutor.Request{
+		Model:   upstreamModel,
+		Payload: payload,
+		Format:  sdktranslator.FormatOpenAI,
+	}, cliproxyexecutor.Options{
+		OriginalRequest: payload,
+		SourceFormat:    sdktranslator.FormatOpenAI,
+	})
+	return probeResultFromError(ProbeMethodRequest, model, errExec)
+}
+
+func probeResultFromError(method, model string, err error) ProbeResult {
+	result := ProbeResult{Success: err == nil, Method: method, Model: model}
+	if err != nil {
+		result.Status = statusCodeFromError(err)
+		result.Error = err.Error()
+	} else {
+		result.Status = http.StatusOK
+	}
+	return result
+}
+
+// probeMarksUnavailable reports whether a failed probe proves the credential itself is
+// unusable (revoked, banned, unpaid or region blocked) rather than a transient upstream issue.
+// A 400 is not proof: many healthy upstreams reject the synthetic ping request itself.
+func probeMarksUnavailable(result ProbeResult) bool {
+	if result.Success || result.Skipped {
+		return false
+	}
+	switch result.Status {
+	case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden:
+		return true
+	}
+	return false
+}
+
+// applyProbeResult blocks a credential that failed a probe until the next scheduled probe,
+// and lifts that block once a probe succeeds again.
+func (m *Manager) applyProbeResult(ctx context.Context, id string, result ProbeResult, interval time.Duration) {
+	now := time.Now()
+	var snapshot *Auth
+	m.mu.Lock()
+	if current := m.auths[id]; current != nil {
+		switch {
+		case probeMarksUnavailable(result):
+			message := healthProbeStatusPrefix + probeFailureReason(result)
+			until := now.Add(interval)
+			current.Unavailable = true
+			current.Status = StatusError
+			current.StatusMessage = message
+			current.NextRetryAfter = until
+			for _, state := range current.ModelStates {
+				if state == nil || state.Status == StatusDisabled {
+					continue
+				}
+				state.Unavailable = true
+				state.Status = StatusError
+				state.StatusMessage = message
+				if state.NextRetryAfter.Before(until) {
+					state.NextRetryAfter = until
+				}
+				state.UpdatedAt = now
+			}
+			current.UpdatedAt = now
+			snapshot = current
+		case result.Success && strings.HasPrefix(current.StatusMessage, healthProbeStatusPrefix):
+			for _, state := range current.ModelStates {
+				if state != nil && strings.HasPrefix(state.StatusMessage, healthProbeStatusPrefix) {
+					resetModelState(state, now)
+				}
+			}
+			current.StatusMessage = ""
+			current.Unavailable = false
+			current.NextRetryAfter = time.Time{}
+			if current.Status == StatusError {
+				current.Status = StatusActive
+			}
+			updateAggregatedAvailability(current, now)
+			current.UpdatedAt = now
+			snapshot = current
+		}
+		if snapshot != nil {
+			_ = m.persist(ctx, snapshot)
+			snapshot = snapshot.Clone()
+		}
+	}
+	m.mu.Unlock()
+	if snapshot == nil {
+		return
+	}
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+	m.hook.OnAuthUpdated(ctx, snapshot)
+}
+
+func probeFailureReason(result ProbeResult) string {
+	reason := strings.TrimSpace(result.Error)
+	if len(reason) > 200 {
+		reason = reason[:200] + "..."
+	}
+	if result.Status > 0 {
+		return fmt.Sprintf("%d %s", result.Status, reason)
+	}
+	return reason
+}
+
+func (m *Manager) recordProbe(id string, result ProbeResult, limit int) {
+	m.probes.mu.Lock()
+	defer m.probes.mu.Unlock()
+	if m.probes.history == nil {
+		m.probes.history = make(map[string][]ProbeResult)
+	}
+	history := append(m.probes.history[id], result)
+	if limit > 0 && len(history) > limit {
+		history = append([]ProbeResult(nil), history[len(history)-limit:]...)
+	}
+	m.probes.history[id] = history
+}
+
+func (m *Manager) lastProbe(id string) (ProbeResult, bool) {
+	m.probes.mu.Lock()
+	defer m.probes.mu.Unlock()
+	history := m.probes.history[id]
+	if len(history) == 0 {
+		return ProbeResult{}, false
+	}
+	return history[len(history)-1], true
+}
+
+// ProbeHistory returns the recorded probe results for an auth, oldest first.
+func (m *Manager) ProbeHistory(id string) []ProbeResult {
+	if m == nil {
+		return nil
+	}
+	m.probes.mu.Lock()
+	defer m.probes.mu.Unlock()
+	return append([]ProbeResult(nil), m.probes.history[id]...)
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+type healthProbeTestExecutor struct {
+	schedulerProviderTestExecutor
+	err *error
+}
+
+func (e healthProbeTestExecutor) ProbeAuth(ctx context.Context, auth *Auth) error {
+	return *e.err
+}
+
+func TestManager_ProbeAuthMarksUnavailableAndRecovers(t *testing.T) {
+	ctx := context.Background()
+	var probeErr error = &Error{Code: "unauthorized", Message: "refresh token revoked", HTTPStatus: http.StatusUnauthorized}
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{HealthProbe: internalconfig.HealthProbeConfig{HistorySize: 2}})
+	manager.RegisterExecutor(healthProbeTestExecutor{
+		schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "codex"},
+		err:                           &probeErr,
+	})
+	auth := &Auth{ID: "probe-auth", Provider: "codex", Status: StatusActive}
+	if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+
+	result, errProbe := manager.ProbeAuth(ctx, auth.ID)
+	if errProbe != nil {
+		t.Fatalf("ProbeAuth() error = %v", errProbe)
+	}
+	if result.Success || result.Status != http.StatusUnauthorized || result.Method != ProbeMethodEndpoint {
+		t.Fatalf("unexpected failed probe result: %+v", result)
+	}
+	updated, _ := manager.GetByID(auth.ID)
+	if !updated.Unavailable || updated.Status != StatusError {
+		t.Fatalf("expected auth to be unavailable after failed probe, got unavailable=%v status=%s", updated.Unavailable, updated.Status)
+	}
+	if !strings.HasPrefix(updated.StatusMessage, healthProbeStatusPrefix) || !strings.Contains(updated.StatusMessage, "refresh token revoked") {
+		t.Fatalf("StatusMessage = %q, want probe failure reason", updated.StatusMessage)
+	}
+
+	probeErr = nil
+	result, errProbe = manager.ProbeAuth(ctx, auth.ID)
+	if errProbe != nil || !result.Success {
+		t.Fatalf("expected successful probe, got %+v err=%v", result, errProbe)
+	}
+	updated, _ = manager.GetByID(auth.ID)
+	if updated.Unavailable || updated.Status != StatusActive || updated.StatusMessage != "" {
+		t.Fatalf("expected probe recovery to clear state, got unavailable=%v status=%s message=%q", updated.Unavailable, updated.Status, updated.StatusMessage)
+	}
+
+	if _, errProbe = manager.ProbeAuth(ctx, auth.ID); errProbe != nil {
+		t.Fatalf("ProbeAuth() error = %v", errProbe)
+	}
+	history := manager.ProbeHistory(auth.ID)
+	if len(history) != 2 {
+		t.Fatalf("ProbeHistory() len = %d, want 2", len(history))
+	}
+	if !history[0].Success || !history[1].Success {
+		t.Fatalf("expected oldest entries to be trimmed, got %+v", history)
+	}
+}
+
+func TestManager_ProbeAuthTransientFailureKeepsAuthAvailable(t *testing.T) {
+	ctx := context.Background()
+	var probeErr error = &Error{Message: "upstream overloaded", HTTPStatus: http.StatusServiceUnavailable}
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(healthProbeTestExecutor{
+		schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "codex"},
+		err:                           &probeErr,
+	})
+	auth := &Auth{ID: "probe-transient", Provider: "codex", Status: StatusActive}
+	if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+
+	result, errProbe := manager.ProbeAuth(ctx, auth.ID)
+	if errProbe != nil {
+		t.Fatalf("ProbeAuth() error = %v", errProbe)
+	}
+	if result.Success {
+		t.Fatalf("expected failed probe result, got %+v", result)
+	}
+	updated, _ := manager.GetByID(auth.ID)
+	if updated.Unavailable {
+		t.Fatal("expected transient probe failure to leave auth available")
+	}
+	if _, errProbe = manager.ProbeAuth(ctx, "missing"); errProbe == nil || errors.Is(errProbe, context.Canceled) {
+		t.Fatalf("expected error for unknown auth, got %v", errProbe)
+	}
+}
+
+// preparingHealthProbeExecutor refreshes the credential before use and records what it probed.
+type preparingHealthProbeExecutor struct {
+	healthProbeTestExecutor
+	probed *string
+}
+
+func (e preparingHealthProbeExecutor) ShouldPrepareRequestAuth(auth *Auth) bool {
+	return auth.Metadata["access_token"] != "fresh"
+}
+
+func (e preparingHealthProbeExecutor) PrepareRequestAuth(_ context.Context, auth *Auth) (*Auth, error) {
+	auth.Metadata = map[string]any{"access_token": "fresh"}
+	return auth, nil
+}
+
+func (e preparingHealthProbeExecutor) ProbeAuth(ctx context.Context, auth *Auth) error {
+	*e.probed, _ = auth.Metadata["access_token"].(string)
+	return e.healthProbeTestExecutor.ProbeAuth(ctx, auth)
+}
+
+func TestManager_ProbeAuthPreparesAuthAndIgnoresBadRequest(t *testing.T) {
+	ctx := context.Background()
+	var probeErr error = &Error{Message: "max_tokens is too small", HTTPStatus: http.StatusBadRequest}
+	var probed string
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(preparingHealthProbeExecutor{
+		healthProbeTestExecutor: healthProbeTestExecutor{
+			schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "codex"},
+			err:                           &probeErr,
+		},
+		probed: &probed,
+	})
+	auth := &Auth{ID: "probe-bad-request", Provider: "codex", Status: StatusActive, Metadata: map[string]any{"access_token": "expired"}}
+	if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+
+	result, errProbe := manager.ProbeAuth(ctx, auth.ID)
+	if errProbe != nil {
+		t.Fatalf("ProbeAuth() error = %v", errProbe)
+	}
+	if probed != "fresh" {
+		t.Fatalf("probe used access token %q, want the prepared one", probed)
+	}
+	if result.Success || result.Status != http.StatusBadRequest {
+		t.Fatalf("unexpected probe result: %+v", result)
+	}
+	updated, _ := manager.GetByID(auth.ID)
+	if updated.Unavailable || updated.Status != StatusActive {
+		t.Fatalf("400 probe marked auth unavailable=%v status=%s, want it left available", updated.Unavailable, updated.Status)
+	}
+}
@@ -618,6 +618,11 @@ type SessionAffinitySelector struct {
 type SessionAffinityConfig struct {
 	Fallback Selector
 	TTL      time.Duration
+	// Store persists bindings so they survive restarts and are shared across replicas.
+	// Nil keeps bindings in process memory only.
+	Store SessionBindingStore
+	// CloseStore closes Store when the selector stops. Set it when the selector owns the store.
+	CloseStore bool
 }
 
 // NewSessionAffinitySelector creates a new session-aware selector.
@@ -638,7 +643,7 @@ func NewSessionAffinitySelectorWithConfig(cfg SessionAffinityConfig) *SessionAff
 	}
 	return &SessionAffinitySelector{
 		fallback: cfg.Fallback,
-		cache:    NewSessionCache(cfg.TTL),
+		cache:    NewSessionCacheWithStore(cfg.TTL, cfg.Store, cfg.CloseStore),
 	}
 }
 
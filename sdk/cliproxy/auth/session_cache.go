@@ -1,41 +1,88 @@
 package auth
 
 import (
+	"context"
+	"io"
 	"strings"
 	"sync"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 const maxStableSessionAliases = 64
 
 // sessionEntry stores an auth binding, its identifier aliases, and expiration.
+// storedUntil is the expiry last written to or read from the binding store.
 type sessionEntry struct {
-	authID    string
-	expiresAt time.Time
-	aliases   []string
+	authID      string
+	expiresAt   time.Time
+	aliases     []string
+	storedUntil time.Time
+}
+
+// sessionStoreOp is a store mutation queued under the cache lock and applied after it is released.
+type sessionStoreOp struct {
+	save       *SessionBinding
+	deleteKeys []string
+	deleteAuth string
 }
 
 // SessionCache provides TTL-based session to auth mapping with automatic cleanup.
+// When a SessionBindingStore is attached, the in-memory map acts as a write-behind
+// cache: reads refresh from the store at most once per sessionStoreRefreshInterval and
+// mutations are queued for a background writer, which coalesces them so selection never
+// waits on store I/O. A TTL refresh is only written once more than half of the stored TTL
+// has elapsed. A failing store is bypassed for sessionStoreRetryAfter.
 type SessionCache struct {
 	mu       sync.RWMutex
 	entries  map[string]sessionEntry
 	ttl      time.Duration
 	stopCh   chan struct{}
 	stopOnce sync.Once
+
+	store      SessionBindingStore
+	closeStore bool
+	// storeMu serializes flushes so queued operations reach the store in order.
+	storeMu sync.Mutex
+	pending []sessionStoreOp
+	// storeWake signals the background writer that operations are pending.
+	storeWake chan struct{}
+	// inflight counts queued operations not yet applied; store reads are not
+	// applied locally while local writes are still in flight.
+	inflight int
+	// refreshedAt records when each key was last read from the store. Local writes
+	// clear it so the next read observes the store again.
+	refreshedAt map[string]time.Time
+	// storeDownUntil skips store round trips after a failure.
+	storeDownUntil time.Time
 }
 
 // NewSessionCache creates a cache with the specified TTL.
 // A background goroutine periodically cleans expired entries.
 func NewSessionCache(ttl time.Duration) *SessionCache {
+	return NewSessionCacheWithStore(ttl, nil, false)
+}
+
+// NewSessionCacheWithStore creates a cache backed by a persistent binding store.
+// When closeStore is true, Stop also closes the store if it implements io.Closer.
+func NewSessionCacheWithStore(ttl time.Duration, store SessionBindingStore, closeStore bool) *SessionCache {
 	if ttl <= 0 {
 		ttl = 30 * time.Minute
 	}
 	c := &SessionCache{
-		entries: make(map[string]sessionEntry),
-		ttl:     ttl,
-		stopCh:  make(chan struct{}),
+		entries:     make(map[string]sessionEntry),
+		refreshedAt: make(map[string]time.Time),
+		ttl:         ttl,
+		stopCh:      make(chan struct{}),
+		store:       store,
+		closeStore:  closeStore && store != nil,
+		storeWake:   make(chan struct{}, 1),
 	}
 	go c.cleanupLoop()
+	if store != nil {
+		go c.storeWriteLoop()
+	}
 	return c
 }
 
@@ -45,6 +92,7 @@ func (c *SessionCache) Get(sessionID string) (string, bool) {
 	if sessionID == "" {
 		return "", false
 	}
+	c.refreshFromStore(sessionID)
 	now := time.Now()
 	c.mu.RLock()
 	entry, ok := c.entries[sessionID]
@@ -57,6 +105,7 @@ func (c *SessionCache) Get(sessionID string) (string, bool) {
 		return "", false
 	}
 
+	defer c.scheduleStoreFlush()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	entry, ok = c.entries[sessionID]
@@ -76,6 +125,8 @@ func (c *SessionCache) GetAndRefresh(sessionID string) (string, bool) {
 	if sessionID == "" {
 		return "", false
 	}
+	c.refreshFromStore(sessionID)
+	defer c.scheduleStoreFlush()
 	now := time.Now()
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -104,6 +155,7 @@ func (c *SessionCache) SetAliases(authID string, sessionIDs ...string) {
 	if authID == "" {
 		return
 	}
+	defer c.scheduleStoreFlush()
 	now := time.Now()
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -130,16 +182,48 @@ func (c *SessionCache) SetAliases(authID string, sessionIDs ...string) {
 }
 
 func (c *SessionCache) replaceAliasGroupsLocked(authID string, expiresAt time.Time, aliases []string, previousGroups ...sessionEntry) {
+	var dropped []string
 	for _, previous := range previousGroups {
-		c.removeAliasGroupLocked(previous)
+		dropped = append(dropped, c.dropAliasGroupLocked(previous)...)
 	}
 	entry := sessionEntry{authID: authID, expiresAt: expiresAt, aliases: aliases}
+	if c.store != nil && len(previousGroups) == 1 {
+		previous := previousGroups[0]
+		// A TTL refresh of an unchanged binding is kept in memory until half of the stored
+		// TTL has elapsed, so busy sessions do not write to the store on every request.
+		if previous.authID == authID && sameSessionAliasSet(previous.aliases, aliases) &&
+			previous.storedUntil.Sub(time.Now()) > c.ttl/2 {
+			entry.storedUntil = previous.storedUntil
+			for _, alias := range aliases {
+				c.entries[alias] = entry
+			}
+			return
+		}
+	}
+	if c.store != nil {
+		entry.storedUntil = expiresAt
+	}
 	for _, alias := range aliases {
 		c.entries[alias] = entry
 	}
+	if c.store == nil {
+		return
+	}
+	if stale := withoutSessionAliases(dropped, aliases); len(stale) > 0 {
+		c.queueStoreOpLocked(sessionStoreOp{deleteKeys: stale})
+	}
+	c.queueStoreOpLocked(sessionStoreOp{save: entry.binding()})
 }
 
 func (c *SessionCache) removeAliasGroupLocked(entry sessionEntry) {
+	if dropped := c.dropAliasGroupLocked(entry); len(dropped) > 0 && c.store != nil {
+		c.queueStoreOpLocked(sessionStoreOp{deleteKeys: dropped})
+	}
+}
+
+// dropAliasGroupLocked removes the alias group from memory only and returns the removed keys.
+func (c *SessionCache) dropAliasGroupLocked(entry sessionEntry) []string {
+	var dropped []string
 	for _, alias := range entry.aliases {
 		current, ok := c.entries[alias]
 		if !ok || current.authID != entry.authID || !current.expiresAt.Equal(entry.expiresAt) ||
@@ -147,7 +231,34 @@ func (c *SessionCache) removeAliasGroupLocked(entry sessionEntry) {
 			continue
 		}
 		delete(c.entries, alias)
+		dropped = append(dropped, alias)
 	}
+	return dropped
+}
+
+func (e sessionEntry) binding() *SessionBinding {
+	return &SessionBinding{
+		AuthID:    e.authID,
+		Aliases:   append([]string(nil), e.aliases...),
+		ExpiresAt: e.expiresAt,
+	}
+}
+
+func withoutSessionAliases(aliases, excluded []string) []string {
+	if len(aliases) == 0 {
+		return nil
+	}
+	skip := make(map[string]struct{}, len(excluded))
+	for _, alias := range excluded {
+		skip[alias] = struct{}{}
+	}
+	out := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		if _, ok := skip[alias]; !ok {
+			out = append(out, alias)
+		}
+	}
+	return out
 }
 
 func compactSessionAliases(aliases []string) []string {
@@ -201,6 +312,23 @@ func equalSessionAliases(left, right []string) bool {
 	return true
 }
 
+// sameSessionAliasSet reports whether both lists hold the same aliases in any order.
+func sameSessionAliasSet(left, right []string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	seen := make(map[string]struct{}, len(left))
+	for _, alias := range left {
+		seen[alias] = struct{}{}
+	}
+	for _, alias := range right {
+		if _, ok := seen[alias]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func mergeSessionAliases(existing []string, candidates ...string) []string {
 	aliases := make([]string, 0, len(existing)+len(candidates))
 	seen := make(map[string]struct{}, cap(aliases))
@@ -228,6 +356,8 @@ func (c *SessionCache) Touch(sessionID, expectedAuthID string) bool {
 	if sessionID == "" || expectedAuthID == "" {
 		return false
 	}
+	c.refreshFromStore(sessionID)
+	defer c.scheduleStoreFlush()
 	now := time.Now()
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -245,13 +375,23 @@ func (c *SessionCache) CompareAndDelete(sessionID, expectedAuthID string) bool {
 	if sessionID == "" || expectedAuthID == "" {
 		return false
 	}
+	c.refreshFromStore(sessionID)
+	defer c.scheduleStoreFlush()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	entry, ok := c.entries[sessionID]
 	if !ok || entry.authID != expectedAuthID {
 		return false
 	}
+	c.detachAliasLocked(sessionID, entry)
+	return true
+}
+
+// detachAliasLocked removes sessionID from its alias group while keeping the
+// remaining aliases bound.
+func (c *SessionCache) detachAliasLocked(sessionID string, entry sessionEntry) {
 	delete(c.entries, sessionID)
+	var remaining *sessionEntry
 	for _, alias := range entry.aliases {
 		if alias == sessionID {
 			continue
@@ -267,9 +407,21 @@ func (c *SessionCache) CompareAndDelete(sessionID, expectedAuthID string) bool {
 			}
 		}
 		current.aliases = filtered
+		if c.store != nil {
+			current.storedUntil = current.expiresAt
+		}
 		c.entries[alias] = current
+		if remaining == nil {
+			remaining = &current
+		}
+	}
+	if c.store == nil {
+		return
+	}
+	c.queueStoreOpLocked(sessionStoreOp{deleteKeys: []string{sessionID}})
+	if remaining != nil && len(remaining.aliases) > 0 {
+		c.queueStoreOpLocked(sessionStoreOp{save: remaining.binding()})
 	}
-	return true
 }
 
 // Invalidate removes a specific session binding without allowing another alias
@@ -278,27 +430,14 @@ func (c *SessionCache) Invalidate(sessionID string) {
 	if sessionID == "" {
 		return
 	}
+	c.refreshFromStore(sessionID)
+	defer c.scheduleStoreFlush()
 	c.mu.Lock()
 	entry, ok := c.entries[sessionID]
-	delete(c.entries, sessionID)
 	if ok {
-		for _, alias := range entry.aliases {
-			if alias == sessionID {
-				continue
-			}
-			current, exists := c.entries[alias]
-			if !exists || current.authID != entry.authID {
-				continue
-			}
-			filtered := make([]string, 0, len(current.aliases))
-			for _, candidate := range current.aliases {
-				if candidate != sessionID {
-					filtered = append(filtered, candidate)
-				}
-			}
-			current.aliases = filtered
-			c.entries[alias] = current
-		}
+		c.detachAliasLocked(sessionID, entry)
+	} else if c.store != nil {
+		c.queueStoreOpLocked(sessionStoreOp{deleteKeys: []string{sessionID}})
 	}
 	c.mu.Unlock()
 }
@@ -309,22 +448,35 @@ func (c *SessionCache) InvalidateAuth(authID string) {
 	if authID == "" {
 		return
 	}
+	defer c.scheduleStoreFlush()
 	c.mu.Lock()
 	for sid, entry := range c.entries {
 		if entry.authID == authID {
 			delete(c.entries, sid)
 		}
 	}
+	if c.store != nil {
+		c.queueStoreOpLocked(sessionStoreOp{deleteAuth: authID})
+	}
 	c.mu.Unlock()
 }
 
-// Stop terminates the background cleanup goroutine.
+// Stop terminates the background cleanup goroutine and closes an owned store.
 func (c *SessionCache) Stop() {
 	if c == nil {
 		return
 	}
 	c.stopOnce.Do(func() {
 		close(c.stopCh)
+		c.flushStore()
+		if !c.closeStore {
+			return
+		}
+		if closer, ok := c.store.(io.Closer); ok {
+			if errClose := closer.Close(); errClose != nil {
+				log.Warnf("session-affinity: failed to close binding store: %v", errClose)
+			}
+		}
 	})
 }
 
@@ -349,5 +501,195 @@ func (c *SessionCache) cleanup() {
 			delete(c.entries, sid)
 		}
 	}
+	for sid, refreshedAt := range c.refreshedAt {
+		if now.Sub(refreshedAt) >= sessionStoreRefreshInterval {
+			delete(c.refreshedAt, sid)
+		}
+	}
+	c.mu.Unlock()
+	// Stores without native key expiry drop their expired rows here.
+	if purger, ok := c.store.(interface{ PurgeExpired(context.Context) error }); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+		defer cancel()
+		if errPurge := purger.PurgeExpired(ctx); errPurge != nil {
+			log.Debugf("session-affinity: failed to purge expired bindings: %v", errPurge)
+		}
+	}
+}
+
+func (c *SessionCache) queueStoreOpLocked(op sessionStoreOp) {
+	c.pending = append(c.pending, op)
+	c.inflight++
+	switch {
+	case op.save != nil:
+		for _, alias := range op.save.Aliases {
+			delete(c.refreshedAt, alias)
+		}
+	case len(op.deleteKeys) > 0:
+		for _, key := range op.deleteKeys {
+			delete(c.refreshedAt, key)
+		}
+	case op.deleteAuth != "":
+		c.refreshedAt = make(map[string]time.Time)
+	}
+}
+
+// storeAvailableLocked reports whether the store is outside its failure backoff.
+func (c *SessionCache) storeAvailableLocked(now time.Time) bool {
+	return !now.Before(c.storeDownUntil)
+}
+
+// markStoreFailed bypasses the store for sessionStoreRetryAfter.
+func (c *SessionCache) markStoreFailed(errStore error) {
+	c.mu.Lock()
+	now := time.Now()
+	wasAvailable := c.storeAvailableLocked(now)
+	c.storeDownUntil = now.Add(sessionStoreRetryAfter)
+	c.mu.Unlock()
+	if wasAvailable {
+		log.Warnf("session-affinity: binding store unavailable, using local cache for %s: %v", sessionStoreRetryAfter, errStore)
+	}
+}
+
+// scheduleStoreFlush wakes the background writer without waiting for it.
+func (c *SessionCache) scheduleStoreFlush() {
+	if c == nil || c.store == nil {
+		return
+	}
+	select {
+	case c.storeWake <- struct{}{}:
+	default:
+	}
+}
+
+// storeWriteLoop applies queued store operations off the selection path. Operations
+// queued while a flush is running are applied together in the next one.
+func (c *SessionCache) storeWriteLoop() {
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-c.storeWake:
+			c.flushStore()
+		}
+	}
+}
+
+// coalesceSessionStoreOps drops saves overwritten by a later save of the same alias group,
+// as long as no delete in between could have touched the group.
+func coalesceSessionStoreOps(ops []sessionStoreOp) []sessionStoreOp {
+	if len(ops) < 2 {
+		return ops
+	}
+	latest := make(map[string]int)
+	out := make([]sessionStoreOp, 0, len(ops))
+	for _, op := range ops {
+		if op.save == nil {
+			clear(latest)
+			out = append(out, op)
+			continue
+		}
+		group := strings.Join(op.save.Aliases, "\x00")
+		if index, ok := latest[group]; ok {
+			out[index] = op
+			continue
+		}
+		latest[group] = len(out)
+		out = append(out, op)
+	}
+	return out
+}
+
+// flushStore applies queued store operations in order. Store failures are logged and
+// leave the in-memory binding in place, so selection keeps working without the store.
+func (c *SessionCache) flushStore() {
+	if c == nil || c.store == nil {
+		return
+	}
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	c.mu.Lock()
+	queued := c.pending
+	c.pending = nil
+	available := c.storeAvailableLocked(time.Now())
+	if !available {
+		c.inflight -= len(queued)
+	}
+	c.mu.Unlock()
+	if len(queued) == 0 || !available {
+		return
+	}
+	ops := coalesceSessionStoreOps(queued)
+	for i, op := range ops {
+		ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+		var errOp error
+		switch {
+		case op.save != nil:
+			errOp = c.store.Save(ctx, *op.save)
+		case len(op.deleteKeys) > 0:
+			errOp = c.store.Delete(ctx, op.deleteKeys...)
+		case op.deleteAuth != "":
+			errOp = c.store.DeleteAuth(ctx, op.deleteAuth)
+		}
+		cancel()
+		if errOp != nil {
+			// Drop the remaining writes instead of waiting on a failing store for each one.
+			c.markStoreFailed(errOp)
+			log.Debugf("session-affinity: dropped %d binding store writes", len(ops)-i)
+			break
+		}
+	}
+	c.mu.Lock()
+	c.inflight -= len(queued)
 	c.mu.Unlock()
 }
+
+// refreshFromStore replaces the local binding for sessionID with the stored one, so
+// bindings created, moved, or removed by another replica are observed before use.
+// Keys read within sessionStoreRefreshInterval are served from memory, and reads are
+// skipped entirely while the store is in its failure backoff.
+func (c *SessionCache) refreshFromStore(sessionID string) {
+	if c == nil || c.store == nil || sessionID == "" {
+		return
+	}
+	now := time.Now()
+	c.mu.RLock()
+	fresh := now.Sub(c.refreshedAt[sessionID]) < sessionStoreRefreshInterval
+	available := c.storeAvailableLocked(now)
+	c.mu.RUnlock()
+	if fresh || !available {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStoreReadTimeout)
+	binding, found, errLoad := c.store.Load(ctx, sessionID)
+	cancel()
+	if errLoad != nil {
+		c.markStoreFailed(errLoad)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inflight > 0 {
+		return
+	}
+	c.refreshedAt[sessionID] = time.Now()
+	current, exists := c.entries[sessionID]
+	if !found {
+		if exists {
+			c.dropAliasGroupLocked(current)
+		}
+		return
+	}
+	aliases := mergeSessionAliases([]string{sessionID}, binding.Aliases...)
+	if exists && current.authID == binding.AuthID && current.storedUntil.Equal(binding.ExpiresAt) &&
+		equalSessionAliases(current.aliases, aliases) {
+		return
+	}
+	if exists {
+		c.dropAliasGroupLocked(current)
+	}
+	entry := sessionEntry{authID: binding.AuthID, expiresAt: binding.ExpiresAt, aliases: aliases, storedUntil: binding.ExpiresAt}
+	for _, alias := range aliases {
+		c.entries[alias] = entry
+	}
+}
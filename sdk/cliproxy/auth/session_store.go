@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// sessionStoreTimeout bounds each session binding store write.
+	sessionStoreTimeout = time.Second
+	// sessionStoreReadTimeout bounds a binding read on the selection path.
+	sessionStoreReadTimeout = 250 * time.Millisecond
+	// sessionStoreRefreshInterval is how long a binding read from the store is served
+	// from memory before the store is consulted again.
+	sessionStoreRefreshInterval = 2 * time.Second
+	// sessionStoreRetryAfter is how long the store is bypassed after a failed round trip.
+	sessionStoreRetryAfter = 30 * time.Second
+)
+
+// DefaultRedisSessionKeyPrefix namespaces session bindings stored in Redis.
+const DefaultRedisSessionKeyPrefix = "cliproxy:session-affinity:"
+
+// SessionBinding is one logical session bound to an auth. Every alias key of the
+// session maps to the same binding.
+type SessionBinding struct {
+	AuthID    string    `json:"auth_id"`
+	Aliases   []string  `json:"aliases"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionBindingStore persists session-affinity bindings outside process memory so they
+// survive restarts and are shared by replicas. Keys are session-affinity cache keys.
+type SessionBindingStore interface {
+	// Load returns the binding stored for key. Expired bindings are reported as missing.
+	Load(ctx context.Context, key string) (SessionBinding, bool, error)
+	// Save stores binding under every alias until binding.ExpiresAt.
+	Save(ctx context.Context, binding SessionBinding) error
+	// Delete removes the given keys.
+	Delete(ctx context.Context, keys ...string) error
+	// DeleteAuth removes every key bound to authID.
+	DeleteAuth(ctx context.Context, authID string) error
+}
+
+// SessionBindingStoreProvider exposes a backend-specific session binding store.
+type SessionBindingStoreProvider interface {
+	SessionBindingStore() SessionBindingStore
+}
+
+// RedisSessionBindingStore stores session bindings as Redis keys with native expiry.
+// Each auth keeps a set of its keys so InvalidateAuth can remove them without a scan.
+type RedisSessionBindingStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisSessionBindingStore creates a Redis-backed store from a redis:// or rediss:// URL.
+// The connection is established lazily on first use.
+func NewRedisSessionBindingStore(rawURL, prefix string) (*RedisSessionBindingStore, error) {
+	options, errParse := redis.ParseURL(strings.TrimSpace(rawURL))
+	if errParse != nil {
+		return nil, fmt.Errorf("redis session store: parse url: %w", errParse)
+	}
+	if strings.TrimSpace(prefix) == "" {
+		prefix = DefaultRedisSessionKeyPrefix
+	}
+	return &RedisSessionBindingStore{client: redis.NewClient(options), prefix: prefix}, nil
+}
+
+func (s *RedisSessionBindingStore) sessionKey(key string) string {
+	return s.prefix + "session:" + key
+}
+
+func (s *RedisSessionBindingStore) authKey(authID string) string {
+	return s.prefix + "auth:" + authID
+}
+
+// Load implements SessionBindingStore.
+func (s *RedisSessionBindingStore) Load(ctx context.Context, key string) (SessionBinding, bool, error) {
+	if s == nil || s.client == nil {
+		return SessionBinding{}, false, fmt.Errorf("redis session store: not initialized")
+	}
+	raw, errGet := s.client.Get(ctx, s.sessionKey(key)).Bytes()
+	if errors.Is(errGet, redis.Nil) {
+		return SessionBinding{}, false, nil
+	}
+	if errGet != nil {
+		return SessionBinding{}, false, fmt.Errorf("redis session store: get: %w", errGet)
+	}
+	var binding SessionBinding
+	if errUnmarshal := json.Unmarshal(raw, &binding); errUnmarshal != nil {
+		return SessionBinding{}, false, fmt.Errorf("redis session store: decode binding: %w", errUnmarshal)
+	}
+	if binding.AuthID == "" || !time.Now().Before(binding.ExpiresAt) {
+		return SessionBinding{}, false, nil
+	}
+	return binding, true, nil
+}
+
+// Save implements SessionBindingStore.
+func (s *RedisSessionBindingStore) Save(ctx context.Context, binding SessionBinding) error {
+	if s == nil || s.client == nil {
+		return fmt.Errorf("redis session store: not initialized")
+	}
+	ttl := time.Until(binding.ExpiresAt)
+	if binding.AuthID == "" || len(binding.Aliases) == 0 || ttl <= 0 {
+		return nil
+	}
+	raw, errMarshal := json.Marshal(binding)
+	if errMarshal != nil {
+		return fmt.Errorf("redis session store: encode binding: %w", errMarshal)
+	}
+	authKey := s.authKey(binding.AuthID)
+	_, errPipe := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		members := make([]any, 0, len(binding.Aliases))
+		for _, alias := range binding.Aliases {
+			pipe.Set(ctx, s.sessionKey(alias), raw, ttl)
+			members = append(members, alias)
+		}
+		pipe.SAdd(ctx, authKey, members...)
+		pipe.Expire(ctx, authKey, ttl)
+		return nil
+	})
+	if errPipe != nil {
+		return fmt.Errorf("redis session store: save: %w", errPipe)
+	}
+	return nil
+}
+
+// Delete implements SessionBindingStore.
+func (s *RedisSessionBindingStore) Delete(ctx context.Context, keys ...string) error {
+	if s == nil || s.client == nil {
+		return fmt.Errorf("redis session store: not initialized")
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	redisKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		redisKeys = append(redisKeys, s.sessionKey(key))
+	}
+	if errDel := s.client.Del(ctx, redisKeys...).Err(); errDel != nil {
+		return fmt.Errorf("redis session store: delete: %w", errDel)
+	}
+	return nil
+}
+
+// DeleteAuth implements SessionBindingStore. Keys that were rebound to another auth
+// since they were indexed are left untouched.
+func (s *RedisSessionBindingStore) DeleteAuth(ctx context.Context, authID string) error {
+	if s == nil || s.client == nil {
+		return fmt.Errorf("redis session store: not initialized")
+	}
+	authKey := s.authKey(authID)
+	members, errMembers := s.client.SMembers(ctx, authKey).Result()
+	if errMembers != nil {
+		return fmt.Errorf("redis session store: list auth sessions: %w", errMembers)
+	}
+	stale := make([]string, 0, len(members))
+	for _, member := range members {
+		binding, ok, errLoad := s.Load(ctx, member)
+		if errLoad != nil {
+			return errLoad
+		}
+		if ok && binding.AuthID == authID {
+			stale = append(stale, s.sessionKey(member))
+		}
+	}
+	stale = append(stale, authKey)
+	if errDel := s.client.Del(ctx, stale...).Err(); errDel != nil {
+		return fmt.Errorf("redis session store: delete auth sessions: %w", errDel)
+	}
+	return nil
+}
+
+// Close releases the Redis connection pool.
+func (s *RedisSessionBindingStore) Close() error {
+	if s == nil || s.client == nil {
+		return nil
+	}
+	return s.client.Close()
+}
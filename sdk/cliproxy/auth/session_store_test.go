@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+type memorySessionBindingStore struct {
+	mu       sync.Mutex
+	bindings map[string]SessionBinding
+	closed   bool
+}
+
+func newMemorySessionBindingStore() *memorySessionBindingStore {
+	return &memorySessionBindingStore{bindings: make(map[string]SessionBinding)}
+}
+
+func (s *memorySessionBindingStore) Load(_ context.Context, key string) (SessionBinding, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	binding, ok := s.bindings[key]
+	if !ok || !time.Now().Before(binding.ExpiresAt) {
+		return SessionBinding{}, false, nil
+	}
+	return binding, true, nil
+}
+
+func (s *memorySessionBindingStore) Save(_ context.Context, binding SessionBinding) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, alias := range binding.Aliases {
+		s.bindings[alias] = binding
+	}
+	return nil
+}
+
+func (s *memorySessionBindingStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.bindings, key)
+	}
+	return nil
+}
+
+func (s *memorySessionBindingStore) DeleteAuth(_ context.Context, authID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, binding := range s.bindings {
+		if binding.AuthID == authID {
+			delete(s.bindings, key)
+		}
+	}
+	return nil
+}
+
+func (s *memorySessionBindingStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *memorySessionBindingStore) authFor(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bindings[key].AuthID
+}
+
+func TestSessionCacheWithStore_BindingSurvivesRestart(t *testing.T) {
+	t.Parallel()
+
+	store := newMemorySessionBindingStore()
+	first := NewSessionCacheWithStore(time.Minute, store, false)
+	first.SetAliases("auth-b", "claude::session-1::model", "claude::fallback-1::model")
+	first.Stop()
+
+	restarted := NewSessionCacheWithStore(time.Minute, store, false)
+	defer restarted.Stop()
+	if authID, ok := restarted.GetAndRefresh("claude::session-1::model"); !ok || authID != "auth-b" {
+		t.Fatalf("GetAndRefresh() after restart = %q, %v; want auth-b, true", authID, ok)
+	}
+	if authID, ok := restarted.Get("claude::fallback-1::model"); !ok || authID != "auth-b" {
+		t.Fatalf("Get(fallback) after restart = %q, %v; want auth-b, true", authID, ok)
+	}
+}
+
+func TestSessionCacheWithStore_ReplicasShareBindings(t *testing.T) {
+	t.Parallel()
+
+	store := newMemorySessionBindingStore()
+	replicaA := NewSessionCacheWithStore(time.Minute, store, false)
+	defer replicaA.Stop()
+	replicaB := NewSessionCacheWithStore(time.Minute, store, false)
+	defer replicaB.Stop()
+
+	replicaA.Set("codex::session-1::gpt", "auth-a")
+	replicaA.flushStore()
+	if authID, ok := replicaB.Get("codex::session-1::gpt"); !ok || authID != "auth-a" {
+		t.Fatalf("replica B Get() = %q, %v; want auth-a, true", authID, ok)
+	}
+
+	// A failover on replica B moves the binding for both replicas.
+	replicaB.Set("codex::session-1::gpt", "auth-c")
+	replicaB.flushStore()
+	if authID, ok := replicaA.GetAndRefresh("codex::session-1::gpt"); !ok || authID != "auth-c" {
+		t.Fatalf("replica A GetAndRefresh() after failover = %q, %v; want auth-c, true", authID, ok)
+	}
+
+	replicaA.InvalidateAuth("auth-c")
+	replicaA.flushStore()
+	if _, ok := replicaB.Get("codex::session-1::gpt"); ok {
+		t.Fatal("replica B still sees a binding for an invalidated auth")
+	}
+	if got := store.authFor("codex::session-1::gpt"); got != "" {
+		t.Fatalf("store binding after InvalidateAuth = %q, want none", got)
+	}
+}
+
+func TestSessionCacheWithStore_CompareAndDeleteKeepsRemainingAliases(t *testing.T) {
+	t.Parallel()
+
+	store := newMemorySessionBindingStore()
+	cache := NewSessionCacheWithStore(time.Minute, store, false)
+	defer cache.Stop()
+
+	cache.SetAliases("auth-a", "p::primary::m", "p::fallback::m")
+	if !cache.CompareAndDelete("p::primary::m", "auth-a") {
+		t.Fatal("CompareAndDelete() = false, want true")
+	}
+	cache.flushStore()
+	if got := store.authFor("p::primary::m"); got != "" {
+		t.Fatalf("store binding for deleted alias = %q, want none", got)
+	}
+	if got := store.authFor("p::fallback::m"); got != "auth-a" {
+		t.Fatalf("store binding for remaining alias = %q, want auth-a", got)
+	}
+}
+
+func TestSessionCacheWithStore_ExpiredStoredBindingIsIgnored(t *testing.T) {
+	t.Parallel()
+
+	store := newMemorySessionBindingStore()
+	_ = store.Save(context.Background(), SessionBinding{
+		AuthID:    "auth-a",
+		Aliases:   []string{"p::stale::m"},
+		ExpiresAt: time.Now().Add(-time.Second),
+	})
+	cache := NewSessionCacheWithStore(time.Minute, store, false)
+	defer cache.Stop()
+	if authID, ok := cache.Get("p::stale::m"); ok {
+		t.Fatalf("Get() returned expired stored binding %q", authID)
+	}
+}
+
+func TestSessionAffinitySelector_StoreBindingSurvivesSelectorReplacement(t *testing.T) {
+	t.Parallel()
+
+	store := newMemorySessionBindingStore()
+	auths := []*Auth{{ID: "auth-a"}, {ID: "auth-b"}, {ID: "auth-c"}}
+	payload := []byte(`{"metadata":{"user_id":"user_xxx_account__session_4b1d6c2e-9a0f-4c57-8f3e-2f1e0d9c8b7a"}}`)
+	opts := cliproxyexecutor.Options{OriginalRequest: payload}
+
+	first := NewSessionAffinitySelectorWithConfig(SessionAffinityConfig{Fallback: &RoundRobinSelector{}, TTL: time.Minute, Store: store})
+	// Advance the fallback so a fresh selector would pick a different auth without the store.
+	picked, errPick := first.Pick(context.Background(), "claude", "claude-3", cliproxyexecutor.Options{}, auths)
+	if errPick != nil {
+		t.Fatalf("Pick() without session error = %v", errPick)
+	}
+	bound, errPick := first.Pick(context.Background(), "claude", "claude-3", opts, auths)
+	if errPick != nil {
+		t.Fatalf("Pick() error = %v", errPick)
+	}
+	if bound.ID == picked.ID {
+		t.Fatalf("fallback did not advance: both picks returned %q", bound.ID)
+	}
+	first.Stop()
+	if store.closed {
+		t.Fatal("selector closed a store it does not own")
+	}
+
+	second := NewSessionAffinitySelectorWithConfig(SessionAffinityConfig{Fallback: &RoundRobinSelector{}, TTL: time.Minute, Store: store, CloseStore: true})
+	got, errPick := second.Pick(context.Background(), "claude", "claude-3", opts, auths)
+	if errPick != nil {
+		t.Fatalf("Pick() after replacement error = %v", errPick)
+	}
+	if got.ID != bound.ID {
+		t.Fatalf("Pick() after replacement = %q, want persisted binding %q", got.ID, bound.ID)
+	}
+	second.Stop()
+	if !store.closed {
+		t.Fatal("selector did not close the store it owns")
+	}
+}
+
+// countingSessionBindingStore counts Load calls and can fail them.
+type countingSessionBindingStore struct {
+	*memorySessionBindingStore
+	loads   atomic.Int32
+	loadErr error
+}
+
+func (s *countingSessionBindingStore) Load(ctx context.Context, key string) (SessionBinding, bool, error) {
+	s.loads.Add(1)
+	if s.loadErr != nil {
+		return SessionBinding{}, false, s.loadErr
+	}
+	return s.memorySessionBindingStore.Load(ctx, key)
+}
+
+func TestSessionCacheWithStore_ServesRecentReadsFromMemory(t *testing.T) {
+	t.Parallel()
+
+	store := &countingSessionBindingStore{memorySessionBindingStore: newMemorySessionBindingStore()}
+	_ = store.Save(context.Background(), SessionBinding{AuthID: "auth-a", Aliases: []string{"p::s::m"}, ExpiresAt: time.Now().Add(time.Minute)})
+	cache := NewSessionCacheWithStore(time.Minute, store, false)
+	defer cache.Stop()
+
+	for i := 0; i < 3; i++ {
+		if authID, ok := cache.Get("p::s::m"); !ok || authID != "auth-a" {
+			t.Fatalf("Get() #%d = %q, %v; want auth-a, true", i, authID, ok)
+		}
+	}
+	if got := store.loads.Load(); got != 1 {
+		t.Fatalf("store loads = %d, want 1 within the refresh interval", got)
+	}
+}
+
+func TestSessionCacheWithStore_FailingStoreIsBypassed(t *testing.T) {
+	t.Parallel()
+
+	store := &countingSessionBindingStore{memorySessionBindingStore: newMemorySessionBindingStore(), loadErr: errors.New("connection refused")}
+	cache := NewSessionCacheWithStore(time.Minute, store, false)
+	defer cache.Stop()
+
+	cache.Set("p::first::m", "auth-a")
+	for _, key := range []string{"p::first::m", "p::second::m", "p::third::m"} {
+		cache.Get(key)
+	}
+	if got := store.loads.Load(); got != 1 {
+		t.Fatalf("store loads = %d, want 1 before the retry backoff expires", got)
+	}
+	if authID, ok := cache.Get("p::first::m"); !ok || authID != "auth-a" {
+		t.Fatalf("Get() with failing store = %q, %v; want local auth-a, true", authID, ok)
+	}
+}
+
+// blockingSessionBindingStore holds every Save until release is closed.
+type blockingSessionBindingStore struct {
+	*memorySessionBindingStore
+	release chan struct{}
+	saves   atomic.Int32
+}
+
+func (s *blockingSessionBindingStore) Save(ctx context.Context, binding SessionBinding) error {
+	<-s.release
+	s.saves.Add(1)
+	return s.memorySessionBindingStore.Save(ctx, binding)
+}
+
+func TestSessionCacheWithStore_WritesDoNotBlockSelection(t *testing.T) {
+	t.Parallel()
+
+	store := &blockingSessionBindingStore{memorySessionBindingStore: newMemorySessionBindingStore(), release: make(chan struct{})}
+	cache := NewSessionCacheWithStore(time.Minute, store, false)
+	defer cache.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Set("p::s::m", "auth-a")
+		for i := 0; i < 50; i++ {
+			cache.GetAndRefresh("p::s::m")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		close(store.release)
+		t.Fatal("session selection waited on a blocked store write")
+	}
+	close(store.release)
+	cache.flushStore()
+	if got := store.authFor("p::s::m"); got != "auth-a" {
+		t.Fatalf("store binding = %q, want auth-a", got)
+	}
+	// The refreshes within half the TTL stay in memory; only the initial binding is written.
+	if got := store.saves.Load(); got != 1 {
+		t.Fatalf("store saves = %d, want 1", got)
+	}
+}
@@ -0,0 +1,118 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// metricsServer runs the dedicated Prometheus listener configured by metrics.addr.
+// Without an address, metrics are served on the main port by the API server.
+type metricsServer struct {
+	mu     sync.Mutex
+	server *http.Server
+	addr   string
+	// token is read by in-flight scrapes, so it does not share mu with listener restarts.
+	token atomic.Value
+}
+
+func (s *Service) applyMetricsConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	if s.metricsServer == nil {
+		s.metricsServer = &metricsServer{}
+	}
+	s.metricsServer.Apply(cfg)
+}
+
+func (s *Service) shutdownMetrics(ctx context.Context) error {
+	if s == nil || s.metricsServer == nil {
+		return nil
+	}
+	return s.metricsServer.Shutdown(ctx)
+}
+
+// Apply starts, restarts or stops the listener to match cfg.
+func (m *metricsServer) Apply(cfg *config.Config) {
+	addr := ""
+	token := ""
+	if cfg != nil && cfg.Metrics.Enable {
+		addr = strings.TrimSpace(cfg.Metrics.Addr)
+		token = strings.TrimSpace(cfg.Metrics.BearerToken)
+	}
+
+	m.token.Store(token)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.server != nil && m.addr == addr {
+		return
+	}
+	if m.server != nil {
+		stopMetricsServer(context.Background(), m.server, m.addr, "restarted")
+		m.server = nil
+	}
+	m.addr = addr
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(m.currentToken))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	m.server = server
+	log.Infof("metrics server starting on %s", addr)
+	go func() {
+		if errServe := server.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			log.Errorf("metrics server failed on %s: %v", addr, errServe)
+			m.mu.Lock()
+			if m.server == server {
+				m.server = nil
+			}
+			m.mu.Unlock()
+		}
+	}()
+}
+
+func (m *metricsServer) currentToken() string {
+	token, _ := m.token.Load().(string)
+	return token
+}
+
+// Shutdown stops the listener.
+func (m *metricsServer) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	server, addr := m.server, m.addr
+	m.server = nil
+	m.mu.Unlock()
+	return stopMetricsServer(ctx, server, addr, "shutdown")
+}
+
+func stopMetricsServer(ctx context.Context, server *http.Server, addr, reason string) error {
+	if server == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if errStop := server.Shutdown(stopCtx); errStop != nil {
+		log.Errorf("metrics server stop failed on %s: %v", addr, errStop)
+		return errStop
+	}
+	log.Infof("metrics server stopped on %s (%s)", addr, reason)
+	return nil
+}
@@ -65,6 +65,9 @@ type Service struct {
 	// pprofServer manages the optional pprof HTTP debug server.
 	pprofServer *pprofServer
 
+	// metricsServer manages the optional dedicated Prometheus metrics listener.
+	metricsServer *metricsServer
+
 	// serverErr channel for server startup/shutdown errors.
 	serverErr chan error
 
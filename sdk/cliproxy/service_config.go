@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/synthesizer"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -25,9 +26,11 @@ type configCommit struct {
 }
 
 type routingRuntimeState struct {
-	strategy           string
-	sessionAffinity    bool
-	sessionAffinityTTL time.Duration
+	strategy                string
+	sessionAffinity         bool
+	sessionAffinityTTL      time.Duration
+	sessionAffinityStore    string
+	sessionAffinityRedisURL string
 }
 
 func normalizedRoutingRuntimeState(cfg *config.Config) routingRuntimeState {
@@ -51,6 +54,15 @@ func normalizedRoutingRuntimeState(cfg *config.Config) routingRuntimeState {
 			state.sessionAffinityTTL = parsed
 		}
 	}
+	if state.sessionAffinity {
+		state.sessionAffinityStore = strings.ToLower(strings.TrimSpace(cfg.Routing.SessionAffinityStore))
+		if state.sessionAffinityStore == config.SessionAffinityStoreMemory {
+			state.sessionAffinityStore = ""
+		}
+		if state.sessionAffinityStore == config.SessionAffinityStoreRedis {
+			state.sessionAffinityRedisURL = strings.TrimSpace(cfg.Routing.SessionAffinityRedisURL)
+		}
+	}
 	return state
 }
 
@@ -65,14 +77,40 @@ func newRoutingSelector(state routingRuntimeState) coreauth.Selector {
 		selector = &coreauth.RoundRobinSelector{}
 	}
 	if state.sessionAffinity {
+		store, ownsStore := newSessionBindingStore(state)
 		selector = coreauth.NewSessionAffinitySelectorWithConfig(coreauth.SessionAffinityConfig{
-			Fallback: selector,
-			TTL:      state.sessionAffinityTTL,
+			Fallback:   selector,
+			TTL:        state.sessionAffinityTTL,
+			Store:      store,
+			CloseStore: ownsStore,
 		})
 	}
 	return selector
 }
 
+// newSessionBindingStore resolves the persistent session-affinity store. The second
+// result reports whether the selector owns the store and must close it. Unavailable
+// stores fall back to in-memory bindings.
+func newSessionBindingStore(state routingRuntimeState) (coreauth.SessionBindingStore, bool) {
+	switch state.sessionAffinityStore {
+	case config.SessionAffinityStoreRedis:
+		store, errStore := coreauth.NewRedisSessionBindingStore(state.sessionAffinityRedisURL, "")
+		if errStore != nil {
+			log.WithError(errStore).Warn("session-affinity: redis store unavailable, keeping bindings in memory")
+			return nil, false
+		}
+		return store, true
+	case config.SessionAffinityStorePostgres:
+		if provider, ok := sdkAuth.GetTokenStore().(coreauth.SessionBindingStoreProvider); ok {
+			if store := provider.SessionBindingStore(); store != nil {
+				return store, false
+			}
+		}
+		log.Warn("session-affinity: postgres store requires the Postgres token store, keeping bindings in memory")
+	}
+	return nil, false
+}
+
 func (s *Service) applyConfigUpdateWithAuthSynthesis(ctx context.Context, newCfg *config.Config, synthesizeConfigAuths bool) bool {
 	commit := s.commitConfigUpdate(newCfg)
 	if commit.cfg == nil {
@@ -147,6 +185,7 @@ func (s *Service) applyConfigRuntime(ctx context.Context, commit configCommit, s
 	if !s.applyPprofConfigContext(ctx, cfg) {
 		return false
 	}
+	s.applyMetricsConfig(cfg)
 	if errContext := ctx.Err(); errContext != nil {
 		return false
 	}
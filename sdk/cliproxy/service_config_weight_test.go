@@ -75,3 +75,29 @@ func TestApplyManagerConfigStopsReplacedServiceAffinitySelector(t *testing.T) {
 		t.Fatal("expected replaced selector to be stopped during routing config apply")
 	}
 }
+
+func TestRoutingRuntimeStateSessionAffinityStore(t *testing.T) {
+	state := normalizedRoutingRuntimeState(&internalconfig.Config{
+		Routing: internalconfig.RoutingConfig{
+			SessionAffinity:         true,
+			SessionAffinityStore:    " Redis ",
+			SessionAffinityRedisURL: "redis://127.0.0.1:6379/0",
+		},
+	})
+	if state.sessionAffinityStore != internalconfig.SessionAffinityStoreRedis {
+		t.Fatalf("session affinity store = %q, want redis", state.sessionAffinityStore)
+	}
+	if state.sessionAffinityRedisURL != "redis://127.0.0.1:6379/0" {
+		t.Fatalf("session affinity redis url = %q", state.sessionAffinityRedisURL)
+	}
+
+	memory := normalizedRoutingRuntimeState(&internalconfig.Config{
+		Routing: internalconfig.RoutingConfig{SessionAffinity: true, SessionAffinityStore: "memory"},
+	})
+	if memory.sessionAffinityStore != "" {
+		t.Fatalf("memory store state = %q, want empty", memory.sessionAffinityStore)
+	}
+	if store, owns := newSessionBindingStore(memory); store != nil || owns {
+		t.Fatalf("newSessionBindingStore(memory) = %T, %v; want nil, false", store, owns)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -167,6 +168,7 @@ func (s *Service) Run(ctx context.Context) error {
 	fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
 
 	s.applyPprofConfig(s.cfg)
+	s.applyMetricsConfig(s.cfg)
 
 	if s.hooks.OnAfterStart != nil {
 		s.hooks.OnAfterStart(s)
@@ -204,6 +206,7 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		s.coreManager.StartHealthProbes(context.Background())
 	}
 
 	select {
@@ -287,6 +290,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopHealthProbes()
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -313,6 +317,12 @@ func (s *Service) Shutdown(ctx context.Context) error {
 				shutdownErr = errShutdownPprof
 			}
 		}
+		if errShutdownMetrics := s.shutdownMetrics(ctx); errShutdownMetrics != nil {
+			log.Errorf("failed to stop metrics server: %v", errShutdownMetrics)
+			if shutdownErr == nil {
+				shutdownErr = errShutdownMetrics
+			}
+		}
 
 		// no legacy clients to persist
 
@@ -326,6 +336,9 @@ func (s *Service) Shutdown(ctx context.Context) error {
 				}
 			}
 		}
+		if errShutdownTracing := tracing.Shutdown(ctx); errShutdownTracing != nil {
+			log.Errorf("failed to flush traces: %v", errShutdownTracing)
+		}
 
 		if s.pluginHost != nil {
 			sdktranslator.SetPluginHooks(nil)
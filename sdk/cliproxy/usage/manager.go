@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -53,6 +54,57 @@ type Record struct {
 	Detail      Detail
 	// ResponseHeaders stores a snapshot of upstream response headers for usage sinks.
 	ResponseHeaders http.Header
+	// Cost is the estimated price of the request. It is filled in before delivery when a
+	// cost estimator is installed and the model is priced, and is nil otherwise.
+	Cost *Cost
+	// ResponseCache is ResponseCacheHit or ResponseCacheMiss when the request was eligible
+	// for the response cache, and empty otherwise. Hits carry no upstream tokens.
+	ResponseCache string
+}
+
+const (
+	// ResponseCacheHit marks a request answered from the response cache.
+	ResponseCacheHit = "hit"
+	// ResponseCacheMiss marks a cacheable request that was sent upstream.
+	ResponseCacheMiss = "miss"
+)
+
+// Cost is an estimated request price, split by token bucket.
+type Cost struct {
+	Currency   string  `json:"currency"`
+	Input      float64 `json:"input"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+	Output     float64 `json:"output"`
+	Reasoning  float64 `json:"reasoning"`
+	Total      float64 `json:"total"`
+	// Multiplier is the service-tier multiplier already applied to every bucket.
+	Multiplier float64 `json:"multiplier"`
+	// PricedAs is the pricing entry that matched the model or alias.
+	PricedAs string `json:"priced_as"`
+}
+
+// CostEstimator prices a usage record. It returns nil when the model is not priced.
+type CostEstimator func(ctx context.Context, record Record) *Cost
+
+var costEstimator atomic.Pointer[CostEstimator]
+
+// SetCostEstimator installs the estimator used to fill Record.Cost. Nil removes it.
+func SetCostEstimator(estimator CostEstimator) {
+	if estimator == nil {
+		costEstimator.Store(nil)
+		return
+	}
+	costEstimator.Store(&estimator)
+}
+
+func estimateCost(ctx context.Context, record *Record) {
+	if record.Cost != nil {
+		return
+	}
+	if estimator := costEstimator.Load(); estimator != nil {
+		record.Cost = (*estimator)(ctx, *record)
+	}
 }
 
 // Failure holds HTTP failure metadata for an upstream request attempt.
@@ -78,6 +130,7 @@ type requestedModelAliasContextKey struct{}
 type reasoningEffortContextKey struct{}
 type serviceTierContextKey struct{}
 type generateContextKey struct{}
+type responseCacheContextKey struct{}
 
 // WithRequestedModelAlias stores the client-requested model name for usage sinks.
 func WithRequestedModelAlias(ctx context.Context, alias string) context.Context {
@@ -195,6 +248,23 @@ func GenerateFromContext(ctx context.Context) bool {
 	}
 }
 
+// WithResponseCache stores the response cache outcome of the request for usage records.
+func WithResponseCache(ctx context.Context, outcome string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, responseCacheContextKey{}, outcome)
+}
+
+// ResponseCacheFromContext returns the response cache outcome stored in ctx.
+func ResponseCacheFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	outcome, _ := ctx.Value(responseCacheContextKey{}).(string)
+	return outcome
+}
+
 // GenerateFlag returns a pointer suitable for Record.Generate.
 func GenerateFlag(generate bool) *bool {
 	return &generate
@@ -315,6 +385,9 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	}
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
+	if record.ResponseCache == "" {
+		record.ResponseCache = ResponseCacheFromContext(ctx)
+	}
 	m.mu.Lock()
 	if m.closed {
 		m.mu.Unlock()
@@ -350,6 +423,7 @@ func (m *Manager) dispatch(item queueItem) {
 	if len(plugins) == 0 {
 		return
 	}
+	estimateCost(item.ctx, &item.record)
 	for _, plugin := range plugins {
 		if plugin == nil {
 			continue
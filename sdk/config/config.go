@@ -7,11 +7,25 @@ package config
 import internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 
 type SDKConfig = internalconfig.SDKConfig
+type ClientAPIKey = internalconfig.ClientAPIKey
 
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
 type ClaudeCodeConfig = internalconfig.ClaudeCodeConfig
+type ShadowConfig = internalconfig.ShadowConfig
+type ShadowRule = internalconfig.ShadowRule
+type CircuitBreakerConfig = internalconfig.CircuitBreakerConfig
+type ClientRateLimit = internalconfig.ClientRateLimit
+type ClientRateLimitConfig = internalconfig.ClientRateLimitConfig
+type ClientBudget = internalconfig.ClientBudget
+type ClientBudgetWeights = internalconfig.ClientBudgetWeights
+type ClientBudgetConfig = internalconfig.ClientBudgetConfig
+type JWTAuthConfig = internalconfig.JWTAuthConfig
+type JWTStaticKey = internalconfig.JWTStaticKey
+type JWTGroupRule = internalconfig.JWTGroupRule
+type MTLSAuthConfig = internalconfig.MTLSAuthConfig
+type MTLSPrincipal = internalconfig.MTLSPrincipal
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type OAuthModelAlias = internalconfig.OAuthModelAlias
@@ -35,6 +49,27 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+
+	SessionAffinityStoreMemory   = internalconfig.SessionAffinityStoreMemory
+	SessionAffinityStoreRedis    = internalconfig.SessionAffinityStoreRedis
+	SessionAffinityStorePostgres = internalconfig.SessionAffinityStorePostgres
+
+	ClientRateLimitBackendMemory = internalconfig.ClientRateLimitBackendMemory
+	ClientRateLimitBackendRedis  = internalconfig.ClientRateLimitBackendRedis
+
+	ClientBudgetUnitTokens = internalconfig.ClientBudgetUnitTokens
+	ClientBudgetUnitCost   = internalconfig.ClientBudgetUnitCost
+
+	TLSClientAuthRequire       = internalconfig.TLSClientAuthRequire
+	TLSClientAuthVerifyIfGiven = internalconfig.TLSClientAuthVerifyIfGiven
+
+	MTLSPrincipalSourceCN       = internalconfig.MTLSPrincipalSourceCN
+	MTLSPrincipalSourceSANURI   = internalconfig.MTLSPrincipalSourceSANURI
+	MTLSPrincipalSourceSANDNS   = internalconfig.MTLSPrincipalSourceSANDNS
+	MTLSPrincipalSourceSANEmail = internalconfig.MTLSPrincipalSourceSANEmail
+
+	ClientKeyHashSHA256 = internalconfig.ClientKeyHashSHA256
+	ClientKeyHashArgon2 = internalconfig.ClientKeyHashArgon2
 )
 
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
@@ -53,6 +88,22 @@ func SaveConfigPreserveCommentsUpdateNestedScalar(configFile string, path []stri
 	return internalconfig.SaveConfigPreserveCommentsUpdateNestedScalar(configFile, path, value)
 }
 
+func IsHashedClientKey(value string) bool { return internalconfig.IsHashedClientKey(value) }
+
+func HashClientKey(key, scheme string) (string, error) {
+	return internalconfig.HashClientKey(key, scheme)
+}
+
+func VerifyClientKey(stored, candidate string) bool {
+	return internalconfig.VerifyClientKey(stored, candidate)
+}
+
+func ClientKeyLookupID(key string) string { return internalconfig.ClientKeyLookupID(key) }
+
+func ClientKeyHashLookupID(stored string) string {
+	return internalconfig.ClientKeyHashLookupID(stored)
+}
+
 func NormalizeCommentIndentation(data []byte) []byte {
 	return internalconfig.NormalizeCommentIndentation(data)
 }
@@ -1353,6 +1353,8 @@ type UsageRecord struct {
 	Failure UsageFailure
 	// Detail contains token usage counters.
 	Detail UsageDetail
+	// Cost is the estimated request cost, or nil when the model is not priced.
+	Cost *UsageCost
 	// ResponseHeaders contains selected upstream response headers.
 	ResponseHeaders http.Header
 }
@@ -1365,6 +1367,28 @@ type UsageFailure struct {
 	Body string
 }
 
+// UsageCost contains an estimated request cost split by token bucket.
+type UsageCost struct {
+	// Currency labels every amount.
+	Currency string
+	// Input is the cost of uncached input tokens.
+	Input float64
+	// CacheRead is the cost of cache read tokens.
+	CacheRead float64
+	// CacheWrite is the cost of cache creation tokens.
+	CacheWrite float64
+	// Output is the cost of non-reasoning output tokens.
+	Output float64
+	// Reasoning is the cost of reasoning tokens.
+	Reasoning float64
+	// Total is the sum of every bucket.
+	Total float64
+	// Multiplier is the service-tier multiplier already applied to each bucket.
+	Multiplier float64
+	// PricedAs is the catalog entry used for the estimate.
+	PricedAs string
+}
+
 // UsageDetail contains token accounting counters.
 type UsageDetail struct {
 	// InputTokens is the prompt or input token count.